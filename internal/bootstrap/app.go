@@ -3,6 +3,8 @@ package bootstrap
 import (
 	"github.com/spf13/cobra"
 	"go-jwt-auth/internal/commands"
+	"go-jwt-auth/internal/lib"
+	"go.uber.org/fx"
 )
 
 var rootCmd = &cobra.Command{
@@ -21,7 +23,16 @@ func NewApp() App {
 	cmd := App{
 		Command: rootCmd,
 	}
-	cmd.AddCommand(commands.GetSubCommands(CommonModules)...)
+
+	var configFile string
+	cmd.PersistentFlags().StringVar(&configFile, "config", "",
+		"config file, .json, .yaml, .yml or .toml; $"+lib.EnvConfig+" or config.json if unset")
+
+	cmd.AddCommand(commands.GetSubCommands(fx.Options(
+		CommonModules,
+		// the flags are parsed by the time the command runs.
+		fx.Provide(func() lib.ConfigFile { return lib.ConfigFile(configFile) }),
+	))...)
 	return cmd
 }
 
@@ -0,0 +1,205 @@
+package commands
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"github.com/spf13/cobra"
+	"go-jwt-auth/internal/domains"
+	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/models"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	_clientsAdd    = "add"
+	_clientsList   = "list"
+	_clientsRemove = "remove"
+
+	// _generatedSecretSize is the size of the generated secrets before encoding.
+	_generatedSecretSize = 32
+)
+
+type ClientsCommand struct {
+	action string
+	id     string
+
+	tenant        string
+	grantTypes    []string
+	scopes        []string
+	redirectURIs  []string
+	accessTTL     time.Duration
+	refreshTTL    time.Duration
+	secret        string
+	publicKeyPath string
+	tlsSubject    string
+	public        bool
+	guidPatterns  []string
+}
+
+func (s *ClientsCommand) Short() string {
+	return "register, list or remove the clients obtaining the tokens"
+}
+
+func (s *ClientsCommand) Setup(cmd *cobra.Command) {
+	cmd.Use = "clients add <id>|list|remove <id>"
+	cmd.ValidArgs = []string{_clientsAdd, _clientsList, _clientsRemove}
+	cmd.Args = cobra.RangeArgs(1, 2)
+
+	flags := cmd.Flags()
+	flags.StringVar(&s.tenant, "tenant", "", "tenant of the client, the default one if empty")
+	flags.StringSliceVar(&s.grantTypes, "grant-types",
+		[]string{string(models.GrantTypeIssue), string(models.GrantTypeRefreshToken)}, "grant types the client may use")
+	flags.StringSliceVar(&s.scopes, "scopes", nil, "scopes the client may request")
+	flags.StringSliceVar(&s.redirectURIs, "redirect-uris", nil, "redirect URIs of the client")
+	flags.DurationVar(&s.accessTTL, "access-ttl", 0, "access token TTL, the tenant's one if 0")
+	flags.DurationVar(&s.refreshTTL, "refresh-ttl", 0, "refresh token TTL, the tenant's one if 0")
+	flags.StringVar(&s.secret, "secret", "", "client secret, generated if none of it, --public-key, --tls-subject and --public is set")
+	flags.StringVar(&s.publicKeyPath, "public-key", "", "PEM file of the public key verifying the private_key_jwt assertions")
+	flags.StringVar(&s.tlsSubject, "tls-subject", "", "subject DN of the certificate of tls_client_auth, e.g. CN=backend,O=Acme")
+	flags.StringSliceVar(&s.guidPatterns, "guid-patterns", nil, "patterns of the GUIDs the client may issue the tokens of, any if empty")
+	flags.BoolVar(&s.public, "public", false, "a browser or mobile app without credentials, it uses the authorization_code grant with PKCE")
+
+	cmd.PreRunE = func(_ *cobra.Command, args []string) error {
+		s.action = args[0]
+		switch s.action {
+		case _clientsAdd, _clientsRemove:
+			if len(args) != 2 {
+				return fmt.Errorf("%s needs the client id", s.action)
+			}
+			s.id = args[1]
+		case _clientsList:
+			if len(args) != 1 {
+				return fmt.Errorf("list takes no arguments")
+			}
+		default:
+			return fmt.Errorf("unknown action %q", s.action)
+		}
+		return nil
+	}
+}
+
+func (s *ClientsCommand) Run() lib.CommandRunner {
+	return func(clients domains.Clients) error {
+		ctx := context.Background()
+
+		switch s.action {
+		case _clientsAdd:
+			return s.add(ctx, clients)
+		case _clientsRemove:
+			if err := clients.Remove(ctx, s.id); err != nil {
+				return fmt.Errorf("can't remove client %q: %w", s.id, err)
+			}
+			fmt.Printf("removed %s\n", s.id)
+		default:
+			all, err := clients.Clients(ctx)
+			if err != nil {
+				return err
+			}
+			printClients(all)
+		}
+
+		return nil
+	}
+}
+
+func (s *ClientsCommand) add(ctx context.Context, clients domains.Clients) error {
+	c := models.Client{
+		ID:           s.id,
+		Tenant:       s.tenant,
+		Scopes:       s.scopes,
+		RedirectURIs: s.redirectURIs,
+		GUIDPatterns: s.guidPatterns,
+		AccessTTL:    s.accessTTL,
+		RefreshTTL:   s.refreshTTL,
+	}
+	for _, g := range s.grantTypes {
+		c.GrantTypes = append(c.GrantTypes, models.GrantType(g))
+	}
+
+	secret, generated := s.secret, false
+	if s.public {
+		if secret != "" || s.publicKeyPath != "" || s.tlsSubject != "" {
+			return fmt.Errorf("public clients have no secret, public key or tls subject")
+		}
+	} else if s.tlsSubject != "" {
+		c.TLSSubject = s.tlsSubject
+	} else if s.publicKeyPath != "" {
+		key, err := os.ReadFile(s.publicKeyPath)
+		if err != nil {
+			return fmt.Errorf("can't read public key: %v", err)
+		}
+		c.PublicKey = string(key)
+	} else if secret == "" {
+		buf := make([]byte, _generatedSecretSize)
+		if _, err := rand.Read(buf); err != nil {
+			return fmt.Errorf("can't generate secret: %v", err)
+		}
+		secret, generated = base64.RawURLEncoding.EncodeToString(buf), true
+	}
+
+	if _, err := clients.Register(ctx, c, secret); err != nil {
+		return fmt.Errorf("can't register client %q: %w", s.id, err)
+	}
+
+	fmt.Printf("registered %s\n", s.id)
+	if generated {
+		// the secret is only stored hashed, it can't be shown later.
+		fmt.Printf("client_secret: %s\n", secret)
+	}
+	return nil
+}
+
+func printClients(clients []models.Client) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTENANT\tAUTH\tGRANT TYPES\tSCOPES\tGUIDS\tACCESS TTL\tREFRESH TTL")
+	for _, c := range clients {
+		auth := "secret"
+		if c.PublicKey != "" {
+			auth = models.ClientAuthPrivateKeyJWT
+		} else if c.TLSSubject != "" {
+			auth = models.ClientAuthTLS
+		} else if c.Public() {
+			auth = models.ClientAuthNone
+		}
+
+		grantTypes := make([]string, 0, len(c.GrantTypes))
+		for _, g := range c.GrantTypes {
+			grantTypes = append(grantTypes, string(g))
+		}
+
+		guids := "*"
+		if !c.Allows(models.GrantTypeIssue) {
+			guids = "-"
+		} else if len(c.GUIDPatterns) > 0 {
+			guids = strings.Join(c.GUIDPatterns, ",")
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			c.ID, orDash(c.Tenant), auth, orDash(strings.Join(grantTypes, ",")),
+			orDash(strings.Join(c.Scopes, ",")), guids, ttlOrDash(c.AccessTTL), ttlOrDash(c.RefreshTTL))
+	}
+	w.Flush()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func ttlOrDash(d time.Duration) string {
+	if d == 0 {
+		return "-"
+	}
+	return d.String()
+}
+
+func NewClientsCommand() *ClientsCommand {
+	return &ClientsCommand{}
+}
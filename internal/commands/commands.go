@@ -10,7 +10,14 @@ import (
 )
 
 var cmds = map[string]lib.Command{
-	"go": NewGoCommand(),
+	"go":           NewGoCommand(),
+	"verify-audit": NewVerifyAuditCommand(),
+	"migrate":      NewMigrateCommand(),
+	"clients":      NewClientsCommand(),
+	"token":        NewTokenCommand(),
+	"keys":         NewKeysCommand(),
+	"sessions":     NewSessionsCommand(),
+	"config":       NewConfigCommand(),
 }
 
 // GetSubCommands gives a list of sub commands
@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"go-jwt-auth/internal/lib"
+	"os"
+)
+
+const _configCheck = "check"
+
+type ConfigCommand struct {
+	json bool
+}
+
+func (s *ConfigCommand) Short() string {
+	return "check the config without starting the server"
+}
+
+func (s *ConfigCommand) Setup(cmd *cobra.Command) {
+	cmd.Use = "config check"
+	cmd.ValidArgs = []string{_configCheck}
+	cmd.Args = cobra.ExactArgs(1)
+
+	cmd.Flags().BoolVar(&s.json, "json", false, "print JSON")
+
+	cmd.PreRunE = func(_ *cobra.Command, args []string) error {
+		if args[0] != _configCheck {
+			return fmt.Errorf("unknown action %q", args[0])
+		}
+		return nil
+	}
+}
+
+// configReport is the result of config check.
+type configReport struct {
+	File     string   `json:"file"`
+	Problems []string `json:"problems"`
+}
+
+// Run loads the config itself, the one of the fx graph fails on the first
+// invalid config before the problems could be printed.
+func (s *ConfigCommand) Run() lib.CommandRunner {
+	return func(file lib.ConfigFile) error {
+		conf, err := lib.LoadConfig(string(file), os.LookupEnv)
+		if err != nil {
+			return fmt.Errorf("can't load config: %w", err)
+		}
+
+		report := configReport{File: conf.PathToConfig, Problems: []string{}}
+		if report.File == "" {
+			report.File = "environment"
+		}
+		var problems lib.ConfigProblems
+		if err := conf.Validate(); errors.As(err, &problems) {
+			report.Problems = problems
+		}
+
+		if s.json {
+			if err := printJSON(os.Stdout, report); err != nil {
+				return err
+			}
+		} else if len(report.Problems) == 0 {
+			fmt.Printf("%s: ok\n", report.File)
+		} else {
+			for _, p := range report.Problems {
+				fmt.Println(p)
+			}
+		}
+
+		if len(report.Problems) > 0 {
+			return fmt.Errorf("%s has %d problems", report.File, len(report.Problems))
+		}
+		return nil
+	}
+}
+
+func NewConfigCommand() *ConfigCommand {
+	return &ConfigCommand{}
+}
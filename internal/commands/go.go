@@ -1,9 +1,21 @@
 package commands
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/cobra"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains"
 	"go-jwt-auth/internal/handler/routes"
 	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/storage"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"net"
+	"net/http"
+	"time"
 )
 
 type GoCommand struct{}
@@ -21,15 +33,95 @@ func (s *GoCommand) Run() lib.CommandRunner {
 		reqHandler lib.RequestHandler,
 		logger lib.Logger,
 		database lib.Database,
-	) {
+		migrator domains.Migrator,
+		sweeper *storage.Sweeper,
+		rewriter *storage.Rewriter,
+		grpcServer *grpc.Server,
+	) error {
+		if !conf.Storage.ManualMigrations {
+			applied, err := migrator.Up(context.Background())
+			if err != nil && !errors.Is(err, constants.ErrNotSupported) {
+				return fmt.Errorf("can't apply migrations: %v", err)
+			}
+			for _, m := range applied {
+				logger.Info("migration applied", zap.Int64("version", m.Version), zap.String("name", m.Name))
+			}
+		}
+
+		rewriter.Start()
+
 		route.Setup()
 
+		// the first server to stop stops the command.
+		errs := make(chan error, 3)
+
+		if conf.GRPC.Port != "" {
+			lis, err := net.Listen("tcp", ":"+conf.GRPC.Port)
+			if err != nil {
+				return fmt.Errorf("can't listen grpc: %v", err)
+			}
+			go func() {
+				logger.Info("Running grpc server", zap.String("port", conf.GRPC.Port))
+				if err := grpcServer.Serve(lis); err != nil {
+					errs <- fmt.Errorf("can't run grpc server: %v", err)
+				}
+			}()
+			defer grpcServer.Stop()
+		}
+
+		if conf.Metrics.Addr != "" {
+			lis, err := net.Listen("tcp", conf.Metrics.Addr)
+			if err != nil {
+				return fmt.Errorf("can't listen metrics: %v", err)
+			}
+			server := newMetricsServer()
+			go func() {
+				logger.Info("Running metrics server", zap.String("addr", conf.Metrics.Addr))
+				if err := server.Serve(lis); err != nil {
+					errs <- fmt.Errorf("can't run metrics server: %v", err)
+				}
+			}()
+			defer server.Close()
+		}
+
+		go func() {
+			errs <- runHTTP(conf, reqHandler, logger)
+		}()
+
+		return <-errs
+	}
+}
+
+// runHTTP serves the Gin routes, with TLS if HTTPS is set.
+func runHTTP(conf lib.Config, reqHandler lib.RequestHandler, logger lib.Logger) error {
+	if !conf.HTTPS {
 		logger.Info("Running server")
-		err := reqHandler.Gin.Run(":" + conf.Port)
-		if err != nil {
-			return
+		if err := reqHandler.Gin.Run(":" + conf.Port); err != nil {
+			return fmt.Errorf("can't run server: %v", err)
 		}
+		return nil
+	}
+
+	tlsConf, err := lib.ServerTLS(conf.TLS)
+	if err != nil {
+		return err
 	}
+	server := &http.Server{Addr: ":" + conf.Port, Handler: reqHandler.Gin, TLSConfig: tlsConf}
+
+	logger.Info("Running server", zap.Bool("client_certificates", tlsConf.ClientCAs != nil))
+	// the certificate is loaded by ServerTLS.
+	if err := server.ListenAndServeTLS("", ""); err != nil {
+		return fmt.Errorf("can't run server: %v", err)
+	}
+	return nil
+}
+
+// newMetricsServer serves the Prometheus metrics on /metrics, apart from the
+// public routes so that only the ones reaching metrics.addr can scrape them.
+func newMetricsServer() *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	return &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
 }
 
 func NewGoCommand() *GoCommand {
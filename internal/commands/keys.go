@@ -0,0 +1,176 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"github.com/spf13/cobra"
+	"go-jwt-auth/internal/domains"
+	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/models"
+	"go-jwt-auth/internal/signing"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	_keysGenerate = "generate"
+	_keysList     = "list"
+	_keysRotate   = "rotate"
+	_keysRetire   = "retire"
+
+	_formatJWK = "jwk"
+	_formatPEM = "pem"
+)
+
+type KeysCommand struct {
+	action string
+	kid    string
+
+	tenant  string
+	keyType string
+	format  string
+	json    bool
+}
+
+func (s *KeysCommand) Short() string {
+	return "generate, list, rotate or retire the signing keys of the key store"
+}
+
+func (s *KeysCommand) Setup(cmd *cobra.Command) {
+	cmd.Use = "keys generate|list|rotate|retire <kid>"
+	cmd.ValidArgs = []string{_keysGenerate, _keysList, _keysRotate, _keysRetire}
+	cmd.Args = cobra.RangeArgs(1, 2)
+
+	flags := cmd.Flags()
+	flags.StringVar(&s.tenant, "tenant", "", "tenant of the key, the default one if empty")
+	flags.StringVar(&s.keyType, "type", "", "key type: hmac, rsa, ec or ed25519; generate defaults to hmac, "+
+		"rotate to the type of the active key if it has to generate one")
+	flags.StringVar(&s.format, "format", _formatJWK, "format of the verification key printed by generate: jwk or pem")
+	flags.BoolVar(&s.json, "json", false, "print JSON")
+
+	cmd.PreRunE = func(_ *cobra.Command, args []string) error {
+		s.action = args[0]
+		switch s.action {
+		case _keysRetire:
+			if len(args) != 2 {
+				return fmt.Errorf("retire needs the kid")
+			}
+			s.kid = args[1]
+			return nil
+		case _keysGenerate:
+			if s.keyType == "" {
+				s.keyType = models.KeyTypeHMAC
+			}
+			if s.format != _formatJWK && s.format != _formatPEM {
+				return fmt.Errorf("unknown format %q", s.format)
+			}
+			if s.format == _formatPEM && s.keyType == models.KeyTypeHMAC {
+				return fmt.Errorf("the hmac keys have no public key, use --format jwk")
+			}
+		case _keysList, _keysRotate:
+		default:
+			return fmt.Errorf("unknown action %q", s.action)
+		}
+		if len(args) != 1 {
+			return fmt.Errorf("%s takes no arguments", s.action)
+		}
+		return nil
+	}
+}
+
+func (s *KeysCommand) Run() lib.CommandRunner {
+	return func(keys domains.Keys) error {
+		ctx := context.Background()
+
+		switch s.action {
+		case _keysGenerate:
+			k, err := keys.Generate(ctx, s.tenant, s.keyType)
+			if err != nil {
+				return fmt.Errorf("can't generate key: %w", err)
+			}
+			fmt.Fprintf(os.Stderr, "generated %s (%s), it signs once rotated in\n", k.ID, k.Algorithm)
+			return printVerificationKey(k, s.format)
+		case _keysRotate:
+			k, err := keys.Rotate(ctx, s.tenant, s.keyType)
+			if err != nil {
+				return fmt.Errorf("can't rotate keys: %w", err)
+			}
+			fmt.Printf("%s (%s) is active for tenant %s\n", k.ID, k.Algorithm, orDash(k.Tenant))
+		case _keysRetire:
+			k, err := keys.Retire(ctx, s.kid)
+			if err != nil {
+				return fmt.Errorf("can't retire key: %w", err)
+			}
+			fmt.Printf("retired %s\n", k.ID)
+		default:
+			all, err := keys.Keys(ctx)
+			if err != nil {
+				return err
+			}
+			if s.json {
+				return printJSON(os.Stdout, keyList(all))
+			}
+			printKeys(all)
+		}
+		return nil
+	}
+}
+
+// printVerificationKey prints the key verifying the tokens of the key: the
+// public key, or the secret of an HMAC key, which only the JWK carries.
+func printVerificationKey(k models.SigningKey, format string) error {
+	if format == _formatPEM {
+		pem, err := signing.PublicPEM(k)
+		if err != nil {
+			return err
+		}
+		fmt.Print(pem)
+		return nil
+	}
+
+	j, err := signing.VerificationJWK(k)
+	if err != nil {
+		return err
+	}
+	return printJSON(os.Stdout, j)
+}
+
+// listedKey is a key of the store without its private key.
+type listedKey struct {
+	ID        string          `json:"kid"`
+	Tenant    string          `json:"tenant"`
+	Algorithm string          `json:"alg"`
+	State     models.KeyState `json:"state"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+func keyList(keys []models.SigningKey) []listedKey {
+	list := make([]listedKey, 0, len(keys))
+	for _, k := range keys {
+		list = append(list, listedKey{
+			ID:        k.ID,
+			Tenant:    k.Tenant,
+			Algorithm: k.Algorithm,
+			State:     k.State,
+			CreatedAt: k.CreatedAt,
+			UpdatedAt: k.UpdatedAt,
+		})
+	}
+	return list
+}
+
+func printKeys(keys []models.SigningKey) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KID\tTENANT\tALG\tSTATE\tCREATED\tSINCE")
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", k.ID, orDash(k.Tenant), k.Algorithm, k.State,
+			k.CreatedAt.Format(time.RFC3339), k.UpdatedAt.Format(time.RFC3339))
+	}
+	w.Flush()
+}
+
+func NewKeysCommand() *KeysCommand {
+	return &KeysCommand{}
+}
@@ -0,0 +1,98 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"github.com/spf13/cobra"
+	"go-jwt-auth/internal/domains"
+	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/models"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	_migrateUp     = "up"
+	_migrateDown   = "down"
+	_migrateStatus = "status"
+)
+
+type MigrateCommand struct {
+	action string
+	steps  int
+}
+
+func (s *MigrateCommand) Short() string {
+	return "apply, revert or list the storage migrations"
+}
+
+func (s *MigrateCommand) Setup(cmd *cobra.Command) {
+	cmd.Use = "migrate up|down|status"
+	cmd.ValidArgs = []string{_migrateUp, _migrateDown, _migrateStatus}
+	cmd.Args = cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs)
+	cmd.Flags().IntVar(&s.steps, "steps", 1, "number of migrations to revert with down")
+	cmd.PreRunE = func(_ *cobra.Command, args []string) error {
+		s.action = args[0]
+		if s.action == _migrateDown && s.steps < 1 {
+			return fmt.Errorf("--steps must be positive")
+		}
+		return nil
+	}
+}
+
+func (s *MigrateCommand) Run() lib.CommandRunner {
+	return func(migrator domains.Migrator) error {
+		ctx := context.Background()
+
+		switch s.action {
+		case _migrateUp:
+			applied, err := migrator.Up(ctx)
+			for _, m := range applied {
+				fmt.Printf("applied %d %s\n", m.Version, m.Name)
+			}
+			if err != nil {
+				return err
+			}
+			if len(applied) == 0 {
+				fmt.Println("no pending migrations")
+			}
+		case _migrateDown:
+			reverted, err := migrator.Down(ctx, s.steps)
+			for _, m := range reverted {
+				fmt.Printf("reverted %d %s\n", m.Version, m.Name)
+			}
+			if err != nil {
+				return err
+			}
+			if len(reverted) == 0 {
+				fmt.Println("no applied migrations")
+			}
+		default:
+			status, err := migrator.Status(ctx)
+			if err != nil {
+				return err
+			}
+			printMigrations(status)
+		}
+
+		return nil
+	}
+}
+
+func printMigrations(status []models.MigrationStatus) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+	for _, m := range status {
+		at := "pending"
+		if m.Applied() {
+			at = time.Unix(m.AppliedAt, 0).UTC().Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", m.Version, m.Name, at)
+	}
+	w.Flush()
+}
+
+func NewMigrateCommand() *MigrateCommand {
+	return &MigrateCommand{}
+}
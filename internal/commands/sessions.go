@@ -0,0 +1,363 @@
+package commands
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains"
+	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/models"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	_sessionsList         = "list"
+	_sessionsRevoke       = "revoke"
+	_sessionsPurgeExpired = "purge-expired"
+	_sessionsStats        = "stats"
+
+	// _sessionIDSize is the number of hex digits of the session IDs.
+	_sessionIDSize = 16
+)
+
+type SessionsCommand struct {
+	action string
+
+	guid string
+	id   string
+
+	tenant string
+	// tenantSet tells the default tenant from all the tenants.
+	tenantSet bool
+	dryRun    bool
+	json      bool
+}
+
+func (s *SessionsCommand) Short() string {
+	return "list, revoke, purge or count the sessions of the storage"
+}
+
+func (s *SessionsCommand) Setup(cmd *cobra.Command) {
+	cmd.Use = "sessions list --guid <guid>|revoke --guid <guid> [--id <id>]|purge-expired|stats"
+	cmd.ValidArgs = []string{_sessionsList, _sessionsRevoke, _sessionsPurgeExpired, _sessionsStats}
+	cmd.Args = cobra.ExactArgs(1)
+
+	flags := cmd.Flags()
+	flags.StringVar(&s.guid, "guid", "", "GUID of the sessions")
+	flags.StringVar(&s.id, "id", "", "ID of the session to revoke, as listed, all the sessions of the GUID if empty")
+	flags.StringVar(&s.tenant, "tenant", "", "tenant of the sessions, \"\" for the default one; all the tenants if unset")
+	flags.BoolVar(&s.dryRun, "dry-run", false, "print what revoke or purge-expired would delete without deleting it")
+	flags.BoolVar(&s.json, "json", false, "print JSON")
+
+	cmd.PreRunE = func(c *cobra.Command, args []string) error {
+		s.action = args[0]
+		s.tenantSet = c.Flags().Changed("tenant")
+		switch s.action {
+		case _sessionsList, _sessionsRevoke:
+			if s.guid == "" {
+				return fmt.Errorf("%s needs --guid", s.action)
+			}
+		case _sessionsPurgeExpired, _sessionsStats:
+		default:
+			return fmt.Errorf("unknown action %q", s.action)
+		}
+		return nil
+	}
+}
+
+func (s *SessionsCommand) Run() lib.CommandRunner {
+	return func(repository domains.Repository, events domains.EventPublisher) error {
+		ctx := context.Background()
+		now := time.Now()
+
+		switch s.action {
+		case _sessionsList:
+			sessions, err := findSessions(ctx, repository, s.guid, s.tenantFilter(), "")
+			if err != nil {
+				return err
+			}
+			return s.printSessions(os.Stdout, sessions, now)
+		case _sessionsRevoke:
+			sessions, err := findSessions(ctx, repository, s.guid, s.tenantFilter(), s.id)
+			if err != nil {
+				return err
+			}
+			if s.id != "" && len(sessions) == 0 {
+				return fmt.Errorf("session %q of %q: %w", s.id, s.guid, constants.ErrNotFound)
+			}
+			if s.dryRun {
+				fmt.Fprintf(os.Stderr, "dry run, %d sessions would be revoked\n", len(sessions))
+				return s.printSessions(os.Stdout, sessions, now)
+			}
+			revoked, err := revokeSessions(ctx, repository, events, sessions)
+			if s.json {
+				if err := printJSON(os.Stdout, revokeResult{GUID: s.guid, Revoked: revoked}); err != nil {
+					return err
+				}
+			} else {
+				fmt.Printf("revoked %d sessions of %s\n", revoked, s.guid)
+			}
+			return err
+		case _sessionsPurgeExpired:
+			return s.purgeExpired(ctx, os.Stdout, repository, now)
+		default:
+			stats, err := collectSessionStats(ctx, repository, now)
+			if err != nil {
+				return err
+			}
+			if s.json {
+				return printJSON(os.Stdout, stats)
+			}
+			printSessionStats(os.Stdout, stats)
+			return nil
+		}
+	}
+}
+
+// tenantFilter is the tenant the sessions are filtered by, nil for all the tenants.
+func (s *SessionsCommand) tenantFilter() *string {
+	if !s.tenantSet {
+		return nil
+	}
+	return &s.tenant
+}
+
+// revokeResult is the output of revoke with --json.
+type revokeResult struct {
+	GUID    string `json:"guid"`
+	Revoked int    `json:"revoked"`
+}
+
+// purgeResult is the output of purge-expired with --json.
+type purgeResult struct {
+	DryRun bool `json:"dry_run"`
+	// Purged are the sessions purged, or the ones that would be with DryRun.
+	Purged int64 `json:"purged"`
+	// Supported is false for the storages expiring the sessions themselves.
+	Supported bool `json:"supported"`
+}
+
+func (s *SessionsCommand) purgeExpired(ctx context.Context, w io.Writer, repository domains.Repository, now time.Time) error {
+	res := purgeResult{DryRun: s.dryRun, Supported: true}
+	if s.dryRun {
+		var expired int64
+		err := repository.ScanTokenData(ctx, func(td models.TokenData) error {
+			if sessionExpired(td, now) {
+				expired++
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("can't count expired sessions: %w", err)
+		}
+		res.Purged = expired
+	} else {
+		purged, err := repository.PurgeExpired(ctx, now.Unix())
+		if errors.Is(err, constants.ErrNotSupported) {
+			res.Supported = false
+		} else if err != nil {
+			return fmt.Errorf("can't purge expired sessions: %w", err)
+		}
+		res.Purged = purged
+	}
+
+	switch {
+	case s.json:
+		return printJSON(w, res)
+	case !res.Supported:
+		fmt.Fprintln(w, "the storage expires the sessions itself, there is nothing to purge")
+	case res.DryRun:
+		fmt.Fprintf(w, "dry run, %d expired sessions would be purged\n", res.Purged)
+	default:
+		fmt.Fprintf(w, "purged %d expired sessions\n", res.Purged)
+	}
+	return nil
+}
+
+func (s *SessionsCommand) printSessions(w io.Writer, sessions []models.TokenData, now time.Time) error {
+	listed := make([]listedSession, 0, len(sessions))
+	for _, td := range sessions {
+		listed = append(listed, listedSession{
+			ID:           sessionID(td),
+			GUID:         td.GUID,
+			Tenant:       td.Tenant,
+			sessionState: newSessionState(td, now),
+		})
+	}
+	if s.json {
+		return printJSON(w, listed)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tTENANT\tCLIENT\tIP\tSCOPE\tACCESS EXPIRES\tREFRESH EXPIRES\tSTATE")
+	for _, l := range listed {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", l.ID, orDash(l.Tenant), orDash(l.ClientID),
+			orDash(l.IP), orDash(l.Scope), formatExpiry(l.AccessExpiresAt), formatExpiry(l.RefreshExpiresAt), l.state())
+	}
+	return tw.Flush()
+}
+
+// listedSession is a session without the hash of its refresh token.
+type listedSession struct {
+	ID     string `json:"id"`
+	GUID   string `json:"guid"`
+	Tenant string `json:"tenant"`
+	sessionState
+}
+
+// sessionID identifies the session in the commands. It is derived from the
+// hash of its refresh token, which is never shown.
+func sessionID(td models.TokenData) string {
+	sum := sha256.Sum256([]byte(td.RefreshHash))
+	return hex.EncodeToString(sum[:])[:_sessionIDSize]
+}
+
+// sessionExpired reports whether the refresh token of the session expired,
+// like the storages purging them do.
+func sessionExpired(td models.TokenData, now time.Time) bool {
+	return td.RefreshExp > 0 && td.RefreshExp < now.Unix()
+}
+
+// findSessions returns the sessions of the GUID issued by the tenant, by all
+// the tenants if it is nil, oldest expiry first. With an id, only that session.
+func findSessions(
+	ctx context.Context,
+	repository domains.Repository,
+	guid string,
+	tenant *string,
+	id string,
+) ([]models.TokenData, error) {
+
+	all, err := repository.GetTokensDataByGUID(ctx, guid)
+	if err != nil && !errors.Is(err, constants.ErrNotFound) {
+		return nil, fmt.Errorf("can't get sessions: %w", err)
+	}
+
+	sessions := []models.TokenData{}
+	for _, td := range all {
+		if tenant != nil && td.Tenant != *tenant {
+			continue
+		}
+		if id != "" && sessionID(td) != id {
+			continue
+		}
+		sessions = append(sessions, td)
+	}
+	sort.SliceStable(sessions, func(a, b int) bool {
+		return sessions[a].RefreshExp < sessions[b].RefreshExp
+	})
+	return sessions, nil
+}
+
+// revokeSessions deletes the sessions and publishes their revocation like the
+// revocation endpoint. The sessions consumed in the meantime aren't counted.
+func revokeSessions(
+	ctx context.Context,
+	repository domains.Repository,
+	events domains.EventPublisher,
+	sessions []models.TokenData,
+) (revoked int, err error) {
+
+	for _, td := range sessions {
+		if err := repository.DeleteTokenData(ctx, td.GUID, td.RefreshHash); err != nil {
+			if errors.Is(err, constants.ErrNotFound) {
+				continue
+			}
+			return revoked, fmt.Errorf("can't revoke session %s: %w", sessionID(td), err)
+		}
+		revoked++
+		events.Publish(ctx, models.Event{Type: models.EventRevoked, GUID: td.GUID, Time: time.Now().Unix()})
+	}
+	return revoked, nil
+}
+
+// sessionStats counts the sessions of the storage.
+type sessionStats struct {
+	sessionCounts
+	Tenants []tenantSessionCounts `json:"tenants"`
+}
+
+type tenantSessionCounts struct {
+	Tenant string `json:"tenant"`
+	sessionCounts
+}
+
+type sessionCounts struct {
+	Sessions int64 `json:"sessions"`
+	Active   int64 `json:"active"`
+	Expired  int64 `json:"expired"`
+	// GUIDs is the number of GUIDs with at least one session.
+	GUIDs int64 `json:"guids"`
+}
+
+func (c *sessionCounts) add(td models.TokenData, now time.Time) {
+	c.Sessions++
+	if sessionExpired(td, now) {
+		c.Expired++
+	} else {
+		c.Active++
+	}
+}
+
+// collectSessionStats goes through all the sessions, the ones of each tenant
+// are counted separately too.
+func collectSessionStats(ctx context.Context, repository domains.Repository, now time.Time) (sessionStats, error) {
+	var (
+		stats   sessionStats
+		tenants = map[string]*tenantSessionCounts{}
+		guids   = map[string]map[string]struct{}{}
+	)
+
+	err := repository.ScanTokenData(ctx, func(td models.TokenData) error {
+		t, ok := tenants[td.Tenant]
+		if !ok {
+			t = &tenantSessionCounts{Tenant: td.Tenant}
+			tenants[td.Tenant], guids[td.Tenant] = t, map[string]struct{}{}
+		}
+		stats.add(td, now)
+		t.add(td, now)
+		guids[td.Tenant][td.GUID] = struct{}{}
+		return nil
+	})
+	if err != nil {
+		return stats, fmt.Errorf("can't go through the sessions: %w", err)
+	}
+
+	// a GUID is counted once overall even if several tenants issued its sessions.
+	all := map[string]struct{}{}
+	stats.Tenants = make([]tenantSessionCounts, 0, len(tenants))
+	for id, t := range tenants {
+		t.GUIDs = int64(len(guids[id]))
+		for guid := range guids[id] {
+			all[guid] = struct{}{}
+		}
+		stats.Tenants = append(stats.Tenants, *t)
+	}
+	stats.GUIDs = int64(len(all))
+	sort.Slice(stats.Tenants, func(a, b int) bool {
+		return stats.Tenants[a].Tenant < stats.Tenants[b].Tenant
+	})
+	return stats, nil
+}
+
+func printSessionStats(w io.Writer, stats sessionStats) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TENANT\tSESSIONS\tACTIVE\tEXPIRED\tGUIDS")
+	for _, t := range stats.Tenants {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\n", orDash(t.Tenant), t.Sessions, t.Active, t.Expired, t.GUIDs)
+	}
+	fmt.Fprintf(tw, "total\t%d\t%d\t%d\t%d\n", stats.Sessions, stats.Active, stats.Expired, stats.GUIDs)
+	tw.Flush()
+}
+
+func NewSessionsCommand() *SessionsCommand {
+	return &SessionsCommand{}
+}
@@ -0,0 +1,271 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/mock"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains/mocks"
+	"go-jwt-auth/internal/models"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFindSessions(t *testing.T) {
+	sessions := []models.TokenData{
+		{GUID: "kwfwe", RefreshHash: "qjfwjnqk", Tenant: "acme", RefreshExp: 300},
+		{GUID: "kwfwe", RefreshHash: "ebfkqbfb", RefreshExp: 200},
+		{GUID: "kwfwe", RefreshHash: "hwejhvqvf", Tenant: "acme", RefreshExp: 100},
+	}
+	acme, defaultTenant := "acme", ""
+
+	tests := []struct {
+		name    string
+		tenant  *string
+		id      string
+		mock    func(repo *mocks.Repository)
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "allTenants",
+			mock: func(repo *mocks.Repository) {
+				repo.On("GetTokensDataByGUID", mock.Anything, "kwfwe").Return(sessions, nil)
+			},
+			want: []string{"hwejhvqvf", "ebfkqbfb", "qjfwjnqk"},
+		},
+		{
+			name:   "tenant",
+			tenant: &acme,
+			mock: func(repo *mocks.Repository) {
+				repo.On("GetTokensDataByGUID", mock.Anything, "kwfwe").Return(sessions, nil)
+			},
+			want: []string{"hwejhvqvf", "qjfwjnqk"},
+		},
+		{
+			name:   "defaultTenant",
+			tenant: &defaultTenant,
+			mock: func(repo *mocks.Repository) {
+				repo.On("GetTokensDataByGUID", mock.Anything, "kwfwe").Return(sessions, nil)
+			},
+			want: []string{"ebfkqbfb"},
+		},
+		{
+			name: "id",
+			id:   sessionID(sessions[0]),
+			mock: func(repo *mocks.Repository) {
+				repo.On("GetTokensDataByGUID", mock.Anything, "kwfwe").Return(sessions, nil)
+			},
+			want: []string{"qjfwjnqk"},
+		},
+		{
+			name: "none",
+			mock: func(repo *mocks.Repository) {
+				repo.On("GetTokensDataByGUID", mock.Anything, "kwfwe").Return(nil, constants.ErrNotFound)
+			},
+			want: []string{},
+		},
+		{
+			name: "error",
+			mock: func(repo *mocks.Repository) {
+				repo.On("GetTokensDataByGUID", mock.Anything, "kwfwe").Return(nil, constants.ErrRepository)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mocks.NewRepository(t)
+			tt.mock(repo)
+
+			got, err := findSessions(context.Background(), repo, "kwfwe", tt.tenant, tt.id)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("findSessions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			hashes := []string{}
+			for _, td := range got {
+				hashes = append(hashes, td.RefreshHash)
+			}
+			if !reflect.DeepEqual(hashes, tt.want) {
+				t.Errorf("findSessions() = %v, want %v", hashes, tt.want)
+			}
+		})
+	}
+}
+
+func TestRevokeSessions(t *testing.T) {
+	sessions := []models.TokenData{
+		{GUID: "kwfwe", RefreshHash: "qjfwjnqk"},
+		{GUID: "kwfwe", RefreshHash: "ebfkqbfb"},
+	}
+	revoked := mock.MatchedBy(func(e models.Event) bool {
+		return e.Type == models.EventRevoked && e.GUID == "kwfwe"
+	})
+
+	tests := []struct {
+		name    string
+		mock    func(repo *mocks.Repository, ev *mocks.EventPublisher)
+		want    int
+		wantErr bool
+	}{
+		{
+			name: "ok",
+			mock: func(repo *mocks.Repository, ev *mocks.EventPublisher) {
+				repo.On("DeleteTokenData", mock.Anything, "kwfwe", "qjfwjnqk").Return(nil)
+				repo.On("DeleteTokenData", mock.Anything, "kwfwe", "ebfkqbfb").Return(nil)
+				ev.On("Publish", mock.Anything, revoked).Return().Twice()
+			},
+			want: 2,
+		},
+		{
+			name: "consumed",
+			mock: func(repo *mocks.Repository, ev *mocks.EventPublisher) {
+				repo.On("DeleteTokenData", mock.Anything, "kwfwe", "qjfwjnqk").Return(constants.ErrNotFound)
+				repo.On("DeleteTokenData", mock.Anything, "kwfwe", "ebfkqbfb").Return(nil)
+				ev.On("Publish", mock.Anything, revoked).Return().Once()
+			},
+			want: 1,
+		},
+		{
+			name: "error",
+			mock: func(repo *mocks.Repository, ev *mocks.EventPublisher) {
+				repo.On("DeleteTokenData", mock.Anything, "kwfwe", "qjfwjnqk").Return(nil)
+				repo.On("DeleteTokenData", mock.Anything, "kwfwe", "ebfkqbfb").Return(errors.New("connection refused"))
+				ev.On("Publish", mock.Anything, revoked).Return().Once()
+			},
+			want:    1,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, ev := mocks.NewRepository(t), mocks.NewEventPublisher(t)
+			tt.mock(repo, ev)
+
+			got, err := revokeSessions(context.Background(), repo, ev, sessions)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("revokeSessions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("revokeSessions() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSessionsCommand_PurgeExpired(t *testing.T) {
+	now := time.Unix(1000, 0)
+
+	tests := []struct {
+		name string
+		cmd  SessionsCommand
+		mock func(repo *mocks.Repository)
+		want string
+	}{
+		{
+			name: "purged",
+			mock: func(repo *mocks.Repository) {
+				repo.On("PurgeExpired", mock.Anything, int64(1000)).Return(int64(3), nil)
+			},
+			want: "purged 3 expired sessions\n",
+		},
+		{
+			name: "json",
+			cmd:  SessionsCommand{json: true},
+			mock: func(repo *mocks.Repository) {
+				repo.On("PurgeExpired", mock.Anything, int64(1000)).Return(int64(3), nil)
+			},
+			want: `{"dry_run":false,"purged":3,"supported":true}`,
+		},
+		{
+			name: "dryRunJSON",
+			cmd:  SessionsCommand{json: true, dryRun: true},
+			mock: func(repo *mocks.Repository) {
+				repo.On("ScanTokenData", mock.Anything, mock.Anything).Return(func(_ context.Context, fn func(models.TokenData) error) error {
+					for _, exp := range []int64{999, 1001} {
+						if err := fn(models.TokenData{RefreshExp: exp}); err != nil {
+							return err
+						}
+					}
+					return nil
+				})
+			},
+			want: `{"dry_run":true,"purged":1,"supported":true}`,
+		},
+		{
+			name: "notSupportedJSON",
+			cmd:  SessionsCommand{json: true},
+			mock: func(repo *mocks.Repository) {
+				repo.On("PurgeExpired", mock.Anything, int64(1000)).Return(int64(0), constants.ErrNotSupported)
+			},
+			want: `{"dry_run":false,"purged":0,"supported":false}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mocks.NewRepository(t)
+			tt.mock(repo)
+
+			var out strings.Builder
+			if err := tt.cmd.purgeExpired(context.Background(), &out, repo, now); err != nil {
+				t.Fatalf("purgeExpired() error = %v", err)
+			}
+			got := out.String()
+			if tt.cmd.json {
+				var compact bytes.Buffer
+				if err := json.Compact(&compact, []byte(got)); err != nil {
+					t.Fatalf("Compact() error = %v", err)
+				}
+				got = compact.String()
+			}
+			if strings.TrimSpace(got) != strings.TrimSpace(tt.want) {
+				t.Errorf("purgeExpired() printed %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollectSessionStats(t *testing.T) {
+	now := time.Unix(1000, 0)
+	repo := mocks.NewRepository(t)
+	repo.On("ScanTokenData", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			fn := args.Get(1).(func(models.TokenData) error)
+			for _, td := range []models.TokenData{
+				{GUID: "kwfwe", Tenant: "acme", RefreshExp: 2000},
+				{GUID: "kwfwe", Tenant: "acme", RefreshExp: 500},
+				{GUID: "lkmlkwe", Tenant: "acme"},
+				{GUID: "kwfwe", RefreshExp: 2000},
+			} {
+				_ = fn(td)
+			}
+		}).
+		Return(nil)
+
+	got, err := collectSessionStats(context.Background(), repo, now)
+	if err != nil {
+		t.Fatalf("collectSessionStats() error = %v", err)
+	}
+
+	want := sessionStats{
+		sessionCounts: sessionCounts{Sessions: 4, Active: 3, Expired: 1, GUIDs: 2},
+		Tenants: []tenantSessionCounts{
+			{Tenant: "", sessionCounts: sessionCounts{Sessions: 1, Active: 1, GUIDs: 1}},
+			{Tenant: "acme", sessionCounts: sessionCounts{Sessions: 3, Active: 2, Expired: 1, GUIDs: 2}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("collectSessionStats() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,421 @@
+package commands
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/spf13/cobra"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains"
+	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/models"
+	"go-jwt-auth/internal/signing"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	_tokenIssue   = "issue"
+	_tokenRefresh = "refresh"
+	_tokenInspect = "inspect"
+)
+
+type TokenCommand struct {
+	action string
+
+	guid    string
+	access  string
+	refresh string
+	token   string
+
+	tenant string
+	// tenantSet tells the default tenant from the tenant of the token for inspect.
+	tenantSet bool
+	client    string
+	json      bool
+}
+
+func (s *TokenCommand) Short() string {
+	return "issue, refresh or inspect tokens directly against the storage"
+}
+
+func (s *TokenCommand) Setup(cmd *cobra.Command) {
+	cmd.Use = "token issue --guid <guid>|refresh --refresh <token>|inspect <jwt>"
+	cmd.ValidArgs = []string{_tokenIssue, _tokenRefresh, _tokenInspect}
+	cmd.Args = cobra.RangeArgs(1, 2)
+
+	flags := cmd.Flags()
+	flags.StringVar(&s.guid, "guid", "", "GUID to issue the tokens of")
+	flags.StringVar(&s.access, "access", "", "access token of the pair to refresh, as issued by /v1/tokens")
+	flags.StringVar(&s.refresh, "refresh", "", "refresh token, of a /v1/tokens pair or of the OAuth grants")
+	flags.StringVar(&s.tenant, "tenant", "", "tenant of the tokens, the default one if empty; inspect uses the tid claim if unset")
+	flags.StringVar(&s.client, "client", "", "client the tokens are issued to or the session is bound to")
+	flags.BoolVar(&s.json, "json", false, "print JSON")
+
+	cmd.PreRunE = func(c *cobra.Command, args []string) error {
+		s.action = args[0]
+		s.tenantSet = c.Flags().Changed("tenant")
+		switch s.action {
+		case _tokenIssue:
+			if s.guid == "" {
+				return fmt.Errorf("issue needs --guid")
+			}
+		case _tokenRefresh:
+			if s.refresh == "" {
+				return fmt.Errorf("refresh needs --refresh")
+			}
+			if s.access == "" && s.client == "" {
+				// the refresh tokens of the grants are refreshed by the refresh_token grant of their client.
+				return fmt.Errorf("refresh needs --access for a /v1/tokens pair or --client for a grant refresh token")
+			}
+		case _tokenInspect:
+			if len(args) != 2 {
+				return fmt.Errorf("inspect needs the token")
+			}
+			s.token = args[1]
+			return nil
+		default:
+			return fmt.Errorf("unknown action %q", s.action)
+		}
+		if len(args) != 1 {
+			return fmt.Errorf("%s takes no arguments", s.action)
+		}
+		return nil
+	}
+}
+
+func (s *TokenCommand) Run() lib.CommandRunner {
+	return func(tokens domains.TokenManager, tenants domains.Tenants, repository domains.Repository) error {
+		ctx := context.Background()
+
+		if s.action == _tokenInspect {
+			tenant := (*string)(nil)
+			if s.tenantSet {
+				tenant = &s.tenant
+			}
+			i, err := inspectToken(ctx, tenants, repository, s.token, tenant)
+			if err != nil {
+				return err
+			}
+			if s.json {
+				return printJSON(os.Stdout, i)
+			}
+			printInspection(os.Stdout, i)
+			return nil
+		}
+
+		ctx, err := s.context(ctx, repository)
+		if err != nil {
+			return err
+		}
+
+		var issued issuedTokens
+		switch {
+		case s.action == _tokenIssue:
+			access, refresh, err := tokens.GetTokens(ctx, s.guid)
+			if err != nil {
+				return fmt.Errorf("can't issue tokens: %w", err)
+			}
+			issued, err = newIssuedTokens(access, refresh, true)
+			if err != nil {
+				return err
+			}
+		case s.access != "":
+			access, refresh, err := tokens.RefreshTokens(ctx, s.access, s.refresh)
+			if err != nil {
+				return fmt.Errorf("can't refresh tokens: %w", err)
+			}
+			issued, err = newIssuedTokens(access, refresh, true)
+			if err != nil {
+				return err
+			}
+		default:
+			t, err := tokens.Grant(ctx, models.GrantRequest{Type: models.GrantTypeRefreshToken, RefreshToken: s.refresh})
+			if err != nil {
+				return fmt.Errorf("can't refresh tokens: %w", err)
+			}
+			issued, err = newIssuedTokens(t.AccessToken, t.RefreshToken, false)
+			if err != nil {
+				return err
+			}
+			issued.Scope = t.Scope
+		}
+
+		if s.json {
+			return printJSON(os.Stdout, issued)
+		}
+		printIssued(os.Stdout, issued)
+		return nil
+	}
+}
+
+// context is the context of the requests of the tenant and of the client, if any.
+func (s *TokenCommand) context(ctx context.Context, repository domains.Repository) (context.Context, error) {
+	ctx = lib.WithTenant(ctx, s.tenant)
+	if s.client == "" {
+		return ctx, nil
+	}
+
+	client, err := repository.GetClient(ctx, s.client)
+	if errors.Is(err, constants.ErrNotFound) {
+		return nil, fmt.Errorf("unknown client %q", s.client)
+	} else if err != nil {
+		return nil, fmt.Errorf("can't get client %q: %w", s.client, err)
+	}
+	if client.Tenant != s.tenant {
+		return nil, fmt.Errorf("client %q belongs to tenant %q", s.client, client.Tenant)
+	}
+	return lib.WithClient(ctx, client), nil
+}
+
+// issuedTokens are the tokens issued by the command.
+type issuedTokens struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	GUID         string    `json:"guid"`
+	Tenant       string    `json:"tenant"`
+	Scope        string    `json:"scope,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// newIssuedTokens reads the GUID, the tenant and the expiry of the access
+// token, base64-encoded like the /v1 tokens if encoded is set.
+func newIssuedTokens(access, refresh string, encoded bool) (issuedTokens, error) {
+	raw := access
+	if encoded {
+		b, err := base64.StdEncoding.DecodeString(access)
+		if err != nil {
+			return issuedTokens{}, fmt.Errorf("can't decode access token: %v", err)
+		}
+		raw = string(b)
+	}
+
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(raw, claims); err != nil {
+		return issuedTokens{}, fmt.Errorf("can't parse access token: %v", err)
+	}
+
+	t := issuedTokens{AccessToken: access, RefreshToken: refresh}
+	t.GUID, _ = claims["guid"].(string)
+	t.Tenant, _ = claims["tid"].(string)
+	t.Scope, _ = claims["scope"].(string)
+	// the iat claim of the access tokens is their expiry.
+	if iat, ok := claims["iat"].(float64); ok {
+		t.ExpiresAt = time.Unix(int64(iat), 0)
+	}
+	return t, nil
+}
+
+func printIssued(w io.Writer, t issuedTokens) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "access_token:\t%s\n", t.AccessToken)
+	fmt.Fprintf(tw, "refresh_token:\t%s\n", t.RefreshToken)
+	fmt.Fprintf(tw, "guid:\t%s\n", t.GUID)
+	fmt.Fprintf(tw, "tenant:\t%s\n", orDash(t.Tenant))
+	if t.Scope != "" {
+		fmt.Fprintf(tw, "scope:\t%s\n", t.Scope)
+	}
+	fmt.Fprintf(tw, "expires:\t%s\n", expiry(t.ExpiresAt, time.Now()))
+	tw.Flush()
+}
+
+// inspection is the state of an access token.
+type inspection struct {
+	Header map[string]any `json:"header"`
+	Claims map[string]any `json:"claims"`
+	Tenant string         `json:"tenant"`
+	// Valid reports whether the signature, the issuer and the audience are the ones of the tenant.
+	Valid     bool      `json:"valid"`
+	Error     string    `json:"error,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Expired   bool      `json:"expired"`
+	// Sessions are the sessions of the GUID issued by the tenant.
+	Sessions []sessionState `json:"sessions"`
+}
+
+type sessionState struct {
+	ClientID         string    `json:"client_id,omitempty"`
+	IP               string    `json:"ip,omitempty"`
+	Scope            string    `json:"scope,omitempty"`
+	AccessExpiresAt  time.Time `json:"access_expires_at"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	Active           bool      `json:"active"`
+}
+
+func newSessionState(td models.TokenData, now time.Time) sessionState {
+	s := sessionState{
+		ClientID: td.ClientID,
+		IP:       td.IP,
+		Scope:    td.Scope,
+		Active:   !sessionExpired(td, now),
+	}
+	// the sessions saved without an expiry never expire.
+	if td.AccessExp > 0 {
+		s.AccessExpiresAt = time.Unix(td.AccessExp, 0)
+	}
+	if td.RefreshExp > 0 {
+		s.RefreshExpiresAt = time.Unix(td.RefreshExp, 0)
+	}
+	return s
+}
+
+func (s sessionState) state() string {
+	if s.Active {
+		return "active"
+	}
+	return "expired"
+}
+
+// inspectToken decodes an access token, raw or base64-encoded like the /v1
+// tokens, and verifies it with the key of the tenant, the one of its tid
+// claim if tenant is nil. The token is inspected even if it is invalid.
+func inspectToken(
+	ctx context.Context,
+	tenants domains.Tenants,
+	repository domains.Repository,
+	token string,
+	tenant *string,
+) (i inspection, err error) {
+
+	if strings.Count(token, ".") != 2 {
+		b, err := base64.StdEncoding.DecodeString(token)
+		if err != nil {
+			return i, fmt.Errorf("the token is neither a JWT nor a base64-encoded one")
+		}
+		token = string(b)
+	}
+
+	claims := jwt.MapClaims{}
+	t, _, err := jwt.NewParser().ParseUnverified(token, claims)
+	if err != nil {
+		return i, fmt.Errorf("can't decode token: %v", err)
+	}
+	i.Header, i.Claims = t.Header, claims
+
+	if tenant != nil {
+		i.Tenant = *tenant
+	} else {
+		i.Tenant, _ = claims["tid"].(string)
+	}
+
+	now := time.Now()
+	// the iat claim of the access tokens is their expiry.
+	if iat, ok := claims["iat"].(float64); ok {
+		i.ExpiresAt = time.Unix(int64(iat), 0)
+		i.Expired = !i.ExpiresAt.After(now)
+	}
+
+	if err := verifyToken(tenants, i.Tenant, token); err != nil {
+		i.Error = err.Error()
+	} else {
+		i.Valid = true
+	}
+
+	guid, _ := claims["guid"].(string)
+	if guid == "" {
+		return i, nil
+	}
+	sessions, err := repository.GetTokensDataByGUID(ctx, guid)
+	if err != nil && !errors.Is(err, constants.ErrNotFound) {
+		return i, fmt.Errorf("can't get sessions: %w", err)
+	}
+	i.Sessions = []sessionState{}
+	for _, s := range sessions {
+		if s.Tenant != i.Tenant {
+			continue
+		}
+		i.Sessions = append(i.Sessions, newSessionState(s, now))
+	}
+	sort.Slice(i.Sessions, func(a, b int) bool {
+		return i.Sessions[a].RefreshExpiresAt.Before(i.Sessions[b].RefreshExpiresAt)
+	})
+	return i, nil
+}
+
+// verifyToken checks the token like the services do, its expiry aside.
+func verifyToken(tenants domains.Tenants, id, token string) error {
+	tenant, err := tenants.Tenant(id)
+	if err != nil {
+		return err
+	}
+
+	opts := []jwt.ParserOption{jwt.WithValidMethods(signing.Methods(tenant))}
+	if tenant.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(tenant.Issuer))
+	}
+	if tenant.Audience != "" {
+		opts = append(opts, jwt.WithAudience(tenant.Audience))
+	}
+
+	_, err = jwt.Parse(token, signing.Keyfunc(tenant), opts...)
+	return err
+}
+
+func printInspection(w io.Writer, i inspection) {
+	header, _ := json.MarshalIndent(i.Header, "", "  ")
+	claims, _ := json.MarshalIndent(i.Claims, "", "  ")
+	fmt.Fprintf(w, "header:\n%s\nclaims:\n%s\n\n", header, claims)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "tenant:\t%s\n", orDash(i.Tenant))
+	if i.Valid {
+		fmt.Fprintf(tw, "signature:\tvalid\n")
+	} else {
+		fmt.Fprintf(tw, "signature:\tinvalid (%s)\n", i.Error)
+	}
+	if i.ExpiresAt.IsZero() {
+		fmt.Fprintf(tw, "expires:\t-\n")
+	} else {
+		fmt.Fprintf(tw, "expires:\t%s\n", expiry(i.ExpiresAt, time.Now()))
+	}
+	tw.Flush()
+
+	if i.Sessions == nil {
+		return
+	}
+	fmt.Fprintf(w, "\nsessions of the guid: %d\n", len(i.Sessions))
+	if len(i.Sessions) == 0 {
+		return
+	}
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CLIENT\tIP\tSCOPE\tACCESS EXPIRES\tREFRESH EXPIRES\tSTATE")
+	for _, s := range i.Sessions {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", orDash(s.ClientID), orDash(s.IP), orDash(s.Scope),
+			formatExpiry(s.AccessExpiresAt), formatExpiry(s.RefreshExpiresAt), s.state())
+	}
+	tw.Flush()
+}
+
+// expiry is the time with how long ago or in how long it is.
+func expiry(t, now time.Time) string {
+	d := t.Sub(now).Round(time.Second)
+	if d <= 0 {
+		return fmt.Sprintf("%s (expired %s ago)", t.Format(time.RFC3339), -d)
+	}
+	return fmt.Sprintf("%s (in %s)", t.Format(time.RFC3339), d)
+}
+
+// formatExpiry is the time, or a dash if there is none.
+func formatExpiry(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
+
+func printJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func NewTokenCommand() *TokenCommand {
+	return &TokenCommand{}
+}
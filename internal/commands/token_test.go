@@ -0,0 +1,113 @@
+package commands
+
+import (
+	"context"
+	"encoding/base64"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/mock"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains/mocks"
+	"go-jwt-auth/internal/models"
+	"testing"
+	"time"
+)
+
+func TestInspectToken(t *testing.T) {
+	exp := time.Now().Add(time.Minute).Unix()
+	acme := models.Tenant{ID: "acme", Key: []byte("acme-secret"), Issuer: "https://auth.acme.example"}
+
+	sign := func(claims jwt.MapClaims, key string) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS512, claims).SignedString([]byte(key))
+		if err != nil {
+			t.Fatalf("SignedString() error = %v", err)
+		}
+		return token
+	}
+	valid := sign(jwt.MapClaims{"guid": "kwfwe", "iat": exp, "tid": "acme", "iss": acme.Issuer}, "acme-secret")
+	otherTenant := ""
+
+	tests := []struct {
+		name         string
+		token        string
+		tenant       *string
+		mock         func(tn *mocks.Tenants, repo *mocks.Repository)
+		wantTenant   string
+		wantValid    bool
+		wantExpired  bool
+		wantSessions int
+		wantErr      bool
+	}{
+		{
+			name:  "ok",
+			token: valid,
+			mock: func(tn *mocks.Tenants, repo *mocks.Repository) {
+				tn.On("Tenant", "acme").Return(acme, nil)
+				repo.On("GetTokensDataByGUID", mock.Anything, "kwfwe").Return([]models.TokenData{
+					{GUID: "kwfwe", Tenant: "acme", ClientID: "svc", RefreshExp: exp},
+					{GUID: "kwfwe", Tenant: "acme", RefreshExp: time.Now().Add(-time.Minute).Unix()},
+					{GUID: "kwfwe", RefreshExp: exp},
+				}, nil)
+			},
+			wantTenant:   "acme",
+			wantValid:    true,
+			wantSessions: 2,
+		},
+		{
+			name:  "base64",
+			token: base64.StdEncoding.EncodeToString([]byte(valid)),
+			mock: func(tn *mocks.Tenants, repo *mocks.Repository) {
+				tn.On("Tenant", "acme").Return(acme, nil)
+				repo.On("GetTokensDataByGUID", mock.Anything, "kwfwe").Return(nil, constants.ErrNotFound)
+			},
+			wantTenant: "acme",
+			wantValid:  true,
+		},
+		{
+			name:   "anotherTenant",
+			token:  valid,
+			tenant: &otherTenant,
+			mock: func(tn *mocks.Tenants, repo *mocks.Repository) {
+				tn.On("Tenant", "").Return(models.Tenant{Key: []byte("secret")}, nil)
+				repo.On("GetTokensDataByGUID", mock.Anything, "kwfwe").Return(nil, constants.ErrNotFound)
+			},
+		},
+		{
+			name:  "expired",
+			token: sign(jwt.MapClaims{"guid": "kwfwe", "iat": time.Now().Add(-time.Minute).Unix()}, "secret"),
+			mock: func(tn *mocks.Tenants, repo *mocks.Repository) {
+				tn.On("Tenant", "").Return(models.Tenant{Key: []byte("secret")}, nil)
+				repo.On("GetTokensDataByGUID", mock.Anything, "kwfwe").Return(nil, constants.ErrNotFound)
+			},
+			wantValid:   true,
+			wantExpired: true,
+		},
+		{
+			name:    "notAToken",
+			token:   "a3dmd2U.f74abec2",
+			mock:    func(tn *mocks.Tenants, repo *mocks.Repository) {},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tn, repo := mocks.NewTenants(t), mocks.NewRepository(t)
+			tt.mock(tn, repo)
+
+			got, err := inspectToken(context.Background(), tn, repo, tt.token, tt.tenant)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("inspectToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.Tenant != tt.wantTenant || got.Valid != tt.wantValid || got.Expired != tt.wantExpired {
+				t.Errorf("inspectToken() = tenant %q valid %v (%s) expired %v, want %q %v %v",
+					got.Tenant, got.Valid, got.Error, got.Expired, tt.wantTenant, tt.wantValid, tt.wantExpired)
+			}
+			if len(got.Sessions) != tt.wantSessions {
+				t.Errorf("inspectToken() sessions = %v, want %d", got.Sessions, tt.wantSessions)
+			}
+		})
+	}
+}
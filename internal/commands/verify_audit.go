@@ -0,0 +1,39 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"github.com/spf13/cobra"
+	"go-jwt-auth/internal/domains"
+	"go-jwt-auth/internal/lib"
+)
+
+type VerifyAuditCommand struct{}
+
+func (s *VerifyAuditCommand) Short() string {
+	return "verify the integrity of the token audit trail"
+}
+
+func (s *VerifyAuditCommand) Setup(cmd *cobra.Command) {}
+
+func (s *VerifyAuditCommand) Run() lib.CommandRunner {
+	return func(auditor domains.Auditor) error {
+		report, err := auditor.Verify(context.Background())
+		if err != nil {
+			return fmt.Errorf("can't verify audit trail: %v", err)
+		}
+
+		if !report.OK() {
+			return fmt.Errorf("audit trail is broken at record %d: %s (%d records verified)",
+				report.BrokenSeq, report.Reason, report.Checked)
+		}
+
+		fmt.Printf("audit trail is intact: %d records, %d checkpoints\n",
+			report.Checked, report.Checkpoints)
+		return nil
+	}
+}
+
+func NewVerifyAuditCommand() *VerifyAuditCommand {
+	return &VerifyAuditCommand{}
+}
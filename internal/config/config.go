@@ -2,10 +2,128 @@ package config
 
 type Storage struct {
 	DatabaseDSN string `json:"dsn"`
+	// SweepInterval is how often the expired sessions are purged from the
+	// storages that can't expire them natively.
+	SweepInterval string `json:"sweep_interval"`
+	// ManualMigrations disables applying the migrations on startup,
+	// they are applied with the migrate command then.
+	ManualMigrations bool `json:"manual_migrations"`
+	// RewriteBatchSize is how many sessions saved in an older schema version
+	// are upgraded at once, RewritePause is the pause between the batches.
+	RewriteBatchSize int64  `json:"rewrite_batch_size"`
+	RewritePause     string `json:"rewrite_pause"`
+}
+
+type Cache struct {
+	// Size is how many GUIDs the in-process cache keeps, 0 disables it.
+	Size int    `json:"size"`
+	TTL  string `json:"ttl"`
+	// SharedDSN is the redis:// DSN of the cache shared by the replicas,
+	// empty disables it. The replicas invalidate each other through it.
+	SharedDSN string `json:"shared_dsn"`
+	SharedTTL string `json:"shared_ttl"`
 }
 
 type JWT struct {
 	Key        string `json:"key"`
 	AccessTTL  string `json:"access_ttl"`
 	RefreshTTL string `json:"refresh_ttl"`
+	// Issuer and Audience are the iss and aud claims, omitted if empty.
+	Issuer   string `json:"issuer"`
+	Audience string `json:"audience"`
+}
+
+type Keys struct {
+	// File is the key store managed by the keys command, it is read again when it changes.
+	File string `json:"file"`
+}
+
+type Tenant struct {
+	// ID is selected by the /t/{id} path prefix or the X-Tenant-ID header.
+	ID string `json:"id"`
+	// JWT settings of the tenant, the empty TTLs are inherited from the default ones.
+	JWT JWT `json:"jwt"`
+}
+
+type Audit struct {
+	Enabled bool `json:"enabled"`
+	// Key signs the checkpoints. It is not a jwt key so that rotating those
+	// keeps the trail verifiable.
+	Key             string `json:"key"`
+	CheckpointEvery int64  `json:"checkpoint_every"`
+}
+
+type Webhooks struct {
+	Subscriptions  []WebhookSubscription `json:"subscriptions"`
+	MaxAttempts    int                   `json:"max_attempts"`
+	InitialBackoff string                `json:"initial_backoff"`
+	MaxBackoff     string                `json:"max_backoff"`
+	Timeout        string                `json:"timeout"`
+}
+
+type WebhookSubscription struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Secret string `json:"secret"`
+	// Events the subscription receives, all of them if empty.
+	Events []string `json:"events"`
+}
+
+type Notifications struct {
+	// NewIPPolicy is either "notify" or "reject".
+	NewIPPolicy string `json:"new_ip_policy"`
+	// Notifier is either "log" or "smtp".
+	Notifier string `json:"notifier"`
+	SMTP     SMTP   `json:"smtp"`
+}
+
+type SMTP struct {
+	Addr     string `json:"addr"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	From     string `json:"from"`
+	// To is a template of the recipient address, e.g. "{{.GUID}}@example.com".
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	// Template is a text/template of the message body executed with models.NewIPNotice.
+	Template string `json:"template"`
+	Timeout  string `json:"timeout"`
+}
+
+type OAuth struct {
+	// CodeTTL is how long the authorization codes are valid, 1m by default.
+	CodeTTL string `json:"code_ttl"`
+	// Authenticator authenticates the users of /oauth/authorize: "" disables
+	// the endpoint, "header" trusts the UserHeader set by a proxy.
+	Authenticator string `json:"authenticator"`
+	UserHeader    string `json:"user_header"`
+	// LoginURL is where the unauthenticated users are sent, with the
+	// authorization request to return to in the return_to parameter.
+	LoginURL string `json:"login_url"`
+}
+
+type Issuance struct {
+	// AuthMethods are the client authentication methods /v1/tokens accepts,
+	// all the ones of the confidential clients if empty.
+	AuthMethods []string `json:"auth_methods"`
+}
+
+type GRPC struct {
+	// Port serves the gRPC API next to the HTTP one, empty disables it.
+	Port string `json:"port"`
+}
+
+type Metrics struct {
+	// Addr serves /metrics on its own listener, e.g. 127.0.0.1:9090, empty
+	// disables it. The metrics are never served on the public port.
+	Addr string `json:"addr"`
+}
+
+type TLS struct {
+	// CertFile and KeyFile are the PEM certificate and key of the server.
+	CertFile string `json:"cert_file"`
+	KeyFile  string `json:"key_file"`
+	// ClientCAFile is the PEM bundle verifying the client certificates of
+	// tls_client_auth, empty to request none.
+	ClientCAFile string `json:"client_ca_file"`
 }
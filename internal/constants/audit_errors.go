@@ -0,0 +1,7 @@
+package constants
+
+import "fmt"
+
+var (
+	ErrAuditConflict = fmt.Errorf("can't append audit record: chain is being appended concurrently")
+)
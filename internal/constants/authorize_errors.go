@@ -0,0 +1,13 @@
+package constants
+
+import "fmt"
+
+var (
+	ErrInvalidRedirectURI      = fmt.Errorf("redirect_uri is not registered for the client")
+	ErrUnsupportedResponseType = fmt.Errorf("unsupported response type")
+	ErrInvalidCodeChallenge    = fmt.Errorf("code_challenge with the S256 code_challenge_method is required")
+	ErrLoginRequired           = fmt.Errorf("user is not authenticated")
+	ErrUserAuthDisabled        = fmt.Errorf("user authentication is not configured")
+	ErrMissingCode             = fmt.Errorf("code or code_verifier was not provided")
+	ErrInvalidCode             = fmt.Errorf("invalid authorization code")
+)
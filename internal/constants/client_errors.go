@@ -0,0 +1,11 @@
+package constants
+
+import "fmt"
+
+var (
+	ErrInvalidClient         = fmt.Errorf("client authentication failed")
+	ErrUnauthorizedClient    = fmt.Errorf("client is not allowed to use this grant type")
+	ErrMultipleClientAuth    = fmt.Errorf("more than one client authentication method is used")
+	ErrInvalidClientMetadata = fmt.Errorf("invalid client metadata")
+	ErrGUIDNotAllowed        = fmt.Errorf("client is not allowed to issue the tokens of this guid")
+)
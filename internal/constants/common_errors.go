@@ -3,6 +3,9 @@ package constants
 import "fmt"
 
 var (
-	ErrGenerate   = fmt.Errorf("can't generate")
-	ErrRepository = fmt.Errorf("repository error")
+	ErrGenerate     = fmt.Errorf("can't generate")
+	ErrRepository   = fmt.Errorf("repository error")
+	ErrNotSupported = fmt.Errorf("not supported")
+	ErrClosed       = fmt.Errorf("closed")
+	ErrQueueFull    = fmt.Errorf("queue is full")
 )
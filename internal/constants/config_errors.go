@@ -0,0 +1,7 @@
+package constants
+
+import "fmt"
+
+var (
+	ErrInvalidConfig = fmt.Errorf("invalid config")
+)
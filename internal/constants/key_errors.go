@@ -0,0 +1,9 @@
+package constants
+
+import "fmt"
+
+var (
+	ErrUnknownKeyType = fmt.Errorf("unknown key type")
+	ErrActiveKey      = fmt.Errorf("the active key can't be retired, rotate it first")
+	ErrNoKeyStore     = fmt.Errorf("keys.file is not set")
+)
@@ -3,3 +3,17 @@ package constants
 const (
 	MaxBcryptLength = 72
 )
+
+const (
+	NewIPPolicyNotify = "notify"
+	NewIPPolicyReject = "reject"
+)
+
+const (
+	NotifierLog  = "log"
+	NotifierSMTP = "smtp"
+)
+
+const (
+	UserAuthenticatorHeader = "header"
+)
@@ -0,0 +1,7 @@
+package constants
+
+import "fmt"
+
+var (
+	ErrIrreversibleMigration = fmt.Errorf("migration can't be reverted")
+)
@@ -0,0 +1,7 @@
+package constants
+
+import "fmt"
+
+var (
+	ErrInsufficientScope = fmt.Errorf("the access token has no openid scope")
+)
@@ -0,0 +1,7 @@
+package constants
+
+import "fmt"
+
+var (
+	ErrUnknownTenant = fmt.Errorf("unknown tenant")
+)
@@ -3,12 +3,16 @@ package constants
 import "fmt"
 
 var (
-	ErrMissingRefreshToken = fmt.Errorf("refresh token was not provided")
-	ErrMissingAccessToken  = fmt.Errorf("access token was not provided")
-	ErrInvalidToken        = fmt.Errorf("invalid token")
-	ErrSignToken           = fmt.Errorf("can't sign token")
-	ErrGenerateToken       = fmt.Errorf("can't generate token")
-	ErrTokenExpired        = fmt.Errorf("token expired")
-	ErrInvalidGUID         = fmt.Errorf("invalid guid")
-	ErrCantHashToken       = fmt.Errorf("can't hash token")
+	ErrMissingRefreshToken  = fmt.Errorf("refresh token was not provided")
+	ErrMissingAccessToken   = fmt.Errorf("access token was not provided")
+	ErrInvalidToken         = fmt.Errorf("invalid token")
+	ErrSignToken            = fmt.Errorf("can't sign token")
+	ErrGenerateToken        = fmt.Errorf("can't generate token")
+	ErrTokenExpired         = fmt.Errorf("token expired")
+	ErrInvalidGUID          = fmt.Errorf("invalid guid")
+	ErrCantHashToken        = fmt.Errorf("can't hash token")
+	ErrNewIP                = fmt.Errorf("refresh from a new ip address is not allowed")
+	ErrInvalidScope         = fmt.Errorf("requested scope is not allowed")
+	ErrUnsupportedGrant     = fmt.Errorf("unsupported grant type")
+	ErrUnsupportedTokenType = fmt.Errorf("revocation of this token type is not supported")
 )
@@ -0,0 +1,17 @@
+package domains
+
+import (
+	"context"
+	"go-jwt-auth/internal/models"
+)
+
+type AuditStorage interface {
+	AppendAuditRecord(ctx context.Context, r models.AuditRecord) error
+	LastAuditRecord(ctx context.Context) (models.AuditRecord, error)
+	GetAuditRecords(ctx context.Context, fromSeq int64, limit int64) ([]models.AuditRecord, error)
+}
+
+type Auditor interface {
+	EventSubscriber
+	Verify(ctx context.Context) (models.AuditReport, error)
+}
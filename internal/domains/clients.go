@@ -0,0 +1,31 @@
+package domains
+
+import (
+	"context"
+	"go-jwt-auth/internal/models"
+)
+
+// ClientStorage is the registry of the clients.
+type ClientStorage interface {
+	// SaveClient saves the client, replacing the one with the same ID.
+	SaveClient(ctx context.Context, c models.Client) error
+	// GetClient returns the client, constants.ErrNotFound if there is none.
+	GetClient(ctx context.Context, id string) (models.Client, error)
+	// GetClients returns all the clients ordered by ID.
+	GetClients(ctx context.Context) ([]models.Client, error)
+	// DeleteClient deletes the client, constants.ErrNotFound if there is none.
+	DeleteClient(ctx context.Context, id string) error
+}
+
+type Clients interface {
+	// Register validates the client, hashes its secret if any and saves it.
+	Register(ctx context.Context, c models.Client, secret string) (models.Client, error)
+	// Authenticate returns the client the credentials belong to, constants.ErrInvalidClient if none.
+	Authenticate(ctx context.Context, creds models.ClientCredentials) (models.Client, error)
+	// Redirect returns the client of an authorization request and the redirect URI to use,
+	// constants.ErrInvalidClient if the client is unknown, constants.ErrInvalidRedirectURI
+	// if the URI isn't registered for it.
+	Redirect(ctx context.Context, id, redirectURI string) (models.Client, string, error)
+	Clients(ctx context.Context) ([]models.Client, error)
+	Remove(ctx context.Context, id string) error
+}
@@ -0,0 +1,14 @@
+package domains
+
+import (
+	"context"
+	"go-jwt-auth/internal/models"
+)
+
+// CodeStorage keeps the authorization codes until they are used.
+type CodeStorage interface {
+	SaveCode(ctx context.Context, c models.AuthorizationCode) error
+	// ConsumeCode deletes the code and returns it, constants.ErrNotFound if there is none.
+	// A code is returned once, even to concurrent callers. The expired codes may still be returned.
+	ConsumeCode(ctx context.Context, hash string) (models.AuthorizationCode, error)
+}
@@ -10,3 +10,24 @@ type Database interface {
 	GetTokensDataByGUID(ctx context.Context, guid string) (t []models.TokenData, err error)
 	DeleteTokenData(ctx context.Context, guid, hash string) error
 }
+
+// ExpiredPurger is implemented by the databases that can't expire the sessions natively.
+type ExpiredPurger interface {
+	PurgeExpired(ctx context.Context, now int64) (purged int64, err error)
+}
+
+// TokenDataScanner is implemented by the databases that can go through all the sessions.
+type TokenDataScanner interface {
+	// ScanTokenData calls fn with every session, in no particular order, until it returns an error.
+	ScanTokenData(ctx context.Context, fn func(models.TokenData) error) error
+}
+
+// TokenDataRewriter is implemented by the databases that can rewrite the
+// sessions saved in an older schema version of models.TokenData.
+type TokenDataRewriter interface {
+	// CountOutdatedTokenData counts the sessions saved in an older version.
+	CountOutdatedTokenData(ctx context.Context) (int64, error)
+	// RewriteOutdatedTokenData upgrades up to limit outdated sessions and returns how many
+	// it upgraded, the ones consumed in the meantime aren't counted. It returns 0 once none is left.
+	RewriteOutdatedTokenData(ctx context.Context, limit int64) (int64, error)
+}
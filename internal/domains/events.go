@@ -0,0 +1,14 @@
+package domains
+
+import (
+	"context"
+	"go-jwt-auth/internal/models"
+)
+
+type EventPublisher interface {
+	Publish(ctx context.Context, e models.Event)
+}
+
+type EventSubscriber interface {
+	HandleEvent(ctx context.Context, e models.Event) error
+}
@@ -2,10 +2,16 @@ package domains
 
 import (
 	"context"
+	"go-jwt-auth/internal/models"
 	"time"
 )
 
 type GeneratorService interface {
-	AccessToken(ctx context.Context, guid string, key []byte, accessTTL time.Duration) (token string, iat int64, err error)
+	AccessToken(ctx context.Context, guid string, tenant models.Tenant, scope string) (token string, iat int64, err error)
+	// ClientAccessToken signs the access token of a client itself, it carries
+	// the client_id claim instead of the guid one.
+	ClientAccessToken(ctx context.Context, clientID string, tenant models.Tenant, scope string) (token string, iat int64, err error)
 	RefreshToken(ctx context.Context, refreshTTL time.Duration) (token string, iat int64, err error)
+	// IDToken signs the OpenID Connect ID token with the key of the tenant.
+	IDToken(ctx context.Context, tenant models.Tenant, t models.IDToken) (token string, err error)
 }
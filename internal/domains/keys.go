@@ -0,0 +1,27 @@
+package domains
+
+import (
+	"context"
+	"go-jwt-auth/internal/models"
+)
+
+// KeyStorage is the key store of the signing keys.
+type KeyStorage interface {
+	// Keys returns all the keys, the retired ones included.
+	Keys(ctx context.Context) ([]models.SigningKey, error)
+	// SaveKeys replaces all the keys.
+	SaveKeys(ctx context.Context, keys []models.SigningKey) error
+}
+
+// Keys manages the lifecycle of the signing keys.
+type Keys interface {
+	// Generate adds a key of the type to the tenant in the next state.
+	Generate(ctx context.Context, tenant, keyType string) (models.SigningKey, error)
+	// Keys returns the keys of all the tenants.
+	Keys(ctx context.Context) ([]models.SigningKey, error)
+	// Rotate activates the oldest next key of the tenant, one of the type is
+	// generated if it has none. The active key becomes a previous one.
+	Rotate(ctx context.Context, tenant, keyType string) (models.SigningKey, error)
+	// Retire retires a key, constants.ErrActiveKey if it is the active one.
+	Retire(ctx context.Context, kid string) (models.SigningKey, error)
+}
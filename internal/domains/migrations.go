@@ -0,0 +1,16 @@
+package domains
+
+import (
+	"context"
+	"go-jwt-auth/internal/models"
+)
+
+// Migrator applies the schema migrations of the storage.
+type Migrator interface {
+	// Up applies the pending migrations in order and returns them.
+	Up(ctx context.Context) ([]models.MigrationStatus, error)
+	// Down reverts the last steps applied migrations and returns them.
+	Down(ctx context.Context, steps int) ([]models.MigrationStatus, error)
+	// Status returns all the migrations in order.
+	Status(ctx context.Context) ([]models.MigrationStatus, error)
+}
@@ -0,0 +1,189 @@
+// Code generated by mockery v2.33.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	models "go-jwt-auth/internal/models"
+)
+
+// AuditStorage is an autogenerated mock type for the AuditStorage type
+type AuditStorage struct {
+	mock.Mock
+}
+
+type AuditStorage_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *AuditStorage) EXPECT() *AuditStorage_Expecter {
+	return &AuditStorage_Expecter{mock: &_m.Mock}
+}
+
+// AppendAuditRecord provides a mock function with given fields: ctx, r
+func (_m *AuditStorage) AppendAuditRecord(ctx context.Context, r models.AuditRecord) error {
+	ret := _m.Called(ctx, r)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.AuditRecord) error); ok {
+		r0 = rf(ctx, r)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AuditStorage_AppendAuditRecord_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AppendAuditRecord'
+type AuditStorage_AppendAuditRecord_Call struct {
+	*mock.Call
+}
+
+// AppendAuditRecord is a helper method to define mock.On call
+//   - ctx context.Context
+//   - r models.AuditRecord
+func (_e *AuditStorage_Expecter) AppendAuditRecord(ctx interface{}, r interface{}) *AuditStorage_AppendAuditRecord_Call {
+	return &AuditStorage_AppendAuditRecord_Call{Call: _e.mock.On("AppendAuditRecord", ctx, r)}
+}
+
+func (_c *AuditStorage_AppendAuditRecord_Call) Run(run func(ctx context.Context, r models.AuditRecord)) *AuditStorage_AppendAuditRecord_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.AuditRecord))
+	})
+	return _c
+}
+
+func (_c *AuditStorage_AppendAuditRecord_Call) Return(_a0 error) *AuditStorage_AppendAuditRecord_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *AuditStorage_AppendAuditRecord_Call) RunAndReturn(run func(context.Context, models.AuditRecord) error) *AuditStorage_AppendAuditRecord_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetAuditRecords provides a mock function with given fields: ctx, fromSeq, limit
+func (_m *AuditStorage) GetAuditRecords(ctx context.Context, fromSeq int64, limit int64) ([]models.AuditRecord, error) {
+	ret := _m.Called(ctx, fromSeq, limit)
+
+	var r0 []models.AuditRecord
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) ([]models.AuditRecord, error)); ok {
+		return rf(ctx, fromSeq, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) []models.AuditRecord); ok {
+		r0 = rf(ctx, fromSeq, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.AuditRecord)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(ctx, fromSeq, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AuditStorage_GetAuditRecords_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAuditRecords'
+type AuditStorage_GetAuditRecords_Call struct {
+	*mock.Call
+}
+
+// GetAuditRecords is a helper method to define mock.On call
+//   - ctx context.Context
+//   - fromSeq int64
+//   - limit int64
+func (_e *AuditStorage_Expecter) GetAuditRecords(ctx interface{}, fromSeq interface{}, limit interface{}) *AuditStorage_GetAuditRecords_Call {
+	return &AuditStorage_GetAuditRecords_Call{Call: _e.mock.On("GetAuditRecords", ctx, fromSeq, limit)}
+}
+
+func (_c *AuditStorage_GetAuditRecords_Call) Run(run func(ctx context.Context, fromSeq int64, limit int64)) *AuditStorage_GetAuditRecords_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int64), args[2].(int64))
+	})
+	return _c
+}
+
+func (_c *AuditStorage_GetAuditRecords_Call) Return(_a0 []models.AuditRecord, _a1 error) *AuditStorage_GetAuditRecords_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *AuditStorage_GetAuditRecords_Call) RunAndReturn(run func(context.Context, int64, int64) ([]models.AuditRecord, error)) *AuditStorage_GetAuditRecords_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// LastAuditRecord provides a mock function with given fields: ctx
+func (_m *AuditStorage) LastAuditRecord(ctx context.Context) (models.AuditRecord, error) {
+	ret := _m.Called(ctx)
+
+	var r0 models.AuditRecord
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (models.AuditRecord, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) models.AuditRecord); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(models.AuditRecord)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AuditStorage_LastAuditRecord_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'LastAuditRecord'
+type AuditStorage_LastAuditRecord_Call struct {
+	*mock.Call
+}
+
+// LastAuditRecord is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *AuditStorage_Expecter) LastAuditRecord(ctx interface{}) *AuditStorage_LastAuditRecord_Call {
+	return &AuditStorage_LastAuditRecord_Call{Call: _e.mock.On("LastAuditRecord", ctx)}
+}
+
+func (_c *AuditStorage_LastAuditRecord_Call) Run(run func(ctx context.Context)) *AuditStorage_LastAuditRecord_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *AuditStorage_LastAuditRecord_Call) Return(_a0 models.AuditRecord, _a1 error) *AuditStorage_LastAuditRecord_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *AuditStorage_LastAuditRecord_Call) RunAndReturn(run func(context.Context) (models.AuditRecord, error)) *AuditStorage_LastAuditRecord_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewAuditStorage creates a new instance of AuditStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewAuditStorage(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *AuditStorage {
+	mock := &AuditStorage{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,231 @@
+// Code generated by mockery v2.33.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	models "go-jwt-auth/internal/models"
+)
+
+// ClientStorage is an autogenerated mock type for the ClientStorage type
+type ClientStorage struct {
+	mock.Mock
+}
+
+type ClientStorage_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *ClientStorage) EXPECT() *ClientStorage_Expecter {
+	return &ClientStorage_Expecter{mock: &_m.Mock}
+}
+
+// DeleteClient provides a mock function with given fields: ctx, id
+func (_m *ClientStorage) DeleteClient(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ClientStorage_DeleteClient_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteClient'
+type ClientStorage_DeleteClient_Call struct {
+	*mock.Call
+}
+
+// DeleteClient is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id string
+func (_e *ClientStorage_Expecter) DeleteClient(ctx interface{}, id interface{}) *ClientStorage_DeleteClient_Call {
+	return &ClientStorage_DeleteClient_Call{Call: _e.mock.On("DeleteClient", ctx, id)}
+}
+
+func (_c *ClientStorage_DeleteClient_Call) Run(run func(ctx context.Context, id string)) *ClientStorage_DeleteClient_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *ClientStorage_DeleteClient_Call) Return(_a0 error) *ClientStorage_DeleteClient_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *ClientStorage_DeleteClient_Call) RunAndReturn(run func(context.Context, string) error) *ClientStorage_DeleteClient_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetClient provides a mock function with given fields: ctx, id
+func (_m *ClientStorage) GetClient(ctx context.Context, id string) (models.Client, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 models.Client
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (models.Client, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) models.Client); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(models.Client)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ClientStorage_GetClient_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetClient'
+type ClientStorage_GetClient_Call struct {
+	*mock.Call
+}
+
+// GetClient is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id string
+func (_e *ClientStorage_Expecter) GetClient(ctx interface{}, id interface{}) *ClientStorage_GetClient_Call {
+	return &ClientStorage_GetClient_Call{Call: _e.mock.On("GetClient", ctx, id)}
+}
+
+func (_c *ClientStorage_GetClient_Call) Run(run func(ctx context.Context, id string)) *ClientStorage_GetClient_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *ClientStorage_GetClient_Call) Return(_a0 models.Client, _a1 error) *ClientStorage_GetClient_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *ClientStorage_GetClient_Call) RunAndReturn(run func(context.Context, string) (models.Client, error)) *ClientStorage_GetClient_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetClients provides a mock function with given fields: ctx
+func (_m *ClientStorage) GetClients(ctx context.Context) ([]models.Client, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []models.Client
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]models.Client, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []models.Client); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Client)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ClientStorage_GetClients_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetClients'
+type ClientStorage_GetClients_Call struct {
+	*mock.Call
+}
+
+// GetClients is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *ClientStorage_Expecter) GetClients(ctx interface{}) *ClientStorage_GetClients_Call {
+	return &ClientStorage_GetClients_Call{Call: _e.mock.On("GetClients", ctx)}
+}
+
+func (_c *ClientStorage_GetClients_Call) Run(run func(ctx context.Context)) *ClientStorage_GetClients_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *ClientStorage_GetClients_Call) Return(_a0 []models.Client, _a1 error) *ClientStorage_GetClients_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *ClientStorage_GetClients_Call) RunAndReturn(run func(context.Context) ([]models.Client, error)) *ClientStorage_GetClients_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SaveClient provides a mock function with given fields: ctx, c
+func (_m *ClientStorage) SaveClient(ctx context.Context, c models.Client) error {
+	ret := _m.Called(ctx, c)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.Client) error); ok {
+		r0 = rf(ctx, c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ClientStorage_SaveClient_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveClient'
+type ClientStorage_SaveClient_Call struct {
+	*mock.Call
+}
+
+// SaveClient is a helper method to define mock.On call
+//   - ctx context.Context
+//   - c models.Client
+func (_e *ClientStorage_Expecter) SaveClient(ctx interface{}, c interface{}) *ClientStorage_SaveClient_Call {
+	return &ClientStorage_SaveClient_Call{Call: _e.mock.On("SaveClient", ctx, c)}
+}
+
+func (_c *ClientStorage_SaveClient_Call) Run(run func(ctx context.Context, c models.Client)) *ClientStorage_SaveClient_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.Client))
+	})
+	return _c
+}
+
+func (_c *ClientStorage_SaveClient_Call) Return(_a0 error) *ClientStorage_SaveClient_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *ClientStorage_SaveClient_Call) RunAndReturn(run func(context.Context, models.Client) error) *ClientStorage_SaveClient_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewClientStorage creates a new instance of ClientStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewClientStorage(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ClientStorage {
+	mock := &ClientStorage{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,303 @@
+// Code generated by mockery v2.33.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	models "go-jwt-auth/internal/models"
+)
+
+// Clients is an autogenerated mock type for the Clients type
+type Clients struct {
+	mock.Mock
+}
+
+type Clients_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *Clients) EXPECT() *Clients_Expecter {
+	return &Clients_Expecter{mock: &_m.Mock}
+}
+
+// Authenticate provides a mock function with given fields: ctx, creds
+func (_m *Clients) Authenticate(ctx context.Context, creds models.ClientCredentials) (models.Client, error) {
+	ret := _m.Called(ctx, creds)
+
+	var r0 models.Client
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.ClientCredentials) (models.Client, error)); ok {
+		return rf(ctx, creds)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.ClientCredentials) models.Client); ok {
+		r0 = rf(ctx, creds)
+	} else {
+		r0 = ret.Get(0).(models.Client)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.ClientCredentials) error); ok {
+		r1 = rf(ctx, creds)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Clients_Authenticate_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Authenticate'
+type Clients_Authenticate_Call struct {
+	*mock.Call
+}
+
+// Authenticate is a helper method to define mock.On call
+//   - ctx context.Context
+//   - creds models.ClientCredentials
+func (_e *Clients_Expecter) Authenticate(ctx interface{}, creds interface{}) *Clients_Authenticate_Call {
+	return &Clients_Authenticate_Call{Call: _e.mock.On("Authenticate", ctx, creds)}
+}
+
+func (_c *Clients_Authenticate_Call) Run(run func(ctx context.Context, creds models.ClientCredentials)) *Clients_Authenticate_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.ClientCredentials))
+	})
+	return _c
+}
+
+func (_c *Clients_Authenticate_Call) Return(_a0 models.Client, _a1 error) *Clients_Authenticate_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Clients_Authenticate_Call) RunAndReturn(run func(context.Context, models.ClientCredentials) (models.Client, error)) *Clients_Authenticate_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Clients provides a mock function with given fields: ctx
+func (_m *Clients) Clients(ctx context.Context) ([]models.Client, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []models.Client
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]models.Client, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []models.Client); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Client)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Clients_Clients_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Clients'
+type Clients_Clients_Call struct {
+	*mock.Call
+}
+
+// Clients is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *Clients_Expecter) Clients(ctx interface{}) *Clients_Clients_Call {
+	return &Clients_Clients_Call{Call: _e.mock.On("Clients", ctx)}
+}
+
+func (_c *Clients_Clients_Call) Run(run func(ctx context.Context)) *Clients_Clients_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *Clients_Clients_Call) Return(_a0 []models.Client, _a1 error) *Clients_Clients_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Clients_Clients_Call) RunAndReturn(run func(context.Context) ([]models.Client, error)) *Clients_Clients_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Redirect provides a mock function with given fields: ctx, id, redirectURI
+func (_m *Clients) Redirect(ctx context.Context, id string, redirectURI string) (models.Client, string, error) {
+	ret := _m.Called(ctx, id, redirectURI)
+
+	var r0 models.Client
+	var r1 string
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (models.Client, string, error)); ok {
+		return rf(ctx, id, redirectURI)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) models.Client); ok {
+		r0 = rf(ctx, id, redirectURI)
+	} else {
+		r0 = ret.Get(0).(models.Client)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) string); ok {
+		r1 = rf(ctx, id, redirectURI)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, string) error); ok {
+		r2 = rf(ctx, id, redirectURI)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Clients_Redirect_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Redirect'
+type Clients_Redirect_Call struct {
+	*mock.Call
+}
+
+// Redirect is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id string
+//   - redirectURI string
+func (_e *Clients_Expecter) Redirect(ctx interface{}, id interface{}, redirectURI interface{}) *Clients_Redirect_Call {
+	return &Clients_Redirect_Call{Call: _e.mock.On("Redirect", ctx, id, redirectURI)}
+}
+
+func (_c *Clients_Redirect_Call) Run(run func(ctx context.Context, id string, redirectURI string)) *Clients_Redirect_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *Clients_Redirect_Call) Return(_a0 models.Client, _a1 string, _a2 error) *Clients_Redirect_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *Clients_Redirect_Call) RunAndReturn(run func(context.Context, string, string) (models.Client, string, error)) *Clients_Redirect_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Register provides a mock function with given fields: ctx, c, secret
+func (_m *Clients) Register(ctx context.Context, c models.Client, secret string) (models.Client, error) {
+	ret := _m.Called(ctx, c, secret)
+
+	var r0 models.Client
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.Client, string) (models.Client, error)); ok {
+		return rf(ctx, c, secret)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.Client, string) models.Client); ok {
+		r0 = rf(ctx, c, secret)
+	} else {
+		r0 = ret.Get(0).(models.Client)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.Client, string) error); ok {
+		r1 = rf(ctx, c, secret)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Clients_Register_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Register'
+type Clients_Register_Call struct {
+	*mock.Call
+}
+
+// Register is a helper method to define mock.On call
+//   - ctx context.Context
+//   - c models.Client
+//   - secret string
+func (_e *Clients_Expecter) Register(ctx interface{}, c interface{}, secret interface{}) *Clients_Register_Call {
+	return &Clients_Register_Call{Call: _e.mock.On("Register", ctx, c, secret)}
+}
+
+func (_c *Clients_Register_Call) Run(run func(ctx context.Context, c models.Client, secret string)) *Clients_Register_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.Client), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *Clients_Register_Call) Return(_a0 models.Client, _a1 error) *Clients_Register_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Clients_Register_Call) RunAndReturn(run func(context.Context, models.Client, string) (models.Client, error)) *Clients_Register_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Remove provides a mock function with given fields: ctx, id
+func (_m *Clients) Remove(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Clients_Remove_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Remove'
+type Clients_Remove_Call struct {
+	*mock.Call
+}
+
+// Remove is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id string
+func (_e *Clients_Expecter) Remove(ctx interface{}, id interface{}) *Clients_Remove_Call {
+	return &Clients_Remove_Call{Call: _e.mock.On("Remove", ctx, id)}
+}
+
+func (_c *Clients_Remove_Call) Run(run func(ctx context.Context, id string)) *Clients_Remove_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Clients_Remove_Call) Return(_a0 error) *Clients_Remove_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Clients_Remove_Call) RunAndReturn(run func(context.Context, string) error) *Clients_Remove_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewClients creates a new instance of Clients. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewClients(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Clients {
+	mock := &Clients{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
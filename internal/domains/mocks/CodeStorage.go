@@ -0,0 +1,134 @@
+// Code generated by mockery v2.33.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	models "go-jwt-auth/internal/models"
+)
+
+// CodeStorage is an autogenerated mock type for the CodeStorage type
+type CodeStorage struct {
+	mock.Mock
+}
+
+type CodeStorage_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *CodeStorage) EXPECT() *CodeStorage_Expecter {
+	return &CodeStorage_Expecter{mock: &_m.Mock}
+}
+
+// ConsumeCode provides a mock function with given fields: ctx, hash
+func (_m *CodeStorage) ConsumeCode(ctx context.Context, hash string) (models.AuthorizationCode, error) {
+	ret := _m.Called(ctx, hash)
+
+	var r0 models.AuthorizationCode
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (models.AuthorizationCode, error)); ok {
+		return rf(ctx, hash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) models.AuthorizationCode); ok {
+		r0 = rf(ctx, hash)
+	} else {
+		r0 = ret.Get(0).(models.AuthorizationCode)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, hash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CodeStorage_ConsumeCode_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ConsumeCode'
+type CodeStorage_ConsumeCode_Call struct {
+	*mock.Call
+}
+
+// ConsumeCode is a helper method to define mock.On call
+//   - ctx context.Context
+//   - hash string
+func (_e *CodeStorage_Expecter) ConsumeCode(ctx interface{}, hash interface{}) *CodeStorage_ConsumeCode_Call {
+	return &CodeStorage_ConsumeCode_Call{Call: _e.mock.On("ConsumeCode", ctx, hash)}
+}
+
+func (_c *CodeStorage_ConsumeCode_Call) Run(run func(ctx context.Context, hash string)) *CodeStorage_ConsumeCode_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *CodeStorage_ConsumeCode_Call) Return(_a0 models.AuthorizationCode, _a1 error) *CodeStorage_ConsumeCode_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *CodeStorage_ConsumeCode_Call) RunAndReturn(run func(context.Context, string) (models.AuthorizationCode, error)) *CodeStorage_ConsumeCode_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SaveCode provides a mock function with given fields: ctx, c
+func (_m *CodeStorage) SaveCode(ctx context.Context, c models.AuthorizationCode) error {
+	ret := _m.Called(ctx, c)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.AuthorizationCode) error); ok {
+		r0 = rf(ctx, c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CodeStorage_SaveCode_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveCode'
+type CodeStorage_SaveCode_Call struct {
+	*mock.Call
+}
+
+// SaveCode is a helper method to define mock.On call
+//   - ctx context.Context
+//   - c models.AuthorizationCode
+func (_e *CodeStorage_Expecter) SaveCode(ctx interface{}, c interface{}) *CodeStorage_SaveCode_Call {
+	return &CodeStorage_SaveCode_Call{Call: _e.mock.On("SaveCode", ctx, c)}
+}
+
+func (_c *CodeStorage_SaveCode_Call) Run(run func(ctx context.Context, c models.AuthorizationCode)) *CodeStorage_SaveCode_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.AuthorizationCode))
+	})
+	return _c
+}
+
+func (_c *CodeStorage_SaveCode_Call) Return(_a0 error) *CodeStorage_SaveCode_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *CodeStorage_SaveCode_Call) RunAndReturn(run func(context.Context, models.AuthorizationCode) error) *CodeStorage_SaveCode_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewCodeStorage creates a new instance of CodeStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewCodeStorage(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *CodeStorage {
+	mock := &CodeStorage{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -7,6 +7,8 @@ import (
 
 	mock "github.com/stretchr/testify/mock"
 
+	models "go-jwt-auth/internal/models"
+
 	time "time"
 )
 
@@ -23,30 +25,30 @@ func (_m *GeneratorService) EXPECT() *GeneratorService_Expecter {
 	return &GeneratorService_Expecter{mock: &_m.Mock}
 }
 
-// AccessToken provides a mock function with given fields: ctx, guid, key, accessTTL
-func (_m *GeneratorService) AccessToken(ctx context.Context, guid string, key []byte, accessTTL time.Duration) (string, int64, error) {
-	ret := _m.Called(ctx, guid, key, accessTTL)
+// AccessToken provides a mock function with given fields: ctx, guid, tenant, scope
+func (_m *GeneratorService) AccessToken(ctx context.Context, guid string, tenant models.Tenant, scope string) (string, int64, error) {
+	ret := _m.Called(ctx, guid, tenant, scope)
 
 	var r0 string
 	var r1 int64
 	var r2 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, []byte, time.Duration) (string, int64, error)); ok {
-		return rf(ctx, guid, key, accessTTL)
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.Tenant, string) (string, int64, error)); ok {
+		return rf(ctx, guid, tenant, scope)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, []byte, time.Duration) string); ok {
-		r0 = rf(ctx, guid, key, accessTTL)
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.Tenant, string) string); ok {
+		r0 = rf(ctx, guid, tenant, scope)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, []byte, time.Duration) int64); ok {
-		r1 = rf(ctx, guid, key, accessTTL)
+	if rf, ok := ret.Get(1).(func(context.Context, string, models.Tenant, string) int64); ok {
+		r1 = rf(ctx, guid, tenant, scope)
 	} else {
 		r1 = ret.Get(1).(int64)
 	}
 
-	if rf, ok := ret.Get(2).(func(context.Context, string, []byte, time.Duration) error); ok {
-		r2 = rf(ctx, guid, key, accessTTL)
+	if rf, ok := ret.Get(2).(func(context.Context, string, models.Tenant, string) error); ok {
+		r2 = rf(ctx, guid, tenant, scope)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -62,15 +64,15 @@ type GeneratorService_AccessToken_Call struct {
 // AccessToken is a helper method to define mock.On call
 //   - ctx context.Context
 //   - guid string
-//   - key []byte
-//   - accessTTL time.Duration
-func (_e *GeneratorService_Expecter) AccessToken(ctx interface{}, guid interface{}, key interface{}, accessTTL interface{}) *GeneratorService_AccessToken_Call {
-	return &GeneratorService_AccessToken_Call{Call: _e.mock.On("AccessToken", ctx, guid, key, accessTTL)}
+//   - tenant models.Tenant
+//   - scope string
+func (_e *GeneratorService_Expecter) AccessToken(ctx interface{}, guid interface{}, tenant interface{}, scope interface{}) *GeneratorService_AccessToken_Call {
+	return &GeneratorService_AccessToken_Call{Call: _e.mock.On("AccessToken", ctx, guid, tenant, scope)}
 }
 
-func (_c *GeneratorService_AccessToken_Call) Run(run func(ctx context.Context, guid string, key []byte, accessTTL time.Duration)) *GeneratorService_AccessToken_Call {
+func (_c *GeneratorService_AccessToken_Call) Run(run func(ctx context.Context, guid string, tenant models.Tenant, scope string)) *GeneratorService_AccessToken_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(string), args[2].([]byte), args[3].(time.Duration))
+		run(args[0].(context.Context), args[1].(string), args[2].(models.Tenant), args[3].(string))
 	})
 	return _c
 }
@@ -80,7 +82,123 @@ func (_c *GeneratorService_AccessToken_Call) Return(token string, iat int64, err
 	return _c
 }
 
-func (_c *GeneratorService_AccessToken_Call) RunAndReturn(run func(context.Context, string, []byte, time.Duration) (string, int64, error)) *GeneratorService_AccessToken_Call {
+func (_c *GeneratorService_AccessToken_Call) RunAndReturn(run func(context.Context, string, models.Tenant, string) (string, int64, error)) *GeneratorService_AccessToken_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ClientAccessToken provides a mock function with given fields: ctx, clientID, tenant, scope
+func (_m *GeneratorService) ClientAccessToken(ctx context.Context, clientID string, tenant models.Tenant, scope string) (string, int64, error) {
+	ret := _m.Called(ctx, clientID, tenant, scope)
+
+	var r0 string
+	var r1 int64
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.Tenant, string) (string, int64, error)); ok {
+		return rf(ctx, clientID, tenant, scope)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.Tenant, string) string); ok {
+		r0 = rf(ctx, clientID, tenant, scope)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, models.Tenant, string) int64); ok {
+		r1 = rf(ctx, clientID, tenant, scope)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, models.Tenant, string) error); ok {
+		r2 = rf(ctx, clientID, tenant, scope)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GeneratorService_ClientAccessToken_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ClientAccessToken'
+type GeneratorService_ClientAccessToken_Call struct {
+	*mock.Call
+}
+
+// ClientAccessToken is a helper method to define mock.On call
+//   - ctx context.Context
+//   - clientID string
+//   - tenant models.Tenant
+//   - scope string
+func (_e *GeneratorService_Expecter) ClientAccessToken(ctx interface{}, clientID interface{}, tenant interface{}, scope interface{}) *GeneratorService_ClientAccessToken_Call {
+	return &GeneratorService_ClientAccessToken_Call{Call: _e.mock.On("ClientAccessToken", ctx, clientID, tenant, scope)}
+}
+
+func (_c *GeneratorService_ClientAccessToken_Call) Run(run func(ctx context.Context, clientID string, tenant models.Tenant, scope string)) *GeneratorService_ClientAccessToken_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(models.Tenant), args[3].(string))
+	})
+	return _c
+}
+
+func (_c *GeneratorService_ClientAccessToken_Call) Return(token string, iat int64, err error) *GeneratorService_ClientAccessToken_Call {
+	_c.Call.Return(token, iat, err)
+	return _c
+}
+
+func (_c *GeneratorService_ClientAccessToken_Call) RunAndReturn(run func(context.Context, string, models.Tenant, string) (string, int64, error)) *GeneratorService_ClientAccessToken_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// IDToken provides a mock function with given fields: ctx, tenant, t
+func (_m *GeneratorService) IDToken(ctx context.Context, tenant models.Tenant, t models.IDToken) (string, error) {
+	ret := _m.Called(ctx, tenant, t)
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.Tenant, models.IDToken) (string, error)); ok {
+		return rf(ctx, tenant, t)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.Tenant, models.IDToken) string); ok {
+		r0 = rf(ctx, tenant, t)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.Tenant, models.IDToken) error); ok {
+		r1 = rf(ctx, tenant, t)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GeneratorService_IDToken_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IDToken'
+type GeneratorService_IDToken_Call struct {
+	*mock.Call
+}
+
+// IDToken is a helper method to define mock.On call
+//   - ctx context.Context
+//   - tenant models.Tenant
+//   - t models.IDToken
+func (_e *GeneratorService_Expecter) IDToken(ctx interface{}, tenant interface{}, t interface{}) *GeneratorService_IDToken_Call {
+	return &GeneratorService_IDToken_Call{Call: _e.mock.On("IDToken", ctx, tenant, t)}
+}
+
+func (_c *GeneratorService_IDToken_Call) Run(run func(ctx context.Context, tenant models.Tenant, t models.IDToken)) *GeneratorService_IDToken_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.Tenant), args[2].(models.IDToken))
+	})
+	return _c
+}
+
+func (_c *GeneratorService_IDToken_Call) Return(token string, err error) *GeneratorService_IDToken_Call {
+	_c.Call.Return(token, err)
+	return _c
+}
+
+func (_c *GeneratorService_IDToken_Call) RunAndReturn(run func(context.Context, models.Tenant, models.IDToken) (string, error)) *GeneratorService_IDToken_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -0,0 +1,135 @@
+// Code generated by mockery v2.33.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	models "go-jwt-auth/internal/models"
+)
+
+// KeyStorage is an autogenerated mock type for the KeyStorage type
+type KeyStorage struct {
+	mock.Mock
+}
+
+type KeyStorage_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *KeyStorage) EXPECT() *KeyStorage_Expecter {
+	return &KeyStorage_Expecter{mock: &_m.Mock}
+}
+
+// Keys provides a mock function with given fields: ctx
+func (_m *KeyStorage) Keys(ctx context.Context) ([]models.SigningKey, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []models.SigningKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]models.SigningKey, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []models.SigningKey); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.SigningKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// KeyStorage_Keys_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Keys'
+type KeyStorage_Keys_Call struct {
+	*mock.Call
+}
+
+// Keys is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *KeyStorage_Expecter) Keys(ctx interface{}) *KeyStorage_Keys_Call {
+	return &KeyStorage_Keys_Call{Call: _e.mock.On("Keys", ctx)}
+}
+
+func (_c *KeyStorage_Keys_Call) Run(run func(ctx context.Context)) *KeyStorage_Keys_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *KeyStorage_Keys_Call) Return(_a0 []models.SigningKey, _a1 error) *KeyStorage_Keys_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *KeyStorage_Keys_Call) RunAndReturn(run func(context.Context) ([]models.SigningKey, error)) *KeyStorage_Keys_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SaveKeys provides a mock function with given fields: ctx, keys
+func (_m *KeyStorage) SaveKeys(ctx context.Context, keys []models.SigningKey) error {
+	ret := _m.Called(ctx, keys)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []models.SigningKey) error); ok {
+		r0 = rf(ctx, keys)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// KeyStorage_SaveKeys_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveKeys'
+type KeyStorage_SaveKeys_Call struct {
+	*mock.Call
+}
+
+// SaveKeys is a helper method to define mock.On call
+//   - ctx context.Context
+//   - keys []models.SigningKey
+func (_e *KeyStorage_Expecter) SaveKeys(ctx interface{}, keys interface{}) *KeyStorage_SaveKeys_Call {
+	return &KeyStorage_SaveKeys_Call{Call: _e.mock.On("SaveKeys", ctx, keys)}
+}
+
+func (_c *KeyStorage_SaveKeys_Call) Run(run func(ctx context.Context, keys []models.SigningKey)) *KeyStorage_SaveKeys_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].([]models.SigningKey))
+	})
+	return _c
+}
+
+func (_c *KeyStorage_SaveKeys_Call) Return(_a0 error) *KeyStorage_SaveKeys_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *KeyStorage_SaveKeys_Call) RunAndReturn(run func(context.Context, []models.SigningKey) error) *KeyStorage_SaveKeys_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewKeyStorage creates a new instance of KeyStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewKeyStorage(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *KeyStorage {
+	mock := &KeyStorage{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,253 @@
+// Code generated by mockery v2.33.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	models "go-jwt-auth/internal/models"
+)
+
+// Keys is an autogenerated mock type for the Keys type
+type Keys struct {
+	mock.Mock
+}
+
+type Keys_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *Keys) EXPECT() *Keys_Expecter {
+	return &Keys_Expecter{mock: &_m.Mock}
+}
+
+// Generate provides a mock function with given fields: ctx, tenant, keyType
+func (_m *Keys) Generate(ctx context.Context, tenant string, keyType string) (models.SigningKey, error) {
+	ret := _m.Called(ctx, tenant, keyType)
+
+	var r0 models.SigningKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (models.SigningKey, error)); ok {
+		return rf(ctx, tenant, keyType)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) models.SigningKey); ok {
+		r0 = rf(ctx, tenant, keyType)
+	} else {
+		r0 = ret.Get(0).(models.SigningKey)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, tenant, keyType)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Keys_Generate_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Generate'
+type Keys_Generate_Call struct {
+	*mock.Call
+}
+
+// Generate is a helper method to define mock.On call
+//   - ctx context.Context
+//   - tenant string
+//   - keyType string
+func (_e *Keys_Expecter) Generate(ctx interface{}, tenant interface{}, keyType interface{}) *Keys_Generate_Call {
+	return &Keys_Generate_Call{Call: _e.mock.On("Generate", ctx, tenant, keyType)}
+}
+
+func (_c *Keys_Generate_Call) Run(run func(ctx context.Context, tenant string, keyType string)) *Keys_Generate_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *Keys_Generate_Call) Return(_a0 models.SigningKey, _a1 error) *Keys_Generate_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Keys_Generate_Call) RunAndReturn(run func(context.Context, string, string) (models.SigningKey, error)) *Keys_Generate_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Keys provides a mock function with given fields: ctx
+func (_m *Keys) Keys(ctx context.Context) ([]models.SigningKey, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []models.SigningKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]models.SigningKey, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []models.SigningKey); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.SigningKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Keys_Keys_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Keys'
+type Keys_Keys_Call struct {
+	*mock.Call
+}
+
+// Keys is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *Keys_Expecter) Keys(ctx interface{}) *Keys_Keys_Call {
+	return &Keys_Keys_Call{Call: _e.mock.On("Keys", ctx)}
+}
+
+func (_c *Keys_Keys_Call) Run(run func(ctx context.Context)) *Keys_Keys_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *Keys_Keys_Call) Return(_a0 []models.SigningKey, _a1 error) *Keys_Keys_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Keys_Keys_Call) RunAndReturn(run func(context.Context) ([]models.SigningKey, error)) *Keys_Keys_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Retire provides a mock function with given fields: ctx, kid
+func (_m *Keys) Retire(ctx context.Context, kid string) (models.SigningKey, error) {
+	ret := _m.Called(ctx, kid)
+
+	var r0 models.SigningKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (models.SigningKey, error)); ok {
+		return rf(ctx, kid)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) models.SigningKey); ok {
+		r0 = rf(ctx, kid)
+	} else {
+		r0 = ret.Get(0).(models.SigningKey)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, kid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Keys_Retire_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Retire'
+type Keys_Retire_Call struct {
+	*mock.Call
+}
+
+// Retire is a helper method to define mock.On call
+//   - ctx context.Context
+//   - kid string
+func (_e *Keys_Expecter) Retire(ctx interface{}, kid interface{}) *Keys_Retire_Call {
+	return &Keys_Retire_Call{Call: _e.mock.On("Retire", ctx, kid)}
+}
+
+func (_c *Keys_Retire_Call) Run(run func(ctx context.Context, kid string)) *Keys_Retire_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Keys_Retire_Call) Return(_a0 models.SigningKey, _a1 error) *Keys_Retire_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Keys_Retire_Call) RunAndReturn(run func(context.Context, string) (models.SigningKey, error)) *Keys_Retire_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Rotate provides a mock function with given fields: ctx, tenant, keyType
+func (_m *Keys) Rotate(ctx context.Context, tenant string, keyType string) (models.SigningKey, error) {
+	ret := _m.Called(ctx, tenant, keyType)
+
+	var r0 models.SigningKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (models.SigningKey, error)); ok {
+		return rf(ctx, tenant, keyType)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) models.SigningKey); ok {
+		r0 = rf(ctx, tenant, keyType)
+	} else {
+		r0 = ret.Get(0).(models.SigningKey)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, tenant, keyType)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Keys_Rotate_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Rotate'
+type Keys_Rotate_Call struct {
+	*mock.Call
+}
+
+// Rotate is a helper method to define mock.On call
+//   - ctx context.Context
+//   - tenant string
+//   - keyType string
+func (_e *Keys_Expecter) Rotate(ctx interface{}, tenant interface{}, keyType interface{}) *Keys_Rotate_Call {
+	return &Keys_Rotate_Call{Call: _e.mock.On("Rotate", ctx, tenant, keyType)}
+}
+
+func (_c *Keys_Rotate_Call) Run(run func(ctx context.Context, tenant string, keyType string)) *Keys_Rotate_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *Keys_Rotate_Call) Return(_a0 models.SigningKey, _a1 error) *Keys_Rotate_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Keys_Rotate_Call) RunAndReturn(run func(context.Context, string, string) (models.SigningKey, error)) *Keys_Rotate_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewKeys creates a new instance of Keys. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewKeys(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Keys {
+	mock := &Keys{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
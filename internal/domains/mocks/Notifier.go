@@ -0,0 +1,81 @@
+// Code generated by mockery v2.33.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	models "go-jwt-auth/internal/models"
+)
+
+// Notifier is an autogenerated mock type for the Notifier type
+type Notifier struct {
+	mock.Mock
+}
+
+type Notifier_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *Notifier) EXPECT() *Notifier_Expecter {
+	return &Notifier_Expecter{mock: &_m.Mock}
+}
+
+// NotifyNewIP provides a mock function with given fields: ctx, n
+func (_m *Notifier) NotifyNewIP(ctx context.Context, n models.NewIPNotice) error {
+	ret := _m.Called(ctx, n)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.NewIPNotice) error); ok {
+		r0 = rf(ctx, n)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Notifier_NotifyNewIP_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'NotifyNewIP'
+type Notifier_NotifyNewIP_Call struct {
+	*mock.Call
+}
+
+// NotifyNewIP is a helper method to define mock.On call
+//   - ctx context.Context
+//   - n models.NewIPNotice
+func (_e *Notifier_Expecter) NotifyNewIP(ctx interface{}, n interface{}) *Notifier_NotifyNewIP_Call {
+	return &Notifier_NotifyNewIP_Call{Call: _e.mock.On("NotifyNewIP", ctx, n)}
+}
+
+func (_c *Notifier_NotifyNewIP_Call) Run(run func(ctx context.Context, n models.NewIPNotice)) *Notifier_NotifyNewIP_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.NewIPNotice))
+	})
+	return _c
+}
+
+func (_c *Notifier_NotifyNewIP_Call) Return(_a0 error) *Notifier_NotifyNewIP_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Notifier_NotifyNewIP_Call) RunAndReturn(run func(context.Context, models.NewIPNotice) error) *Notifier_NotifyNewIP_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewNotifier creates a new instance of Notifier. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewNotifier(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Notifier {
+	mock := &Notifier{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
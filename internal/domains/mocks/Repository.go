@@ -23,6 +23,102 @@ func (_m *Repository) EXPECT() *Repository_Expecter {
 	return &Repository_Expecter{mock: &_m.Mock}
 }
 
+// ConsumeCode provides a mock function with given fields: ctx, hash
+func (_m *Repository) ConsumeCode(ctx context.Context, hash string) (models.AuthorizationCode, error) {
+	ret := _m.Called(ctx, hash)
+
+	var r0 models.AuthorizationCode
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (models.AuthorizationCode, error)); ok {
+		return rf(ctx, hash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) models.AuthorizationCode); ok {
+		r0 = rf(ctx, hash)
+	} else {
+		r0 = ret.Get(0).(models.AuthorizationCode)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, hash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Repository_ConsumeCode_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ConsumeCode'
+type Repository_ConsumeCode_Call struct {
+	*mock.Call
+}
+
+// ConsumeCode is a helper method to define mock.On call
+//   - ctx context.Context
+//   - hash string
+func (_e *Repository_Expecter) ConsumeCode(ctx interface{}, hash interface{}) *Repository_ConsumeCode_Call {
+	return &Repository_ConsumeCode_Call{Call: _e.mock.On("ConsumeCode", ctx, hash)}
+}
+
+func (_c *Repository_ConsumeCode_Call) Run(run func(ctx context.Context, hash string)) *Repository_ConsumeCode_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Repository_ConsumeCode_Call) Return(_a0 models.AuthorizationCode, _a1 error) *Repository_ConsumeCode_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Repository_ConsumeCode_Call) RunAndReturn(run func(context.Context, string) (models.AuthorizationCode, error)) *Repository_ConsumeCode_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteClient provides a mock function with given fields: ctx, id
+func (_m *Repository) DeleteClient(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Repository_DeleteClient_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteClient'
+type Repository_DeleteClient_Call struct {
+	*mock.Call
+}
+
+// DeleteClient is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id string
+func (_e *Repository_Expecter) DeleteClient(ctx interface{}, id interface{}) *Repository_DeleteClient_Call {
+	return &Repository_DeleteClient_Call{Call: _e.mock.On("DeleteClient", ctx, id)}
+}
+
+func (_c *Repository_DeleteClient_Call) Run(run func(ctx context.Context, id string)) *Repository_DeleteClient_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Repository_DeleteClient_Call) Return(_a0 error) *Repository_DeleteClient_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Repository_DeleteClient_Call) RunAndReturn(run func(context.Context, string) error) *Repository_DeleteClient_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // DeleteTokenData provides a mock function with given fields: ctx, guid, hash
 func (_m *Repository) DeleteTokenData(ctx context.Context, guid string, hash string) error {
 	ret := _m.Called(ctx, guid, hash)
@@ -67,6 +163,113 @@ func (_c *Repository_DeleteTokenData_Call) RunAndReturn(run func(context.Context
 	return _c
 }
 
+// GetClient provides a mock function with given fields: ctx, id
+func (_m *Repository) GetClient(ctx context.Context, id string) (models.Client, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 models.Client
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (models.Client, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) models.Client); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(models.Client)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Repository_GetClient_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetClient'
+type Repository_GetClient_Call struct {
+	*mock.Call
+}
+
+// GetClient is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id string
+func (_e *Repository_Expecter) GetClient(ctx interface{}, id interface{}) *Repository_GetClient_Call {
+	return &Repository_GetClient_Call{Call: _e.mock.On("GetClient", ctx, id)}
+}
+
+func (_c *Repository_GetClient_Call) Run(run func(ctx context.Context, id string)) *Repository_GetClient_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Repository_GetClient_Call) Return(_a0 models.Client, _a1 error) *Repository_GetClient_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Repository_GetClient_Call) RunAndReturn(run func(context.Context, string) (models.Client, error)) *Repository_GetClient_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetClients provides a mock function with given fields: ctx
+func (_m *Repository) GetClients(ctx context.Context) ([]models.Client, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []models.Client
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]models.Client, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []models.Client); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Client)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Repository_GetClients_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetClients'
+type Repository_GetClients_Call struct {
+	*mock.Call
+}
+
+// GetClients is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *Repository_Expecter) GetClients(ctx interface{}) *Repository_GetClients_Call {
+	return &Repository_GetClients_Call{Call: _e.mock.On("GetClients", ctx)}
+}
+
+func (_c *Repository_GetClients_Call) Run(run func(ctx context.Context)) *Repository_GetClients_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *Repository_GetClients_Call) Return(_a0 []models.Client, _a1 error) *Repository_GetClients_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Repository_GetClients_Call) RunAndReturn(run func(context.Context) ([]models.Client, error)) *Repository_GetClients_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetTokensDataByGUID provides a mock function with given fields: ctx, guid
 func (_m *Repository) GetTokensDataByGUID(ctx context.Context, guid string) ([]models.TokenData, error) {
 	ret := _m.Called(ctx, guid)
@@ -122,6 +325,145 @@ func (_c *Repository_GetTokensDataByGUID_Call) RunAndReturn(run func(context.Con
 	return _c
 }
 
+// PurgeExpired provides a mock function with given fields: ctx, now
+func (_m *Repository) PurgeExpired(ctx context.Context, now int64) (int64, error) {
+	ret := _m.Called(ctx, now)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (int64, error)); ok {
+		return rf(ctx, now)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) int64); ok {
+		r0 = rf(ctx, now)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Repository_PurgeExpired_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PurgeExpired'
+type Repository_PurgeExpired_Call struct {
+	*mock.Call
+}
+
+// PurgeExpired is a helper method to define mock.On call
+//   - ctx context.Context
+//   - now int64
+func (_e *Repository_Expecter) PurgeExpired(ctx interface{}, now interface{}) *Repository_PurgeExpired_Call {
+	return &Repository_PurgeExpired_Call{Call: _e.mock.On("PurgeExpired", ctx, now)}
+}
+
+func (_c *Repository_PurgeExpired_Call) Run(run func(ctx context.Context, now int64)) *Repository_PurgeExpired_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int64))
+	})
+	return _c
+}
+
+func (_c *Repository_PurgeExpired_Call) Return(purged int64, err error) *Repository_PurgeExpired_Call {
+	_c.Call.Return(purged, err)
+	return _c
+}
+
+func (_c *Repository_PurgeExpired_Call) RunAndReturn(run func(context.Context, int64) (int64, error)) *Repository_PurgeExpired_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SaveClient provides a mock function with given fields: ctx, c
+func (_m *Repository) SaveClient(ctx context.Context, c models.Client) error {
+	ret := _m.Called(ctx, c)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.Client) error); ok {
+		r0 = rf(ctx, c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Repository_SaveClient_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveClient'
+type Repository_SaveClient_Call struct {
+	*mock.Call
+}
+
+// SaveClient is a helper method to define mock.On call
+//   - ctx context.Context
+//   - c models.Client
+func (_e *Repository_Expecter) SaveClient(ctx interface{}, c interface{}) *Repository_SaveClient_Call {
+	return &Repository_SaveClient_Call{Call: _e.mock.On("SaveClient", ctx, c)}
+}
+
+func (_c *Repository_SaveClient_Call) Run(run func(ctx context.Context, c models.Client)) *Repository_SaveClient_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.Client))
+	})
+	return _c
+}
+
+func (_c *Repository_SaveClient_Call) Return(_a0 error) *Repository_SaveClient_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Repository_SaveClient_Call) RunAndReturn(run func(context.Context, models.Client) error) *Repository_SaveClient_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SaveCode provides a mock function with given fields: ctx, c
+func (_m *Repository) SaveCode(ctx context.Context, c models.AuthorizationCode) error {
+	ret := _m.Called(ctx, c)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.AuthorizationCode) error); ok {
+		r0 = rf(ctx, c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Repository_SaveCode_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveCode'
+type Repository_SaveCode_Call struct {
+	*mock.Call
+}
+
+// SaveCode is a helper method to define mock.On call
+//   - ctx context.Context
+//   - c models.AuthorizationCode
+func (_e *Repository_Expecter) SaveCode(ctx interface{}, c interface{}) *Repository_SaveCode_Call {
+	return &Repository_SaveCode_Call{Call: _e.mock.On("SaveCode", ctx, c)}
+}
+
+func (_c *Repository_SaveCode_Call) Run(run func(ctx context.Context, c models.AuthorizationCode)) *Repository_SaveCode_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.AuthorizationCode))
+	})
+	return _c
+}
+
+func (_c *Repository_SaveCode_Call) Return(_a0 error) *Repository_SaveCode_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Repository_SaveCode_Call) RunAndReturn(run func(context.Context, models.AuthorizationCode) error) *Repository_SaveCode_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // SaveTokenData provides a mock function with given fields: ctx, t
 func (_m *Repository) SaveTokenData(ctx context.Context, t models.TokenData) error {
 	ret := _m.Called(ctx, t)
@@ -165,6 +507,49 @@ func (_c *Repository_SaveTokenData_Call) RunAndReturn(run func(context.Context,
 	return _c
 }
 
+// ScanTokenData provides a mock function with given fields: ctx, fn
+func (_m *Repository) ScanTokenData(ctx context.Context, fn func(models.TokenData) error) error {
+	ret := _m.Called(ctx, fn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, func(models.TokenData) error) error); ok {
+		r0 = rf(ctx, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Repository_ScanTokenData_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ScanTokenData'
+type Repository_ScanTokenData_Call struct {
+	*mock.Call
+}
+
+// ScanTokenData is a helper method to define mock.On call
+//   - ctx context.Context
+//   - fn func(models.TokenData) error
+func (_e *Repository_Expecter) ScanTokenData(ctx interface{}, fn interface{}) *Repository_ScanTokenData_Call {
+	return &Repository_ScanTokenData_Call{Call: _e.mock.On("ScanTokenData", ctx, fn)}
+}
+
+func (_c *Repository_ScanTokenData_Call) Run(run func(ctx context.Context, fn func(models.TokenData) error)) *Repository_ScanTokenData_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(func(models.TokenData) error))
+	})
+	return _c
+}
+
+func (_c *Repository_ScanTokenData_Call) Return(_a0 error) *Repository_ScanTokenData_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Repository_ScanTokenData_Call) RunAndReturn(run func(context.Context, func(models.TokenData) error) error) *Repository_ScanTokenData_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewRepository creates a new instance of Repository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewRepository(t interface {
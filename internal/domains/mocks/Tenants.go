@@ -0,0 +1,88 @@
+// Code generated by mockery v2.33.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	models "go-jwt-auth/internal/models"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Tenants is an autogenerated mock type for the Tenants type
+type Tenants struct {
+	mock.Mock
+}
+
+type Tenants_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *Tenants) EXPECT() *Tenants_Expecter {
+	return &Tenants_Expecter{mock: &_m.Mock}
+}
+
+// Tenant provides a mock function with given fields: id
+func (_m *Tenants) Tenant(id string) (models.Tenant, error) {
+	ret := _m.Called(id)
+
+	var r0 models.Tenant
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (models.Tenant, error)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(string) models.Tenant); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Get(0).(models.Tenant)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Tenants_Tenant_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Tenant'
+type Tenants_Tenant_Call struct {
+	*mock.Call
+}
+
+// Tenant is a helper method to define mock.On call
+//   - id string
+func (_e *Tenants_Expecter) Tenant(id interface{}) *Tenants_Tenant_Call {
+	return &Tenants_Tenant_Call{Call: _e.mock.On("Tenant", id)}
+}
+
+func (_c *Tenants_Tenant_Call) Run(run func(id string)) *Tenants_Tenant_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *Tenants_Tenant_Call) Return(_a0 models.Tenant, _a1 error) *Tenants_Tenant_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Tenants_Tenant_Call) RunAndReturn(run func(string) (models.Tenant, error)) *Tenants_Tenant_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewTenants creates a new instance of Tenants. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewTenants(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Tenants {
+	mock := &Tenants{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -6,6 +6,8 @@ import (
 	context "context"
 
 	mock "github.com/stretchr/testify/mock"
+
+	models "go-jwt-auth/internal/models"
 )
 
 // TokenManager is an autogenerated mock type for the TokenManager type
@@ -21,6 +23,59 @@ func (_m *TokenManager) EXPECT() *TokenManager_Expecter {
 	return &TokenManager_Expecter{mock: &_m.Mock}
 }
 
+// Authorize provides a mock function with given fields: ctx, r
+func (_m *TokenManager) Authorize(ctx context.Context, r models.AuthorizationRequest) (string, error) {
+	ret := _m.Called(ctx, r)
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.AuthorizationRequest) (string, error)); ok {
+		return rf(ctx, r)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.AuthorizationRequest) string); ok {
+		r0 = rf(ctx, r)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.AuthorizationRequest) error); ok {
+		r1 = rf(ctx, r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TokenManager_Authorize_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Authorize'
+type TokenManager_Authorize_Call struct {
+	*mock.Call
+}
+
+// Authorize is a helper method to define mock.On call
+//   - ctx context.Context
+//   - r models.AuthorizationRequest
+func (_e *TokenManager_Expecter) Authorize(ctx interface{}, r interface{}) *TokenManager_Authorize_Call {
+	return &TokenManager_Authorize_Call{Call: _e.mock.On("Authorize", ctx, r)}
+}
+
+func (_c *TokenManager_Authorize_Call) Run(run func(ctx context.Context, r models.AuthorizationRequest)) *TokenManager_Authorize_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.AuthorizationRequest))
+	})
+	return _c
+}
+
+func (_c *TokenManager_Authorize_Call) Return(code string, err error) *TokenManager_Authorize_Call {
+	_c.Call.Return(code, err)
+	return _c
+}
+
+func (_c *TokenManager_Authorize_Call) RunAndReturn(run func(context.Context, models.AuthorizationRequest) (string, error)) *TokenManager_Authorize_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetTokens provides a mock function with given fields: ctx, guid
 func (_m *TokenManager) GetTokens(ctx context.Context, guid string) (string, string, error) {
 	ret := _m.Called(ctx, guid)
@@ -81,6 +136,112 @@ func (_c *TokenManager_GetTokens_Call) RunAndReturn(run func(context.Context, st
 	return _c
 }
 
+// Grant provides a mock function with given fields: ctx, r
+func (_m *TokenManager) Grant(ctx context.Context, r models.GrantRequest) (models.Tokens, error) {
+	ret := _m.Called(ctx, r)
+
+	var r0 models.Tokens
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.GrantRequest) (models.Tokens, error)); ok {
+		return rf(ctx, r)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.GrantRequest) models.Tokens); ok {
+		r0 = rf(ctx, r)
+	} else {
+		r0 = ret.Get(0).(models.Tokens)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.GrantRequest) error); ok {
+		r1 = rf(ctx, r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TokenManager_Grant_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Grant'
+type TokenManager_Grant_Call struct {
+	*mock.Call
+}
+
+// Grant is a helper method to define mock.On call
+//   - ctx context.Context
+//   - r models.GrantRequest
+func (_e *TokenManager_Expecter) Grant(ctx interface{}, r interface{}) *TokenManager_Grant_Call {
+	return &TokenManager_Grant_Call{Call: _e.mock.On("Grant", ctx, r)}
+}
+
+func (_c *TokenManager_Grant_Call) Run(run func(ctx context.Context, r models.GrantRequest)) *TokenManager_Grant_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.GrantRequest))
+	})
+	return _c
+}
+
+func (_c *TokenManager_Grant_Call) Return(_a0 models.Tokens, _a1 error) *TokenManager_Grant_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *TokenManager_Grant_Call) RunAndReturn(run func(context.Context, models.GrantRequest) (models.Tokens, error)) *TokenManager_Grant_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Introspect provides a mock function with given fields: ctx, token
+func (_m *TokenManager) Introspect(ctx context.Context, token string) (models.Introspection, error) {
+	ret := _m.Called(ctx, token)
+
+	var r0 models.Introspection
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (models.Introspection, error)); ok {
+		return rf(ctx, token)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) models.Introspection); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Get(0).(models.Introspection)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TokenManager_Introspect_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Introspect'
+type TokenManager_Introspect_Call struct {
+	*mock.Call
+}
+
+// Introspect is a helper method to define mock.On call
+//   - ctx context.Context
+//   - token string
+func (_e *TokenManager_Expecter) Introspect(ctx interface{}, token interface{}) *TokenManager_Introspect_Call {
+	return &TokenManager_Introspect_Call{Call: _e.mock.On("Introspect", ctx, token)}
+}
+
+func (_c *TokenManager_Introspect_Call) Run(run func(ctx context.Context, token string)) *TokenManager_Introspect_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *TokenManager_Introspect_Call) Return(_a0 models.Introspection, _a1 error) *TokenManager_Introspect_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *TokenManager_Introspect_Call) RunAndReturn(run func(context.Context, string) (models.Introspection, error)) *TokenManager_Introspect_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // RefreshTokens provides a mock function with given fields: ctx, access, refresh
 func (_m *TokenManager) RefreshTokens(ctx context.Context, access string, refresh string) (string, string, error) {
 	ret := _m.Called(ctx, access, refresh)
@@ -142,6 +303,102 @@ func (_c *TokenManager_RefreshTokens_Call) RunAndReturn(run func(context.Context
 	return _c
 }
 
+// Revoke provides a mock function with given fields: ctx, token
+func (_m *TokenManager) Revoke(ctx context.Context, token string) error {
+	ret := _m.Called(ctx, token)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// TokenManager_Revoke_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Revoke'
+type TokenManager_Revoke_Call struct {
+	*mock.Call
+}
+
+// Revoke is a helper method to define mock.On call
+//   - ctx context.Context
+//   - token string
+func (_e *TokenManager_Expecter) Revoke(ctx interface{}, token interface{}) *TokenManager_Revoke_Call {
+	return &TokenManager_Revoke_Call{Call: _e.mock.On("Revoke", ctx, token)}
+}
+
+func (_c *TokenManager_Revoke_Call) Run(run func(ctx context.Context, token string)) *TokenManager_Revoke_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *TokenManager_Revoke_Call) Return(_a0 error) *TokenManager_Revoke_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *TokenManager_Revoke_Call) RunAndReturn(run func(context.Context, string) error) *TokenManager_Revoke_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UserInfo provides a mock function with given fields: ctx, access
+func (_m *TokenManager) UserInfo(ctx context.Context, access string) (models.UserInfo, error) {
+	ret := _m.Called(ctx, access)
+
+	var r0 models.UserInfo
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (models.UserInfo, error)); ok {
+		return rf(ctx, access)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) models.UserInfo); ok {
+		r0 = rf(ctx, access)
+	} else {
+		r0 = ret.Get(0).(models.UserInfo)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, access)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TokenManager_UserInfo_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UserInfo'
+type TokenManager_UserInfo_Call struct {
+	*mock.Call
+}
+
+// UserInfo is a helper method to define mock.On call
+//   - ctx context.Context
+//   - access string
+func (_e *TokenManager_Expecter) UserInfo(ctx interface{}, access interface{}) *TokenManager_UserInfo_Call {
+	return &TokenManager_UserInfo_Call{Call: _e.mock.On("UserInfo", ctx, access)}
+}
+
+func (_c *TokenManager_UserInfo_Call) Run(run func(ctx context.Context, access string)) *TokenManager_UserInfo_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *TokenManager_UserInfo_Call) Return(_a0 models.UserInfo, _a1 error) *TokenManager_UserInfo_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *TokenManager_UserInfo_Call) RunAndReturn(run func(context.Context, string) (models.UserInfo, error)) *TokenManager_UserInfo_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewTokenManager creates a new instance of TokenManager. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewTokenManager(t interface {
@@ -0,0 +1,88 @@
+// Code generated by mockery v2.33.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	http "net/http"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// UserAuthenticator is an autogenerated mock type for the UserAuthenticator type
+type UserAuthenticator struct {
+	mock.Mock
+}
+
+type UserAuthenticator_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *UserAuthenticator) EXPECT() *UserAuthenticator_Expecter {
+	return &UserAuthenticator_Expecter{mock: &_m.Mock}
+}
+
+// AuthenticateUser provides a mock function with given fields: r
+func (_m *UserAuthenticator) AuthenticateUser(r *http.Request) (string, error) {
+	ret := _m.Called(r)
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*http.Request) (string, error)); ok {
+		return rf(r)
+	}
+	if rf, ok := ret.Get(0).(func(*http.Request) string); ok {
+		r0 = rf(r)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(*http.Request) error); ok {
+		r1 = rf(r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UserAuthenticator_AuthenticateUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AuthenticateUser'
+type UserAuthenticator_AuthenticateUser_Call struct {
+	*mock.Call
+}
+
+// AuthenticateUser is a helper method to define mock.On call
+//   - r *http.Request
+func (_e *UserAuthenticator_Expecter) AuthenticateUser(r interface{}) *UserAuthenticator_AuthenticateUser_Call {
+	return &UserAuthenticator_AuthenticateUser_Call{Call: _e.mock.On("AuthenticateUser", r)}
+}
+
+func (_c *UserAuthenticator_AuthenticateUser_Call) Run(run func(r *http.Request)) *UserAuthenticator_AuthenticateUser_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*http.Request))
+	})
+	return _c
+}
+
+func (_c *UserAuthenticator_AuthenticateUser_Call) Return(guid string, err error) *UserAuthenticator_AuthenticateUser_Call {
+	_c.Call.Return(guid, err)
+	return _c
+}
+
+func (_c *UserAuthenticator_AuthenticateUser_Call) RunAndReturn(run func(*http.Request) (string, error)) *UserAuthenticator_AuthenticateUser_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewUserAuthenticator creates a new instance of UserAuthenticator. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewUserAuthenticator(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *UserAuthenticator {
+	mock := &UserAuthenticator{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
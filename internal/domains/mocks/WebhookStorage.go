@@ -0,0 +1,124 @@
+// Code generated by mockery v2.33.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	models "go-jwt-auth/internal/models"
+)
+
+// WebhookStorage is an autogenerated mock type for the WebhookStorage type
+type WebhookStorage struct {
+	mock.Mock
+}
+
+type WebhookStorage_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *WebhookStorage) EXPECT() *WebhookStorage_Expecter {
+	return &WebhookStorage_Expecter{mock: &_m.Mock}
+}
+
+// SaveWebhookDeadLetter provides a mock function with given fields: ctx, d
+func (_m *WebhookStorage) SaveWebhookDeadLetter(ctx context.Context, d models.WebhookDelivery) error {
+	ret := _m.Called(ctx, d)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.WebhookDelivery) error); ok {
+		r0 = rf(ctx, d)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// WebhookStorage_SaveWebhookDeadLetter_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveWebhookDeadLetter'
+type WebhookStorage_SaveWebhookDeadLetter_Call struct {
+	*mock.Call
+}
+
+// SaveWebhookDeadLetter is a helper method to define mock.On call
+//   - ctx context.Context
+//   - d models.WebhookDelivery
+func (_e *WebhookStorage_Expecter) SaveWebhookDeadLetter(ctx interface{}, d interface{}) *WebhookStorage_SaveWebhookDeadLetter_Call {
+	return &WebhookStorage_SaveWebhookDeadLetter_Call{Call: _e.mock.On("SaveWebhookDeadLetter", ctx, d)}
+}
+
+func (_c *WebhookStorage_SaveWebhookDeadLetter_Call) Run(run func(ctx context.Context, d models.WebhookDelivery)) *WebhookStorage_SaveWebhookDeadLetter_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.WebhookDelivery))
+	})
+	return _c
+}
+
+func (_c *WebhookStorage_SaveWebhookDeadLetter_Call) Return(_a0 error) *WebhookStorage_SaveWebhookDeadLetter_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *WebhookStorage_SaveWebhookDeadLetter_Call) RunAndReturn(run func(context.Context, models.WebhookDelivery) error) *WebhookStorage_SaveWebhookDeadLetter_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SaveWebhookDelivery provides a mock function with given fields: ctx, d
+func (_m *WebhookStorage) SaveWebhookDelivery(ctx context.Context, d models.WebhookDelivery) error {
+	ret := _m.Called(ctx, d)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.WebhookDelivery) error); ok {
+		r0 = rf(ctx, d)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// WebhookStorage_SaveWebhookDelivery_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveWebhookDelivery'
+type WebhookStorage_SaveWebhookDelivery_Call struct {
+	*mock.Call
+}
+
+// SaveWebhookDelivery is a helper method to define mock.On call
+//   - ctx context.Context
+//   - d models.WebhookDelivery
+func (_e *WebhookStorage_Expecter) SaveWebhookDelivery(ctx interface{}, d interface{}) *WebhookStorage_SaveWebhookDelivery_Call {
+	return &WebhookStorage_SaveWebhookDelivery_Call{Call: _e.mock.On("SaveWebhookDelivery", ctx, d)}
+}
+
+func (_c *WebhookStorage_SaveWebhookDelivery_Call) Run(run func(ctx context.Context, d models.WebhookDelivery)) *WebhookStorage_SaveWebhookDelivery_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.WebhookDelivery))
+	})
+	return _c
+}
+
+func (_c *WebhookStorage_SaveWebhookDelivery_Call) Return(_a0 error) *WebhookStorage_SaveWebhookDelivery_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *WebhookStorage_SaveWebhookDelivery_Call) RunAndReturn(run func(context.Context, models.WebhookDelivery) error) *WebhookStorage_SaveWebhookDelivery_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewWebhookStorage creates a new instance of WebhookStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewWebhookStorage(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *WebhookStorage {
+	mock := &WebhookStorage{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,10 @@
+package domains
+
+import (
+	"context"
+	"go-jwt-auth/internal/models"
+)
+
+type Notifier interface {
+	NotifyNewIP(ctx context.Context, n models.NewIPNotice) error
+}
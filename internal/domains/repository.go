@@ -2,4 +2,11 @@ package domains
 
 type Repository interface {
 	Database
+	ClientStorage
+	CodeStorage
+	// TokenDataScanner goes through the sessions, constants.ErrNotSupported if the database can't.
+	TokenDataScanner
+	// ExpiredPurger purges the expired sessions, constants.ErrNotSupported if the database
+	// expires them natively.
+	ExpiredPurger
 }
@@ -0,0 +1,8 @@
+package domains
+
+import "go-jwt-auth/internal/models"
+
+type Tenants interface {
+	// Tenant returns the settings of the tenant, constants.ErrUnknownTenant if there is none.
+	Tenant(id string) (models.Tenant, error)
+}
@@ -1,8 +1,21 @@
 package domains
 
-import "context"
+import (
+	"context"
+	"go-jwt-auth/internal/models"
+)
 
 type TokenManager interface {
 	GetTokens(ctx context.Context, guid string) (string, string, error)
 	RefreshTokens(ctx context.Context, access, refresh string) (string, string, error)
+	// Grant issues the tokens of an OAuth grant to the client of the request.
+	Grant(ctx context.Context, r models.GrantRequest) (models.Tokens, error)
+	// Authorize issues an authorization code of the user to the client of the request.
+	Authorize(ctx context.Context, r models.AuthorizationRequest) (code string, err error)
+	// UserInfo returns the claims of the subject of an access token with the openid scope.
+	UserInfo(ctx context.Context, access string) (models.UserInfo, error)
+	// Revoke revokes a refresh token of the grants issued to the client of the request.
+	Revoke(ctx context.Context, token string) error
+	// Introspect returns the state of a token issued by the tenant of the request.
+	Introspect(ctx context.Context, token string) (models.Introspection, error)
 }
@@ -0,0 +1,10 @@
+package domains
+
+import "net/http"
+
+// UserAuthenticator authenticates the users of the authorization requests.
+type UserAuthenticator interface {
+	// AuthenticateUser returns the GUID of the user sending the request,
+	// constants.ErrLoginRequired if the user isn't authenticated.
+	AuthenticateUser(r *http.Request) (guid string, err error)
+}
@@ -0,0 +1,15 @@
+package domains
+
+import (
+	"context"
+	"go-jwt-auth/internal/models"
+)
+
+type WebhookStorage interface {
+	SaveWebhookDelivery(ctx context.Context, d models.WebhookDelivery) error
+	SaveWebhookDeadLetter(ctx context.Context, d models.WebhookDelivery) error
+}
+
+type WebhookDispatcher interface {
+	EventSubscriber
+}
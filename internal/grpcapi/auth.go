@@ -0,0 +1,143 @@
+package grpcapi
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains"
+	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/models"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// The metadata of the calls, the equivalents of the HTTP headers.
+const (
+	AuthorizationMetadata = "authorization"
+	APIKeyMetadata        = "x-api-key"
+	TenantMetadata        = "x-tenant-id"
+)
+
+// requestContext carries the IP address and the tenant of the call to the
+// services, like the HTTP requestContext. The tenant must exist.
+func requestContext(ctx context.Context, tenants domains.Tenants) (context.Context, error) {
+	id := first(ctx, TenantMetadata)
+	if _, err := tenants.Tenant(id); err != nil {
+		return nil, err
+	}
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		ip := p.Addr.String()
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+		ctx = lib.WithClientIP(ctx, ip)
+	}
+	return lib.WithTenant(ctx, id), nil
+}
+
+// authenticate authenticates the client of the call with one of the methods,
+// any if none, and carries it to the services.
+func authenticate(ctx context.Context, clients domains.Clients, methods ...string) (context.Context, models.Client, error) {
+	creds, err := clientCredentials(ctx)
+	if err != nil {
+		return nil, models.Client{}, err
+	}
+	if len(methods) > 0 && !contains(methods, creds.Method) {
+		return nil, models.Client{}, constants.ErrInvalidClient
+	}
+
+	client, err := clients.Authenticate(ctx, creds)
+	if err != nil {
+		return nil, models.Client{}, err
+	}
+	return lib.WithClient(ctx, client), client, nil
+}
+
+// clientCredentials extracts the credentials of the only authentication method used.
+func clientCredentials(ctx context.Context) (creds models.ClientCredentials, err error) {
+	var found []models.ClientCredentials
+
+	if auth := first(ctx, AuthorizationMetadata); auth != "" {
+		scheme, encoded, _ := strings.Cut(auth, " ")
+		if !strings.EqualFold(scheme, "Basic") {
+			return creds, constants.ErrInvalidClient
+		}
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return creds, constants.ErrInvalidClient
+		}
+		id, secret, ok := strings.Cut(string(raw), ":")
+		if !ok {
+			return creds, constants.ErrInvalidClient
+		}
+		// RFC 6749 2.3.1: the credentials are form-encoded, like the ones of the HTTP header.
+		if id, err = url.QueryUnescape(id); err != nil {
+			return creds, constants.ErrInvalidClient
+		}
+		if secret, err = url.QueryUnescape(secret); err != nil {
+			return creds, constants.ErrInvalidClient
+		}
+		found = append(found, models.ClientCredentials{Method: models.ClientAuthSecretBasic, ID: id, Secret: secret})
+	}
+
+	if key := first(ctx, APIKeyMetadata); key != "" {
+		id, secret, ok := strings.Cut(key, ":")
+		if !ok {
+			return creds, constants.ErrInvalidClient
+		}
+		found = append(found, models.ClientCredentials{Method: models.ClientAuthAPIKey, ID: id, Secret: secret})
+	}
+
+	switch len(found) {
+	case 0:
+		if cert, ok := verifiedCertificate(ctx); ok {
+			return models.ClientCredentials{
+				Method:     models.ClientAuthTLS,
+				ID:         cert.Subject.CommonName,
+				TLSSubject: cert.Subject.String(),
+			}, nil
+		}
+		return creds, constants.ErrInvalidClient
+	case 1:
+		return found[0], nil
+	default:
+		return creds, constants.ErrMultipleClientAuth
+	}
+}
+
+// verifiedCertificate returns the client certificate of the connection if
+// the TLS handshake has verified it against the client CAs.
+func verifiedCertificate(ctx context.Context) (*x509.Certificate, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return nil, false
+	}
+	return info.State.VerifiedChains[0][0], true
+}
+
+// first returns the first value of the metadata key of the call.
+func first(ctx context.Context, key string) string {
+	if v := metadata.ValueFromIncomingContext(ctx, key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,24 @@
+package grpcapi
+
+import (
+	"go-jwt-auth/internal/constants"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// statusError converts an error to a gRPC status, the equivalent of handler.HTTPError.
+func statusError(err error) error {
+	switch err { // no errors.Is() because we get an explicit error from the service every time.
+	case constants.ErrMissingRefreshToken, constants.ErrMissingAccessToken, constants.ErrInvalidClient:
+		return status.Error(codes.Unauthenticated, err.Error())
+	case constants.ErrTokenExpired, constants.ErrNewIP, constants.ErrUnauthorizedClient, constants.ErrGUIDNotAllowed:
+		return status.Error(codes.PermissionDenied, err.Error())
+	case constants.ErrInvalidToken, constants.ErrInvalidGUID, constants.ErrNotFound,
+		constants.ErrMultipleClientAuth, constants.ErrUnsupportedTokenType:
+		return status.Error(codes.InvalidArgument, err.Error())
+	case constants.ErrUnknownTenant:
+		return status.Error(codes.NotFound, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
@@ -0,0 +1,43 @@
+// Package grpcapi serves the AuthService of proto/jwtauth/v1 next to the HTTP API.
+package grpcapi
+
+import (
+	"go-jwt-auth/internal/lib"
+	jwtauthv1 "go-jwt-auth/pkg/api/jwtauth/v1"
+	"go.uber.org/fx"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// Module exports dependency to container
+var Module = fx.Options(
+	fx.Provide(NewAuthService),
+	fx.Provide(NewServer),
+)
+
+// NewServer creates the gRPC server of the AuthService with the health and
+// reflection services. It serves TLS like the HTTP server if HTTPS is set.
+func NewServer(conf lib.Config, auth *AuthService) (*grpc.Server, error) {
+	var opts []grpc.ServerOption
+	if conf.HTTPS {
+		tlsConf, err := lib.ServerTLS(conf.TLS)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConf)))
+	}
+
+	server := grpc.NewServer(opts...)
+	jwtauthv1.RegisterAuthServiceServer(server, auth)
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus(jwtauthv1.AuthService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	reflection.Register(server)
+	return server, nil
+}
@@ -0,0 +1,131 @@
+package grpcapi
+
+import (
+	"context"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains"
+	"go-jwt-auth/internal/handler"
+	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/models"
+	jwtauthv1 "go-jwt-auth/pkg/api/jwtauth/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AuthService is the gRPC equivalent of the token and OAuth handlers.
+type AuthService struct {
+	jwtauthv1.UnimplementedAuthServiceServer
+
+	tokens  domains.TokenManager
+	clients domains.Clients
+	tenants domains.Tenants
+
+	// issuanceMethods are the client authentication methods of GetTokens.
+	issuanceMethods []string
+}
+
+func NewAuthService(
+	tokens domains.TokenManager,
+	clients domains.Clients,
+	tenants domains.Tenants,
+	conf lib.Config,
+) (*AuthService, error) {
+	methods, err := handler.IssuanceMethods(conf.Issuance.AuthMethods)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthService{
+		tokens:          tokens,
+		clients:         clients,
+		tenants:         tenants,
+		issuanceMethods: methods,
+	}, nil
+}
+
+func (s *AuthService) GetTokens(ctx context.Context, req *jwtauthv1.GetTokensRequest) (*jwtauthv1.TokenPair, error) {
+	ctx, err := s.callContext(ctx, models.GrantTypeIssue, s.issuanceMethods...)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	access, refresh, err := s.tokens.GetTokens(ctx, req.GetGuid())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &jwtauthv1.TokenPair{AccessToken: access, RefreshToken: refresh}, nil
+}
+
+func (s *AuthService) RefreshTokens(ctx context.Context, req *jwtauthv1.RefreshTokensRequest) (*jwtauthv1.TokenPair, error) {
+	ctx, err := s.callContext(ctx, models.GrantTypeRefreshToken)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	access, refresh, err := s.tokens.RefreshTokens(ctx, req.GetAccessToken(), req.GetRefreshToken())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &jwtauthv1.TokenPair{AccessToken: access, RefreshToken: refresh}, nil
+}
+
+// Revoke answers the unknown tokens like the revoked ones, see RFC 7009 2.2.
+func (s *AuthService) Revoke(ctx context.Context, req *jwtauthv1.RevokeRequest) (*jwtauthv1.RevokeResponse, error) {
+	if req.GetToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "token was not provided")
+	}
+	ctx, err := s.callContext(ctx, "")
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	if err := s.tokens.Revoke(ctx, req.GetToken()); err != nil {
+		return nil, statusError(err)
+	}
+	return &jwtauthv1.RevokeResponse{}, nil
+}
+
+func (s *AuthService) Introspect(ctx context.Context, req *jwtauthv1.IntrospectRequest) (*jwtauthv1.IntrospectResponse, error) {
+	if req.GetToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "token was not provided")
+	}
+	ctx, err := s.callContext(ctx, "")
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	i, err := s.tokens.Introspect(ctx, req.GetToken())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &jwtauthv1.IntrospectResponse{
+		Active:    i.Active,
+		TokenType: i.TokenType,
+		Sub:       i.Subject,
+		ClientId:  i.ClientID,
+		Scope:     i.Scope,
+		Exp:       i.ExpiresAt,
+		Iss:       i.Issuer,
+		Aud:       i.Audience,
+		Tid:       i.Tenant,
+	}, nil
+}
+
+// callContext resolves the tenant of the call and authenticates its client
+// with one of the methods, any if none. The client must be allowed the
+// grant type, if any, like with handler.ClientAuth.
+func (s *AuthService) callContext(ctx context.Context, grant models.GrantType, methods ...string) (context.Context, error) {
+	ctx, err := requestContext(ctx, s.tenants)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, client, err := authenticate(ctx, s.clients, methods...)
+	if err != nil {
+		return nil, err
+	}
+	if grant != "" && !client.Allows(grant) {
+		return nil, constants.ErrUnauthorizedClient
+	}
+	return ctx, nil
+}
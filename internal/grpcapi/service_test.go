@@ -0,0 +1,194 @@
+package grpcapi
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"github.com/stretchr/testify/mock"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains/mocks"
+	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/models"
+	jwtauthv1 "go-jwt-auth/pkg/api/jwtauth/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"testing"
+)
+
+var _svc = models.Client{
+	ID:         "svc",
+	GrantTypes: []models.GrantType{models.GrantTypeIssue, models.GrantTypeRefreshToken},
+}
+
+func basic(id, secret string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(id+":"+secret))
+}
+
+// dial serves the service on an in-memory listener.
+func dial(t *testing.T, auth *AuthService) *grpc.ClientConn {
+	t.Helper()
+	server, err := NewServer(lib.Config{}, auth)
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	lis := bufconn.Listen(1 << 20)
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func TestAuthService_GetTokens(t *testing.T) {
+	tests := []struct {
+		name     string
+		md       metadata.MD
+		mock     func(tm *mocks.TokenManager, cl *mocks.Clients, tn *mocks.Tenants)
+		want     *jwtauthv1.TokenPair
+		wantCode codes.Code
+	}{
+		{
+			name: "ok",
+			md:   metadata.Pairs(AuthorizationMetadata, basic("svc", "s3cret"), TenantMetadata, "acme"),
+			mock: func(tm *mocks.TokenManager, cl *mocks.Clients, tn *mocks.Tenants) {
+				tn.On("Tenant", "acme").Return(models.Tenant{}, nil)
+				cl.On("Authenticate", mock.Anything, models.ClientCredentials{
+					Method: models.ClientAuthSecretBasic, ID: "svc", Secret: "s3cret",
+				}).Return(_svc, nil)
+				tm.On("GetTokens", mock.MatchedBy(func(ctx context.Context) bool {
+					client, ok := lib.Client(ctx)
+					return lib.Tenant(ctx) == "acme" && ok && client.ID == "svc"
+				}), "kwfwe").Return("YWNjZXNz", "cmVmcmVzaA==", nil)
+			},
+			want: &jwtauthv1.TokenPair{AccessToken: "YWNjZXNz", RefreshToken: "cmVmcmVzaA=="},
+		},
+		{
+			name: "apiKey",
+			md:   metadata.Pairs(APIKeyMetadata, "billing:key"),
+			mock: func(tm *mocks.TokenManager, cl *mocks.Clients, tn *mocks.Tenants) {
+				tn.On("Tenant", "").Return(models.Tenant{}, nil)
+				cl.On("Authenticate", mock.Anything, models.ClientCredentials{
+					Method: models.ClientAuthAPIKey, ID: "billing", Secret: "key",
+				}).Return(_svc, nil)
+				tm.On("GetTokens", mock.Anything, "kwfwe").Return("", "", constants.ErrGUIDNotAllowed)
+			},
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name: "noClient",
+			mock: func(tm *mocks.TokenManager, cl *mocks.Clients, tn *mocks.Tenants) {
+				tn.On("Tenant", "").Return(models.Tenant{}, nil)
+			},
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name: "twoClients",
+			md:   metadata.Pairs(AuthorizationMetadata, basic("svc", "s3cret"), APIKeyMetadata, "billing:key"),
+			mock: func(tm *mocks.TokenManager, cl *mocks.Clients, tn *mocks.Tenants) {
+				tn.On("Tenant", "").Return(models.Tenant{}, nil)
+			},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name: "noGrant",
+			md:   metadata.Pairs(AuthorizationMetadata, basic("spa", "s3cret")),
+			mock: func(tm *mocks.TokenManager, cl *mocks.Clients, tn *mocks.Tenants) {
+				tn.On("Tenant", "").Return(models.Tenant{}, nil)
+				cl.On("Authenticate", mock.Anything, mock.Anything).Return(models.Client{ID: "spa"}, nil)
+			},
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name: "unknownTenant",
+			md:   metadata.Pairs(TenantMetadata, "globex"),
+			mock: func(tm *mocks.TokenManager, cl *mocks.Clients, tn *mocks.Tenants) {
+				tn.On("Tenant", "globex").Return(models.Tenant{}, constants.ErrUnknownTenant)
+			},
+			wantCode: codes.NotFound,
+		},
+		{
+			name: "generate",
+			md:   metadata.Pairs(AuthorizationMetadata, basic("svc", "s3cret")),
+			mock: func(tm *mocks.TokenManager, cl *mocks.Clients, tn *mocks.Tenants) {
+				tn.On("Tenant", "").Return(models.Tenant{}, nil)
+				cl.On("Authenticate", mock.Anything, mock.Anything).Return(_svc, nil)
+				tm.On("GetTokens", mock.Anything, "kwfwe").Return("", "", errors.Join(constants.ErrGenerate, constants.ErrGenerateToken))
+			},
+			wantCode: codes.Internal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tm, cl, tn := mocks.NewTokenManager(t), mocks.NewClients(t), mocks.NewTenants(t)
+			tt.mock(tm, cl, tn)
+			auth, err := NewAuthService(tm, cl, tn, lib.Config{})
+			if err != nil {
+				t.Fatalf("NewAuthService() error = %v", err)
+			}
+
+			ctx := metadata.NewOutgoingContext(context.Background(), tt.md)
+			got, err := jwtauthv1.NewAuthServiceClient(dial(t, auth)).GetTokens(ctx, &jwtauthv1.GetTokensRequest{Guid: "kwfwe"})
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("GetTokens() code = %v, want %v (%v)", code, tt.wantCode, err)
+			}
+			if tt.want != nil && (got.GetAccessToken() != tt.want.AccessToken || got.GetRefreshToken() != tt.want.RefreshToken) {
+				t.Errorf("GetTokens() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthService_Introspect(t *testing.T) {
+	tm, cl, tn := mocks.NewTokenManager(t), mocks.NewClients(t), mocks.NewTenants(t)
+	tn.On("Tenant", "").Return(models.Tenant{}, nil)
+	cl.On("Authenticate", mock.Anything, mock.Anything).Return(_svc, nil)
+	tm.On("Introspect", mock.Anything, "token").Return(models.Introspection{
+		Active: true, TokenType: models.TokenTypeRefreshToken, Subject: "kwfwe", ClientID: "svc", ExpiresAt: 4102444800,
+	}, nil)
+	tm.On("Revoke", mock.Anything, "jwt").Return(constants.ErrUnsupportedTokenType)
+
+	auth, err := NewAuthService(tm, cl, tn, lib.Config{})
+	if err != nil {
+		t.Fatalf("NewAuthService() error = %v", err)
+	}
+	conn := dial(t, auth)
+	client := jwtauthv1.NewAuthServiceClient(conn)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), AuthorizationMetadata, basic("svc", "s3cret"))
+
+	got, err := client.Introspect(ctx, &jwtauthv1.IntrospectRequest{Token: "token"})
+	if err != nil {
+		t.Fatalf("Introspect() error = %v", err)
+	}
+	if !got.Active || got.Sub != "kwfwe" || got.ClientId != "svc" || got.Exp != 4102444800 || got.TokenType != models.TokenTypeRefreshToken {
+		t.Errorf("Introspect() = %v", got)
+	}
+
+	if _, err := client.Introspect(ctx, &jwtauthv1.IntrospectRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Introspect() without a token error = %v, want %v", err, codes.InvalidArgument)
+	}
+	if _, err := client.Revoke(ctx, &jwtauthv1.RevokeRequest{Token: "jwt"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Revoke() error = %v, want %v", err, codes.InvalidArgument)
+	}
+
+	health, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{
+		Service: jwtauthv1.AuthService_ServiceDesc.ServiceName,
+	})
+	if err != nil || health.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("Check() = %v, %v, want SERVING", health, err)
+	}
+}
@@ -0,0 +1,210 @@
+package handler
+
+import (
+	"crypto/x509"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains"
+	"go-jwt-auth/internal/models"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// ClientAssertionType is the client_assertion_type of private_key_jwt, see RFC 7523 2.2.
+	ClientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+	// APIKeyHeader carries the API key of a client: its ID and secret separated by a colon.
+	APIKeyHeader = "X-API-Key"
+
+	_clientKey = "client"
+)
+
+// IssuanceMethods returns the client authentication methods of the issuance:
+// the configured ones, which must be known, or all of the confidential clients.
+func IssuanceMethods(configured []string) ([]string, error) {
+	if len(configured) == 0 {
+		return models.IssuanceAuthMethods, nil
+	}
+	for _, m := range configured {
+		if !contains(models.IssuanceAuthMethods, m) {
+			return nil, fmt.Errorf("unknown issuance auth method %q", m)
+		}
+	}
+	return configured, nil
+}
+
+// clientAuthRequest are the client credentials sent in the request body.
+type clientAuthRequest struct {
+	ClientID            string `json:"client_id" form:"client_id"`
+	ClientSecret        string `json:"client_secret" form:"client_secret"`
+	ClientAssertionType string `json:"client_assertion_type" form:"client_assertion_type"`
+	ClientAssertion     string `json:"client_assertion" form:"client_assertion"`
+}
+
+// ClientAuth authenticates the client of the request with one of the methods,
+// any if none, and checks that it may use the grant type. It goes after
+// Tenant: the client must belong to the tenant of the request.
+func ClientAuth(clients domains.Clients, grant models.GrantType, methods ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		client, err := authenticateClient(c, clients, methods...)
+		if err != nil {
+			HTTPError(c, err)
+			return
+		}
+
+		if !client.Allows(grant) {
+			HTTPError(c, constants.ErrUnauthorizedClient)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// authenticateClient authenticates the client of the request with one of the
+// methods, any if none, and stores it for requestContext.
+func authenticateClient(c *gin.Context, clients domains.Clients, methods ...string) (models.Client, error) {
+	creds, err := clientCredentials(c)
+	if err != nil {
+		return models.Client{}, err
+	}
+	if len(methods) > 0 && !contains(methods, creds.Method) {
+		return models.Client{}, constants.ErrInvalidClient
+	}
+
+	client, err := clients.Authenticate(requestContext(c), creds)
+	if err != nil {
+		return models.Client{}, err
+	}
+
+	c.Set(_clientKey, client)
+	return client, nil
+}
+
+// clientCredentials extracts the credentials of the only authentication method used, see RFC 6749 2.3.
+func clientCredentials(c *gin.Context) (creds models.ClientCredentials, err error) {
+	var found []models.ClientCredentials
+
+	if id, secret, ok := c.Request.BasicAuth(); ok {
+		// RFC 6749 2.3.1: the credentials are form-encoded before being put in the header.
+		if id, err = url.QueryUnescape(id); err != nil {
+			return creds, constants.ErrInvalidClient
+		}
+		if secret, err = url.QueryUnescape(secret); err != nil {
+			return creds, constants.ErrInvalidClient
+		}
+		found = append(found, models.ClientCredentials{Method: models.ClientAuthSecretBasic, ID: id, Secret: secret})
+	}
+
+	if key := c.GetHeader(APIKeyHeader); key != "" {
+		// the client IDs have no colon, the secrets may.
+		id, secret, ok := strings.Cut(key, ":")
+		if !ok {
+			return creds, constants.ErrInvalidClient
+		}
+		found = append(found, models.ClientCredentials{Method: models.ClientAuthAPIKey, ID: id, Secret: secret})
+	}
+
+	var req clientAuthRequest
+	if err := bindBody(c, &req); err != nil {
+		return creds, constants.ErrInvalidClient
+	}
+	if req.ClientSecret != "" {
+		found = append(found, models.ClientCredentials{
+			Method: models.ClientAuthSecretPost,
+			ID:     req.ClientID,
+			Secret: req.ClientSecret,
+		})
+	}
+	if req.ClientAssertionType != "" || req.ClientAssertion != "" {
+		if req.ClientAssertionType != ClientAssertionType {
+			return creds, constants.ErrInvalidClient
+		}
+		found = append(found, models.ClientCredentials{
+			Method:    models.ClientAuthPrivateKeyJWT,
+			ID:        req.ClientID,
+			Assertion: req.ClientAssertion,
+			Audience:  []string{endpointURL(c)},
+		})
+	}
+
+	switch len(found) {
+	case 0:
+		if cert, ok := verifiedCertificate(c); ok {
+			// the certificate is the credential, the client is named by it
+			// unless the request names it, see RFC 8705 2.
+			id := req.ClientID
+			if id == "" {
+				id = cert.Subject.CommonName
+			}
+			return models.ClientCredentials{Method: models.ClientAuthTLS, ID: id, TLSSubject: cert.Subject.String()}, nil
+		}
+		if req.ClientID != "" {
+			// a public client, see RFC 6749 4.1.3.
+			return models.ClientCredentials{Method: models.ClientAuthNone, ID: req.ClientID}, nil
+		}
+		return creds, constants.ErrInvalidClient
+	case 1:
+		return found[0], nil
+	default:
+		return creds, constants.ErrMultipleClientAuth
+	}
+}
+
+// verifiedCertificate returns the client certificate of the connection if
+// the TLS handshake has verified it against the client CAs.
+func verifiedCertificate(c *gin.Context) (*x509.Certificate, bool) {
+	if c.Request.TLS == nil || len(c.Request.TLS.VerifiedChains) == 0 || len(c.Request.TLS.VerifiedChains[0]) == 0 {
+		return nil, false
+	}
+	return c.Request.TLS.VerifiedChains[0][0], true
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// bindBody binds the JSON or form body, if any. The body stays available to the handlers.
+func bindBody(c *gin.Context, obj any) error {
+	switch c.ContentType() {
+	case binding.MIMEJSON:
+		return c.ShouldBindBodyWith(obj, binding.JSON)
+	case binding.MIMEPOSTForm:
+		return c.ShouldBindWith(obj, binding.FormPost)
+	default:
+		return nil
+	}
+}
+
+// endpointURL is the URL the request is sent to under the public URL set by
+// Tenant, without the query. The Host header is the client's own, an audience
+// built from it would accept the assertions made for another server.
+func endpointURL(c *gin.Context) string {
+	return c.GetString(_publicURLKey) + c.Request.URL.Path
+}
+
+// requestClient returns the client authenticated by ClientAuth, if any.
+func requestClient(c *gin.Context) (models.Client, bool) {
+	v, ok := c.Get(_clientKey)
+	if !ok {
+		return models.Client{}, false
+	}
+	client, ok := v.(models.Client)
+	return client, ok
+}
+
+// unauthorizedClient asks the client to authenticate, see RFC 6749 5.2.
+func unauthorizedClient(c *gin.Context, err error) {
+	c.Header("WWW-Authenticate", `Basic realm="jwt-auth"`)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+		"error": err.Error(),
+	})
+}
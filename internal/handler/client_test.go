@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains/mocks"
+	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestClientAuth(t *testing.T) {
+	backend := models.Client{ID: "backend", GrantTypes: []models.GrantType{models.GrantTypeIssue}}
+
+	tests := []struct {
+		name        string
+		basic       [2]string
+		apiKey      string
+		cert        *x509.Certificate
+		methods     []string
+		contentType string
+		body        string
+		wantCreds   *models.ClientCredentials
+		client      models.Client
+		authErr     error
+		wantStatus  int
+		wantClient  string
+	}{
+		{
+			name:       "basic",
+			basic:      [2]string{"backend", "qjfwjnqk"},
+			wantCreds:  &models.ClientCredentials{Method: models.ClientAuthSecretBasic, ID: "backend", Secret: "qjfwjnqk"},
+			client:     backend,
+			wantStatus: http.StatusOK,
+			wantClient: "backend",
+		},
+		{
+			name:       "basicFormEncoded",
+			basic:      [2]string{"backend", url.QueryEscape("qj:fw jn")},
+			wantCreds:  &models.ClientCredentials{Method: models.ClientAuthSecretBasic, ID: "backend", Secret: "qj:fw jn"},
+			client:     backend,
+			wantStatus: http.StatusOK,
+			wantClient: "backend",
+		},
+		{
+			name:       "apiKey",
+			apiKey:     "backend:qj:fw",
+			wantCreds:  &models.ClientCredentials{Method: models.ClientAuthAPIKey, ID: "backend", Secret: "qj:fw"},
+			client:     backend,
+			wantStatus: http.StatusOK,
+			wantClient: "backend",
+		},
+		{
+			name:       "apiKeyWithoutID",
+			apiKey:     "qjfwjnqk",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "tlsClientAuth",
+			cert:       &x509.Certificate{Subject: pkix.Name{CommonName: "backend", Organization: []string{"Acme"}}},
+			wantCreds:  &models.ClientCredentials{Method: models.ClientAuthTLS, ID: "backend", TLSSubject: "CN=backend,O=Acme"},
+			client:     backend,
+			wantStatus: http.StatusOK,
+			wantClient: "backend",
+		},
+		{
+			// the certificate of the connection isn't a credential then.
+			name:       "basicOverTLS",
+			basic:      [2]string{"backend", "qjfwjnqk"},
+			cert:       &x509.Certificate{Subject: pkix.Name{CommonName: "proxy"}},
+			wantCreds:  &models.ClientCredentials{Method: models.ClientAuthSecretBasic, ID: "backend", Secret: "qjfwjnqk"},
+			client:     backend,
+			wantStatus: http.StatusOK,
+			wantClient: "backend",
+		},
+		{
+			name:       "methodNotAccepted",
+			basic:      [2]string{"backend", "qjfwjnqk"},
+			methods:    []string{models.ClientAuthAPIKey, models.ClientAuthTLS},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:        "postForm",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "client_id=backend&client_secret=qjfwjnqk",
+			wantCreds:   &models.ClientCredentials{Method: models.ClientAuthSecretPost, ID: "backend", Secret: "qjfwjnqk"},
+			client:      backend,
+			wantStatus:  http.StatusOK,
+			wantClient:  "backend",
+		},
+		{
+			name:        "postJSON",
+			contentType: "application/json",
+			body:        `{"client_id":"backend","client_secret":"qjfwjnqk"}`,
+			wantCreds:   &models.ClientCredentials{Method: models.ClientAuthSecretPost, ID: "backend", Secret: "qjfwjnqk"},
+			client:      backend,
+			wantStatus:  http.StatusOK,
+			wantClient:  "backend",
+		},
+		{
+			name:        "privateKeyJWT",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "client_assertion_type=" + url.QueryEscape(ClientAssertionType) + "&client_assertion=ebfkqbfb",
+			wantCreds: &models.ClientCredentials{
+				Method:    models.ClientAuthPrivateKeyJWT,
+				Assertion: "ebfkqbfb",
+				Audience:  []string{"https://auth.example.com/v1/tokens"},
+			},
+			client:     backend,
+			wantStatus: http.StatusOK,
+			wantClient: "backend",
+		},
+		{
+			name:        "public",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "client_id=backend&code=ebfkqbfb",
+			wantCreds:   &models.ClientCredentials{Method: models.ClientAuthNone, ID: "backend"},
+			client:      backend,
+			wantStatus:  http.StatusOK,
+			wantClient:  "backend",
+		},
+		{
+			name:        "unknownAssertionType",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "client_assertion_type=saml&client_assertion=ebfkqbfb",
+			wantStatus:  http.StatusUnauthorized,
+		},
+		{
+			name:        "multipleMethods",
+			basic:       [2]string{"backend", "qjfwjnqk"},
+			contentType: "application/x-www-form-urlencoded",
+			body:        "client_id=backend&client_secret=qjfwjnqk",
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:       "noCredentials",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "invalidClient",
+			basic:      [2]string{"backend", "ebfkqbfb"},
+			wantCreds:  &models.ClientCredentials{Method: models.ClientAuthSecretBasic, ID: "backend", Secret: "ebfkqbfb"},
+			authErr:    constants.ErrInvalidClient,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "grantTypeNotAllowed",
+			basic:      [2]string{"backend", "qjfwjnqk"},
+			wantCreds:  &models.ClientCredentials{Method: models.ClientAuthSecretBasic, ID: "backend", Secret: "qjfwjnqk"},
+			client:     models.Client{ID: "backend", GrantTypes: []models.GrantType{models.GrantTypeRefreshToken}},
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clients := mocks.NewClients(t)
+			if tt.wantCreds != nil {
+				clients.EXPECT().Authenticate(mock.Anything, *tt.wantCreds).Return(tt.client, tt.authErr)
+			}
+
+			tenants := mocks.NewTenants(t)
+			tenants.On("Tenant", "").Return(models.Tenant{}, nil)
+
+			var gotClient string
+			r := gin.New()
+			r.Use(Tenant(tenants, "https://auth.example.com"))
+			r.POST("/v1/tokens", ClientAuth(clients, models.GrantTypeIssue, tt.methods...), func(c *gin.Context) {
+				client, _ := lib.Client(requestContext(c))
+				gotClient = client.ID
+			})
+
+			// the audience of the assertions doesn't follow the Host of the request.
+			req := httptest.NewRequest(http.MethodPost, "/v1/tokens", strings.NewReader(tt.body))
+			req.Host = "evil.example.com"
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			if tt.basic[0] != "" {
+				req.SetBasicAuth(tt.basic[0], tt.basic[1])
+			}
+			if tt.apiKey != "" {
+				req.Header.Set(APIKeyHeader, tt.apiKey)
+			}
+			if tt.cert != nil {
+				req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{tt.cert}}}
+			}
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %v, want %v, body %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Errorf("WWW-Authenticate header is missing")
+			}
+			if gotClient != tt.wantClient {
+				t.Errorf("client = %v, want %v", gotClient, tt.wantClient)
+			}
+		})
+	}
+}
@@ -13,14 +13,21 @@ func HTTPError(c *gin.Context, err error) {
 		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 			"error": err.Error(),
 		})
-	case constants.ErrTokenExpired:
+	case constants.ErrInvalidClient:
+		unauthorizedClient(c, err)
+	case constants.ErrTokenExpired, constants.ErrNewIP, constants.ErrUnauthorizedClient, constants.ErrGUIDNotAllowed:
 		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
 			"error": err.Error(),
 		})
-	case constants.ErrInvalidToken, constants.ErrInvalidGUID, constants.ErrNotFound:
+	case constants.ErrInvalidToken, constants.ErrInvalidGUID, constants.ErrNotFound,
+		constants.ErrMultipleClientAuth:
 		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
 			"error": err.Error(),
 		})
+	case constants.ErrUnknownTenant:
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
 	default:
 		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
@@ -4,4 +4,5 @@ import "go.uber.org/fx"
 
 var Module = fx.Options(
 	fx.Provide(NewTokenHandler),
+	fx.Provide(NewOAuthHandler),
 )
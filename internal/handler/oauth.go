@@ -0,0 +1,350 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains"
+	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/models"
+	"go.uber.org/zap"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// OAuth error codes, see RFC 6749 5.2.
+const (
+	OAuthInvalidRequest       = "invalid_request"
+	OAuthInvalidClient        = "invalid_client"
+	OAuthInvalidGrant         = "invalid_grant"
+	OAuthUnauthorizedClient   = "unauthorized_client"
+	OAuthUnsupportedGrantType = "unsupported_grant_type"
+	OAuthInvalidScope         = "invalid_scope"
+	OAuthServerError          = "server_error"
+	// the errors of the authorization endpoint, see RFC 6749 4.1.2.1.
+	OAuthAccessDenied            = "access_denied"
+	OAuthUnsupportedResponseType = "unsupported_response_type"
+	// the error of the revocation endpoint, see RFC 7009 2.2.1.
+	OAuthUnsupportedTokenType = "unsupported_token_type"
+)
+
+// ResponseTypeCode is the only response_type of the authorization endpoint.
+const ResponseTypeCode = "code"
+
+// OAuthHandler serves the OAuth 2.0 endpoints, see RFC 6749.
+type OAuthHandler struct {
+	tokens  domains.TokenManager
+	clients domains.Clients
+	users   domains.UserAuthenticator
+	tenants domains.Tenants
+	logger  lib.Logger
+
+	// loginURL is where the unauthenticated users are sent, empty to deny their requests.
+	loginURL string
+}
+
+func NewOAuthHandler(
+	logger lib.Logger,
+	tokens domains.TokenManager,
+	clients domains.Clients,
+	users domains.UserAuthenticator,
+	tenants domains.Tenants,
+	conf lib.Config,
+) OAuthHandler {
+	return OAuthHandler{
+		tokens:   tokens,
+		clients:  clients,
+		users:    users,
+		tenants:  tenants,
+		logger:   logger,
+		loginURL: conf.OAuth.LoginURL,
+	}
+}
+
+// Authorize is the authorization endpoint of the code flow with PKCE, see
+// RFC 6749 4.1 and RFC 7636. It goes after Tenant. The errors about the client
+// or the redirect URI are shown to the user, the others are sent to the
+// client through the redirect URI.
+func (h *OAuthHandler) Authorize(c *gin.Context) {
+	var req AuthorizeRequest
+	if err := c.ShouldBind(&req); err != nil {
+		OAuthError(c, OAuthInvalidRequest, err.Error())
+		return
+	}
+
+	client, redirectURI, err := h.clients.Redirect(requestContext(c), req.ClientID, req.RedirectURI)
+	if err != nil {
+		if err == constants.ErrInvalidClient {
+			// the user agent can't authenticate the client, no challenge.
+			OAuthError(c, OAuthInvalidRequest, err.Error())
+			return
+		}
+		OAuthHTTPError(c, err)
+		return
+	}
+	c.Set(_clientKey, client)
+
+	if req.ResponseType != ResponseTypeCode {
+		redirectError(c, redirectURI, req.State, constants.ErrUnsupportedResponseType)
+		return
+	}
+
+	guid, err := h.users.AuthenticateUser(c.Request)
+	if err == constants.ErrLoginRequired && h.loginURL != "" {
+		h.login(c)
+		return
+	}
+	if err != nil {
+		h.logger.Debug("user not authenticated", zap.Error(err))
+		redirectError(c, redirectURI, req.State, err)
+		return
+	}
+
+	code, err := h.tokens.Authorize(requestContext(c), models.AuthorizationRequest{
+		GUID:                guid,
+		RedirectURI:         req.RedirectURI,
+		Scope:               req.Scope,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+		Nonce:               req.Nonce,
+	})
+	if err != nil {
+		redirectError(c, redirectURI, req.State, err)
+		return
+	}
+
+	redirect(c, redirectURI, req.State, url.Values{"code": {code}})
+}
+
+// login sends the user to the login page, which sends the user back to the
+// authorization request in the return_to parameter once authenticated.
+func (h *OAuthHandler) login(c *gin.Context) {
+	u, err := url.Parse(h.loginURL)
+	if err != nil {
+		OAuthHTTPError(c, err)
+		return
+	}
+
+	// the form of a POST request is sent back as a GET one.
+	q := u.Query()
+	q.Set("return_to", endpointURL(c)+"?"+c.Request.Form.Encode())
+	u.RawQuery = q.Encode()
+
+	c.Redirect(http.StatusFound, u.String())
+}
+
+// redirect sends the user agent back to the client with the parameters and the state.
+func redirect(c *gin.Context, redirectURI, state string, params url.Values) {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		OAuthHTTPError(c, err)
+		return
+	}
+
+	q := u.Query()
+	for k, v := range params {
+		q[k] = v
+	}
+	if state != "" {
+		q.Set("state", state)
+	}
+	u.RawQuery = q.Encode()
+
+	noStore(c)
+	c.Redirect(http.StatusFound, u.String())
+}
+
+// redirectError sends the error back to the client, see RFC 6749 4.1.2.1.
+func redirectError(c *gin.Context, redirectURI, state string, err error) {
+	_, code := oauthErrorCode(err)
+	redirect(c, redirectURI, state, url.Values{
+		"error":             {code},
+		"error_description": {err.Error()},
+	})
+}
+
+// Token is the token endpoint, see RFC 6749 3.2. It goes after Tenant.
+func (h *OAuthHandler) Token(c *gin.Context) {
+	if c.ContentType() != binding.MIMEPOSTForm {
+		OAuthError(c, OAuthInvalidRequest, "the request must be application/x-www-form-urlencoded")
+		return
+	}
+
+	var req TokenRequest
+	if err := c.ShouldBindWith(&req, binding.FormPost); err != nil {
+		OAuthError(c, OAuthInvalidRequest, err.Error())
+		return
+	}
+	if req.GrantType == "" {
+		OAuthError(c, OAuthInvalidRequest, "grant_type was not provided")
+		return
+	}
+
+	if _, err := authenticateClient(c, h.clients); err != nil {
+		OAuthHTTPError(c, err)
+		return
+	}
+
+	tokens, err := h.tokens.Grant(requestContext(c), models.GrantRequest{
+		Type:         models.GrantType(req.GrantType),
+		RefreshToken: req.RefreshToken,
+		Scope:        req.Scope,
+		Code:         req.Code,
+		RedirectURI:  req.RedirectURI,
+		CodeVerifier: req.CodeVerifier,
+	})
+	if err != nil {
+		OAuthHTTPError(c, err)
+		return
+	}
+
+	expiresIn := tokens.ExpiresAt - time.Now().Unix()
+	if expiresIn < 0 {
+		expiresIn = 0
+	}
+
+	noStore(c)
+	c.JSON(http.StatusOK, TokenResponse{
+		AccessToken:  tokens.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    expiresIn,
+		RefreshToken: tokens.RefreshToken,
+		Scope:        tokens.Scope,
+		IDToken:      tokens.IDToken,
+	})
+}
+
+// Revoke is the revocation endpoint, see RFC 7009. It goes after Tenant.
+// The unknown tokens are answered like the revoked ones.
+func (h *OAuthHandler) Revoke(c *gin.Context) {
+	var req RevokeRequest
+	if !bindTokenForm(c, &req) {
+		return
+	}
+	if req.Token == "" {
+		OAuthError(c, OAuthInvalidRequest, "token was not provided")
+		return
+	}
+
+	if _, err := authenticateClient(c, h.clients); err != nil {
+		OAuthHTTPError(c, err)
+		return
+	}
+
+	if err := h.tokens.Revoke(requestContext(c), req.Token); err != nil {
+		OAuthHTTPError(c, err)
+		return
+	}
+
+	noStore(c)
+	c.Status(http.StatusOK)
+}
+
+// Introspect is the introspection endpoint, see RFC 7662. It goes after Tenant.
+func (h *OAuthHandler) Introspect(c *gin.Context) {
+	var req IntrospectRequest
+	if !bindTokenForm(c, &req) {
+		return
+	}
+	if req.Token == "" {
+		OAuthError(c, OAuthInvalidRequest, "token was not provided")
+		return
+	}
+
+	if _, err := authenticateClient(c, h.clients); err != nil {
+		OAuthHTTPError(c, err)
+		return
+	}
+
+	i, err := h.tokens.Introspect(requestContext(c), req.Token)
+	if err != nil {
+		OAuthHTTPError(c, err)
+		return
+	}
+
+	noStore(c)
+	c.JSON(http.StatusOK, IntrospectionResponse{
+		Active:    i.Active,
+		TokenType: i.TokenType,
+		Sub:       i.Subject,
+		ClientID:  i.ClientID,
+		Scope:     i.Scope,
+		Exp:       i.ExpiresAt,
+		Iss:       i.Issuer,
+		Aud:       i.Audience,
+		Tid:       i.Tenant,
+	})
+}
+
+// bindTokenForm binds the form of the revocation and introspection requests,
+// it aborts the request if the form is not url-encoded.
+func bindTokenForm(c *gin.Context, req any) bool {
+	if c.ContentType() != binding.MIMEPOSTForm {
+		OAuthError(c, OAuthInvalidRequest, "the request must be application/x-www-form-urlencoded")
+		return false
+	}
+	if err := c.ShouldBindWith(req, binding.FormPost); err != nil {
+		OAuthError(c, OAuthInvalidRequest, err.Error())
+		return false
+	}
+	return true
+}
+
+// OAuthHTTPError converts an error to an OAuth error response, see RFC 6749 5.2.
+func OAuthHTTPError(c *gin.Context, err error) {
+	status, code := oauthErrorCode(err)
+	if status == http.StatusUnauthorized {
+		c.Header("WWW-Authenticate", `Basic realm="jwt-auth"`)
+	}
+	oauthError(c, status, code, err.Error())
+}
+
+// oauthErrorCode returns the status and the OAuth error code of an error.
+func oauthErrorCode(err error) (int, string) {
+	switch err { // no errors.Is() because we get an explicit error from the service every time.
+	case constants.ErrInvalidClient:
+		return http.StatusUnauthorized, OAuthInvalidClient
+	case constants.ErrMultipleClientAuth, constants.ErrMissingRefreshToken, constants.ErrMissingCode,
+		constants.ErrInvalidCodeChallenge, constants.ErrInvalidRedirectURI:
+		return http.StatusBadRequest, OAuthInvalidRequest
+	case constants.ErrUnauthorizedClient:
+		return http.StatusBadRequest, OAuthUnauthorizedClient
+	case constants.ErrUnsupportedGrant:
+		return http.StatusBadRequest, OAuthUnsupportedGrantType
+	case constants.ErrUnsupportedResponseType:
+		return http.StatusBadRequest, OAuthUnsupportedResponseType
+	case constants.ErrUnsupportedTokenType:
+		return http.StatusBadRequest, OAuthUnsupportedTokenType
+	case constants.ErrInvalidScope:
+		return http.StatusBadRequest, OAuthInvalidScope
+	case constants.ErrInvalidToken, constants.ErrTokenExpired, constants.ErrNotFound, constants.ErrNewIP,
+		constants.ErrInvalidCode:
+		return http.StatusBadRequest, OAuthInvalidGrant
+	case constants.ErrLoginRequired, constants.ErrUserAuthDisabled:
+		return http.StatusForbidden, OAuthAccessDenied
+	case constants.ErrUnknownTenant:
+		return http.StatusNotFound, OAuthInvalidRequest
+	default:
+		return http.StatusInternalServerError, OAuthServerError
+	}
+}
+
+// OAuthError aborts the request with a 400 OAuth error.
+func OAuthError(c *gin.Context, code, description string) {
+	oauthError(c, http.StatusBadRequest, code, description)
+}
+
+func oauthError(c *gin.Context, status int, code, description string) {
+	noStore(c)
+	c.AbortWithStatusJSON(status, OAuthErrorResponse{
+		Error:            code,
+		ErrorDescription: description,
+	})
+}
+
+// noStore forbids caching the response, see RFC 6749 5.1.
+func noStore(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+}
@@ -0,0 +1,535 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains/mocks"
+	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/models"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOAuthHandler_Token(t *testing.T) {
+	backend := models.Client{ID: "backend"}
+	creds := models.ClientCredentials{Method: models.ClientAuthSecretBasic, ID: "backend", Secret: "qjfwjnqk"}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		authErr     error
+		wantGrant   *models.GrantRequest
+		tokens      models.Tokens
+		grantErr    error
+		wantStatus  int
+		wantError   string
+	}{
+		{
+			name:        "refreshToken",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "grant_type=refresh_token&refresh_token=a2dmd2U.ebfkqbfb&scope=read",
+			wantGrant:   &models.GrantRequest{Type: models.GrantTypeRefreshToken, RefreshToken: "a2dmd2U.ebfkqbfb", Scope: "read"},
+			tokens: models.Tokens{
+				AccessToken:  "eyJhbGciOiJIUzUxMiJ9",
+				RefreshToken: "a2dmd2U.qfeqjfkj",
+				ExpiresAt:    time.Now().Add(time.Hour).Unix(),
+				Scope:        "read",
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:        "authorizationCode",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "grant_type=authorization_code&code=qfeqjfkj&code_verifier=ebfkqbfb",
+			wantGrant:   &models.GrantRequest{Type: models.GrantTypeAuthorizationCode, Code: "qfeqjfkj", CodeVerifier: "ebfkqbfb"},
+			tokens: models.Tokens{
+				AccessToken:  "eyJhbGciOiJIUzUxMiJ9",
+				RefreshToken: "a2dmd2U.qfeqjfkj",
+				ExpiresAt:    time.Now().Add(time.Hour).Unix(),
+				Scope:        "openid",
+				IDToken:      "eyJhbGciOiJIUzUxMiJ9.e30",
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:        "clientCredentials",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "grant_type=client_credentials",
+			wantGrant:   &models.GrantRequest{Type: models.GrantTypeClientCredentials},
+			tokens:      models.Tokens{AccessToken: "eyJhbGciOiJIUzUxMiJ9", ExpiresAt: time.Now().Add(time.Hour).Unix()},
+			wantStatus:  http.StatusOK,
+		},
+		{
+			name:        "json",
+			contentType: "application/json",
+			body:        `{"grant_type":"client_credentials"}`,
+			wantStatus:  http.StatusBadRequest,
+			wantError:   OAuthInvalidRequest,
+		},
+		{
+			name:        "noGrantType",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "scope=read",
+			wantStatus:  http.StatusBadRequest,
+			wantError:   OAuthInvalidRequest,
+		},
+		{
+			name:        "invalidClient",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "grant_type=client_credentials",
+			authErr:     constants.ErrInvalidClient,
+			wantStatus:  http.StatusUnauthorized,
+			wantError:   OAuthInvalidClient,
+		},
+		{
+			name:        "unsupportedGrantType",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "grant_type=password",
+			wantGrant:   &models.GrantRequest{Type: "password"},
+			grantErr:    constants.ErrUnsupportedGrant,
+			wantStatus:  http.StatusBadRequest,
+			wantError:   OAuthUnsupportedGrantType,
+		},
+		{
+			name:        "unauthorizedClient",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "grant_type=client_credentials",
+			wantGrant:   &models.GrantRequest{Type: models.GrantTypeClientCredentials},
+			grantErr:    constants.ErrUnauthorizedClient,
+			wantStatus:  http.StatusBadRequest,
+			wantError:   OAuthUnauthorizedClient,
+		},
+		{
+			name:        "invalidScope",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "grant_type=client_credentials&scope=admin",
+			wantGrant:   &models.GrantRequest{Type: models.GrantTypeClientCredentials, Scope: "admin"},
+			grantErr:    constants.ErrInvalidScope,
+			wantStatus:  http.StatusBadRequest,
+			wantError:   OAuthInvalidScope,
+		},
+		{
+			name:        "invalidGrant",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "grant_type=refresh_token&refresh_token=a2dmd2U.ebfkqbfb",
+			wantGrant:   &models.GrantRequest{Type: models.GrantTypeRefreshToken, RefreshToken: "a2dmd2U.ebfkqbfb"},
+			grantErr:    constants.ErrTokenExpired,
+			wantStatus:  http.StatusBadRequest,
+			wantError:   OAuthInvalidGrant,
+		},
+		{
+			name:        "serverError",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "grant_type=client_credentials",
+			wantGrant:   &models.GrantRequest{Type: models.GrantTypeClientCredentials},
+			grantErr:    constants.ErrRepository,
+			wantStatus:  http.StatusInternalServerError,
+			wantError:   OAuthServerError,
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clients := mocks.NewClients(t)
+			tokens := mocks.NewTokenManager(t)
+			if tt.wantGrant != nil || tt.authErr != nil {
+				client := backend
+				if tt.authErr != nil {
+					client = models.Client{}
+				}
+				clients.EXPECT().Authenticate(mock.Anything, creds).Return(client, tt.authErr)
+			}
+			if tt.wantGrant != nil {
+				tokens.EXPECT().Grant(mock.Anything, *tt.wantGrant).Return(tt.tokens, tt.grantErr)
+			}
+
+			h := NewOAuthHandler(lib.Logger{Logger: zap.NewNop()}, tokens, clients, nil, nil, lib.Config{})
+			r := gin.New()
+			r.POST("/oauth/token", h.Token)
+
+			req := httptest.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			req.SetBasicAuth(creds.ID, creds.Secret)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %v, want %v, body %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if w.Header().Get("Cache-Control") != "no-store" {
+				t.Errorf("Cache-Control = %q, want no-store", w.Header().Get("Cache-Control"))
+			}
+
+			if tt.wantError != "" {
+				var got OAuthErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+					t.Fatalf("Unmarshal() error = %v", err)
+				}
+				if got.Error != tt.wantError {
+					t.Errorf("error = %v, want %v", got.Error, tt.wantError)
+				}
+				return
+			}
+
+			var got TokenResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if got.AccessToken != tt.tokens.AccessToken || got.RefreshToken != tt.tokens.RefreshToken ||
+				got.Scope != tt.tokens.Scope || got.IDToken != tt.tokens.IDToken || got.TokenType != "Bearer" {
+				t.Errorf("response = %+v, want %+v", got, tt.tokens)
+			}
+			if got.ExpiresIn < 3590 || got.ExpiresIn > 3600 {
+				t.Errorf("expires_in = %v, want about an hour", got.ExpiresIn)
+			}
+		})
+	}
+}
+
+func TestOAuthHandler_Authorize(t *testing.T) {
+	const (
+		callback  = "https://app.example.com/callback"
+		challenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+	)
+	app := models.Client{ID: "app", RedirectURIs: []string{callback}}
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {"app"},
+		"state":                 {"xyz"},
+		"code_challenge":        {challenge},
+		"code_challenge_method": {"S256"},
+	}
+	with := func(k, v string) url.Values {
+		q := url.Values{}
+		for key, values := range query {
+			q[key] = values
+		}
+		q.Set(k, v)
+		return q
+	}
+
+	tests := []struct {
+		name         string
+		query        url.Values
+		redirectErr  error
+		authenticate bool
+		userErr      error
+		loginURL     string
+		authorize    bool
+		authorizeErr error
+		wantStatus   int
+		wantLocation string
+		wantError    string
+	}{
+		{
+			name:         "ok",
+			query:        query,
+			authenticate: true,
+			authorize:    true,
+			wantStatus:   http.StatusFound,
+			wantLocation: callback + "?code=qfeqjfkj&state=xyz",
+		},
+		{
+			name:        "unknownClient",
+			query:       query,
+			redirectErr: constants.ErrInvalidClient,
+			wantStatus:  http.StatusBadRequest,
+			wantError:   OAuthInvalidRequest,
+		},
+		{
+			name:        "unregisteredRedirectURI",
+			query:       with("redirect_uri", "https://evil.example.com"),
+			redirectErr: constants.ErrInvalidRedirectURI,
+			wantStatus:  http.StatusBadRequest,
+			wantError:   OAuthInvalidRequest,
+		},
+		{
+			name:         "unsupportedResponseType",
+			query:        with("response_type", "token"),
+			wantStatus:   http.StatusFound,
+			wantLocation: callback + "?error=unsupported_response_type&error_description=unsupported+response+type&state=xyz",
+		},
+		{
+			name:         "login",
+			query:        query,
+			authenticate: true,
+			userErr:      constants.ErrLoginRequired,
+			loginURL:     "https://login.example.com/?app=jwt",
+			wantStatus:   http.StatusFound,
+			wantLocation: "https://login.example.com/?app=jwt&return_to=" +
+				url.QueryEscape("https://auth.example.com/oauth/authorize?"+query.Encode()),
+		},
+		{
+			name:         "accessDenied",
+			query:        query,
+			authenticate: true,
+			userErr:      constants.ErrLoginRequired,
+			wantStatus:   http.StatusFound,
+			wantLocation: callback + "?error=access_denied&error_description=user+is+not+authenticated&state=xyz",
+		},
+		{
+			name:         "noCodeChallenge",
+			query:        with("code_challenge_method", "plain"),
+			authenticate: true,
+			authorize:    true,
+			authorizeErr: constants.ErrInvalidCodeChallenge,
+			wantStatus:   http.StatusFound,
+			wantLocation: callback + "?error=invalid_request&error_description=" +
+				url.QueryEscape(constants.ErrInvalidCodeChallenge.Error()) + "&state=xyz",
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clients := mocks.NewClients(t)
+			users := mocks.NewUserAuthenticator(t)
+			tokens := mocks.NewTokenManager(t)
+
+			clients.EXPECT().Redirect(mock.Anything, "app", tt.query.Get("redirect_uri")).Return(app, callback, tt.redirectErr)
+			if tt.authenticate {
+				users.EXPECT().AuthenticateUser(mock.Anything).Return("kwfwe", tt.userErr)
+			}
+			if tt.authorize {
+				tokens.EXPECT().Authorize(mock.Anything, models.AuthorizationRequest{
+					GUID:                "kwfwe",
+					CodeChallenge:       challenge,
+					CodeChallengeMethod: tt.query.Get("code_challenge_method"),
+				}).Return("qfeqjfkj", tt.authorizeErr)
+			}
+
+			conf := lib.Config{}
+			conf.OAuth.LoginURL = tt.loginURL
+			tenants := mocks.NewTenants(t)
+			tenants.On("Tenant", "").Return(models.Tenant{}, nil)
+
+			h := NewOAuthHandler(lib.Logger{Logger: zap.NewNop()}, tokens, clients, users, tenants, conf)
+			r := gin.New()
+			r.GET("/oauth/authorize", Tenant(tenants, "https://auth.example.com"), h.Authorize)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/oauth/authorize?"+tt.query.Encode(), nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %v, want %v, body %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if got := w.Header().Get("Location"); got != tt.wantLocation {
+				t.Errorf("Location = %v, want %v", got, tt.wantLocation)
+			}
+			if tt.wantError != "" {
+				var got OAuthErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+					t.Fatalf("Unmarshal() error = %v", err)
+				}
+				if got.Error != tt.wantError {
+					t.Errorf("error = %v, want %v", got.Error, tt.wantError)
+				}
+			}
+		})
+	}
+}
+
+func TestOAuthHandler_Revoke(t *testing.T) {
+	backend := models.Client{ID: "backend"}
+	creds := models.ClientCredentials{Method: models.ClientAuthSecretBasic, ID: "backend", Secret: "qjfwjnqk"}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantToken   string
+		revokeErr   error
+		wantStatus  int
+		wantError   string
+	}{
+		{
+			name:        "ok",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "token=a2dmd2U.ebfkqbfb&token_type_hint=refresh_token",
+			wantToken:   "a2dmd2U.ebfkqbfb",
+			wantStatus:  http.StatusOK,
+		},
+		{
+			name:        "accessToken",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "token=eyJhbGciOiJIUzUxMiJ9.e30.c2ln",
+			wantToken:   "eyJhbGciOiJIUzUxMiJ9.e30.c2ln",
+			revokeErr:   constants.ErrUnsupportedTokenType,
+			wantStatus:  http.StatusBadRequest,
+			wantError:   OAuthUnsupportedTokenType,
+		},
+		{
+			name:        "noToken",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "token_type_hint=refresh_token",
+			wantStatus:  http.StatusBadRequest,
+			wantError:   OAuthInvalidRequest,
+		},
+		{
+			name:        "json",
+			contentType: "application/json",
+			body:        `{"token":"a2dmd2U.ebfkqbfb"}`,
+			wantStatus:  http.StatusBadRequest,
+			wantError:   OAuthInvalidRequest,
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clients := mocks.NewClients(t)
+			tokens := mocks.NewTokenManager(t)
+			if tt.wantToken != "" {
+				clients.EXPECT().Authenticate(mock.Anything, creds).Return(backend, nil)
+				tokens.EXPECT().Revoke(mock.Anything, tt.wantToken).Return(tt.revokeErr)
+			}
+
+			h := NewOAuthHandler(lib.Logger{Logger: zap.NewNop()}, tokens, clients, nil, nil, lib.Config{})
+			r := gin.New()
+			r.POST("/oauth/revoke", h.Revoke)
+
+			req := httptest.NewRequest(http.MethodPost, "/oauth/revoke", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			req.SetBasicAuth(creds.ID, creds.Secret)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %v, want %v, body %s", w.Code, tt.wantStatus, w.Body)
+			}
+
+			if tt.wantError != "" {
+				var got OAuthErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+					t.Fatalf("Unmarshal() error = %v", err)
+				}
+				if got.Error != tt.wantError {
+					t.Errorf("error = %v, want %v", got.Error, tt.wantError)
+				}
+			}
+		})
+	}
+}
+
+func TestOAuthHandler_Introspect(t *testing.T) {
+	backend := models.Client{ID: "backend"}
+	creds := models.ClientCredentials{Method: models.ClientAuthSecretBasic, ID: "backend", Secret: "qjfwjnqk"}
+	exp := time.Now().Add(time.Hour).Unix()
+
+	tests := []struct {
+		name          string
+		body          string
+		authErr       error
+		introspection models.Introspection
+		introspectErr error
+		wantStatus    int
+		want          IntrospectionResponse
+		wantError     string
+	}{
+		{
+			name: "active",
+			body: "token=a2dmd2U.ebfkqbfb",
+			introspection: models.Introspection{
+				Active:    true,
+				TokenType: models.TokenTypeRefreshToken,
+				Subject:   "kwfwe",
+				ClientID:  "backend",
+				Scope:     "read",
+				ExpiresAt: exp,
+				Tenant:    "acme",
+			},
+			wantStatus: http.StatusOK,
+			want: IntrospectionResponse{
+				Active:    true,
+				TokenType: models.TokenTypeRefreshToken,
+				Sub:       "kwfwe",
+				ClientID:  "backend",
+				Scope:     "read",
+				Exp:       exp,
+				Tid:       "acme",
+			},
+		},
+		{
+			name:       "inactive",
+			body:       "token=qfeqjfkj",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "invalidClient",
+			body:       "token=qfeqjfkj",
+			authErr:    constants.ErrInvalidClient,
+			wantStatus: http.StatusUnauthorized,
+			wantError:  OAuthInvalidClient,
+		},
+		{
+			name:          "publicClient",
+			body:          "token=qfeqjfkj",
+			introspectErr: constants.ErrUnauthorizedClient,
+			wantStatus:    http.StatusBadRequest,
+			wantError:     OAuthUnauthorizedClient,
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clients := mocks.NewClients(t)
+			tokens := mocks.NewTokenManager(t)
+			if tt.authErr != nil {
+				clients.EXPECT().Authenticate(mock.Anything, creds).Return(models.Client{}, tt.authErr)
+			} else {
+				clients.EXPECT().Authenticate(mock.Anything, creds).Return(backend, nil)
+				tokens.EXPECT().Introspect(mock.Anything, mock.Anything).Return(tt.introspection, tt.introspectErr)
+			}
+
+			h := NewOAuthHandler(lib.Logger{Logger: zap.NewNop()}, tokens, clients, nil, nil, lib.Config{})
+			r := gin.New()
+			r.POST("/oauth/introspect", h.Introspect)
+
+			req := httptest.NewRequest(http.MethodPost, "/oauth/introspect", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.SetBasicAuth(creds.ID, creds.Secret)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %v, want %v, body %s", w.Code, tt.wantStatus, w.Body)
+			}
+
+			if tt.wantError != "" {
+				var got OAuthErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+					t.Fatalf("Unmarshal() error = %v", err)
+				}
+				if got.Error != tt.wantError {
+					t.Errorf("error = %v, want %v", got.Error, tt.wantError)
+				}
+				return
+			}
+
+			var got IntrospectionResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("response = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/models"
+	"go-jwt-auth/internal/signing"
+	"go.uber.org/zap"
+	"net/http"
+	"strings"
+)
+
+// Bearer token error codes, see RFC 6750 3.1.
+const (
+	BearerInvalidToken      = "invalid_token"
+	BearerInsufficientScope = "insufficient_scope"
+)
+
+// Discovery is the OpenID Provider configuration of the tenant, see OpenID
+// Connect Discovery 4. It goes after Tenant.
+func (h *OAuthHandler) Discovery(c *gin.Context) {
+	tenant, err := h.tenants.Tenant(c.GetString(_tenantKey))
+	if err != nil {
+		HTTPError(c, err)
+		return
+	}
+
+	base := baseURL(c)
+	c.JSON(http.StatusOK, DiscoveryResponse{
+		Issuer:                 tenant.OIDCIssuer(base),
+		AuthorizationEndpoint:  base + "/oauth/authorize",
+		TokenEndpoint:          base + "/oauth/token",
+		UserinfoEndpoint:       base + "/userinfo",
+		RevocationEndpoint:     base + "/oauth/revoke",
+		IntrospectionEndpoint:  base + "/oauth/introspect",
+		JWKSURI:                base + "/.well-known/jwks.json",
+		ScopesSupported:        []string{models.ScopeOpenID},
+		ResponseTypesSupported: []string{ResponseTypeCode},
+		GrantTypesSupported: []string{
+			string(models.GrantTypeAuthorizationCode),
+			string(models.GrantTypeRefreshToken),
+			string(models.GrantTypeClientCredentials),
+		},
+		SubjectTypesSupported:            []string{"public"},
+		IDTokenSigningAlgValuesSupported: signing.IDTokenMethods(tenant),
+		TokenEndpointAuthMethodsSupported: []string{
+			models.ClientAuthSecretBasic,
+			models.ClientAuthSecretPost,
+			models.ClientAuthPrivateKeyJWT,
+			models.ClientAuthNone,
+		},
+		CodeChallengeMethodsSupported: []string{models.CodeChallengeS256},
+		ClaimsSupported:               []string{"iss", "sub", "aud", "exp", "iat", "auth_time", "nonce", "at_hash", "tid"},
+	})
+}
+
+// JWKS is the JWK set of the public keys of the tenant, see RFC 7517 5. The
+// HMAC keys are secrets, the tokens they sign are verified with the shared key.
+// It goes after Tenant.
+func (h *OAuthHandler) JWKS(c *gin.Context) {
+	tenant, err := h.tenants.Tenant(c.GetString(_tenantKey))
+	if err != nil {
+		HTTPError(c, err)
+		return
+	}
+
+	set, err := signing.JWKS(tenant)
+	if err != nil {
+		h.logger.Error("can't build jwks", zap.Error(err))
+		HTTPError(c, err)
+		return
+	}
+
+	// the verifiers refresh the set, the next keys are published ahead of their rotation.
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, set)
+}
+
+// UserInfo returns the claims of the subject of the bearer access token, see
+// OpenID Connect Core 5.3. It goes after Tenant.
+func (h *OAuthHandler) UserInfo(c *gin.Context) {
+	access, ok := bearerToken(c)
+	if !ok {
+		// no error code if the request has no token, see RFC 6750 3.1.
+		c.Header("WWW-Authenticate", `Bearer realm="jwt-auth"`)
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	info, err := h.tokens.UserInfo(requestContext(c), access)
+	if err != nil {
+		bearerError(c, err)
+		return
+	}
+
+	noStore(c)
+	c.JSON(http.StatusOK, UserInfoResponse{
+		Sub: info.Subject,
+		Tid: info.Tenant,
+	})
+}
+
+// bearerToken returns the token of the Authorization header, see RFC 6750 2.1.
+func bearerToken(c *gin.Context) (string, bool) {
+	scheme, token, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return token, true
+}
+
+// bearerError aborts the request with the error of the bearer token, see RFC 6750 3.
+func bearerError(c *gin.Context, err error) {
+	var status int
+	var code string
+	switch err { // no errors.Is() because we get an explicit error from the service every time.
+	case constants.ErrInvalidToken, constants.ErrTokenExpired, constants.ErrMissingAccessToken:
+		status, code = http.StatusUnauthorized, BearerInvalidToken
+	case constants.ErrInsufficientScope:
+		status, code = http.StatusForbidden, BearerInsufficientScope
+	default:
+		HTTPError(c, err)
+		return
+	}
+
+	c.Header("WWW-Authenticate", `Bearer realm="jwt-auth", error="`+code+`"`)
+	oauthError(c, status, code, err.Error())
+}
@@ -1,5 +1,46 @@
 package handler
 
+type GetTokensRequest struct {
+	GUID string `json:"guid" form:"guid"`
+}
+
 type RefreshTokensRequest struct {
 	RefreshToken string `json:"refresh_token"`
 }
+
+// TokenRequest is the form of the token endpoint, see RFC 6749 4.1.3, 4.4.2 and 6.
+// The client credentials are read by authenticateClient.
+type TokenRequest struct {
+	GrantType    string `form:"grant_type"`
+	RefreshToken string `form:"refresh_token"`
+	Scope        string `form:"scope"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier"`
+}
+
+// AuthorizeRequest is the authorization request of the code flow, see RFC 6749 4.1.1 and RFC 7636 4.3.
+type AuthorizeRequest struct {
+	ResponseType        string `form:"response_type"`
+	ClientID            string `form:"client_id"`
+	RedirectURI         string `form:"redirect_uri"`
+	Scope               string `form:"scope"`
+	State               string `form:"state"`
+	CodeChallenge       string `form:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method"`
+	// Nonce is the OpenID Connect nonce, repeated in the ID token.
+	Nonce string `form:"nonce"`
+}
+
+// RevokeRequest is the revocation request, see RFC 7009 2.1.
+// The token_type_hint is accepted but not needed to find the token.
+type RevokeRequest struct {
+	Token         string `form:"token"`
+	TokenTypeHint string `form:"token_type_hint"`
+}
+
+// IntrospectRequest is the introspection request, see RFC 7662 2.1.
+type IntrospectRequest struct {
+	Token         string `form:"token"`
+	TokenTypeHint string `form:"token_type_hint"`
+}
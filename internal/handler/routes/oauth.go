@@ -0,0 +1,46 @@
+package routes
+
+import (
+	"go-jwt-auth/internal/domains"
+	"go-jwt-auth/internal/handler"
+	"go-jwt-auth/internal/lib"
+)
+
+type OAuthRoutes struct {
+	oauthHandler   handler.OAuthHandler
+	requestHandler lib.RequestHandler
+	tenants        domains.Tenants
+	publicURL      string
+}
+
+func NewOAuthRoutes(
+	reqHandler lib.RequestHandler,
+	oh handler.OAuthHandler,
+	tenants domains.Tenants,
+	conf lib.Config,
+) OAuthRoutes {
+	return OAuthRoutes{
+		oauthHandler:   oh,
+		requestHandler: reqHandler,
+		tenants:        tenants,
+		publicURL:      conf.PublicURL,
+	}
+}
+
+func (or OAuthRoutes) Setup() {
+	for _, prefix := range []string{"/", "/t/:" + handler.TenantParam} {
+		oauth := or.requestHandler.Gin.Group(prefix, handler.Tenant(or.tenants, or.publicURL))
+
+		// the token, revocation and introspection endpoints authenticate the client itself to answer with OAuth errors.
+		oauth.POST("/oauth/token", or.oauthHandler.Token)
+		oauth.POST("/oauth/revoke", or.oauthHandler.Revoke)
+		oauth.POST("/oauth/introspect", or.oauthHandler.Introspect)
+		oauth.GET("/oauth/authorize", or.oauthHandler.Authorize)
+		oauth.POST("/oauth/authorize", or.oauthHandler.Authorize)
+
+		oauth.GET("/.well-known/openid-configuration", or.oauthHandler.Discovery)
+		oauth.GET("/.well-known/jwks.json", or.oauthHandler.JWKS)
+		oauth.GET("/userinfo", or.oauthHandler.UserInfo)
+		oauth.POST("/userinfo", or.oauthHandler.UserInfo)
+	}
+}
@@ -1,24 +1,54 @@
 package routes
 
 import (
+	"go-jwt-auth/internal/domains"
 	"go-jwt-auth/internal/handler"
 	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/models"
 )
 
 type TokenRoutes struct {
 	tokenHandler   handler.TokenHandler
 	requestHandler lib.RequestHandler
+	tenants        domains.Tenants
+	clients        domains.Clients
+	publicURL      string
+
+	// issuanceMethods are the client authentication methods of /v1/tokens.
+	issuanceMethods []string
 }
 
-func NewTokenRoutes(reqHandler lib.RequestHandler, th handler.TokenHandler) TokenRoutes {
-	return TokenRoutes{
-		tokenHandler:   th,
-		requestHandler: reqHandler,
+func NewTokenRoutes(
+	reqHandler lib.RequestHandler,
+	th handler.TokenHandler,
+	tenants domains.Tenants,
+	clients domains.Clients,
+	conf lib.Config,
+) (TokenRoutes, error) {
+	methods, err := handler.IssuanceMethods(conf.Issuance.AuthMethods)
+	if err != nil {
+		return TokenRoutes{}, err
 	}
+
+	return TokenRoutes{
+		tokenHandler:    th,
+		requestHandler:  reqHandler,
+		tenants:         tenants,
+		clients:         clients,
+		publicURL:       conf.PublicURL,
+		issuanceMethods: methods,
+	}, nil
 }
 
 func (tr TokenRoutes) Setup() {
-	tokens := tr.requestHandler.Gin.Group("/")
-	tokens.GET("/v1/tokens", tr.tokenHandler.GetTokens)
-	tokens.POST("/v1/refresh", tr.tokenHandler.RefreshTokens)
+	// the tenant is selected by the X-Tenant-ID header or the /t/{tenant} prefix.
+	for _, prefix := range []string{"/", "/t/:" + handler.TenantParam} {
+		tokens := tr.requestHandler.Gin.Group(prefix, handler.Tenant(tr.tenants, tr.publicURL))
+
+		// the client authenticates every request, see handler.ClientAuth.
+		issue := handler.ClientAuth(tr.clients, models.GrantTypeIssue, tr.issuanceMethods...)
+		tokens.GET("/v1/tokens", issue, tr.tokenHandler.GetTokens)
+		tokens.POST("/v1/tokens", issue, tr.tokenHandler.GetTokens)
+		tokens.POST("/v1/refresh", handler.ClientAuth(tr.clients, models.GrantTypeRefreshToken), tr.tokenHandler.RefreshTokens)
+	}
 }
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"go-jwt-auth/internal/domains"
+	"go-jwt-auth/internal/lib"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// TenantParam is the path parameter of the /t/{tenant} prefix.
+	TenantParam = "tenant"
+	// TenantHeader selects the tenant of the requests without the path prefix.
+	TenantHeader = "X-Tenant-ID"
+
+	_tenantKey    = "tenant"
+	_publicURLKey = "public_url"
+)
+
+// Tenant resolves the tenant of the request from the path prefix or the
+// header, the requests with neither belong to the default tenant. The
+// endpoints are served under publicURL, see lib.Config.PublicURL.
+func Tenant(tenants domains.Tenants, publicURL string) gin.HandlerFunc {
+	publicURL = strings.TrimSuffix(publicURL, "/")
+	return func(c *gin.Context) {
+		id := c.Param(TenantParam)
+		if header := c.GetHeader(TenantHeader); header != "" {
+			if id != "" && id != header {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+					"error": "the tenant of the path and the header differ",
+				})
+				return
+			}
+			id = header
+		}
+
+		if _, err := tenants.Tenant(id); err != nil {
+			HTTPError(c, err)
+			return
+		}
+
+		c.Set(_tenantKey, id)
+		c.Set(_publicURLKey, publicURL)
+		c.Next()
+	}
+}
+
+// requestContext carries the IP address, the tenant, its base URL and the client of the request to the services.
+func requestContext(c *gin.Context) context.Context {
+	ctx := lib.WithTenant(lib.WithClientIP(c, c.ClientIP()), c.GetString(_tenantKey))
+	ctx = lib.WithBaseURL(ctx, baseURL(c))
+	if client, ok := requestClient(c); ok {
+		ctx = lib.WithClient(ctx, client)
+	}
+	return ctx
+}
+
+// baseURL is the URL the endpoints of the tenant of the request are served
+// under: the public URL, with the /t/{tenant} prefix for the tenants other
+// than the default one whichever way the request selects them. The Host
+// header is set by the clients, it would let them pick the issuer.
+func baseURL(c *gin.Context) string {
+	u := c.GetString(_publicURLKey)
+	if id := c.GetString(_tenantKey); id != "" {
+		u += "/t/" + url.PathEscape(id)
+	}
+	return u
+}
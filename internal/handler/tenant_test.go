@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains/mocks"
+	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTenant(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		header     string
+		wantTenant string
+		wantStatus int
+	}{
+		{
+			name:       "default",
+			path:       "/v1/tokens",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "path",
+			path:       "/t/acme/v1/tokens",
+			wantTenant: "acme",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "header",
+			path:       "/v1/tokens",
+			header:     "acme",
+			wantTenant: "acme",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "pathAndHeader",
+			path:       "/t/acme/v1/tokens",
+			header:     "acme",
+			wantTenant: "acme",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "mismatch",
+			path:       "/t/acme/v1/tokens",
+			header:     "globex",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unknown",
+			path:       "/t/globex/v1/tokens",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenants := mocks.NewTenants(t)
+			tenants.On("Tenant", "").Return(models.Tenant{}, nil).Maybe()
+			tenants.On("Tenant", "acme").Return(models.Tenant{ID: "acme"}, nil).Maybe()
+			tenants.On("Tenant", "globex").Return(models.Tenant{}, constants.ErrUnknownTenant).Maybe()
+
+			var gotTenant string
+			r := gin.New()
+			for _, prefix := range []string{"/", "/t/:" + TenantParam} {
+				r.Group(prefix, Tenant(tenants, "http://example.com")).GET("/v1/tokens", func(c *gin.Context) {
+					gotTenant = lib.Tenant(requestContext(c))
+				})
+			}
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(TenantHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %v, want %v", w.Code, tt.wantStatus)
+			}
+			if gotTenant != tt.wantTenant {
+				t.Errorf("tenant = %q, want %q", gotTenant, tt.wantTenant)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"go-jwt-auth/internal/domains"
 	"go-jwt-auth/internal/lib"
 	"net/http"
@@ -21,9 +22,14 @@ func NewTokenHandler(logger lib.Logger, service domains.TokenManager) TokenHandl
 }
 
 func (h *TokenHandler) GetTokens(c *gin.Context) {
-	guid := c.DefaultQuery("guid", "")
+	gtr := &GetTokensRequest{GUID: c.Query("guid")}
+	if err := bindBody(c, gtr); err != nil {
+		HTTPError(c, err)
+		return
+	}
+	guid := gtr.GUID
 
-	access, refresh, err := h.tokens.GetTokens(c, guid)
+	access, refresh, err := h.tokens.GetTokens(requestContext(c), guid)
 	if err != nil {
 		HTTPError(c, err)
 		return
@@ -40,12 +46,12 @@ func (h *TokenHandler) RefreshTokens(c *gin.Context) {
 	access = strings.TrimLeft(access, "Bearer ")
 
 	rtr := &RefreshTokensRequest{}
-	if err := c.BindJSON(rtr); err != nil {
+	if err := c.ShouldBindBodyWith(rtr, binding.JSON); err != nil {
 		HTTPError(c, err)
 		return
 	}
 
-	access, refresh, err := h.tokens.RefreshTokens(c, access, rtr.RefreshToken)
+	access, refresh, err := h.tokens.RefreshTokens(requestContext(c), access, rtr.RefreshToken)
 	if err != nil {
 		HTTPError(c, err)
 		return
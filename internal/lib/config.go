@@ -2,59 +2,275 @@ package lib
 
 import (
 	"encoding/json"
-	"flag"
+	"errors"
 	"fmt"
+	"github.com/pelletier/go-toml/v2"
 	"go-jwt-auth/internal/config"
-	"io"
+	"gopkg.in/yaml.v3"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
 )
 
 const (
 	_defaultConfigPath = "config.json"
+
+	// EnvPrefix prefixes the environment variables overriding the config,
+	// e.g. JWT_AUTH_STORAGE_DSN overrides storage.dsn.
+	EnvPrefix = "JWT_AUTH_"
+	// EnvConfig is the environment variable with the path of the config file.
+	EnvConfig = EnvPrefix + "CONFIG"
+	// _envDatabaseDSN is the former name of JWT_AUTH_STORAGE_DSN.
+	_envDatabaseDSN = EnvPrefix + "DATABASE_DSN"
 )
 
 type Config struct {
-	Port  string `json:"port"`
-	HTTPS bool   `json:"https"`
+	Port string `json:"port"`
+	// HTTPS serves TLS with the certificate of TLS.
+	HTTPS bool       `json:"https"`
+	TLS   config.TLS `json:"tls"`
+	// GRPC serves the gRPC API, with TLS if HTTPS is set.
+	GRPC config.GRPC `json:"grpc"`
+	// Metrics serves the Prometheus metrics.
+	Metrics config.Metrics `json:"metrics"`
+	// TrustedProxies are the IPs and CIDRs of the proxies allowed to set the
+	// client IP with X-Forwarded-For, none by default.
+	TrustedProxies []string `json:"trusted_proxies"`
+	// PublicURL is the URL the clients reach the server at, e.g.
+	// https://auth.example.com. The OpenID Connect endpoints and the issuer of
+	// the tenants without one are built from it, never from the Host header.
+	PublicURL string `json:"public_url"`
 
+	// PathToConfig is the file the config was loaded from, empty if there was none.
 	PathToConfig string `json:"-"`
 
-	Storage config.Storage `json:"storage"`
-	JWT     config.JWT     `json:"jwt"`
+	Storage  config.Storage  `json:"storage"`
+	Cache    config.Cache    `json:"cache"`
+	JWT      config.JWT      `json:"jwt"`
+	Keys     config.Keys     `json:"keys"`
+	Tenants  []config.Tenant `json:"tenants"`
+	Audit    config.Audit    `json:"audit"`
+	Webhooks config.Webhooks `json:"webhooks"`
+
+	Notifications config.Notifications `json:"notifications"`
+	OAuth         config.OAuth         `json:"oauth"`
+	Issuance      config.Issuance      `json:"issuance"`
+
+	// unknown are the keys of the file that aren't settings, reported by Validate.
+	unknown []string
 }
 
-// NewConfig creates a new config.
-func NewConfig() (conf Config, err error) {
-	flag.Parse()
+// ConfigFile is the path of the config file set by the --config flag, empty if unset.
+type ConfigFile string
 
-	if conf, err = FromJSON(_defaultConfigPath); err != nil {
+// NewConfig creates a new config from the config file and the environment.
+//
+// The file is the one of --config, else the one of JWT_AUTH_CONFIG, else
+// config.json in the working directory, which may be missing then. The
+// environment variables override the file: JWT_AUTH_ and the path of the
+// setting, e.g. JWT_AUTH_JWT_ACCESS_TTL for jwt.access_ttl. The config
+// must pass Validate.
+func NewConfig(file ConfigFile) (conf Config, err error) {
+	if conf, err = LoadConfig(string(file), os.LookupEnv); err != nil {
 		return conf, fmt.Errorf("can't load config: %v", err)
 	}
+	if err := conf.Validate(); err != nil {
+		return conf, fmt.Errorf("%w, run config check for the details", err)
+	}
+	return conf, nil
+}
+
+// LoadConfig loads the config from the file, see NewConfig, with the
+// environment variables of lookup.
+func LoadConfig(path string, lookup func(string) (string, bool)) (conf Config, err error) {
+	explicit := path != ""
+	if !explicit {
+		path, explicit = lookup(EnvConfig)
+	}
+	if !explicit {
+		path = _defaultConfigPath
+	}
 
-	if dsn, ok := os.LookupEnv("JWT_AUTH_DATABASE_DSN"); ok {
+	conf, err = FromFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !explicit {
+		// everything may come from the environment.
+		conf, err = Config{}, nil
+	} else if err != nil {
+		return conf, err
+	} else {
+		conf.PathToConfig = path
+	}
+
+	if dsn, ok := lookup(_envDatabaseDSN); ok {
 		conf.Storage.DatabaseDSN = dsn
 	}
+	if err := applyEnv(reflect.ValueOf(&conf).Elem(), EnvPrefix, lookup); err != nil {
+		return conf, err
+	}
 
 	return conf, nil
 }
 
-// FromJSON loads a config from a json file.
-func FromJSON(filename string) (conf Config, err error) {
-	file, err := os.Open(filename)
+// FromFile loads a config from a JSON, YAML or TOML file, by its extension.
+// The settings have the same names in all the formats.
+func FromFile(filename string) (conf Config, err error) {
+	all, err := os.ReadFile(filename)
 	if err != nil {
-		return conf, fmt.Errorf("can't open %s: %v", filename, err)
+		return conf, fmt.Errorf("can't read %s: %w", filename, err)
 	}
-	defer file.Close()
 
-	all, err := io.ReadAll(file)
+	var raw any
+	switch ext := strings.ToLower(filepath.Ext(filename)); ext {
+	case ".json":
+		if err := json.Unmarshal(all, &conf); err != nil {
+			return conf, fmt.Errorf("can't unmarshal %s: %v", filename, err)
+		}
+		if err := json.Unmarshal(all, &raw); err != nil {
+			return conf, fmt.Errorf("can't unmarshal %s: %v", filename, err)
+		}
+		conf.unknown = unknownSettings(raw, reflect.TypeOf(conf), "")
+		return conf, nil
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(all, &raw)
+	case ".toml":
+		err = toml.Unmarshal(all, &raw)
+	default:
+		return conf, fmt.Errorf("%s: unknown config format %q, use .json, .yaml, .yml or .toml", filename, ext)
+	}
 	if err != nil {
-		return conf, fmt.Errorf("can't read %s: %v", filename, err)
+		return conf, fmt.Errorf("can't unmarshal %s: %v", filename, err)
 	}
 
-	err = json.Unmarshal(all, &conf)
+	// the settings are decoded by their json names, the scalars written
+	// unquoted, like port: 8080, are taken as the strings they stand for.
+	all, err = json.Marshal(coerce(raw, reflect.TypeOf(conf)))
 	if err != nil {
+		return conf, fmt.Errorf("can't convert %s: %v", filename, err)
+	}
+	if err := json.Unmarshal(all, &conf); err != nil {
 		return conf, fmt.Errorf("can't unmarshal %s: %v", filename, err)
 	}
-
+	conf.unknown = unknownSettings(raw, reflect.TypeOf(conf), "")
 	return conf, nil
 }
+
+// coerce converts the scalars of v, decoded from YAML or TOML, to strings
+// where t has a string.
+func coerce(v any, t reflect.Type) any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		if t.Kind() != reflect.Struct {
+			return v
+		}
+		for i := 0; i < t.NumField(); i++ {
+			name, ok := jsonName(t.Field(i))
+			if !ok {
+				continue
+			}
+			if fv, ok := v[name]; ok {
+				v[name] = coerce(fv, t.Field(i).Type)
+			}
+		}
+		return v
+	case []any:
+		if t.Kind() != reflect.Slice {
+			return v
+		}
+		for i := range v {
+			v[i] = coerce(v[i], t.Elem())
+		}
+		return v
+	case nil, string:
+		return v
+	default:
+		if t.Kind() == reflect.String {
+			return fmt.Sprint(v)
+		}
+		return v
+	}
+}
+
+// applyEnv overrides the fields of the struct v with the environment
+// variables named after the prefix and their json names. The lists of
+// strings are comma-separated, the other lists are JSON.
+func applyEnv(v reflect.Value, prefix string, lookup func(string) (string, bool)) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name, ok := jsonName(t.Field(i))
+		if !ok {
+			continue
+		}
+		key, field := prefix+strings.ToUpper(name), v.Field(i)
+
+		if field.Kind() == reflect.Struct {
+			if err := applyEnv(field, key+"_", lookup); err != nil {
+				return err
+			}
+			continue
+		}
+
+		s, ok := lookup(key)
+		if !ok {
+			continue
+		}
+		if err := setField(field, s); err != nil {
+			return fmt.Errorf("invalid %s: %v", key, err)
+		}
+	}
+	return nil
+}
+
+func setField(v reflect.Value, s string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			// the list replaces the one of the file, json would merge them.
+			v.Set(reflect.Zero(v.Type()))
+			return json.Unmarshal([]byte(s), v.Addr().Interface())
+		}
+		var list []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		v.Set(reflect.ValueOf(list))
+	default:
+		return json.Unmarshal([]byte(s), v.Addr().Interface())
+	}
+	return nil
+}
+
+// jsonName is the name of the setting of the field, it reports false for
+// the fields that aren't settings.
+func jsonName(f reflect.StructField) (string, bool) {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "-" || !f.IsExported() {
+		return "", false
+	}
+	if name == "" {
+		name = f.Name
+	}
+	return name, true
+}
@@ -0,0 +1,246 @@
+package lib
+
+import (
+	"go-jwt-auth/internal/config"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const (
+	_jsonConfig = `{
+  "port": "8080",
+  "storage": {"dsn": "memory://", "rewrite_batch_size": 500},
+  "jwt": {"key": "secret", "access_ttl": "15m"},
+  "tenants": [{"id": "acme", "jwt": {"key": "acme-secret"}}],
+  "issuance": {"auth_methods": ["client_secret_basic"]}
+}`
+	_yamlConfig = `
+port: 8080
+storage:
+  dsn: memory://
+  rewrite_batch_size: 500
+jwt:
+  key: secret
+  access_ttl: 15m
+tenants:
+  - id: acme
+    jwt:
+      key: acme-secret
+issuance:
+  auth_methods: [client_secret_basic]
+`
+	_tomlConfig = `
+port = 8080
+
+[storage]
+dsn = "memory://"
+rewrite_batch_size = 500
+
+[jwt]
+key = "secret"
+access_ttl = "15m"
+
+[[tenants]]
+id = "acme"
+jwt = { key = "acme-secret" }
+
+[issuance]
+auth_methods = ["client_secret_basic"]
+`
+)
+
+// writeConfig writes the config file to a temporary directory.
+func writeConfig(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return path
+}
+
+// env is a lookup of the environment variables.
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := vars[key]
+		return v, ok
+	}
+}
+
+func TestFromFile(t *testing.T) {
+	want := Config{
+		Port:     "8080",
+		Storage:  config.Storage{DatabaseDSN: "memory://", RewriteBatchSize: 500},
+		JWT:      config.JWT{Key: "secret", AccessTTL: "15m"},
+		Tenants:  []config.Tenant{{ID: "acme", JWT: config.JWT{Key: "acme-secret"}}},
+		Issuance: config.Issuance{AuthMethods: []string{"client_secret_basic"}},
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr bool
+	}{
+		{name: "json", file: "config.json", content: _jsonConfig},
+		{name: "yaml", file: "config.yaml", content: _yamlConfig},
+		{name: "yml", file: "config.yml", content: _yamlConfig},
+		{name: "toml", file: "config.toml", content: _tomlConfig},
+		{name: "unknownFormat", file: "config.ini", content: "port=8080", wantErr: true},
+		{name: "invalid", file: "config.yaml", content: "storage: [", wantErr: true},
+		{name: "wrongType", file: "config.toml", content: "[storage]\nrewrite_batch_size = \"many\"", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromFile(writeConfig(t, tt.file, tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FromFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, want) {
+				t.Errorf("FromFile() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	file := writeConfig(t, "config.json", _jsonConfig)
+
+	tests := []struct {
+		name    string
+		path    string
+		env     map[string]string
+		check   func(t *testing.T, conf Config)
+		wantErr bool
+	}{
+		{
+			name: "file",
+			path: file,
+			check: func(t *testing.T, conf Config) {
+				if conf.PathToConfig != file || conf.Storage.DatabaseDSN != "memory://" {
+					t.Errorf("LoadConfig() = %+v", conf)
+				}
+			},
+		},
+		{
+			name: "envFile",
+			env:  map[string]string{EnvConfig: file},
+			check: func(t *testing.T, conf Config) {
+				if conf.PathToConfig != file || conf.JWT.Key != "secret" {
+					t.Errorf("LoadConfig() = %+v", conf)
+				}
+			},
+		},
+		{
+			name: "flagOverEnvFile",
+			path: file,
+			env:  map[string]string{EnvConfig: "missing.json"},
+			check: func(t *testing.T, conf Config) {
+				if conf.PathToConfig != file {
+					t.Errorf("LoadConfig() loaded %q, want %q", conf.PathToConfig, file)
+				}
+			},
+		},
+		{
+			name: "overrides",
+			path: file,
+			env: map[string]string{
+				"JWT_AUTH_PORT":                       "9090",
+				"JWT_AUTH_HTTPS":                      "true",
+				"JWT_AUTH_STORAGE_DSN":                "postgres://localhost/auth",
+				"JWT_AUTH_STORAGE_REWRITE_BATCH_SIZE": "100",
+				"JWT_AUTH_JWT_ACCESS_TTL":             "5m",
+				"JWT_AUTH_NOTIFICATIONS_SMTP_ADDR":    "smtp.example.com:587",
+				"JWT_AUTH_ISSUANCE_AUTH_METHODS":      "client_secret_basic, tls_client_auth",
+				"JWT_AUTH_TENANTS":                    `[{"id": "globex"}]`,
+			},
+			check: func(t *testing.T, conf Config) {
+				want := Config{
+					Port:         "9090",
+					HTTPS:        true,
+					PathToConfig: file,
+					Storage:      config.Storage{DatabaseDSN: "postgres://localhost/auth", RewriteBatchSize: 100},
+					JWT:          config.JWT{Key: "secret", AccessTTL: "5m"},
+					Tenants:      []config.Tenant{{ID: "globex"}},
+					Issuance:     config.Issuance{AuthMethods: []string{"client_secret_basic", "tls_client_auth"}},
+				}
+				want.Notifications.SMTP.Addr = "smtp.example.com:587"
+				if !reflect.DeepEqual(conf, want) {
+					t.Errorf("LoadConfig() = %+v, want %+v", conf, want)
+				}
+			},
+		},
+		{
+			name: "databaseDSN",
+			path: file,
+			env:  map[string]string{"JWT_AUTH_DATABASE_DSN": "redis://localhost:6379"},
+			check: func(t *testing.T, conf Config) {
+				if conf.Storage.DatabaseDSN != "redis://localhost:6379" {
+					t.Errorf("LoadConfig() dsn = %q", conf.Storage.DatabaseDSN)
+				}
+			},
+		},
+		{
+			name: "storageDSNOverDatabaseDSN",
+			path: file,
+			env: map[string]string{
+				"JWT_AUTH_DATABASE_DSN": "redis://localhost:6379",
+				"JWT_AUTH_STORAGE_DSN":  "file://tokens.db",
+			},
+			check: func(t *testing.T, conf Config) {
+				if conf.Storage.DatabaseDSN != "file://tokens.db" {
+					t.Errorf("LoadConfig() dsn = %q", conf.Storage.DatabaseDSN)
+				}
+			},
+		},
+		{
+			name:    "invalidOverride",
+			path:    file,
+			env:     map[string]string{"JWT_AUTH_AUDIT_ENABLED": "sometimes"},
+			wantErr: true,
+		},
+		{
+			name:    "missingFile",
+			path:    filepath.Join(t.TempDir(), "config.yaml"),
+			wantErr: true,
+		},
+		{
+			name:    "missingEnvFile",
+			env:     map[string]string{EnvConfig: filepath.Join(t.TempDir(), "config.yaml")},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf, err := LoadConfig(tt.path, env(tt.env))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				tt.check(t, conf)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_NoDefaultFile(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Getwd() error = %v", err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("Chdir() error = %v", err)
+	}
+	defer os.Chdir(wd)
+
+	conf, err := LoadConfig("", env(map[string]string{"JWT_AUTH_STORAGE_DSN": "memory://"}))
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if conf.PathToConfig != "" || conf.Storage.DatabaseDSN != "memory://" {
+		t.Errorf("LoadConfig() = %+v", conf)
+	}
+}
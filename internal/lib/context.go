@@ -0,0 +1,58 @@
+package lib
+
+import (
+	"context"
+	"go-jwt-auth/internal/models"
+)
+
+type clientIPKey struct{}
+
+// WithClientIP returns a copy of ctx carrying the IP address of the client.
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+// ClientIP returns the IP address of the client stored in ctx, if any.
+func ClientIP(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey{}).(string)
+	return ip
+}
+
+type tenantKey struct{}
+
+// WithTenant returns a copy of ctx carrying the ID of the tenant of the request.
+func WithTenant(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// Tenant returns the ID of the tenant stored in ctx, the default tenant if none.
+func Tenant(ctx context.Context) string {
+	id, _ := ctx.Value(tenantKey{}).(string)
+	return id
+}
+
+type clientKey struct{}
+
+// WithClient returns a copy of ctx carrying the authenticated client of the request.
+func WithClient(ctx context.Context, c models.Client) context.Context {
+	return context.WithValue(ctx, clientKey{}, c)
+}
+
+// Client returns the client stored in ctx, if any.
+func Client(ctx context.Context) (models.Client, bool) {
+	c, ok := ctx.Value(clientKey{}).(models.Client)
+	return c, ok
+}
+
+type baseURLKey struct{}
+
+// WithBaseURL returns a copy of ctx carrying the URL the endpoints of the tenant of the request are served under.
+func WithBaseURL(ctx context.Context, url string) context.Context {
+	return context.WithValue(ctx, baseURLKey{}, url)
+}
+
+// BaseURL returns the URL stored in ctx, if any.
+func BaseURL(ctx context.Context) string {
+	url, _ := ctx.Value(baseURLKey{}).(string)
+	return url
+}
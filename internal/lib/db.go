@@ -2,30 +2,63 @@ package lib
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"github.com/egorgasay/dockerdb/v3"
+	_ "github.com/jackc/pgx/v5/stdlib" // registers the pgx driver for database/sql
+	"github.com/redis/go-redis/v9"
+	"go.etcd.io/bbolt"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/zap"
 	"log"
+	"strings"
 	"time"
 )
 
+// Database holds the connection to the storage selected by the DSN.
 type Database struct {
 	*mongo.Database
+	// SQL is the connection pool of the SQL drivers.
+	SQL *sql.DB
+	// Redis is the client of the redis driver.
+	Redis *redis.Client
+	// Bolt is the database file of the file driver.
+	Bolt *bbolt.DB
+	// Driver is the storage backend, one of the Driver* constants.
+	Driver string
 }
 
 const (
 	DBName  = "jwt-auth-db"
 	_autoUp = "AUTO_UP"
+
+	DriverMongo    = "mongo"
+	DriverMemory   = "memory"
+	DriverPostgres = "postgres"
+	DriverRedis    = "redis"
+	DriverFile     = "file"
+
+	_mongoScheme      = "mongodb://"
+	_mongoSRVScheme   = "mongodb+srv://"
+	_memoryScheme     = "memory://"
+	_postgresScheme   = "postgres://"
+	_postgresqlScheme = "postgresql://"
+	_redisScheme      = "redis://"
+	_redissScheme     = "rediss://"
+	_fileScheme       = "file://"
+	_pgxDriverName    = "pgx"
+
+	// _fileLockTimeout bounds waiting for another process holding the database file.
+	_fileLockTimeout = time.Second
 )
 
 func NewDatabase(conf Config) (db Database, err error) {
 	ctx := context.Background()
 	var client *mongo.Client
 
-	switch conf.Storage.DatabaseDSN {
-	case _autoUp:
+	switch dsn := conf.Storage.DatabaseDSN; {
+	case dsn == _autoUp:
 		vdbConf := dockerdb.EmptyConfig().Vendor("mongo").DBName(DBName).
 			NoSQL(func(c dockerdb.Config) (stop bool) {
 				dsn := fmt.Sprintf("mongodb://127.0.0.1:%s", c.GetActualPort())
@@ -51,15 +84,39 @@ func NewDatabase(conf Config) (db Database, err error) {
 			return db, fmt.Errorf("can't up or connect to dockerdb: %v", err)
 		}
 
-		return Database{Database: client.Database(DBName)}, nil
-	case "":
+		return Database{Database: client.Database(DBName), Driver: DriverMongo}, nil
+	case dsn == "":
 		return db, fmt.Errorf("DatabaseDSN shouldn't be empty")
+	case strings.HasPrefix(dsn, _memoryScheme):
+		return Database{Driver: DriverMemory}, nil
+	case strings.HasPrefix(dsn, _postgresScheme), strings.HasPrefix(dsn, _postgresqlScheme):
+		pool, err := sql.Open(_pgxDriverName, dsn)
+		if err != nil {
+			return db, fmt.Errorf("can't open postgres: %v", err)
+		}
+
+		return Database{SQL: pool, Driver: DriverPostgres}, nil
+	case strings.HasPrefix(dsn, _redisScheme), strings.HasPrefix(dsn, _redissScheme):
+		opt, err := redis.ParseURL(dsn)
+		if err != nil {
+			return db, fmt.Errorf("can't parse redis dsn: %v", err)
+		}
+
+		return Database{Redis: redis.NewClient(opt), Driver: DriverRedis}, nil
+	case strings.HasPrefix(dsn, _fileScheme):
+		path := strings.TrimPrefix(dsn, _fileScheme)
+		file, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: _fileLockTimeout})
+		if err != nil {
+			return db, fmt.Errorf("can't open %s: %v", path, err)
+		}
+
+		return Database{Bolt: file, Driver: DriverFile}, nil
 	default:
-		client, err = mongo.Connect(ctx, options.Client().ApplyURI(conf.Storage.DatabaseDSN))
+		client, err = mongo.Connect(ctx, options.Client().ApplyURI(dsn))
 		if err != nil {
 			return db, err
 		}
 
-		return Database{Database: client.Database(DBName)}, nil
+		return Database{Database: client.Database(DBName), Driver: DriverMongo}, nil
 	}
 }
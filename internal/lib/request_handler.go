@@ -1,14 +1,21 @@
 package lib
 
-import "github.com/gin-gonic/gin"
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+)
 
 // RequestHandler function
 type RequestHandler struct {
 	Gin *gin.Engine
 }
 
-// NewRequestHandler creates a new request handler
-func NewRequestHandler() RequestHandler {
+// NewRequestHandler creates a new request handler, it takes the client IP
+// from X-Forwarded-For only behind the trusted proxies of the config.
+func NewRequestHandler(conf Config) (RequestHandler, error) {
 	engine := gin.New()
-	return RequestHandler{Gin: engine}
+	if err := engine.SetTrustedProxies(conf.TrustedProxies); err != nil {
+		return RequestHandler{}, fmt.Errorf("invalid trusted_proxies: %v", err)
+	}
+	return RequestHandler{Gin: engine}, nil
 }
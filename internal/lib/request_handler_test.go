@@ -0,0 +1,41 @@
+package lib
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewRequestHandler_ClientIP(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxies []string
+		want    string
+	}{
+		{name: "noProxies", want: "10.0.0.1"},
+		{name: "trustedProxy", proxies: []string{"10.0.0.0/8"}, want: "203.0.113.7"},
+		{name: "untrustedProxy", proxies: []string{"192.168.0.1"}, want: "10.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := NewRequestHandler(Config{TrustedProxies: tt.proxies})
+			if err != nil {
+				t.Fatalf("NewRequestHandler() error = %v", err)
+			}
+
+			var got string
+			h.Gin.GET("/", func(c *gin.Context) { got = c.ClientIP() })
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = "10.0.0.1:4312"
+			r.Header.Set("X-Forwarded-For", "203.0.113.7")
+			h.Gin.ServeHTTP(httptest.NewRecorder(), r)
+
+			if got != tt.want {
+				t.Errorf("ClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package lib
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"go-jwt-auth/internal/config"
+	"os"
+)
+
+// ServerTLS is the TLS config of the HTTP and gRPC servers. With client CAs it
+// verifies the client certificates sent for tls_client_auth, the clients may send none.
+func ServerTLS(conf config.TLS) (*tls.Config, error) {
+	if conf.CertFile == "" || conf.KeyFile == "" {
+		return nil, fmt.Errorf("https needs tls.cert_file and tls.key_file")
+	}
+
+	cert, err := tls.LoadX509KeyPair(conf.CertFile, conf.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("can't load tls certificate: %v", err)
+	}
+
+	tlsConf := &tls.Config{MinVersion: tls.VersionTLS12, Certificates: []tls.Certificate{cert}}
+	if conf.ClientCAFile == "" {
+		return tlsConf, nil
+	}
+
+	pem, err := os.ReadFile(conf.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("can't read client ca file: %v", err)
+	}
+	tlsConf.ClientCAs = x509.NewCertPool()
+	if !tlsConf.ClientCAs.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates in client ca file %s", conf.ClientCAFile)
+	}
+	tlsConf.ClientAuth = tls.VerifyClientCertIfGiven
+
+	return tlsConf, nil
+}
@@ -0,0 +1,471 @@
+package lib
+
+import (
+	"fmt"
+	"go-jwt-auth/internal/config"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/models"
+	"math"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// _minKeySize is the size of the SHA-256 output, RFC 7518 3.2 asks for
+	// at least the size of the hash output of the HMAC keys.
+	_minKeySize = 32
+	// _minKeyEntropy is the least entropy of the characters of the keys, in
+	// bits, estimated from their frequency: it rejects the keys repeating a
+	// few characters, like a repeated word. Random hex has 4 bits per
+	// character, random base64 6, and 32 bytes of 3 bits have 96 bits.
+	_minKeyEntropy = 3
+
+	// _maxAccessTTL bounds the lifetime of the access tokens, they can't be revoked.
+	_maxAccessTTL  = 24 * time.Hour
+	_maxRefreshTTL = 365 * 24 * time.Hour
+	// _maxCodeTTL is the lifetime of the authorization codes recommended by RFC 6749 4.1.2.
+	_maxCodeTTL = 10 * time.Minute
+
+	// _sampleKey is the jwt.key the sample config.json used to ship, it is
+	// public and signs nothing safely.
+	_sampleKey = "xWTbr5IUjQJAMAZLLO4q8verXf1rMAF5rgvo0WcreOK1HwDe8GtKBGpcum3r9m4C"
+)
+
+// ConfigProblems are the problems of a config, each one starts with the
+// setting it is about.
+type ConfigProblems []string
+
+func (p ConfigProblems) Error() string {
+	return fmt.Sprintf("%v: %s", constants.ErrInvalidConfig, strings.Join(p, "; "))
+}
+
+func (p ConfigProblems) Unwrap() error {
+	return constants.ErrInvalidConfig
+}
+
+// Validate checks the whole config and reports all its problems at once as
+// ConfigProblems, nil if it has none.
+func (c Config) Validate() error {
+	v := &validator{problems: append(ConfigProblems(nil), c.unknown...)}
+
+	v.port("port", c.Port)
+	if c.GRPC.Port != "" {
+		v.port("grpc.port", c.GRPC.Port)
+		if c.GRPC.Port == c.Port {
+			v.add("grpc.port", "is the port of the HTTP server")
+		}
+	}
+	if c.Metrics.Addr != "" {
+		v.metricsAddr(c.Metrics.Addr, c.Port, c.GRPC.Port)
+	}
+	if c.HTTPS {
+		v.file("tls.cert_file", c.TLS.CertFile)
+		v.file("tls.key_file", c.TLS.KeyFile)
+	}
+	if c.TLS.ClientCAFile != "" {
+		v.file("tls.client_ca_file", c.TLS.ClientCAFile)
+	}
+	for i, p := range c.TrustedProxies {
+		if net.ParseIP(p) == nil {
+			if _, _, err := net.ParseCIDR(p); err != nil {
+				v.add(fmt.Sprintf("trusted_proxies[%d]", i), "%q must be an IP or a CIDR", p)
+			}
+		}
+	}
+
+	v.publicURL(c.PublicURL)
+	v.storage(c.Storage)
+	v.cache(c.Cache)
+
+	access, refresh := v.jwt("jwt", c.JWT, 0, 0)
+	ids := map[string]bool{}
+	for i, t := range c.Tenants {
+		path := fmt.Sprintf("tenants[%d]", i)
+		if !models.TenantIDPattern.MatchString(t.ID) {
+			v.add(path+".id", "%q must be 1 to 64 letters, digits, _ or -", t.ID)
+		} else if ids[t.ID] {
+			v.add(path+".id", "%q is the id of another tenant", t.ID)
+		}
+		ids[t.ID] = true
+		v.jwt(path+".jwt", t.JWT, access, refresh)
+	}
+
+	if c.Audit.Enabled {
+		v.key("audit.key", c.Audit.Key)
+	}
+	if c.Audit.CheckpointEvery < 0 {
+		v.add("audit.checkpoint_every", "must not be negative")
+	}
+	v.webhooks(c.Webhooks)
+	v.notifications(c.Notifications)
+	v.oauth(c.OAuth, c.TrustedProxies)
+	for i, m := range c.Issuance.AuthMethods {
+		v.oneOf(fmt.Sprintf("issuance.auth_methods[%d]", i), m, models.IssuanceAuthMethods...)
+	}
+
+	if len(v.problems) > 0 {
+		return v.problems
+	}
+	return nil
+}
+
+// validator collects the problems of a config.
+type validator struct {
+	problems ConfigProblems
+}
+
+func (v *validator) add(path, format string, args ...any) {
+	v.problems = append(v.problems, path+": "+fmt.Sprintf(format, args...))
+}
+
+// publicURL checks the URL the OpenID Connect issuer and endpoints are built
+// from, the server has no other trustworthy one.
+func (v *validator) publicURL(s string) {
+	if s == "" {
+		v.add("public_url", "is empty, set it to the URL the clients reach the server at, e.g. https://auth.example.com")
+		return
+	}
+	if u, err := url.Parse(s); err == nil && (u.RawQuery != "" || u.Fragment != "") {
+		v.add("public_url", "%q must have no query or fragment", s)
+		return
+	}
+	v.url("public_url", s, true)
+}
+
+func (v *validator) storage(s config.Storage) {
+	switch dsn := s.DatabaseDSN; {
+	case dsn == "":
+		v.add("storage.dsn", "is empty, set it to the DSN of the storage, e.g. memory://")
+	case dsn == _autoUp:
+	case strings.HasPrefix(dsn, _fileScheme):
+		if strings.TrimPrefix(dsn, _fileScheme) == "" {
+			v.add("storage.dsn", "has no file path, e.g. file://tokens.db")
+		}
+	default:
+		v.dsn("storage.dsn", dsn, _mongoScheme, _mongoSRVScheme, _postgresScheme, _postgresqlScheme,
+			_redisScheme, _redissScheme, _memoryScheme)
+	}
+
+	v.duration("storage.sweep_interval", s.SweepInterval, 0)
+	if s.RewriteBatchSize < 0 {
+		v.add("storage.rewrite_batch_size", "must not be negative")
+	}
+	if s.RewritePause != "" {
+		if d, err := time.ParseDuration(s.RewritePause); err != nil {
+			v.add("storage.rewrite_pause", "%v", err)
+		} else if d < 0 {
+			v.add("storage.rewrite_pause", "must not be negative")
+		}
+	}
+}
+
+func (v *validator) cache(c config.Cache) {
+	if c.Size < 0 {
+		v.add("cache.size", "must not be negative, 0 disables the cache")
+	}
+	v.duration("cache.ttl", c.TTL, 0)
+	if c.SharedDSN != "" {
+		v.dsn("cache.shared_dsn", c.SharedDSN, _redisScheme, _redissScheme)
+	}
+	v.duration("cache.shared_ttl", c.SharedTTL, 0)
+}
+
+// jwt checks the jwt settings of a tenant, the empty TTLs are inherited.
+// It returns the TTLs of the tenant, 0 if they are invalid.
+func (v *validator) jwt(path string, j config.JWT, access, refresh time.Duration) (time.Duration, time.Duration) {
+	v.key(path+".key", j.Key)
+
+	if j.AccessTTL != "" {
+		access = v.duration(path+".access_ttl", j.AccessTTL, _maxAccessTTL)
+	} else if access == 0 {
+		v.add(path+".access_ttl", "is empty, e.g. 15m")
+	}
+	if j.RefreshTTL != "" {
+		refresh = v.duration(path+".refresh_ttl", j.RefreshTTL, _maxRefreshTTL)
+	} else if refresh == 0 {
+		v.add(path+".refresh_ttl", "is empty, e.g. 720h")
+	}
+
+	if access > 0 && refresh > 0 && refresh <= access {
+		v.add(path+".refresh_ttl", "%s must be longer than access_ttl %s", refresh, access)
+	}
+	return access, refresh
+}
+
+// key checks that the HMAC key is long and random enough.
+func (v *validator) key(path, key string) {
+	switch entropy := keyEntropy(key); {
+	case key == "":
+		v.add(path, "is empty, set it to a secret of its own, e.g. the output of openssl rand -base64 48")
+	case key == _sampleKey:
+		v.add(path, "is the public key of the sample config, set it to a secret of its own, "+
+			"e.g. the output of openssl rand -base64 48")
+	case len(key) < _minKeySize:
+		v.add(path, "is %d bytes, it must be at least %d, e.g. the output of openssl rand -base64 48", len(key), _minKeySize)
+	case entropy < _minKeyEntropy:
+		v.add(path, "repeats a few characters, about %.1f bits of entropy per character, it must have at least %d, "+
+			"e.g. the output of openssl rand -base64 48", entropy, _minKeyEntropy)
+	}
+}
+
+// keyEntropy estimates the entropy of the characters of the key in bits
+// from their frequency.
+func keyEntropy(key string) float64 {
+	counts := map[rune]int{}
+	n := 0
+	for _, r := range key {
+		counts[r]++
+		n++
+	}
+
+	var perChar float64
+	for _, c := range counts {
+		p := float64(c) / float64(n)
+		perChar -= p * math.Log2(p)
+	}
+	return perChar
+}
+
+func (v *validator) webhooks(w config.Webhooks) {
+	events := []string{string(models.EventIssued), string(models.EventRefreshed),
+		string(models.EventRevoked), string(models.EventReuseDetected)}
+
+	for i, s := range w.Subscriptions {
+		path := fmt.Sprintf("webhooks.subscriptions[%d]", i)
+		v.url(path+".url", s.URL, true)
+		for j, e := range s.Events {
+			v.oneOf(fmt.Sprintf("%s.events[%d]", path, j), e, events...)
+		}
+	}
+
+	if w.MaxAttempts < 0 {
+		v.add("webhooks.max_attempts", "must not be negative")
+	}
+	initial := v.duration("webhooks.initial_backoff", w.InitialBackoff, 0)
+	max := v.duration("webhooks.max_backoff", w.MaxBackoff, 0)
+	if initial > 0 && max > 0 && max < initial {
+		v.add("webhooks.max_backoff", "%s is shorter than initial_backoff %s", max, initial)
+	}
+	v.duration("webhooks.timeout", w.Timeout, 0)
+}
+
+func (v *validator) notifications(n config.Notifications) {
+	if n.NewIPPolicy != "" {
+		v.oneOf("notifications.new_ip_policy", n.NewIPPolicy, constants.NewIPPolicyNotify, constants.NewIPPolicyReject)
+	}
+	if n.Notifier != "" {
+		v.oneOf("notifications.notifier", n.Notifier, constants.NotifierLog, constants.NotifierSMTP)
+	}
+	if n.Notifier != constants.NotifierSMTP {
+		return
+	}
+
+	if _, _, err := net.SplitHostPort(n.SMTP.Addr); err != nil {
+		v.add("notifications.smtp.addr", "must be host:port, %v", err)
+	}
+	v.duration("notifications.smtp.timeout", n.SMTP.Timeout, 0)
+}
+
+func (v *validator) oauth(o config.OAuth, trustedProxies []string) {
+	v.duration("oauth.code_ttl", o.CodeTTL, _maxCodeTTL)
+	if o.Authenticator != "" {
+		v.oneOf("oauth.authenticator", o.Authenticator, constants.UserAuthenticatorHeader)
+	}
+	if o.Authenticator == constants.UserAuthenticatorHeader && o.UserHeader == "" {
+		v.add("oauth.user_header", "is empty, the header authenticator needs it")
+	}
+	if o.Authenticator == constants.UserAuthenticatorHeader && len(trustedProxies) == 0 {
+		v.add("oauth.authenticator", "header needs trusted_proxies, the proxies allowed to set oauth.user_header")
+	}
+	if o.LoginURL != "" {
+		v.url("oauth.login_url", o.LoginURL, false)
+	}
+}
+
+// duration checks the duration if it is set: it must be positive and at
+// most max, if any. It returns the duration, 0 if it is unset or invalid.
+func (v *validator) duration(path, s string, max time.Duration) time.Duration {
+	if s == "" {
+		return 0
+	}
+
+	d, err := time.ParseDuration(s)
+	switch {
+	case err != nil:
+		v.add(path, "%v", err)
+		return 0
+	case d <= 0:
+		v.add(path, "%s must be positive", s)
+		return 0
+	case max > 0 && d > max:
+		v.add(path, "%s must be at most %s", s, max)
+		return 0
+	}
+	return d
+}
+
+func (v *validator) port(path, port string) {
+	if port == "" {
+		v.add(path, "is empty, e.g. 8080")
+		return
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > math.MaxUint16 {
+		v.add(path, "%q must be a number from 1 to 65535", port)
+	}
+}
+
+// metricsAddr checks the host:port of metrics.addr, its port can't be the
+// one of the HTTP or the gRPC server.
+func (v *validator) metricsAddr(addr, httpPort, grpcPort string) {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		v.add("metrics.addr", "%q must be host:port, e.g. 127.0.0.1:9090", addr)
+		return
+	}
+	v.port("metrics.addr", port)
+	if port == httpPort || port == grpcPort {
+		v.add("metrics.addr", "%q has the port of another server", addr)
+	}
+}
+
+func (v *validator) file(path, name string) {
+	if name == "" {
+		v.add(path, "is empty, https needs it")
+		return
+	}
+	if _, err := os.Stat(name); err != nil {
+		v.add(path, "%v", err)
+	}
+}
+
+func (v *validator) dsn(path, dsn string, schemes ...string) {
+	for _, s := range schemes {
+		if strings.HasPrefix(dsn, s) {
+			return
+		}
+	}
+	scheme, _, ok := strings.Cut(dsn, "://")
+	if !ok {
+		scheme = dsn
+	}
+	v.add(path, "unknown scheme %q, use %s", scheme, strings.Join(schemes, ", "))
+}
+
+// url checks that the URL is absolute, and https or http if web is set.
+func (v *validator) url(path, s string, web bool) {
+	u, err := url.Parse(s)
+	switch {
+	case err != nil:
+		v.add(path, "%v", err)
+	case !u.IsAbs() || u.Host == "":
+		v.add(path, "%q must be an absolute URL", s)
+	case web && u.Scheme != "https" && u.Scheme != "http":
+		v.add(path, "%q must be an http or https URL", s)
+	}
+}
+
+func (v *validator) oneOf(path, value string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.add(path, "unknown value %q, use %s", value, strings.Join(allowed, ", "))
+}
+
+// unknownSettings returns the keys of the decoded config v that aren't
+// settings of t, with the setting they may be a typo of.
+func unknownSettings(v any, t reflect.Type, path string) (unknown []string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		if t.Kind() != reflect.Struct {
+			return nil
+		}
+		fields := map[string]reflect.Type{}
+		for i := 0; i < t.NumField(); i++ {
+			if name, ok := jsonName(t.Field(i)); ok {
+				fields[name] = t.Field(i).Type
+			}
+		}
+
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			ft, ok := fields[k]
+			if !ok {
+				msg := path + k + ": unknown setting"
+				if s := closest(k, fields); s != "" {
+					msg += ", did you mean " + path + s + "?"
+				}
+				unknown = append(unknown, msg)
+				continue
+			}
+			unknown = append(unknown, unknownSettings(v[k], ft, path+k+".")...)
+		}
+	case []any:
+		if t.Kind() != reflect.Slice {
+			return nil
+		}
+		prefix := strings.TrimSuffix(path, ".")
+		for i, e := range v {
+			unknown = append(unknown, unknownSettings(e, t.Elem(), fmt.Sprintf("%s[%d].", prefix, i))...)
+		}
+	}
+	return unknown
+}
+
+// closest is the setting the key may be a typo of, empty if there is none.
+func closest(key string, settings map[string]reflect.Type) string {
+	best, bestDistance := "", 3
+	for s := range settings {
+		d := editDistance(key, s)
+		if strings.Contains(key, s) || strings.Contains(s, key) {
+			d = 1
+		}
+		if d < bestDistance || d == bestDistance && s < best {
+			best, bestDistance = s, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance of a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j-1] + cost
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,298 @@
+package lib
+
+import (
+	"errors"
+	"go-jwt-auth/internal/config"
+	"go-jwt-auth/internal/constants"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const _strongKey = "49nJBXZf16ekkhceQCB3UPeGsBN4HY8VfnP0w/a7e9LKABaKDD6TfpWG01yMQEGk"
+
+// validConfig is a config passing Validate.
+func validConfig() Config {
+	return Config{
+		Port:      "8080",
+		PublicURL: "https://auth.example.com",
+		Storage:   config.Storage{DatabaseDSN: "memory://"},
+		JWT:       config.JWT{Key: _strongKey, AccessTTL: "15m", RefreshTTL: "720h"},
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		want   []string
+	}{
+		{
+			name:   "ok",
+			modify: func(c *Config) {},
+		},
+		{
+			name: "okTenants",
+			modify: func(c *Config) {
+				c.Tenants = []config.Tenant{{ID: "acme", JWT: config.JWT{Key: _strongKey + "a", AccessTTL: "5m"}}}
+				c.Storage.DatabaseDSN = "mongodb+srv://cluster.example.com"
+				c.GRPC.Port = "9090"
+				c.Metrics.Addr = "127.0.0.1:9100"
+			},
+		},
+		{
+			name:   "emptyKey",
+			modify: func(c *Config) { c.JWT.Key = "" },
+			want:   []string{"jwt.key: is empty, set it to a secret of its own, e.g. the output of openssl rand -base64 48"},
+		},
+		{
+			name:   "shortKey",
+			modify: func(c *Config) { c.JWT.Key = "secret" },
+			want:   []string{"jwt.key: is 6 bytes, it must be at least 32, e.g. the output of openssl rand -base64 48"},
+		},
+		{
+			name:   "lowEntropyKey",
+			modify: func(c *Config) { c.JWT.Key = strings.Repeat("secret", 8) },
+			want: []string{"jwt.key: repeats a few characters, about 2.3 bits of entropy per character, " +
+				"it must have at least 3, e.g. the output of openssl rand -base64 48"},
+		},
+		{
+			name:   "sampleKey",
+			modify: func(c *Config) { c.JWT.Key = _sampleKey },
+			want: []string{"jwt.key: is the public key of the sample config, set it to a secret of its own, " +
+				"e.g. the output of openssl rand -base64 48"},
+		},
+		{
+			name:   "hexKey",
+			modify: func(c *Config) { c.JWT.Key = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08" },
+		},
+		{
+			name:   "auditKey",
+			modify: func(c *Config) { c.Audit.Enabled = true },
+			want:   []string{"audit.key: is empty, set it to a secret of its own, e.g. the output of openssl rand -base64 48"},
+		},
+		{
+			name:   "refreshShorterThanAccess",
+			modify: func(c *Config) { c.JWT.RefreshTTL = "10m" },
+			want:   []string{"jwt.refresh_ttl: 10m0s must be longer than access_ttl 15m0s"},
+		},
+		{
+			name: "inheritedTTLs",
+			modify: func(c *Config) {
+				c.Tenants = []config.Tenant{{ID: "acme", JWT: config.JWT{Key: _strongKey, AccessTTL: "1000h"}}}
+			},
+			want: []string{"tenants[0].jwt.access_ttl: 1000h must be at most 24h0m0s"},
+		},
+		{
+			name: "tenantRefreshShorterThanInherited",
+			modify: func(c *Config) {
+				c.Tenants = []config.Tenant{{ID: "acme", JWT: config.JWT{Key: _strongKey, RefreshTTL: "1m"}}}
+			},
+			want: []string{"tenants[0].jwt.refresh_ttl: 1m0s must be longer than access_ttl 15m0s"},
+		},
+		{
+			name: "tenants",
+			modify: func(c *Config) {
+				c.Tenants = []config.Tenant{
+					{ID: "acme", JWT: config.JWT{Key: _strongKey}},
+					{ID: "acme", JWT: config.JWT{Key: _strongKey}},
+					{ID: "a/b", JWT: config.JWT{Key: _strongKey}},
+				}
+			},
+			want: []string{
+				`tenants[1].id: "acme" is the id of another tenant`,
+				`tenants[2].id: "a/b" must be 1 to 64 letters, digits, _ or -`,
+			},
+		},
+		{
+			name: "ports",
+			modify: func(c *Config) {
+				c.Port = "70000"
+				c.GRPC.Port = "70000"
+			},
+			want: []string{
+				`port: "70000" must be a number from 1 to 65535`,
+				`grpc.port: "70000" must be a number from 1 to 65535`,
+				"grpc.port: is the port of the HTTP server",
+			},
+		},
+		{
+			name:   "metricsAddr",
+			modify: func(c *Config) { c.Metrics.Addr = "localhost" },
+			want:   []string{`metrics.addr: "localhost" must be host:port, e.g. 127.0.0.1:9090`},
+		},
+		{
+			name:   "metricsPublicPort",
+			modify: func(c *Config) { c.Metrics.Addr = ":8080" },
+			want:   []string{`metrics.addr: ":8080" has the port of another server`},
+		},
+		{
+			name:   "trustedProxies",
+			modify: func(c *Config) { c.TrustedProxies = []string{"10.0.0.1", "10.1.0.0/16", "proxy.local"} },
+			want:   []string{`trusted_proxies[2]: "proxy.local" must be an IP or a CIDR`},
+		},
+		{
+			name:   "emptyPublicURL",
+			modify: func(c *Config) { c.PublicURL = "" },
+			want:   []string{"public_url: is empty, set it to the URL the clients reach the server at, e.g. https://auth.example.com"},
+		},
+		{
+			name:   "publicURL",
+			modify: func(c *Config) { c.PublicURL = "auth.example.com" },
+			want:   []string{`public_url: "auth.example.com" must be an absolute URL`},
+		},
+		{
+			name:   "publicURLWithQuery",
+			modify: func(c *Config) { c.PublicURL = "https://auth.example.com/?tenant=acme" },
+			want:   []string{`public_url: "https://auth.example.com/?tenant=acme" must have no query or fragment`},
+		},
+		{
+			name:   "httpsWithoutCertificate",
+			modify: func(c *Config) { c.HTTPS = true },
+			want:   []string{"tls.cert_file: is empty, https needs it", "tls.key_file: is empty, https needs it"},
+		},
+		{
+			name:   "dsn",
+			modify: func(c *Config) { c.Storage.DatabaseDSN = "mysql://localhost/auth" },
+			want: []string{`storage.dsn: unknown scheme "mysql", use mongodb://, mongodb+srv://, postgres://, ` +
+				`postgresql://, redis://, rediss://, memory://`},
+		},
+		{
+			name:   "fileDSN",
+			modify: func(c *Config) { c.Storage.DatabaseDSN = "file://" },
+			want:   []string{"storage.dsn: has no file path, e.g. file://tokens.db"},
+		},
+		{
+			name: "durations",
+			modify: func(c *Config) {
+				c.Storage.SweepInterval = "often"
+				c.Cache.TTL = "-1s"
+				c.Webhooks.InitialBackoff = "1m"
+				c.Webhooks.MaxBackoff = "1s"
+				c.OAuth.CodeTTL = "1h"
+			},
+			want: []string{
+				`storage.sweep_interval: time: invalid duration "often"`,
+				"cache.ttl: -1s must be positive",
+				"webhooks.max_backoff: 1s is shorter than initial_backoff 1m0s",
+				"oauth.code_ttl: 1h must be at most 10m0s",
+			},
+		},
+		{
+			name: "values",
+			modify: func(c *Config) {
+				c.Cache.SharedDSN = "memcached://localhost"
+				c.Webhooks.Subscriptions = []config.WebhookSubscription{{URL: "/hook", Events: []string{"issued", "deleted"}}}
+				c.Notifications.Notifier = "smtp"
+				c.OAuth.Authenticator = "header"
+				c.Issuance.AuthMethods = []string{"client_secret_basic", "none"}
+			},
+			want: []string{
+				`cache.shared_dsn: unknown scheme "memcached", use redis://, rediss://`,
+				`webhooks.subscriptions[0].url: "/hook" must be an absolute URL`,
+				`webhooks.subscriptions[0].events[1]: unknown value "deleted", use issued, refreshed, revoked, reuse_detected`,
+				"notifications.smtp.addr: must be host:port, missing port in address",
+				"oauth.user_header: is empty, the header authenticator needs it",
+				"oauth.authenticator: header needs trusted_proxies, the proxies allowed to set oauth.user_header",
+				`issuance.auth_methods[1]: unknown value "none", use client_secret_basic, client_secret_post, ` +
+					`api_key, tls_client_auth, private_key_jwt`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := validConfig()
+			tt.modify(&c)
+
+			err := c.Validate()
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+
+			var problems ConfigProblems
+			if !errors.As(err, &problems) || !errors.Is(err, constants.ErrInvalidConfig) {
+				t.Fatalf("Validate() error = %v, want ConfigProblems", err)
+			}
+			if !reflect.DeepEqual([]string(problems), tt.want) {
+				t.Errorf("Validate() =\n%s\nwant\n%s", strings.Join(problems, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+// TestConfig_Validate_SampleConfig checks that the sample config.json needs
+// keys of its own before the server starts.
+func TestConfig_Validate_SampleConfig(t *testing.T) {
+	c, err := LoadConfig(filepath.Join("..", "..", "config.json"), func(string) (string, bool) { return "", false })
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	want := []string{
+		"jwt.key: is empty, set it to a secret of its own, e.g. the output of openssl rand -base64 48",
+		"audit.key: is empty, set it to a secret of its own, e.g. the output of openssl rand -base64 48",
+	}
+	var problems ConfigProblems
+	if !errors.As(c.Validate(), &problems) || !reflect.DeepEqual([]string(problems), want) {
+		t.Errorf("Validate() =\n%s\nwant\n%s", strings.Join(problems, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestConfig_Validate_TLSFiles(t *testing.T) {
+	cert := filepath.Join(t.TempDir(), "cert.pem")
+	if err := os.WriteFile(cert, []byte("cert"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	c := validConfig()
+	c.HTTPS = true
+	c.TLS = config.TLS{CertFile: cert, KeyFile: cert, ClientCAFile: filepath.Join(t.TempDir(), "ca.pem")}
+
+	err := c.Validate()
+	var problems ConfigProblems
+	if !errors.As(err, &problems) || len(problems) != 1 || !strings.HasPrefix(problems[0], "tls.client_ca_file: ") {
+		t.Errorf("Validate() error = %v, want a missing tls.client_ca_file", err)
+	}
+}
+
+func TestFromFile_UnknownSettings(t *testing.T) {
+	content := `{
+  "enable_https": true,
+  "jwt": {"key": "` + _strongKey + `", "acess_ttl": "15m"},
+  "tenants": [{"id": "acme", "jwt": {"refresh": "720h"}}],
+  "whatever": 1
+}`
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{name: "json", file: "config.json", content: content},
+		{name: "yaml", file: "config.yaml", content: content},
+	}
+
+	want := []string{
+		"enable_https: unknown setting, did you mean https?",
+		"jwt.acess_ttl: unknown setting, did you mean jwt.access_ttl?",
+		"tenants[0].jwt.refresh: unknown setting, did you mean tenants[0].jwt.refresh_ttl?",
+		"whatever: unknown setting",
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf, err := FromFile(writeConfig(t, tt.file, tt.content))
+			if err != nil {
+				t.Fatalf("FromFile() error = %v", err)
+			}
+			if !reflect.DeepEqual(conf.unknown, want) {
+				t.Errorf("FromFile() unknown = %q, want %q", conf.unknown, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+// Package metrics holds the prometheus metrics of the service.
+// They are exposed on /metrics.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const _namespace = "jwt_auth"
+
+var (
+	// ExpiredSessionsPurged counts the sessions removed by the sweeper.
+	ExpiredSessionsPurged = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: _namespace,
+		Name:      "expired_sessions_purged_total",
+		Help:      "Number of expired sessions removed by the sweeper.",
+	})
+	// SweepFailures counts the sweeps that failed.
+	SweepFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: _namespace,
+		Name:      "sweep_failures_total",
+		Help:      "Number of failed sweeps of the expired sessions.",
+	})
+	// OutdatedSessions is the number of sessions left to upgrade by the rewriter.
+	OutdatedSessions = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: _namespace,
+		Name:      "outdated_sessions",
+		Help:      "Number of sessions saved in an older schema version left to upgrade.",
+	})
+	// SessionsUpgraded counts the sessions upgraded by the rewriter.
+	SessionsUpgraded = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: _namespace,
+		Name:      "sessions_upgraded_total",
+		Help:      "Number of sessions upgraded to the current schema version by the rewriter.",
+	})
+	// CacheRequests counts the lookups of the repository caches by cache ("lru", "shared")
+	// and result ("hit", "miss").
+	CacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: _namespace,
+		Name:      "cache_requests_total",
+		Help:      "Number of session lookups in the repository caches.",
+	}, []string{"cache", "result"})
+)
+
+const (
+	CacheLRU    = "lru"
+	CacheShared = "shared"
+	CacheHit    = "hit"
+	CacheMiss   = "miss"
+)
@@ -0,0 +1,27 @@
+package models
+
+// AuditRecord is a single entry of the hash-chained audit trail.
+type AuditRecord struct {
+	Seq        int64     `bson:"seq"`
+	Event      EventType `bson:"event"`
+	GUID       string    `bson:"guid"`
+	ClientID   string    `bson:"client_id,omitempty"`
+	Time       int64     `bson:"time"`
+	PrevHash   string    `bson:"prev_hash"`
+	Hash       string    `bson:"hash"`
+	Checkpoint string    `bson:"checkpoint,omitempty"`
+}
+
+// AuditReport is the result of walking the audit chain.
+type AuditReport struct {
+	Checked     int64
+	Checkpoints int64
+	// BrokenSeq is the sequence number of the first broken link, 0 if the chain is intact.
+	BrokenSeq int64
+	Reason    string
+}
+
+// OK reports whether the chain is intact.
+func (r AuditReport) OK() bool {
+	return r.BrokenSeq == 0
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"path"
+	"time"
+)
+
+// GrantType is a way of obtaining the tokens a client can be allowed to use.
+type GrantType string
+
+const (
+	// GrantTypeIssue is issuing the tokens of any GUID with /v1/tokens, it is
+	// for the trusted backends authenticating the users themselves.
+	GrantTypeIssue             GrantType = "issue"
+	GrantTypeRefreshToken      GrantType = "refresh_token"
+	GrantTypeClientCredentials GrantType = "client_credentials"
+	GrantTypeAuthorizationCode GrantType = "authorization_code"
+)
+
+// Client authentication methods, see RFC 6749 2.3 and RFC 7523 2.2.
+const (
+	ClientAuthSecretBasic   = "client_secret_basic"
+	ClientAuthSecretPost    = "client_secret_post"
+	ClientAuthPrivateKeyJWT = "private_key_jwt"
+	// ClientAuthAPIKey is the client ID and the secret in the X-API-Key header.
+	ClientAuthAPIKey = "api_key"
+	// ClientAuthTLS is the certificate of a mutual TLS connection, see RFC 8705 2.1.
+	ClientAuthTLS = "tls_client_auth"
+	// ClientAuthNone is the client_id alone, only public clients use it.
+	ClientAuthNone = "none"
+)
+
+// IssuanceAuthMethods are the client authentication methods of the issuance
+// by default: the ones of the confidential clients.
+var IssuanceAuthMethods = []string{
+	ClientAuthSecretBasic,
+	ClientAuthSecretPost,
+	ClientAuthAPIKey,
+	ClientAuthTLS,
+	ClientAuthPrivateKeyJWT,
+}
+
+// Client is an application registered to obtain the tokens.
+type Client struct {
+	ID string `bson:"_id"`
+	// Tenant is the only tenant the client obtains the tokens of.
+	Tenant string `bson:"tenant"`
+	// SecretHash is the bcrypt hash of the secret, empty for the clients
+	// authenticating with private_key_jwt.
+	SecretHash string `bson:"secret_hash,omitempty"`
+	// PublicKey is the PEM encoded key verifying the private_key_jwt
+	// assertions of the client, empty for the clients with a secret.
+	PublicKey string `bson:"public_key,omitempty"`
+	// TLSSubject is the subject DN of the certificate of the clients
+	// authenticating with tls_client_auth, e.g. "CN=backend,O=Acme".
+	TLSSubject   string      `bson:"tls_subject,omitempty"`
+	GrantTypes   []GrantType `bson:"grant_types"`
+	Scopes       []string    `bson:"scopes"`
+	RedirectURIs []string    `bson:"redirect_uris"`
+	// GUIDPatterns are the path.Match patterns of the GUIDs the client may
+	// issue the tokens of with /v1/tokens, any GUID if empty.
+	GUIDPatterns []string `bson:"guid_patterns,omitempty"`
+	// AccessTTL and RefreshTTL override the ones of the tenant if positive.
+	AccessTTL  time.Duration `bson:"access_ttl"`
+	RefreshTTL time.Duration `bson:"refresh_ttl"`
+}
+
+// Allows reports whether the client may use the grant type.
+func (c Client) Allows(g GrantType) bool {
+	for _, allowed := range c.GrantTypes {
+		if allowed == g {
+			return true
+		}
+	}
+	return false
+}
+
+// Public reports whether the client has no credentials, e.g. a browser or
+// mobile app, see RFC 6749 2.1. It proves the codes it uses with PKCE instead.
+func (c Client) Public() bool {
+	return c.SecretHash == "" && c.PublicKey == "" && c.TLSSubject == ""
+}
+
+// MayIssue reports whether the client may issue the tokens of the GUID.
+func (c Client) MayIssue(guid string) bool {
+	if len(c.GUIDPatterns) == 0 {
+		return true
+	}
+	for _, p := range c.GUIDPatterns {
+		if ok, _ := path.Match(p, guid); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientCredentials are the credentials a client authenticates a request with.
+type ClientCredentials struct {
+	// Method is one of the ClientAuth* constants.
+	Method string
+	ID     string
+	Secret string
+	// Assertion is the private_key_jwt assertion.
+	Assertion string
+	// Audience are the values the aud claim of the assertion may take,
+	// the URL of the endpoint the assertion is sent to.
+	Audience []string
+	// TLSSubject is the subject DN of the verified certificate of tls_client_auth.
+	TLSSubject string
+}
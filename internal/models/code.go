@@ -0,0 +1,38 @@
+package models
+
+// CodeChallengeS256 is the only PKCE code_challenge_method accepted, see RFC 7636 4.2.
+const CodeChallengeS256 = "S256"
+
+// AuthorizationRequest is an authorization request of a user to a client, see RFC 6749 4.1.1.
+type AuthorizationRequest struct {
+	// GUID is the user authenticated by the domains.UserAuthenticator.
+	GUID string
+	// RedirectURI is the redirect_uri of the request, empty if it was omitted.
+	RedirectURI string
+	// Scope is the space-delimited scope requested, empty for the default one.
+	Scope               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	// Nonce is the OpenID Connect nonce, repeated in the ID token.
+	Nonce string
+}
+
+// AuthorizationCode is a one-time code of the authorization_code grant.
+// Only the hash of the code is stored.
+type AuthorizationCode struct {
+	// Hash is the hex SHA-256 of the code.
+	Hash     string `bson:"_id" json:"hash"`
+	Tenant   string `bson:"tenant" json:"tenant"`
+	ClientID string `bson:"client_id" json:"client_id"`
+	// RedirectURI is the redirect_uri of the authorization request, the token
+	// request must repeat it. Empty if it was omitted.
+	RedirectURI   string `bson:"redirect_uri,omitempty" json:"redirect_uri,omitempty"`
+	GUID          string `bson:"guid" json:"guid"`
+	Scope         string `bson:"scope,omitempty" json:"scope,omitempty"`
+	CodeChallenge string `bson:"code_challenge" json:"code_challenge"`
+	Nonce         string `bson:"nonce,omitempty" json:"nonce,omitempty"`
+	// AuthTime is when the user was authenticated, unix.
+	AuthTime int64 `bson:"auth_time" json:"auth_time"`
+	// ExpiresAt is unix, the codes live for a minute by default.
+	ExpiresAt int64 `bson:"expires_at" json:"expires_at"`
+}
@@ -0,0 +1,21 @@
+package models
+
+// EventType is a kind of token lifecycle event.
+type EventType string
+
+const (
+	EventIssued        EventType = "issued"
+	EventRefreshed     EventType = "refreshed"
+	EventRevoked       EventType = "revoked"
+	EventReuseDetected EventType = "reuse_detected"
+)
+
+// Event is a token lifecycle event raised by the TokenManager.
+type Event struct {
+	Type EventType
+	// GUID is the user of the tokens, empty for the tokens of a client itself.
+	GUID string
+	// ClientID is set instead of GUID for the client_credentials tokens.
+	ClientID string
+	Time     int64
+}
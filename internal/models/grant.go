@@ -0,0 +1,27 @@
+package models
+
+// GrantRequest is a token request of an OAuth grant, see RFC 6749 4.
+type GrantRequest struct {
+	Type GrantType
+	// RefreshToken is the token of the refresh_token grant.
+	RefreshToken string
+	// Code, RedirectURI and CodeVerifier are the ones of the authorization_code grant.
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	// Scope is the space-delimited scope requested, empty for the default one.
+	Scope string
+}
+
+// Tokens are the tokens issued by a grant.
+type Tokens struct {
+	AccessToken string
+	// RefreshToken is empty if the grant issues none.
+	RefreshToken string
+	// ExpiresAt is the expiry of the access token, unix.
+	ExpiresAt int64
+	// Scope is the space-delimited scope granted.
+	Scope string
+	// IDToken is the OpenID Connect ID token, empty if the openid scope was not granted.
+	IDToken string
+}
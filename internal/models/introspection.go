@@ -0,0 +1,26 @@
+package models
+
+// The token type hints of the revocation and introspection requests, see RFC 7009 2.1.
+const (
+	TokenTypeAccessToken  = "access_token"
+	TokenTypeRefreshToken = "refresh_token"
+)
+
+// Introspection is the state of a token, see RFC 7662 2.2.
+// Only Active is set if the token is not active.
+type Introspection struct {
+	Active bool
+	// TokenType is TokenTypeAccessToken or TokenTypeRefreshToken.
+	TokenType string
+	// Subject is the GUID of the user or the ID of the client of a client_credentials
+	// token, ClientID tells them apart.
+	Subject string
+	// ClientID is the client the token was issued to, empty for the tokens issued before the clients.
+	ClientID string
+	Scope    string
+	// ExpiresAt is unix.
+	ExpiresAt int64
+	Tenant    string
+	Issuer    string
+	Audience  string
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// KeyState is the stage of a signing key in its lifecycle.
+type KeyState string
+
+const (
+	// KeyStateNext keys are published but don't sign yet, the verifiers fetch them before they are used.
+	KeyStateNext KeyState = "next"
+	// KeyStateActive is the key signing the tokens of the tenant.
+	KeyStateActive KeyState = "active"
+	// KeyStatePrevious keys verify the tokens signed before the rotation.
+	KeyStatePrevious KeyState = "previous"
+	// KeyStateRetired keys neither sign nor verify.
+	KeyStateRetired KeyState = "retired"
+)
+
+// The types of the signing keys, see signing.Generate.
+const (
+	KeyTypeHMAC    = "hmac"
+	KeyTypeRSA     = "rsa"
+	KeyTypeEC      = "ec"
+	KeyTypeEd25519 = "ed25519"
+)
+
+// SigningKey is a key of the key store signing the tokens of a tenant.
+type SigningKey struct {
+	ID string `json:"kid"`
+	// Tenant is the ID of the tenant of the key, empty for the default tenant.
+	Tenant string `json:"tenant"`
+	// Algorithm is the JWS alg of the key: HS512, RS256, ES256 or EdDSA.
+	Algorithm string   `json:"alg"`
+	State     KeyState `json:"state"`
+	// Private is the PKCS #8 PEM of the private key, or the base64url secret of the HMAC keys.
+	Private   string    `json:"private"`
+	CreatedAt time.Time `json:"created_at"`
+	// UpdatedAt is when the key entered its state.
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,14 @@
+package models
+
+// MigrationStatus is the state of a storage migration.
+type MigrationStatus struct {
+	Version int64
+	Name    string
+	// AppliedAt is the unix time the migration was applied at, 0 while it is pending.
+	AppliedAt int64
+}
+
+// Applied reports whether the migration is applied.
+func (s MigrationStatus) Applied() bool {
+	return s.AppliedAt != 0
+}
@@ -1,8 +1,22 @@
 package models
 
+// TokenDataVersion is the current schema version of TokenData.
+// Bump it with an upgrade in internal/storage/tokenschema when the shape changes.
+const TokenDataVersion = 4
+
 type TokenData struct {
-	GUID        string `bson:"guid"`
-	RefreshHash string `bson:"refresh_hash"`
-	RefreshExp  int64  `bson:"refresh_exp"`
-	AccessExp   int64  `bson:"access_exp"`
+	// SchemaVersion is the shape the session was saved in, 0 for the sessions saved before versioning.
+	SchemaVersion int    `bson:"schema_version"`
+	GUID          string `bson:"guid"`
+	RefreshHash   string `bson:"refresh_hash"`
+	RefreshExp    int64  `bson:"refresh_exp"`
+	AccessExp     int64  `bson:"access_exp"`
+	// IP is the address of the client the session was issued to.
+	IP string `bson:"ip,omitempty"`
+	// Tenant is the ID of the tenant the session was issued by, empty for the default tenant.
+	Tenant string `bson:"tenant,omitempty"`
+	// ClientID is the client the session was issued to, empty for the sessions issued before the clients.
+	ClientID string `bson:"client_id,omitempty"`
+	// Scope is the space-delimited scope granted to the session, it is kept when the session is refreshed.
+	Scope string `bson:"scope,omitempty"`
 }
@@ -0,0 +1,9 @@
+package models
+
+// NewIPNotice warns the user that a session was refreshed from a new IP address.
+type NewIPNotice struct {
+	GUID       string
+	PreviousIP string
+	IP         string
+	Time       int64
+}
@@ -0,0 +1,28 @@
+package models
+
+// ScopeOpenID is the scope of the OpenID Connect requests, the authorization
+// code grant issues an ID token next to the access token if it is granted.
+const ScopeOpenID = "openid"
+
+// IDToken is an OpenID Connect ID token of a user to a client, see OpenID Connect Core 2.
+type IDToken struct {
+	Issuer string
+	// Subject is the GUID of the user.
+	Subject string
+	// Audience is the ID of the client.
+	Audience string
+	// Nonce is the nonce of the authorization request, empty if it was omitted.
+	Nonce string
+	// AuthTime is when the user was authenticated, unix.
+	AuthTime int64
+	// AccessToken is the access token issued with the ID token, its hash is the at_hash claim.
+	AccessToken string
+	// ExpiresAt is unix, the ID tokens expire with the access tokens.
+	ExpiresAt int64
+}
+
+// UserInfo is the claims of the subject of an access token, see OpenID Connect Core 5.3.
+type UserInfo struct {
+	Subject string
+	Tenant  string
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"regexp"
+	"time"
+)
+
+// TenantIDPattern keeps the tenant IDs safe to use in paths and headers.
+var TenantIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// Tenant is a product hosted by the service with its own token settings.
+// The default tenant has an empty ID.
+type Tenant struct {
+	ID         string
+	Key        []byte
+	Issuer     string
+	Audience   string
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+	// Keys are the keys of the tenant in the key store, the retired ones aside.
+	// The tokens are signed with Key while none is active.
+	Keys []SigningKey
+}
+
+// OIDCIssuer is the issuer of the ID tokens and of the OpenID Connect
+// discovery: the issuer of the tenant or, if it has none, the URL its
+// endpoints are served under.
+func (t Tenant) OIDCIssuer(baseURL string) string {
+	if t.Issuer != "" {
+		return t.Issuer
+	}
+	return baseURL
+}
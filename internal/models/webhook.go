@@ -0,0 +1,24 @@
+package models
+
+// WebhookPayload is the JSON body sent to webhook subscribers.
+type WebhookPayload struct {
+	ID   string    `json:"id"`
+	Type EventType `json:"type"`
+	GUID string    `json:"guid"`
+	// ClientID is set instead of GUID for the tokens of a client itself.
+	ClientID string `json:"client_id,omitempty"`
+	Time     int64  `json:"time"`
+}
+
+// WebhookDelivery is a single attempt to deliver a payload to a subscriber.
+type WebhookDelivery struct {
+	ID           string    `bson:"id"`
+	Subscription string    `bson:"subscription"`
+	URL          string    `bson:"url"`
+	Event        EventType `bson:"event"`
+	Payload      string    `bson:"payload"`
+	Attempt      int       `bson:"attempt"`
+	StatusCode   int       `bson:"status_code"`
+	Error        string    `bson:"error,omitempty"`
+	Time         int64     `bson:"time"`
+}
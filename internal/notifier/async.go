@@ -0,0 +1,83 @@
+package notifier
+
+import (
+	"context"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains"
+	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/models"
+	"go.uber.org/zap"
+	"sync"
+)
+
+// _noticeQueueSize bounds the notices waiting to be sent, the ones beyond are refused.
+const _noticeQueueSize = 256
+
+// AsyncNotifier sends the notices of another notifier in the background, so
+// that a slow mail server doesn't hold the requests raising them.
+type AsyncNotifier struct {
+	next   domains.Notifier
+	logger lib.Logger
+
+	// mu guards closed and the sends to queue.
+	mu     sync.Mutex
+	closed bool
+	queue  chan models.NewIPNotice
+	done   chan struct{}
+}
+
+// NewAsyncNotifier creates a new instance of AsyncNotifier sending the notices with next.
+func NewAsyncNotifier(next domains.Notifier, logger lib.Logger) *AsyncNotifier {
+	n := &AsyncNotifier{
+		next:   next,
+		logger: logger,
+		queue:  make(chan models.NewIPNotice, _noticeQueueSize),
+		done:   make(chan struct{}),
+	}
+	go n.work()
+	return n
+}
+
+// NotifyNewIP queues the notice, it fails if the queue is full or closed.
+func (n *AsyncNotifier) NotifyNewIP(_ context.Context, notice models.NewIPNotice) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.closed {
+		return constants.ErrClosed
+	}
+
+	select {
+	case n.queue <- notice:
+		return nil
+	default:
+		return constants.ErrQueueFull
+	}
+}
+
+// Close refuses the new notices and waits for the queued ones to be sent,
+// or for ctx to be done.
+func (n *AsyncNotifier) Close(ctx context.Context) error {
+	n.mu.Lock()
+	if !n.closed {
+		n.closed = true
+		close(n.queue)
+	}
+	n.mu.Unlock()
+
+	select {
+	case <-n.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (n *AsyncNotifier) work() {
+	defer close(n.done)
+	for notice := range n.queue {
+		if err := n.next.NotifyNewIP(context.Background(), notice); err != nil {
+			n.logger.Error("can't send new ip notice", zap.String("guid", notice.GUID), zap.Error(err))
+		}
+	}
+}
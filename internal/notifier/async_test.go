@@ -0,0 +1,42 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/mock"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains/mocks"
+	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/models"
+	"testing"
+)
+
+func TestAsyncNotifier(t *testing.T) {
+	logger, err := lib.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create Logger instance: %v", err)
+	}
+
+	notices := []models.NewIPNotice{
+		{GUID: "kwfwe", PreviousIP: "10.0.0.1", IP: "10.0.0.2"},
+		{GUID: "lkmlkwe", PreviousIP: "10.0.0.1", IP: "10.0.0.3"},
+	}
+
+	next := mocks.NewNotifier(t)
+	next.On("NotifyNewIP", mock.Anything, notices[0]).Return(errors.New("connection refused")).Once()
+	next.On("NotifyNewIP", mock.Anything, notices[1]).Return(nil).Once()
+
+	n := NewAsyncNotifier(next, logger)
+	for _, notice := range notices {
+		if err := n.NotifyNewIP(context.Background(), notice); err != nil {
+			t.Errorf("NotifyNewIP() error = %v", err)
+		}
+	}
+
+	if err := n.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := n.NotifyNewIP(context.Background(), notices[0]); !errors.Is(err, constants.ErrClosed) {
+		t.Errorf("NotifyNewIP() after Close error = %v, want %v", err, constants.ErrClosed)
+	}
+}
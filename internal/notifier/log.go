@@ -0,0 +1,29 @@
+package notifier
+
+import (
+	"context"
+	"go-jwt-auth/internal/domains"
+	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/models"
+	"go.uber.org/zap"
+)
+
+// LogNotifier only writes the notices to the log.
+type LogNotifier struct {
+	logger lib.Logger
+}
+
+// NewLogNotifier creates a new instance of LogNotifier.
+func NewLogNotifier(logger lib.Logger) domains.Notifier {
+	return &LogNotifier{logger: logger}
+}
+
+// NotifyNewIP logs the notice.
+func (n *LogNotifier) NotifyNewIP(_ context.Context, notice models.NewIPNotice) error {
+	n.logger.Warn("session refreshed from a new ip",
+		zap.String("guid", notice.GUID),
+		zap.String("previous_ip", notice.PreviousIP),
+		zap.String("ip", notice.IP),
+	)
+	return nil
+}
@@ -0,0 +1,41 @@
+// Package notifier delivers security notices to users.
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains"
+	"go-jwt-auth/internal/lib"
+	"go.uber.org/fx"
+)
+
+var Module = fx.Options(
+	fx.Provide(NewNotifier),
+)
+
+// NewNotifier creates the notifier selected in the config, sending in the
+// background until the app stops.
+func NewNotifier(lc fx.Lifecycle, conf lib.Config, logger lib.Logger) (domains.Notifier, error) {
+	var next domains.Notifier
+	switch conf.Notifications.Notifier {
+	case constants.NotifierLog, "":
+		next = NewLogNotifier(logger)
+	case constants.NotifierSMTP:
+		smtp, err := NewSMTPNotifier(conf.Notifications.SMTP)
+		if err != nil {
+			return nil, err
+		}
+		next = smtp
+	default:
+		return nil, fmt.Errorf("unknown notifier %q", conf.Notifications.Notifier)
+	}
+
+	n := NewAsyncNotifier(next, logger)
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return n.Close(ctx)
+		},
+	})
+	return n, nil
+}
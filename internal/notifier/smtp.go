@@ -0,0 +1,190 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"go-jwt-auth/internal/config"
+	"go-jwt-auth/internal/domains"
+	"go-jwt-auth/internal/models"
+	"net"
+	"net/smtp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	_defaultSubject  = "New sign-in to your account"
+	_defaultTemplate = `Your session issued to {{.PreviousIP}} was refreshed from {{.IP}} at {{time .Time}}.
+
+If it wasn't you, revoke your sessions and change your password.
+`
+	_defaultSMTPTimeout = 10 * time.Second
+)
+
+var _funcs = template.FuncMap{
+	"time": func(unix int64) string {
+		return time.Unix(unix, 0).UTC().Format(time.RFC1123)
+	},
+}
+
+// SMTPNotifier sends the notices by email.
+type SMTPNotifier struct {
+	addr     string
+	auth     smtp.Auth
+	from     string
+	to       *template.Template
+	subject  *template.Template
+	body     *template.Template
+	timeout  time.Duration
+	hostname string
+}
+
+// NewSMTPNotifier creates a new instance of SMTPNotifier.
+func NewSMTPNotifier(conf config.SMTP) (domains.Notifier, error) {
+	host, _, err := net.SplitHostPort(conf.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid smtp addr: %v", err)
+	}
+
+	n := &SMTPNotifier{
+		addr:     conf.Addr,
+		from:     conf.From,
+		timeout:  _defaultSMTPTimeout,
+		hostname: host,
+	}
+
+	if conf.Username != "" {
+		n.auth = smtp.PlainAuth("", conf.Username, conf.Password, host)
+	}
+
+	if conf.Timeout != "" {
+		if n.timeout, err = time.ParseDuration(conf.Timeout); err != nil {
+			return nil, fmt.Errorf("can't parse smtp timeout: %v", err)
+		}
+	}
+
+	if n.to, err = parseTemplate("to", conf.To, ""); err != nil {
+		return nil, err
+	}
+	if n.subject, err = parseTemplate("subject", conf.Subject, _defaultSubject); err != nil {
+		return nil, err
+	}
+	if n.body, err = parseTemplate("template", conf.Template, _defaultTemplate); err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+// NotifyNewIP sends the notice to the address rendered from the To template.
+func (n *SMTPNotifier) NotifyNewIP(ctx context.Context, notice models.NewIPNotice) error {
+	to, err := render(n.to, notice)
+	if err != nil {
+		return err
+	}
+	if to == "" {
+		return fmt.Errorf("empty recipient for %s", notice.GUID)
+	}
+
+	subject, err := render(n.subject, notice)
+	if err != nil {
+		return err
+	}
+
+	body, err := render(n.body, notice)
+	if err != nil {
+		return err
+	}
+
+	msg := strings.Join([]string{
+		"From: " + n.from,
+		"To: " + to,
+		"Subject: " + subject,
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=utf-8",
+		"",
+		body,
+	}, "\r\n")
+
+	return n.send(ctx, to, []byte(msg))
+}
+
+// send delivers the message over a single SMTP session.
+func (n *SMTPNotifier) send(ctx context.Context, to string, msg []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, n.timeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", n.addr)
+	if err != nil {
+		return fmt.Errorf("can't connect to smtp server: %v", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, n.hostname)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("can't start smtp session: %v", err)
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: n.hostname}); err != nil {
+			return fmt.Errorf("can't start tls: %v", err)
+		}
+	}
+
+	if n.auth != nil {
+		if err := c.Auth(n.auth); err != nil {
+			return fmt.Errorf("can't authenticate: %v", err)
+		}
+	}
+
+	if err := c.Mail(n.from); err != nil {
+		return fmt.Errorf("smtp MAIL: %v", err)
+	}
+	if err := c.Rcpt(to); err != nil {
+		return fmt.Errorf("smtp RCPT: %v", err)
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA: %v", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("can't write message: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("can't send message: %v", err)
+	}
+
+	return c.Quit()
+}
+
+// parseTemplate parses text, falling back to def if text is empty.
+func parseTemplate(name, text, def string) (*template.Template, error) {
+	if text == "" {
+		text = def
+	}
+
+	t, err := template.New(name).Funcs(_funcs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse smtp %s: %v", name, err)
+	}
+	return t, nil
+}
+
+// render executes the template with the notice.
+func render(t *template.Template, notice models.NewIPNotice) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, notice); err != nil {
+		return "", fmt.Errorf("can't render smtp %s: %v", t.Name(), err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
@@ -0,0 +1,143 @@
+package notifier
+
+import (
+	"bufio"
+	"context"
+	"go-jwt-auth/internal/config"
+	"go-jwt-auth/internal/models"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+// smtpStandIn is a minimal SMTP server accepting a single message.
+type smtpStandIn struct {
+	ln   net.Listener
+	rcpt chan string
+	data chan string
+}
+
+func newSMTPStandIn(t *testing.T) *smtpStandIn {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("can't listen: %v", err)
+	}
+
+	s := &smtpStandIn{ln: ln, rcpt: make(chan string, 1), data: make(chan string, 1)}
+	go s.serve()
+	t.Cleanup(func() { ln.Close() })
+
+	return s
+}
+
+func (s *smtpStandIn) serve() {
+	conn, err := s.ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := textproto.NewReader(bufio.NewReader(conn))
+	w := textproto.NewWriter(bufio.NewWriter(conn))
+
+	_ = w.PrintfLine("220 localhost ready")
+	for {
+		line, err := r.ReadLine()
+		if err != nil {
+			return
+		}
+
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch cmd {
+		case "EHLO", "HELO":
+			_ = w.PrintfLine("250 localhost")
+		case "RCPT":
+			s.rcpt <- strings.Trim(strings.TrimPrefix(line, "RCPT TO:"), "<>")
+			_ = w.PrintfLine("250 ok")
+		case "DATA":
+			_ = w.PrintfLine("354 go ahead")
+			lines, err := r.ReadDotLines()
+			if err != nil {
+				return
+			}
+			s.data <- strings.Join(lines, "\n")
+			_ = w.PrintfLine("250 queued")
+		case "QUIT":
+			_ = w.PrintfLine("221 bye")
+			return
+		default:
+			_ = w.PrintfLine("250 ok")
+		}
+	}
+}
+
+func TestSMTPNotifier_NotifyNewIP(t *testing.T) {
+	srv := newSMTPStandIn(t)
+
+	n, err := NewSMTPNotifier(config.SMTP{
+		Addr:     srv.ln.Addr().String(),
+		From:     "security@example.com",
+		To:       "{{.GUID}}@users.example.com",
+		Subject:  "New sign-in from {{.IP}}",
+		Template: "{{.PreviousIP}} -> {{.IP}}",
+	})
+	if err != nil {
+		t.Fatalf("NewSMTPNotifier() error = %v", err)
+	}
+
+	err = n.NotifyNewIP(context.Background(), models.NewIPNotice{
+		GUID:       "kwfwe",
+		PreviousIP: "10.0.0.1",
+		IP:         "10.0.0.2",
+	})
+	if err != nil {
+		t.Fatalf("NotifyNewIP() error = %v", err)
+	}
+
+	if rcpt := <-srv.rcpt; rcpt != "kwfwe@users.example.com" {
+		t.Errorf("rcpt = %v, want %v", rcpt, "kwfwe@users.example.com")
+	}
+
+	data := <-srv.data
+	for _, want := range []string{
+		"To: kwfwe@users.example.com",
+		"Subject: New sign-in from 10.0.0.2",
+		"10.0.0.1 -> 10.0.0.2",
+	} {
+		if !strings.Contains(data, want) {
+			t.Errorf("message doesn't contain %q:\n%s", want, data)
+		}
+	}
+}
+
+func TestNewSMTPNotifier(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    config.SMTP
+		wantErr bool
+	}{
+		{
+			name: "ok",
+			conf: config.SMTP{Addr: "127.0.0.1:25", To: "{{.GUID}}@example.com"},
+		},
+		{
+			name:    "badAddr",
+			conf:    config.SMTP{Addr: "127.0.0.1", To: "{{.GUID}}@example.com"},
+			wantErr: true,
+		},
+		{
+			name:    "badTemplate",
+			conf:    config.SMTP{Addr: "127.0.0.1:25", To: "{{.GUID"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewSMTPNotifier(tt.conf); (err != nil) != tt.wantErr {
+				t.Errorf("NewSMTPNotifier() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
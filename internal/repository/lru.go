@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"container/list"
+	"context"
+	"go-jwt-auth/internal/domains"
+	"go-jwt-auth/internal/metrics"
+	"go-jwt-auth/internal/models"
+	"sync"
+	"time"
+)
+
+const _defaultLRUTTL = 30 * time.Second
+
+// lruCache is an in-process read-through cache of the sessions of the
+// recently used GUIDs. The entries are invalidated when the sessions of the
+// GUID are saved or deleted through the cache, the TTL bounds how long the
+// changes made by the other replicas stay unseen.
+type lruCache struct {
+	domains.Repository
+
+	mu    sync.Mutex
+	size  int
+	ttl   time.Duration
+	now   func() time.Time
+	order *list.List
+	items map[string]*list.Element
+	// gen is incremented by every invalidation, the sessions read from the
+	// next repository aren't cached if it changed during the read.
+	gen uint64
+}
+
+type lruEntry struct {
+	guid    string
+	tokens  []models.TokenData
+	expires time.Time
+}
+
+func newLRUCache(next domains.Repository, size int, ttl time.Duration) *lruCache {
+	return &lruCache{
+		Repository: next,
+		size:       size,
+		ttl:        ttl,
+		now:        time.Now,
+		order:      list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+// GetTokensDataByGUID retrieves the sessions from the cache or the next repository.
+func (c *lruCache) GetTokensDataByGUID(ctx context.Context, guid string) ([]models.TokenData, error) {
+	if t, ok := c.get(guid); ok {
+		metrics.CacheRequests.WithLabelValues(metrics.CacheLRU, metrics.CacheHit).Inc()
+		return t, nil
+	}
+	metrics.CacheRequests.WithLabelValues(metrics.CacheLRU, metrics.CacheMiss).Inc()
+
+	c.mu.Lock()
+	gen := c.gen
+	c.mu.Unlock()
+
+	t, err := c.Repository.GetTokensDataByGUID(ctx, guid)
+	if err != nil {
+		return nil, err
+	}
+
+	c.put(gen, guid, t)
+	return t, nil
+}
+
+// SaveTokenData saves the session and invalidates the sessions of its GUID.
+func (c *lruCache) SaveTokenData(ctx context.Context, t models.TokenData) error {
+	defer c.Invalidate(t.GUID)
+	return c.Repository.SaveTokenData(ctx, t)
+}
+
+// DeleteTokenData deletes the session and invalidates the sessions of its GUID.
+func (c *lruCache) DeleteTokenData(ctx context.Context, guid, hash string) error {
+	defer c.Invalidate(guid)
+	return c.Repository.DeleteTokenData(ctx, guid, hash)
+}
+
+// PurgeExpired purges the expired sessions and drops the cache if it purged any,
+// the purged GUIDs aren't known.
+func (c *lruCache) PurgeExpired(ctx context.Context, now int64) (int64, error) {
+	purged, err := c.Repository.PurgeExpired(ctx, now)
+	if purged > 0 {
+		c.Invalidate(_allGUIDs)
+	}
+	return purged, err
+}
+
+// Invalidate drops the sessions of the GUID from the cache, all of them for _allGUIDs.
+func (c *lruCache) Invalidate(guid string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	if guid == _allGUIDs {
+		c.order.Init()
+		c.items = make(map[string]*list.Element)
+		return
+	}
+	if e, ok := c.items[guid]; ok {
+		c.remove(e)
+	}
+}
+
+func (c *lruCache) get(guid string) ([]models.TokenData, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.items[guid]
+	if !ok {
+		return nil, false
+	}
+
+	entry := e.Value.(*lruEntry)
+	if !c.now().Before(entry.expires) {
+		c.remove(e)
+		return nil, false
+	}
+
+	c.order.MoveToFront(e)
+	return append([]models.TokenData(nil), entry.tokens...), true
+}
+
+func (c *lruCache) put(gen uint64, guid string, t []models.TokenData) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.gen != gen {
+		return
+	}
+
+	entry := &lruEntry{
+		guid:    guid,
+		tokens:  append([]models.TokenData(nil), t...),
+		expires: c.now().Add(c.ttl),
+	}
+	if e, ok := c.items[guid]; ok {
+		e.Value = entry
+		c.order.MoveToFront(e)
+		return
+	}
+
+	c.items[guid] = c.order.PushFront(entry)
+	if c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *lruCache) remove(e *list.Element) {
+	c.order.Remove(e)
+	delete(c.items, e.Value.(*lruEntry).guid)
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains/mocks"
+	"go-jwt-auth/internal/models"
+	"gotest.tools/v3/assert"
+	"testing"
+	"time"
+)
+
+func TestLRUCache_GetTokensDataByGUID(t *testing.T) {
+	ctx := context.Background()
+	ikj := []models.TokenData{{GUID: "ikj", RefreshHash: "qjfwjnqk"}}
+	kwfwe := []models.TokenData{{GUID: "kwfwe", RefreshHash: "ebfkqbfb"}}
+	qkefkq := []models.TokenData{{GUID: "qkefkq", RefreshHash: "hwejhvqvf"}}
+
+	next := mocks.NewRepository(t)
+	next.On("GetTokensDataByGUID", ctx, "ikj").Return(ikj, nil).Twice()
+	next.On("GetTokensDataByGUID", ctx, "kwfwe").Return(kwfwe, nil).Once()
+	next.On("GetTokensDataByGUID", ctx, "qkefkq").Return(qkefkq, nil).Once()
+	next.On("GetTokensDataByGUID", ctx, "lkmlkwe").Return(nil, constants.ErrNotFound).Twice()
+
+	c := newLRUCache(next, 2, time.Minute)
+
+	for _, want := range [][]models.TokenData{ikj, ikj, kwfwe, kwfwe} {
+		got, err := c.GetTokensDataByGUID(ctx, want[0].GUID)
+		if err != nil {
+			t.Fatalf("GetTokensDataByGUID() error = %v", err)
+		}
+		assert.DeepEqual(t, want, got)
+	}
+
+	// evicts ikj, the least recently used.
+	if _, err := c.GetTokensDataByGUID(ctx, "qkefkq"); err != nil {
+		t.Fatalf("GetTokensDataByGUID() error = %v", err)
+	}
+	if _, err := c.GetTokensDataByGUID(ctx, "ikj"); err != nil {
+		t.Fatalf("GetTokensDataByGUID() error = %v", err)
+	}
+
+	// misses aren't cached.
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetTokensDataByGUID(ctx, "lkmlkwe"); err != constants.ErrNotFound {
+			t.Fatalf("GetTokensDataByGUID() error = %v, want %v", err, constants.ErrNotFound)
+		}
+	}
+}
+
+func TestLRUCache_Invalidation(t *testing.T) {
+	ctx := context.Background()
+	before := []models.TokenData{{GUID: "ikj", RefreshHash: "qjfwjnqk"}}
+	after := []models.TokenData{{GUID: "ikj", RefreshHash: "ebfkqbfb"}}
+
+	next := mocks.NewRepository(t)
+	next.On("GetTokensDataByGUID", ctx, "ikj").Return(before, nil).Once()
+	next.On("DeleteTokenData", ctx, "ikj", "qjfwjnqk").Return(nil).Once()
+	next.On("SaveTokenData", ctx, after[0]).Return(nil).Once()
+	next.On("GetTokensDataByGUID", ctx, "ikj").Return(after, nil).Once()
+
+	c := newLRUCache(next, 10, time.Minute)
+
+	got, err := c.GetTokensDataByGUID(ctx, "ikj")
+	if err != nil {
+		t.Fatalf("GetTokensDataByGUID() error = %v", err)
+	}
+	assert.DeepEqual(t, before, got)
+
+	if err := c.DeleteTokenData(ctx, "ikj", "qjfwjnqk"); err != nil {
+		t.Fatalf("DeleteTokenData() error = %v", err)
+	}
+	if err := c.SaveTokenData(ctx, after[0]); err != nil {
+		t.Fatalf("SaveTokenData() error = %v", err)
+	}
+
+	got, err = c.GetTokensDataByGUID(ctx, "ikj")
+	if err != nil {
+		t.Fatalf("GetTokensDataByGUID() error = %v", err)
+	}
+	assert.DeepEqual(t, after, got)
+}
+
+func TestLRUCache_TTL(t *testing.T) {
+	ctx := context.Background()
+	tokens := []models.TokenData{{GUID: "ikj", RefreshHash: "qjfwjnqk"}}
+
+	next := mocks.NewRepository(t)
+	next.On("GetTokensDataByGUID", ctx, "ikj").Return(tokens, nil).Twice()
+
+	now := time.Unix(1000, 0)
+	c := newLRUCache(next, 10, time.Minute)
+	c.now = func() time.Time { return now }
+
+	for _, elapsed := range []time.Duration{0, 30 * time.Second, time.Minute} {
+		now = now.Add(elapsed)
+		if _, err := c.GetTokensDataByGUID(ctx, "ikj"); err != nil {
+			t.Fatalf("GetTokensDataByGUID() error = %v", err)
+		}
+	}
+}
+
+func TestLRUCache_InvalidatedDuringRead(t *testing.T) {
+	ctx := context.Background()
+	stale := []models.TokenData{{GUID: "ikj", RefreshHash: "qjfwjnqk"}}
+
+	next := mocks.NewRepository(t)
+	c := newLRUCache(next, 10, time.Minute)
+
+	// the session is consumed while its old state is being read.
+	next.EXPECT().GetTokensDataByGUID(ctx, "ikj").
+		Run(func(context.Context, string) { c.Invalidate("ikj") }).
+		Return(stale, nil).Once()
+	next.On("GetTokensDataByGUID", ctx, "ikj").Return(nil, constants.ErrNotFound).Once()
+
+	if _, err := c.GetTokensDataByGUID(ctx, "ikj"); err != nil {
+		t.Fatalf("GetTokensDataByGUID() error = %v", err)
+	}
+	if _, err := c.GetTokensDataByGUID(ctx, "ikj"); err != constants.ErrNotFound {
+		t.Errorf("GetTokensDataByGUID() error = %v, want %v", err, constants.ErrNotFound)
+	}
+}
+
+func TestLRUCache_PurgeExpired(t *testing.T) {
+	ctx := context.Background()
+	tokens := []models.TokenData{{GUID: "ikj", RefreshHash: "qjfwjnqk", RefreshExp: 100}}
+
+	next := mocks.NewRepository(t)
+	next.On("GetTokensDataByGUID", ctx, "ikj").Return(tokens, nil).Once()
+	next.On("PurgeExpired", ctx, int64(200)).Return(int64(0), nil).Once()
+	next.On("PurgeExpired", ctx, int64(200)).Return(int64(1), nil).Once()
+	next.On("GetTokensDataByGUID", ctx, "ikj").Return(nil, constants.ErrNotFound).Once()
+
+	c := newLRUCache(next, 10, time.Minute)
+	if _, err := c.GetTokensDataByGUID(ctx, "ikj"); err != nil {
+		t.Fatalf("GetTokensDataByGUID() error = %v", err)
+	}
+
+	// nothing purged, the cache is kept.
+	if _, err := c.PurgeExpired(ctx, 200); err != nil {
+		t.Fatalf("PurgeExpired() error = %v", err)
+	}
+	if _, err := c.GetTokensDataByGUID(ctx, "ikj"); err != nil {
+		t.Fatalf("GetTokensDataByGUID() error = %v", err)
+	}
+
+	if _, err := c.PurgeExpired(ctx, 200); err != nil {
+		t.Fatalf("PurgeExpired() error = %v", err)
+	}
+	if _, err := c.GetTokensDataByGUID(ctx, "ikj"); err != constants.ErrNotFound {
+		t.Errorf("GetTokensDataByGUID() of a purged session error = %v, want %v", err, constants.ErrNotFound)
+	}
+}
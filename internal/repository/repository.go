@@ -1,15 +1,25 @@
 // Package repository for the possibility of using a large number of
 // databases in the future through a single abstraction.
+//
+// The repository is a chain of decorators around the database, e.g. the
+// caches of the sessions.
 package repository
 
 import (
+	"context"
+	"fmt"
+	"go-jwt-auth/internal/constants"
 	"go-jwt-auth/internal/domains"
 	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/models"
 	"go.uber.org/fx"
+	"time"
 )
 
 type repository struct {
 	domains.Database
+	domains.ClientStorage
+	domains.CodeStorage
 	logger lib.Logger
 }
 
@@ -17,9 +27,101 @@ var Module = fx.Options(
 	fx.Provide(NewRepository),
 )
 
-func NewRepository(db domains.Database, lg lib.Logger) (domains.Repository, error) {
-	return &repository{
-		Database: db,
-		logger:   lg,
-	}, nil
+// Decorator wraps a repository to add behaviour to it. A decorator embeds
+// the repository it wraps, so it only implements the methods it changes.
+type Decorator func(next domains.Repository) domains.Repository
+
+// Chain wraps the repository with the decorators, the last one is the outermost.
+func Chain(repo domains.Repository, decorators ...Decorator) domains.Repository {
+	for _, d := range decorators {
+		repo = d(repo)
+	}
+	return repo
+}
+
+func NewRepository(lc fx.Lifecycle, db domains.Database, lg lib.Logger, conf lib.Config) (domains.Repository, error) {
+	clients, ok := db.(domains.ClientStorage)
+	if !ok {
+		return nil, fmt.Errorf("client registry: %w", constants.ErrNotSupported)
+	}
+	codes, ok := db.(domains.CodeStorage)
+	if !ok {
+		return nil, fmt.Errorf("authorization codes: %w", constants.ErrNotSupported)
+	}
+
+	var (
+		decorators []Decorator
+		shared     *sharedCache
+	)
+
+	if conf.Cache.SharedDSN != "" {
+		ttl, err := durationOr(conf.Cache.SharedTTL, _defaultSharedTTL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid shared cache ttl: %v", err)
+		}
+
+		shared, err = newSharedCache(conf.Cache.SharedDSN, ttl, lg)
+		if err != nil {
+			return nil, err
+		}
+		lc.Append(fx.Hook{
+			OnStop: func(context.Context) error {
+				return shared.Close()
+			},
+		})
+
+		decorators = append(decorators, shared.decorate)
+	}
+
+	if conf.Cache.Size > 0 {
+		ttl, err := durationOr(conf.Cache.TTL, _defaultLRUTTL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cache ttl: %v", err)
+		}
+
+		decorators = append(decorators, func(next domains.Repository) domains.Repository {
+			lru := newLRUCache(next, conf.Cache.Size, ttl)
+			if shared != nil {
+				// the sessions changed by the other replicas.
+				shared.Subscribe(lru.Invalidate)
+			}
+			return lru
+		})
+	}
+
+	return Chain(&repository{Database: db, ClientStorage: clients, CodeStorage: codes, logger: lg}, decorators...), nil
+}
+
+// ScanTokenData goes through the sessions of the database, if it can.
+func (r *repository) ScanTokenData(ctx context.Context, fn func(models.TokenData) error) error {
+	scanner, ok := r.Database.(domains.TokenDataScanner)
+	if !ok {
+		return fmt.Errorf("scanning sessions: %w", constants.ErrNotSupported)
+	}
+	return scanner.ScanTokenData(ctx, fn)
+}
+
+// PurgeExpired purges the expired sessions of the database, if it doesn't expire them natively.
+func (r *repository) PurgeExpired(ctx context.Context, now int64) (int64, error) {
+	purger, ok := r.Database.(domains.ExpiredPurger)
+	if !ok {
+		return 0, fmt.Errorf("purging sessions: %w", constants.ErrNotSupported)
+	}
+	return purger.PurgeExpired(ctx, now)
+}
+
+// durationOr parses s, it returns def if s is empty.
+func durationOr(s string, def time.Duration) (time.Duration, error) {
+	if s == "" {
+		return def, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("%s must be positive", s)
+	}
+	return d, nil
 }
@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	goredis "github.com/redis/go-redis/v9"
+	"go-jwt-auth/internal/domains"
+	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/metrics"
+	"go-jwt-auth/internal/models"
+	"go.uber.org/zap"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	_defaultSharedTTL = 5 * time.Minute
+
+	_cachePrefix = "jwt-auth:cache:"
+	// _invalidations is the channel the replicas publish the changed GUIDs to,
+	// _allGUIDs when the sessions of any GUID may have changed.
+	_invalidations = _cachePrefix + "invalidations"
+	_allGUIDs      = ""
+	// _scanCount is the number of keys asked for by every SCAN of the cached sessions.
+	_scanCount = 1000
+)
+
+// _fill caches the sessions unless they were invalidated since the version was read.
+//
+// KEYS[1] - sessions, KEYS[2] - version.
+// ARGV[1] - version read before the sessions, ARGV[2] - sessions, ARGV[3] - ttl (ms).
+var _fill = goredis.NewScript(`
+local version = redis.call('GET', KEYS[2]) or ''
+if version ~= ARGV[1] then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+return 1
+`)
+
+// sharedCache is a read-through cache of the sessions in redis shared by
+// the replicas. Every change is published, so the replicas drop the
+// sessions from their in-process caches.
+//
+// The cache is best effort: when redis fails, the sessions are read from
+// the next repository.
+type sharedCache struct {
+	domains.Repository
+
+	client *goredis.Client
+	ttl    time.Duration
+	logger lib.Logger
+
+	mu   sync.Mutex
+	subs []*goredis.PubSub
+	wg   sync.WaitGroup
+}
+
+func newSharedCache(dsn string, ttl time.Duration, logger lib.Logger) (*sharedCache, error) {
+	opt, err := goredis.ParseURL(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse shared cache dsn: %v", err)
+	}
+
+	return &sharedCache{
+		client: goredis.NewClient(opt),
+		ttl:    ttl,
+		logger: logger,
+	}, nil
+}
+
+func (c *sharedCache) decorate(next domains.Repository) domains.Repository {
+	c.Repository = next
+	return c
+}
+
+// GetTokensDataByGUID retrieves the sessions from the cache or the next repository.
+func (c *sharedCache) GetTokensDataByGUID(ctx context.Context, guid string) ([]models.TokenData, error) {
+	values, err := c.client.MGet(ctx, sessionsKey(guid), versionKey(guid)).Result()
+	if err != nil {
+		c.logger.Warn("can't read shared cache", zap.Error(err))
+		metrics.CacheRequests.WithLabelValues(metrics.CacheShared, metrics.CacheMiss).Inc()
+		return c.Repository.GetTokensDataByGUID(ctx, guid)
+	}
+
+	if data, ok := values[0].(string); ok {
+		var t []models.TokenData
+		if err := json.Unmarshal([]byte(data), &t); err == nil {
+			metrics.CacheRequests.WithLabelValues(metrics.CacheShared, metrics.CacheHit).Inc()
+			return t, nil
+		}
+	}
+	metrics.CacheRequests.WithLabelValues(metrics.CacheShared, metrics.CacheMiss).Inc()
+
+	version, _ := values[1].(string)
+
+	t, err := c.Repository.GetTokensDataByGUID(ctx, guid)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(t)
+	if err != nil {
+		return nil, fmt.Errorf("can't marshal tokens: %v", err)
+	}
+
+	keys := []string{sessionsKey(guid), versionKey(guid)}
+	if err := _fill.Run(ctx, c.client, keys, version, data, c.ttl.Milliseconds()).Err(); err != nil {
+		c.logger.Warn("can't fill shared cache", zap.Error(err))
+	}
+
+	return t, nil
+}
+
+// SaveTokenData saves the session and invalidates the sessions of its GUID.
+func (c *sharedCache) SaveTokenData(ctx context.Context, t models.TokenData) error {
+	defer c.invalidate(ctx, t.GUID)
+	return c.Repository.SaveTokenData(ctx, t)
+}
+
+// DeleteTokenData deletes the session and invalidates the sessions of its GUID.
+func (c *sharedCache) DeleteTokenData(ctx context.Context, guid, hash string) error {
+	defer c.invalidate(ctx, guid)
+	return c.Repository.DeleteTokenData(ctx, guid, hash)
+}
+
+// PurgeExpired purges the expired sessions and drops all the cached sessions
+// if it purged any, the purged GUIDs aren't known.
+func (c *sharedCache) PurgeExpired(ctx context.Context, now int64) (int64, error) {
+	purged, err := c.Repository.PurgeExpired(ctx, now)
+	if purged > 0 {
+		c.invalidateAll(ctx)
+	}
+	return purged, err
+}
+
+// invalidateAll drops all the cached sessions like invalidate, and tells the
+// replicas to drop theirs.
+func (c *sharedCache) invalidateAll(ctx context.Context) {
+	iter := c.client.Scan(ctx, 0, sessionsKey("*"), _scanCount).Iterator()
+	pipe := c.client.TxPipeline()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		guid := strings.TrimSuffix(strings.TrimPrefix(key, _cachePrefix+"{"), "}:sessions")
+		pipe.Incr(ctx, versionKey(guid))
+		pipe.PExpire(ctx, versionKey(guid), 2*c.ttl)
+		pipe.Del(ctx, key)
+
+		if pipe.Len() >= 3*_scanCount {
+			if _, err := pipe.Exec(ctx); err != nil {
+				c.logger.Error("can't invalidate shared cache", zap.Error(err))
+			}
+		}
+	}
+	if err := iter.Err(); err != nil {
+		c.logger.Error("can't scan shared cache", zap.Error(err))
+	}
+
+	pipe.Publish(ctx, _invalidations, _allGUIDs)
+	if _, err := pipe.Exec(ctx); err != nil {
+		c.logger.Error("can't invalidate shared cache", zap.Error(err))
+	}
+}
+
+// invalidate drops the sessions of the GUID from the cache and tells the
+// replicas about it. The version outlives the cached sessions, so a read
+// started before the invalidation can't cache the old sessions.
+func (c *sharedCache) invalidate(ctx context.Context, guid string) {
+	_, err := c.client.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		pipe.Incr(ctx, versionKey(guid))
+		pipe.PExpire(ctx, versionKey(guid), 2*c.ttl)
+		pipe.Del(ctx, sessionsKey(guid))
+		pipe.Publish(ctx, _invalidations, guid)
+		return nil
+	})
+	if err != nil {
+		c.logger.Error("can't invalidate shared cache", zap.String("guid", guid), zap.Error(err))
+	}
+}
+
+// Subscribe calls fn with the GUIDs invalidated by any replica until Close is
+// called, with _allGUIDs if the sessions of any GUID may have changed.
+func (c *sharedCache) Subscribe(fn func(guid string)) {
+	sub := c.client.Subscribe(context.Background(), _invalidations)
+
+	c.mu.Lock()
+	c.subs = append(c.subs, sub)
+	c.mu.Unlock()
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		for msg := range sub.Channel() {
+			fn(msg.Payload)
+		}
+	}()
+}
+
+// Close stops the subscriptions and closes the client.
+func (c *sharedCache) Close() error {
+	c.mu.Lock()
+	for _, sub := range c.subs {
+		sub.Close()
+	}
+	c.mu.Unlock()
+	c.wg.Wait()
+
+	return c.client.Close()
+}
+
+func sessionsKey(guid string) string {
+	return _cachePrefix + "{" + guid + "}:sessions"
+}
+
+func versionKey(guid string) string {
+	return _cachePrefix + "{" + guid + "}:version"
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"go-jwt-auth/internal/config"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains"
+	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/models"
+	"go-jwt-auth/internal/storage/memory"
+	"go.uber.org/fx/fxtest"
+	"testing"
+	"time"
+)
+
+// newReplica creates the repository of a replica, the replicas share the database and the cache.
+func newReplica(t *testing.T, db domains.Database, dsn string) domains.Repository {
+	logger, err := lib.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create Logger instance: %v", err)
+	}
+
+	lc := fxtest.NewLifecycle(t)
+	t.Cleanup(lc.RequireStop)
+
+	repo, err := NewRepository(lc, db, logger, lib.Config{Cache: config.Cache{
+		Size:      10,
+		TTL:       "1h",
+		SharedDSN: dsn,
+		SharedTTL: "1h",
+	}})
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	return repo
+}
+
+func TestSharedCache_Invalidation(t *testing.T) {
+	mr := miniredis.RunT(t)
+	dsn := "redis://" + mr.Addr()
+
+	ctx := context.Background()
+	db := memory.New()
+	session := models.TokenData{GUID: "ikj", RefreshHash: "qjfwjnqk"}
+	if err := db.SaveTokenData(ctx, session); err != nil {
+		t.Fatalf("SaveTokenData() error = %v", err)
+	}
+
+	a, b := newReplica(t, db, dsn), newReplica(t, db, dsn)
+
+	for _, r := range []domains.Repository{a, b} {
+		if _, err := r.GetTokensDataByGUID(ctx, "ikj"); err != nil {
+			t.Fatalf("GetTokensDataByGUID() error = %v", err)
+		}
+	}
+	if !mr.Exists(sessionsKey("ikj")) {
+		t.Fatalf("the sessions aren't in the shared cache")
+	}
+
+	// b consumes the session, a must stop seeing it.
+	if err := b.DeleteTokenData(ctx, "ikj", "qjfwjnqk"); err != nil {
+		t.Fatalf("DeleteTokenData() error = %v", err)
+	}
+	if mr.Exists(sessionsKey("ikj")) {
+		t.Errorf("the sessions are still in the shared cache")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		_, err := a.GetTokensDataByGUID(ctx, "ikj")
+		if err == constants.ErrNotFound {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("GetTokensDataByGUID() of a consumed session error = %v, want %v", err, constants.ErrNotFound)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSharedCache_Unavailable(t *testing.T) {
+	mr := miniredis.RunT(t)
+	dsn := "redis://" + mr.Addr()
+
+	ctx := context.Background()
+	db := memory.New()
+	if err := db.SaveTokenData(ctx, models.TokenData{GUID: "ikj", RefreshHash: "qjfwjnqk"}); err != nil {
+		t.Fatalf("SaveTokenData() error = %v", err)
+	}
+
+	repo := newReplica(t, db, dsn)
+	mr.Close()
+
+	// the cache is best effort, the sessions are read from the database.
+	if _, err := repo.GetTokensDataByGUID(ctx, "ikj"); err != nil {
+		t.Errorf("GetTokensDataByGUID() error = %v", err)
+	}
+}
+
+func TestSharedCache_PurgeExpired(t *testing.T) {
+	mr := miniredis.RunT(t)
+	dsn := "redis://" + mr.Addr()
+
+	ctx := context.Background()
+	db := memory.New()
+	if err := db.SaveTokenData(ctx, models.TokenData{GUID: "ikj", RefreshHash: "qjfwjnqk", RefreshExp: 100}); err != nil {
+		t.Fatalf("SaveTokenData() error = %v", err)
+	}
+
+	a, b := newReplica(t, db, dsn), newReplica(t, db, dsn)
+	for _, r := range []domains.Repository{a, b} {
+		if _, err := r.GetTokensDataByGUID(ctx, "ikj"); err != nil {
+			t.Fatalf("GetTokensDataByGUID() error = %v", err)
+		}
+	}
+
+	// b purges the session, a must stop seeing it.
+	if purged, err := b.PurgeExpired(ctx, 200); err != nil || purged != 1 {
+		t.Fatalf("PurgeExpired() = %v, %v, want 1", purged, err)
+	}
+	if mr.Exists(sessionsKey("ikj")) {
+		t.Errorf("the sessions are still in the shared cache")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		_, err := a.GetTokensDataByGUID(ctx, "ikj")
+		if err == constants.ErrNotFound {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("GetTokensDataByGUID() of a purged session error = %v, want %v", err, constants.ErrNotFound)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
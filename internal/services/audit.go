@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains"
+	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/models"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	_defaultCheckpointEvery = 100
+	_auditAppendAttempts    = 5
+	_auditBatchSize         = 500
+)
+
+// AuditService keeps a tamper-evident trail of token events.
+// Every record contains the hash of the previous one, and every
+// checkpointEvery records the hash is signed with the service key, so
+// rewriting the history requires the key and not only write access to
+// the storage.
+type AuditService struct {
+	storage         domains.AuditStorage
+	logger          lib.Logger
+	key             []byte
+	enabled         bool
+	checkpointEvery int64
+
+	mu sync.Mutex
+}
+
+// NewAuditService creates a new instance of AuditService.
+func NewAuditService(st domains.AuditStorage, logger lib.Logger, conf lib.Config) domains.Auditor {
+	checkpointEvery := conf.Audit.CheckpointEvery
+	if checkpointEvery <= 0 {
+		checkpointEvery = _defaultCheckpointEvery
+	}
+
+	return &AuditService{
+		storage:         st,
+		logger:          logger,
+		key:             []byte(conf.Audit.Key),
+		enabled:         conf.Audit.Enabled,
+		checkpointEvery: checkpointEvery,
+	}
+}
+
+// HandleEvent appends the event to the end of the chain.
+func (a *AuditService) HandleEvent(ctx context.Context, e models.Event) error {
+	if !a.enabled {
+		return nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for i := 0; i < _auditAppendAttempts; i++ {
+		last, err := a.storage.LastAuditRecord(ctx)
+		if err != nil && !errors.Is(err, constants.ErrNotFound) {
+			return err
+		}
+
+		err = a.storage.AppendAuditRecord(ctx, a.next(last, e))
+		if errors.Is(err, constants.ErrAlreadyExists) {
+			// another replica has taken this position, link to its record.
+			continue
+		}
+		return err
+	}
+
+	return constants.ErrAuditConflict
+}
+
+// Verify walks the chain from the first record and reports the first broken link.
+// Every checkpointEvery record must carry a valid checkpoint, a chain rehashed
+// without them is broken. Records appended after the last checkpoint are only
+// protected by the hashes.
+func (a *AuditService) Verify(ctx context.Context) (report models.AuditReport, err error) {
+	var prev models.AuditRecord
+	for {
+		records, err := a.storage.GetAuditRecords(ctx, prev.Seq+1, _auditBatchSize)
+		if err != nil {
+			return report, err
+		}
+
+		for _, r := range records {
+			if r.Seq != prev.Seq+1 {
+				report.BrokenSeq, report.Reason = prev.Seq+1, "record is missing"
+				return report, nil
+			}
+			if reason := a.checkLink(prev, r); reason != "" {
+				report.BrokenSeq, report.Reason = r.Seq, reason
+				return report, nil
+			}
+			if r.Checkpoint != "" {
+				report.Checkpoints++
+			}
+
+			report.Checked++
+			prev = r
+		}
+
+		if len(records) < _auditBatchSize {
+			return report, nil
+		}
+	}
+}
+
+// next builds the record following prev.
+func (a *AuditService) next(prev models.AuditRecord, e models.Event) models.AuditRecord {
+	r := models.AuditRecord{
+		Seq:      prev.Seq + 1,
+		Event:    e.Type,
+		GUID:     e.GUID,
+		ClientID: e.ClientID,
+		Time:     e.Time,
+		PrevHash: prev.Hash,
+	}
+	r.Hash = auditHash(r)
+
+	if r.Seq%a.checkpointEvery == 0 {
+		r.Checkpoint = a.sign(r.Hash)
+	}
+
+	return r
+}
+
+// checkLink returns the reason why r can't follow prev, or an empty string.
+func (a *AuditService) checkLink(prev, r models.AuditRecord) string {
+	switch {
+	case r.PrevHash != prev.Hash:
+		return "previous hash mismatch"
+	case r.Hash != auditHash(r):
+		return "hash mismatch"
+	case r.Checkpoint == "" && r.Seq%a.checkpointEvery == 0:
+		return "checkpoint is missing"
+	case r.Checkpoint != "" && !hmac.Equal([]byte(r.Checkpoint), []byte(a.sign(r.Hash))):
+		return "invalid checkpoint signature"
+	}
+	return ""
+}
+
+// sign signs the hash with the service key.
+func (a *AuditService) sign(hash string) string {
+	mac := hmac.New(sha512.New, a.key)
+	mac.Write([]byte(hash))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// auditHash calculates the hash of the record content and the previous hash.
+// The client is only hashed when set, so the records written before it keep their hash.
+func auditHash(r models.AuditRecord) string {
+	fields := []string{
+		strconv.FormatInt(r.Seq, 10),
+		string(r.Event),
+		r.GUID,
+		strconv.FormatInt(r.Time, 10),
+		r.PrevHash,
+	}
+	if r.ClientID != "" {
+		fields = append(fields, r.ClientID)
+	}
+	content := strings.Join(fields, "|")
+
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
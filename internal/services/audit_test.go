@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains/mocks"
+	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/models"
+	"testing"
+)
+
+func TestAuditService_HandleEvent(t *testing.T) {
+	logger, err := lib.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create Logger instance: %v", err)
+	}
+
+	a := &AuditService{
+		logger:          logger,
+		key:             []byte("123"),
+		enabled:         true,
+		checkpointEvery: 2,
+	}
+
+	prev := a.next(models.AuditRecord{}, models.Event{Type: models.EventIssued, GUID: "kwfwe", Time: 1})
+	e := models.Event{Type: models.EventRefreshed, GUID: "kwfwe", Time: 2}
+
+	st := mocks.NewAuditStorage(t)
+	st.On("LastAuditRecord", _contextType).Return(prev, nil)
+	st.On("AppendAuditRecord", _contextType, a.next(prev, e)).
+		Return(constants.ErrAlreadyExists).Once()
+	st.On("AppendAuditRecord", _contextType, a.next(prev, e)).
+		Return(nil).Once()
+	a.storage = st
+
+	if err := a.HandleEvent(context.Background(), e); err != nil {
+		t.Errorf("HandleEvent() error = %v", err)
+	}
+}
+
+func TestAuditService_Verify(t *testing.T) {
+	tests := []struct {
+		name       string
+		tamper     func(chain []models.AuditRecord) []models.AuditRecord
+		wantBroken int64
+	}{
+		{
+			name:   "ok",
+			tamper: func(chain []models.AuditRecord) []models.AuditRecord { return chain },
+		},
+		{
+			name: "editedGUID",
+			tamper: func(chain []models.AuditRecord) []models.AuditRecord {
+				chain[2].GUID = "qkefkq"
+				return chain
+			},
+			wantBroken: 3,
+		},
+		{
+			name: "rehashedChain",
+			tamper: func(chain []models.AuditRecord) []models.AuditRecord {
+				chain[1].GUID = "qkefkq"
+				chain[1].Hash = auditHash(chain[1])
+				for i := 2; i < len(chain); i++ {
+					chain[i].PrevHash = chain[i-1].Hash
+					chain[i].Hash = auditHash(chain[i])
+				}
+				return chain
+			},
+			wantBroken: 4,
+		},
+		{
+			name: "rehashedWithoutCheckpoints",
+			tamper: func(chain []models.AuditRecord) []models.AuditRecord {
+				chain[1].GUID = "qkefkq"
+				chain[1].Hash = auditHash(chain[1])
+				for i := 2; i < len(chain); i++ {
+					chain[i].PrevHash = chain[i-1].Hash
+					chain[i].Hash = auditHash(chain[i])
+					chain[i].Checkpoint = ""
+				}
+				return chain
+			},
+			wantBroken: 4,
+		},
+		{
+			name: "deleted",
+			tamper: func(chain []models.AuditRecord) []models.AuditRecord {
+				return append(chain[:1], chain[2:]...)
+			},
+			wantBroken: 2,
+		},
+	}
+
+	logger, err := lib.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create Logger instance: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &AuditService{
+				logger:          logger,
+				key:             []byte("123"),
+				enabled:         true,
+				checkpointEvery: 4,
+			}
+
+			var chain []models.AuditRecord
+			var prev models.AuditRecord
+			for i := 0; i < 6; i++ {
+				prev = a.next(prev, models.Event{Type: models.EventIssued, GUID: "ikj", Time: int64(i)})
+				chain = append(chain, prev)
+			}
+			chain = tt.tamper(chain)
+
+			st := mocks.NewAuditStorage(t)
+			st.On("GetAuditRecords", _contextType, int64(1), int64(_auditBatchSize)).
+				Return(chain, nil).Maybe()
+			a.storage = st
+
+			report, err := a.Verify(context.Background())
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if report.BrokenSeq != tt.wantBroken {
+				t.Errorf("Verify() broken = %v (%s), want %v", report.BrokenSeq, report.Reason, tt.wantBroken)
+			}
+		})
+	}
+}
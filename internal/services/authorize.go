@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/models"
+	"go-jwt-auth/internal/signing"
+	"go.uber.org/zap"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	_defaultCodeTTL = time.Minute
+	_codeBytes      = 32
+)
+
+var (
+	// _codeChallenge is a base64url SHA-256, see RFC 7636 4.2.
+	_codeChallenge = regexp.MustCompile(`^[A-Za-z0-9_-]{43}$`)
+	// _codeVerifier is the code_verifier syntax of RFC 7636 4.1.
+	_codeVerifier = regexp.MustCompile(`^[A-Za-z0-9._~-]{43,128}$`)
+)
+
+// Authorize issues a one-time authorization code of the user to the client of
+// the request, see RFC 6749 4.1. The code is bound to the PKCE code challenge,
+// only its hash is stored.
+func (tm *TokenManager) Authorize(ctx context.Context, r models.AuthorizationRequest) (string, error) {
+	client, ok := lib.Client(ctx)
+	if !ok {
+		return "", constants.ErrInvalidClient
+	}
+	if !client.Allows(models.GrantTypeAuthorizationCode) {
+		return "", constants.ErrUnauthorizedClient
+	}
+	if !validSubject(r.GUID) {
+		return "", constants.ErrInvalidGUID
+	}
+	if r.CodeChallengeMethod != models.CodeChallengeS256 || !_codeChallenge.MatchString(r.CodeChallenge) {
+		return "", constants.ErrInvalidCodeChallenge
+	}
+
+	scope := strings.Join(strings.Fields(r.Scope), " ")
+	if scope == "" {
+		scope = strings.Join(client.Scopes, " ")
+	}
+	if !scopeWithin(scope, client.Scopes) {
+		return "", constants.ErrInvalidScope
+	}
+
+	tenant, err := tm.tenant(ctx)
+	if err != nil {
+		return "", err
+	}
+	if scopeWithin(models.ScopeOpenID, strings.Fields(scope)) && !signing.SignsIDTokens(tenant) {
+		// the clients couldn't check the ID tokens, see signing.SignsIDTokens.
+		return "", constants.ErrInvalidScope
+	}
+
+	raw := make([]byte, _codeBytes)
+	if _, err := rand.Read(raw); err != nil {
+		tm.logger.Error("can't generate code", zap.Error(err))
+		return "", errors.Join(constants.ErrGenerate, err)
+	}
+	code := base64.RawURLEncoding.EncodeToString(raw)
+
+	if err := tm.repository.SaveCode(ctx, models.AuthorizationCode{
+		Hash:          codeHash(code),
+		Tenant:        tenant.ID,
+		ClientID:      client.ID,
+		RedirectURI:   r.RedirectURI,
+		GUID:          r.GUID,
+		Scope:         scope,
+		CodeChallenge: r.CodeChallenge,
+		Nonce:         r.Nonce,
+		AuthTime:      time.Now().Unix(),
+		ExpiresAt:     time.Now().Add(tm.codeTTL).Unix(),
+	}); err != nil {
+		tm.logger.Error("can't save code", zap.Error(err))
+		return "", constants.ErrRepository
+	}
+
+	return code, nil
+}
+
+// authorizationCode exchanges the authorization code for a pair of the user
+// of the code, see RFC 6749 4.1.3. The code is consumed even if the request
+// turns out to be invalid.
+func (tm *TokenManager) authorizationCode(
+	ctx context.Context,
+	tenant models.Tenant,
+	client models.Client,
+	r models.GrantRequest,
+) (t models.Tokens, err error) {
+
+	if r.Code == "" || r.CodeVerifier == "" {
+		return t, constants.ErrMissingCode
+	}
+
+	code, err := tm.repository.ConsumeCode(ctx, codeHash(r.Code))
+	if err != nil {
+		if errors.Is(err, constants.ErrNotFound) {
+			return t, constants.ErrInvalidCode
+		}
+		tm.logger.Error("can't consume code", zap.Error(err))
+		return t, constants.ErrRepository
+	}
+
+	switch {
+	case code.ExpiresAt < time.Now().Unix():
+		tm.logger.Debug("expired code", zap.String("client", client.ID))
+		return t, constants.ErrInvalidCode
+	case code.Tenant != tenant.ID || code.ClientID != client.ID:
+		tm.logger.Warn("code of another client", zap.String("client", client.ID), zap.String("code_client", code.ClientID))
+		return t, constants.ErrInvalidCode
+	case code.RedirectURI != "" && code.RedirectURI != r.RedirectURI:
+		tm.logger.Debug("redirect_uri mismatch", zap.String("client", client.ID))
+		return t, constants.ErrInvalidCode
+	case !verifyCodeChallenge(code.CodeChallenge, r.CodeVerifier):
+		tm.logger.Debug("code_verifier mismatch", zap.String("client", client.ID))
+		return t, constants.ErrInvalidCode
+	}
+
+	openID := scopeWithin(models.ScopeOpenID, strings.Fields(code.Scope))
+	if openID && !signing.SignsIDTokens(tenant) {
+		// the active key was rotated to an hmac one since the code was issued.
+		return t, constants.ErrInvalidScope
+	}
+	issuer := tenant.OIDCIssuer(lib.BaseURL(ctx))
+	if openID && issuer == "" {
+		// the clients can't check the ID tokens without it, see lib.Config.PublicURL.
+		tm.logger.Error("no issuer for the id token", zap.String("tenant", tenant.ID))
+		return t, constants.ErrGenerate
+	}
+
+	access, refresh, exp, err := tm.issue(ctx, tenant, code.GUID, code.Scope)
+	if err != nil {
+		return t, err
+	}
+
+	var idToken string
+	if openID {
+		idToken, err = tm.generator.IDToken(ctx, tenant, models.IDToken{
+			Issuer:      issuer,
+			Subject:     code.GUID,
+			Audience:    client.ID,
+			Nonce:       code.Nonce,
+			AuthTime:    code.AuthTime,
+			AccessToken: access,
+			ExpiresAt:   exp,
+		})
+		if err != nil {
+			tm.logger.Error("can't generate id token", zap.Error(err))
+			return t, errors.Join(constants.ErrGenerate, err)
+		}
+	}
+
+	tm.publish(ctx, models.EventIssued, code.GUID)
+	return models.Tokens{
+		AccessToken:  access,
+		RefreshToken: grantRefreshToken(code.GUID, refresh),
+		ExpiresAt:    exp,
+		Scope:        code.Scope,
+		IDToken:      idToken,
+	}, nil
+}
+
+// codeHash is the hash the code is stored by.
+func codeHash(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// verifyCodeChallenge checks the code_verifier against the S256 code_challenge, see RFC 7636 4.6.
+func verifyCodeChallenge(challenge, verifier string) bool {
+	if !_codeVerifier.MatchString(verifier) {
+		return false
+	}
+
+	sum := sha256.Sum256([]byte(verifier))
+	return subtle.ConstantTimeCompare([]byte(base64.RawURLEncoding.EncodeToString(sum[:])), []byte(challenge)) == 1
+}
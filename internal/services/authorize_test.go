@@ -0,0 +1,360 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/mock"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains/mocks"
+	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/models"
+	"go-jwt-auth/internal/signing"
+	"testing"
+	"time"
+)
+
+// _challenge is the base64url SHA-256 of _verifier.
+const (
+	_verifier  = "dBjftJeZ4CVP-mJ0kJKCYa75qKG3kfDLrd3bXgx-oJo"
+	_challenge = "9LSUlVbzRuCc0mpzaGIqYFmcY5G8ncHt22BmATxCV38"
+)
+
+func newTestCodeTokenManager(t *testing.T, repo *mocks.Repository, tenant models.Tenant) *TokenManager {
+	logger, err := lib.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create Logger instance: %v", err)
+	}
+	events := mocks.NewEventPublisher(t)
+	events.On("Publish", mock.Anything, _eventType).Maybe()
+
+	return &TokenManager{
+		repository: repo,
+		logger:     logger,
+		tenants:    Tenants{"": tenant},
+		generator:  NewGeneratorService(logger),
+		events:     events,
+		codeTTL:    _defaultCodeTTL,
+	}
+}
+
+// withIDTokenKey returns the tenant with an active ES256 key, which can sign the ID tokens.
+func withIDTokenKey(t *testing.T, tenant models.Tenant) models.Tenant {
+	k, err := signing.Generate(tenant.ID, models.KeyTypeEC)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	k.State = models.KeyStateActive
+	tenant.Keys = append(tenant.Keys, k)
+	return tenant
+}
+
+func TestTokenManager_Authorize(t *testing.T) {
+	spa := models.Client{
+		ID:         "spa",
+		GrantTypes: []models.GrantType{models.GrantTypeAuthorizationCode},
+		Scopes:     []string{"read", "write"},
+	}
+	req := models.AuthorizationRequest{
+		GUID:                "kwfwe",
+		RedirectURI:         "https://app.example.com/callback",
+		CodeChallenge:       _challenge,
+		CodeChallengeMethod: models.CodeChallengeS256,
+	}
+	with := func(modify func(r *models.AuthorizationRequest)) models.AuthorizationRequest {
+		r := req
+		modify(&r)
+		return r
+	}
+
+	openID := models.Client{ID: "spa", GrantTypes: spa.GrantTypes, Scopes: []string{"openid", "read"}}
+
+	tests := []struct {
+		name      string
+		client    models.Client
+		req       models.AuthorizationRequest
+		hmacOnly  bool
+		wantScope string
+		wantErr   error
+	}{
+		{
+			name:      "ok",
+			client:    spa,
+			req:       req,
+			wantScope: "read write",
+		},
+		{
+			name:      "scope",
+			client:    spa,
+			req:       with(func(r *models.AuthorizationRequest) { r.Scope = "write" }),
+			wantScope: "write",
+		},
+		{
+			name:      "openid",
+			client:    openID,
+			req:       with(func(r *models.AuthorizationRequest) { r.Scope, r.Nonce = "openid", "n-0S6_WzA2Mj" }),
+			wantScope: "openid",
+		},
+		{
+			// the clients couldn't check the ID tokens signed with the secret.
+			name:     "openidHMACOnly",
+			client:   openID,
+			req:      with(func(r *models.AuthorizationRequest) { r.Scope = "openid" }),
+			hmacOnly: true,
+			wantErr:  constants.ErrInvalidScope,
+		},
+		{
+			name:    "invalidScope",
+			client:  spa,
+			req:     with(func(r *models.AuthorizationRequest) { r.Scope = "admin" }),
+			wantErr: constants.ErrInvalidScope,
+		},
+		{
+			name:    "plainChallenge",
+			client:  spa,
+			req:     with(func(r *models.AuthorizationRequest) { r.CodeChallengeMethod = "plain" }),
+			wantErr: constants.ErrInvalidCodeChallenge,
+		},
+		{
+			name:    "noChallenge",
+			client:  spa,
+			req:     with(func(r *models.AuthorizationRequest) { r.CodeChallenge = "" }),
+			wantErr: constants.ErrInvalidCodeChallenge,
+		},
+		{
+			name:    "unauthorizedClient",
+			client:  models.Client{ID: "backend", GrantTypes: []models.GrantType{models.GrantTypeIssue}},
+			req:     req,
+			wantErr: constants.ErrUnauthorizedClient,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mocks.NewRepository(t)
+			var saved models.AuthorizationCode
+			if tt.wantErr == nil {
+				repo.EXPECT().SaveCode(mock.Anything, mock.Anything).RunAndReturn(func(_ context.Context, c models.AuthorizationCode) error {
+					saved = c
+					return nil
+				})
+			}
+
+			tenant := models.Tenant{Key: []byte("123")}
+			if !tt.hmacOnly {
+				tenant = withIDTokenKey(t, tenant)
+			}
+			tm := newTestCodeTokenManager(t, repo, tenant)
+			code, err := tm.Authorize(lib.WithClient(context.Background(), tt.client), tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Authorize() error = %v, wantErr %v", err, tt.wantErr)
+			} else if tt.wantErr != nil {
+				return
+			}
+
+			if saved.Hash != codeHash(code) || saved.Hash == code {
+				t.Errorf("Authorize() saved hash %q of code %q", saved.Hash, code)
+			}
+			if saved.ClientID != "spa" || saved.GUID != "kwfwe" || saved.Scope != tt.wantScope ||
+				saved.RedirectURI != tt.req.RedirectURI || saved.CodeChallenge != _challenge || saved.Nonce != tt.req.Nonce {
+				t.Errorf("Authorize() saved %+v", saved)
+			}
+			if ttl := time.Until(time.Unix(saved.ExpiresAt, 0)); ttl <= 0 || ttl > _defaultCodeTTL {
+				t.Errorf("Authorize() code ttl = %v", ttl)
+			}
+			if time.Since(time.Unix(saved.AuthTime, 0)) > time.Minute {
+				t.Errorf("Authorize() auth_time = %v", saved.AuthTime)
+			}
+		})
+	}
+}
+
+func TestTokenManager_Grant_AuthorizationCode(t *testing.T) {
+	const (
+		code     = "SplxlOBeZQQYbYS6WxSbIA"
+		callback = "https://app.example.com/callback"
+	)
+
+	tenant := withIDTokenKey(t, models.Tenant{ID: "acme", Key: []byte("123"), AccessTTL: time.Minute, RefreshTTL: time.Hour})
+	spa := models.Client{ID: "spa", Tenant: "acme", GrantTypes: []models.GrantType{models.GrantTypeAuthorizationCode}}
+	stored := models.AuthorizationCode{
+		Hash:          codeHash(code),
+		Tenant:        "acme",
+		ClientID:      "spa",
+		RedirectURI:   callback,
+		GUID:          "kwfwe",
+		Scope:         "read",
+		CodeChallenge: _challenge,
+		ExpiresAt:     time.Now().Add(time.Minute).Unix(),
+	}
+	req := models.GrantRequest{
+		Type:         models.GrantTypeAuthorizationCode,
+		Code:         code,
+		RedirectURI:  callback,
+		CodeVerifier: _verifier,
+	}
+
+	tests := []struct {
+		name        string
+		stored      *models.AuthorizationCode
+		consume     error
+		modify      func(r *models.GrantRequest, c *models.AuthorizationCode)
+		wantErr     error
+		noBaseURL   bool
+		hmacOnly    bool
+		wantSaved   bool
+		wantIDToken bool
+	}{
+		{
+			name:      "ok",
+			stored:    &stored,
+			wantSaved: true,
+		},
+		{
+			name:   "openid",
+			stored: &stored,
+			modify: func(_ *models.GrantRequest, c *models.AuthorizationCode) {
+				c.Scope, c.Nonce = "openid read", "n-0S6_WzA2Mj"
+			},
+			wantSaved:   true,
+			wantIDToken: true,
+		},
+		{
+			name:   "openidWithoutIssuer",
+			stored: &stored,
+			modify: func(_ *models.GrantRequest, c *models.AuthorizationCode) {
+				c.Scope, c.Nonce = "openid read", "n-0S6_WzA2Mj"
+			},
+			noBaseURL: true,
+			wantErr:   constants.ErrGenerate,
+		},
+		{
+			// the key was rotated to an hmac one since the code was issued.
+			name:   "openidHMACOnly",
+			stored: &stored,
+			modify: func(_ *models.GrantRequest, c *models.AuthorizationCode) {
+				c.Scope = "openid read"
+			},
+			hmacOnly: true,
+			wantErr:  constants.ErrInvalidScope,
+		},
+		{
+			name:   "redirectURIOmittedInBoth",
+			stored: &stored,
+			modify: func(r *models.GrantRequest, c *models.AuthorizationCode) {
+				r.RedirectURI, c.RedirectURI = "", ""
+			},
+			wantSaved: true,
+		},
+		{
+			name:    "missingVerifier",
+			modify:  func(r *models.GrantRequest, _ *models.AuthorizationCode) { r.CodeVerifier = "" },
+			wantErr: constants.ErrMissingCode,
+		},
+		{
+			name:    "unknownCode",
+			consume: constants.ErrNotFound,
+			wantErr: constants.ErrInvalidCode,
+		},
+		{
+			name:   "wrongVerifier",
+			stored: &stored,
+			modify: func(r *models.GrantRequest, _ *models.AuthorizationCode) {
+				sum := sha256.Sum256([]byte("another"))
+				r.CodeVerifier = base64.RawURLEncoding.EncodeToString(sum[:])
+			},
+			wantErr: constants.ErrInvalidCode,
+		},
+		{
+			// the plain method would accept the challenge itself as the verifier.
+			name:    "challengeAsVerifier",
+			stored:  &stored,
+			modify:  func(r *models.GrantRequest, _ *models.AuthorizationCode) { r.CodeVerifier = _challenge },
+			wantErr: constants.ErrInvalidCode,
+		},
+		{
+			name:    "redirectURIMismatch",
+			stored:  &stored,
+			modify:  func(r *models.GrantRequest, _ *models.AuthorizationCode) { r.RedirectURI = "https://app.example.com/" },
+			wantErr: constants.ErrInvalidCode,
+		},
+		{
+			name:    "codeOfAnotherClient",
+			stored:  &stored,
+			modify:  func(_ *models.GrantRequest, c *models.AuthorizationCode) { c.ClientID = "mobile" },
+			wantErr: constants.ErrInvalidCode,
+		},
+		{
+			name:   "expired",
+			stored: &stored,
+			modify: func(_ *models.GrantRequest, c *models.AuthorizationCode) {
+				c.ExpiresAt = time.Now().Add(-time.Second).Unix()
+			},
+			wantErr: constants.ErrInvalidCode,
+		},
+		{
+			name:    "repositoryError",
+			consume: errors.New("connection refused"),
+			wantErr: constants.ErrRepository,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, c := req, stored
+			if tt.modify != nil {
+				tt.modify(&r, &c)
+			}
+
+			repo := mocks.NewRepository(t)
+			if tt.stored != nil || tt.consume != nil {
+				repo.EXPECT().ConsumeCode(mock.Anything, codeHash(code)).Return(c, tt.consume)
+			}
+			if tt.wantSaved {
+				repo.EXPECT().SaveTokenData(mock.Anything, mock.MatchedBy(func(td models.TokenData) bool {
+					return td.GUID == "kwfwe" && td.ClientID == "spa" && td.Scope == c.Scope && td.Tenant == "acme"
+				})).Return(nil)
+			}
+
+			tnt := tenant
+			if tt.hmacOnly {
+				tnt.Keys = nil
+			}
+			tm := newTestCodeTokenManager(t, repo, tnt)
+			tm.tenants = Tenants{"acme": tnt}
+			ctx := lib.WithClient(lib.WithTenant(context.Background(), "acme"), spa)
+			if !tt.noBaseURL {
+				ctx = lib.WithBaseURL(ctx, "https://auth.example.com/t/acme")
+			}
+
+			got, err := tm.Grant(ctx, r)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Grant() error = %v, wantErr %v", err, tt.wantErr)
+			} else if tt.wantErr != nil {
+				return
+			}
+
+			guid, _, ok := parseGrantRefreshToken(got.RefreshToken)
+			if !ok || guid != "kwfwe" || got.Scope != c.Scope || got.AccessToken == "" {
+				t.Errorf("Grant() = %+v", got)
+			}
+
+			if !tt.wantIDToken {
+				if got.IDToken != "" {
+					t.Errorf("Grant() id token without the openid scope")
+				}
+				return
+			}
+			claims := jwt.MapClaims{}
+			if _, err := jwt.ParseWithClaims(got.IDToken, claims, signing.Keyfunc(tenant)); err != nil {
+				t.Fatalf("ParseWithClaims() error = %v", err)
+			}
+			if claims["iss"] != "https://auth.example.com/t/acme" || claims["sub"] != "kwfwe" || claims["aud"] != "spa" ||
+				claims["nonce"] != c.Nonce || claims["at_hash"] != accessTokenHash("ES256", got.AccessToken) {
+				t.Errorf("Grant() id token claims = %v", claims)
+			}
+		})
+	}
+}
@@ -0,0 +1,405 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains"
+	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/models"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// _maxAssertionTTL bounds how long the assertions are remembered against replays.
+	_maxAssertionTTL = time.Hour
+)
+
+var (
+	// _clientID keeps the client IDs safe to use in the Basic credentials and the storage keys.
+	_clientID = regexp.MustCompile(`^[A-Za-z0-9._~-]{1,128}$`)
+
+	_rsaMethods     = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"}
+	_ecdsaMethods   = []string{"ES256", "ES384", "ES512"}
+	_ed25519Methods = []string{"EdDSA"}
+)
+
+// ClientService is the registry of the clients and their authentication.
+type ClientService struct {
+	storage domains.Repository
+	tenants domains.Tenants
+	logger  lib.Logger
+	now     func() time.Time
+
+	mu sync.Mutex
+	// seen are the expiries of the assertions used, by client ID and jti.
+	seen map[string]int64
+}
+
+// NewClientService creates a new instance of ClientService.
+func NewClientService(st domains.Repository, tenants domains.Tenants, logger lib.Logger) domains.Clients {
+	return &ClientService{
+		storage: st,
+		tenants: tenants,
+		logger:  logger,
+		now:     time.Now,
+		seen:    make(map[string]int64),
+	}
+}
+
+// Register validates the client and saves it. A client authenticates with
+// only one of the secret, the private key of c.PublicKey or the certificate of
+// c.TLSSubject. A client with none of them is public, it can only use the authorization_code grant and
+// refresh the tokens of it.
+func (s *ClientService) Register(ctx context.Context, c models.Client, secret string) (models.Client, error) {
+	if err := s.validate(c, secret); err != nil {
+		return c, err
+	}
+
+	if secret != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+		if err != nil {
+			return c, fmt.Errorf("can't hash secret: %v", err)
+		}
+		c.SecretHash = string(hash)
+	}
+
+	if err := s.storage.SaveClient(ctx, c); err != nil {
+		return c, err
+	}
+	return c, nil
+}
+
+func (s *ClientService) validate(c models.Client, secret string) error {
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: %s", constants.ErrInvalidClientMetadata, fmt.Sprintf(format, args...))
+	}
+
+	if !_clientID.MatchString(c.ID) {
+		return invalid("invalid client id %q", c.ID)
+	}
+	if _, err := s.tenants.Tenant(c.Tenant); err != nil {
+		return invalid("tenant %q: %v", c.Tenant, err)
+	}
+
+	credentials := 0
+	for _, cred := range []string{secret, c.PublicKey, c.TLSSubject} {
+		if cred != "" {
+			credentials++
+		}
+	}
+
+	switch {
+	case credentials == 0:
+		for _, g := range c.GrantTypes {
+			if g != models.GrantTypeAuthorizationCode && g != models.GrantTypeRefreshToken {
+				return invalid("public clients can't use the %q grant type", g)
+			}
+		}
+	case credentials > 1:
+		return invalid("a secret, a public key and a tls subject are mutually exclusive")
+	case len(secret) > constants.MaxBcryptLength:
+		return invalid("the secret is longer than %d bytes", constants.MaxBcryptLength)
+	case c.PublicKey != "":
+		if _, _, err := parsePublicKey(c.PublicKey); err != nil {
+			return invalid("public key: %v", err)
+		}
+	}
+
+	for _, g := range c.GrantTypes {
+		switch g {
+		case models.GrantTypeIssue, models.GrantTypeRefreshToken,
+			models.GrantTypeClientCredentials, models.GrantTypeAuthorizationCode:
+		default:
+			return invalid("unknown grant type %q", g)
+		}
+	}
+	for _, scope := range c.Scopes {
+		if scope == "" || strings.ContainsAny(scope, " \"\\") {
+			return invalid("invalid scope %q", scope)
+		}
+	}
+	for _, uri := range c.RedirectURIs {
+		// RFC 6749 3.1.2: absolute and without a fragment.
+		u, err := url.Parse(uri)
+		if err != nil || !u.IsAbs() || u.Fragment != "" {
+			return invalid("invalid redirect uri %q", uri)
+		}
+	}
+	for _, p := range c.GUIDPatterns {
+		if _, err := path.Match(p, ""); err != nil || p == "" {
+			return invalid("invalid guid pattern %q", p)
+		}
+	}
+	if c.AccessTTL < 0 || c.RefreshTTL < 0 {
+		return invalid("negative ttl")
+	}
+
+	return nil
+}
+
+// Authenticate returns the client the credentials belong to. The client
+// must be registered with the tenant of the request.
+func (s *ClientService) Authenticate(ctx context.Context, creds models.ClientCredentials) (c models.Client, err error) {
+	switch creds.Method {
+	case models.ClientAuthSecretBasic, models.ClientAuthSecretPost, models.ClientAuthAPIKey:
+		c, err = s.authenticateSecret(ctx, creds)
+	case models.ClientAuthTLS:
+		c, err = s.authenticateTLS(ctx, creds)
+	case models.ClientAuthPrivateKeyJWT:
+		c, err = s.authenticateAssertion(ctx, creds)
+	case models.ClientAuthNone:
+		c, err = s.authenticatePublic(ctx, creds)
+	default:
+		return c, constants.ErrInvalidClient
+	}
+	if err != nil {
+		return c, err
+	}
+
+	if tenant := lib.Tenant(ctx); c.Tenant != tenant {
+		s.logger.Warn("client of another tenant", zap.String("client", c.ID), zap.String("tenant", tenant))
+		return models.Client{}, constants.ErrInvalidClient
+	}
+
+	return c, nil
+}
+
+func (s *ClientService) authenticateSecret(ctx context.Context, creds models.ClientCredentials) (models.Client, error) {
+	c, err := s.client(ctx, creds.ID)
+	if err != nil {
+		return c, err
+	}
+
+	if c.SecretHash == "" {
+		s.logger.Debug("client has no secret", zap.String("client", c.ID))
+		return models.Client{}, constants.ErrInvalidClient
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(c.SecretHash), []byte(creds.Secret)); err != nil {
+		s.logger.Debug("client secret mismatch", zap.String("client", c.ID))
+		return models.Client{}, constants.ErrInvalidClient
+	}
+
+	return c, nil
+}
+
+// authenticateTLS matches the subject of the certificate verified by the TLS
+// handshake with the one registered for the client, see RFC 8705 2.1.2.
+func (s *ClientService) authenticateTLS(ctx context.Context, creds models.ClientCredentials) (models.Client, error) {
+	c, err := s.client(ctx, creds.ID)
+	if err != nil {
+		return c, err
+	}
+
+	if c.TLSSubject == "" || c.TLSSubject != creds.TLSSubject {
+		s.logger.Debug("client certificate subject mismatch", zap.String("client", c.ID), zap.String("subject", creds.TLSSubject))
+		return models.Client{}, constants.ErrInvalidClient
+	}
+	return c, nil
+}
+
+// authenticatePublic identifies a public client by its ID, the confidential
+// clients must prove their credentials.
+func (s *ClientService) authenticatePublic(ctx context.Context, creds models.ClientCredentials) (models.Client, error) {
+	c, err := s.client(ctx, creds.ID)
+	if err != nil {
+		return c, err
+	}
+
+	if !c.Public() {
+		s.logger.Debug("confidential client without credentials", zap.String("client", c.ID))
+		return models.Client{}, constants.ErrInvalidClient
+	}
+	return c, nil
+}
+
+// authenticateAssertion verifies a private_key_jwt assertion, see RFC 7523 3.
+// Each assertion is accepted once.
+func (s *ClientService) authenticateAssertion(ctx context.Context, creds models.ClientCredentials) (models.Client, error) {
+	var (
+		c         models.Client
+		claims    jwt.RegisteredClaims
+		lookupErr error
+	)
+
+	_, err := jwt.ParseWithClaims(creds.Assertion, &claims, func(t *jwt.Token) (interface{}, error) {
+		id, err := t.Claims.GetIssuer()
+		if err != nil {
+			return nil, err
+		}
+		if creds.ID != "" && creds.ID != id {
+			return nil, fmt.Errorf("issuer %q isn't the client_id", id)
+		}
+
+		if c, lookupErr = s.client(ctx, id); lookupErr != nil {
+			return nil, lookupErr
+		}
+		if c.PublicKey == "" {
+			return nil, fmt.Errorf("client %q has no public key", id)
+		}
+
+		key, methods, err := parsePublicKey(c.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range methods {
+			if t.Method.Alg() == m {
+				return key, nil
+			}
+		}
+		return nil, fmt.Errorf("unexpected signing method %q", t.Method.Alg())
+	}, jwt.WithTimeFunc(s.now))
+	if errors.Is(lookupErr, constants.ErrRepository) {
+		return models.Client{}, lookupErr
+	}
+	if err != nil {
+		s.logger.Debug("invalid client assertion", zap.Error(err))
+		return models.Client{}, constants.ErrInvalidClient
+	}
+
+	if claims.Subject != c.ID {
+		s.logger.Debug("client assertion subject mismatch", zap.String("client", c.ID))
+		return models.Client{}, constants.ErrInvalidClient
+	}
+	if !s.audienceMatches(ctx, claims.Audience, creds.Audience) {
+		s.logger.Debug("client assertion audience mismatch", zap.String("client", c.ID))
+		return models.Client{}, constants.ErrInvalidClient
+	}
+	if claims.ID == "" || claims.ExpiresAt == nil || claims.ExpiresAt.Sub(s.now()) > _maxAssertionTTL {
+		s.logger.Debug("client assertion without jti or a bounded exp", zap.String("client", c.ID))
+		return models.Client{}, constants.ErrInvalidClient
+	}
+	if !s.firstUse(c.ID+"\x00"+claims.ID, claims.ExpiresAt.Unix()) {
+		s.logger.Warn("client assertion replay", zap.String("client", c.ID))
+		return models.Client{}, constants.ErrInvalidClient
+	}
+
+	return c, nil
+}
+
+// audienceMatches reports whether the assertion is meant for the endpoint
+// or the issuer of the tenant of the request.
+func (s *ClientService) audienceMatches(ctx context.Context, aud jwt.ClaimStrings, accepted []string) bool {
+	if tenant, err := s.tenants.Tenant(lib.Tenant(ctx)); err == nil && tenant.Issuer != "" {
+		accepted = append(accepted[:len(accepted):len(accepted)], tenant.Issuer)
+	}
+
+	for _, a := range aud {
+		for _, acc := range accepted {
+			if a == acc {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// firstUse remembers the assertion until it expires and reports whether it
+// hasn't been used before. The assertions are remembered by this replica only.
+func (s *ClientService) firstUse(key string, exp int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now().Unix()
+	for k, e := range s.seen {
+		if e < now {
+			delete(s.seen, k)
+		}
+	}
+
+	if _, ok := s.seen[key]; ok {
+		return false
+	}
+	s.seen[key] = exp
+	return true
+}
+
+// client returns the registered client, constants.ErrInvalidClient if there is none.
+func (s *ClientService) client(ctx context.Context, id string) (models.Client, error) {
+	c, err := s.storage.GetClient(ctx, id)
+	if err != nil {
+		if errors.Is(err, constants.ErrNotFound) {
+			s.logger.Debug("unknown client", zap.String("client", id))
+			return c, constants.ErrInvalidClient
+		}
+		s.logger.Error("can't get client", zap.Error(err))
+		return c, constants.ErrRepository
+	}
+	return c, nil
+}
+
+// Redirect returns the client of an authorization request and the redirect URI
+// to use. The URI must be one of the registered ones, it can be omitted if
+// there is only one, see RFC 6749 3.1.2.3.
+func (s *ClientService) Redirect(ctx context.Context, id, redirectURI string) (models.Client, string, error) {
+	c, err := s.client(ctx, id)
+	if err != nil {
+		return c, "", err
+	}
+	if c.Tenant != lib.Tenant(ctx) {
+		s.logger.Debug("client of another tenant", zap.String("client", c.ID))
+		return models.Client{}, "", constants.ErrInvalidClient
+	}
+
+	if redirectURI == "" {
+		if len(c.RedirectURIs) != 1 {
+			return c, "", constants.ErrInvalidRedirectURI
+		}
+		return c, c.RedirectURIs[0], nil
+	}
+
+	for _, uri := range c.RedirectURIs {
+		if uri == redirectURI {
+			return c, uri, nil
+		}
+	}
+	return c, "", constants.ErrInvalidRedirectURI
+}
+
+// Clients returns all the registered clients.
+func (s *ClientService) Clients(ctx context.Context) ([]models.Client, error) {
+	return s.storage.GetClients(ctx)
+}
+
+// Remove removes the client, its sessions stay until they expire.
+func (s *ClientService) Remove(ctx context.Context, id string) error {
+	return s.storage.DeleteClient(ctx, id)
+}
+
+// parsePublicKey parses a PEM encoded public key and returns the signing methods it verifies.
+func parsePublicKey(data string) (key any, methods []string, err error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, nil, fmt.Errorf("no PEM block")
+	}
+
+	key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch key.(type) {
+	case *rsa.PublicKey:
+		return key, _rsaMethods, nil
+	case *ecdsa.PublicKey:
+		return key, _ecdsaMethods, nil
+	case ed25519.PublicKey:
+		return key, _ed25519Methods, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported key type %T", key)
+	}
+}
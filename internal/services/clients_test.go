@@ -0,0 +1,499 @@
+package services
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/mock"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains/mocks"
+	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/models"
+	"golang.org/x/crypto/bcrypt"
+	"testing"
+	"time"
+)
+
+const _endpoint = "https://auth.example.com/v1/tokens"
+
+func newTestClientService(t *testing.T, repo *mocks.Repository) *ClientService {
+	logger, err := lib.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create Logger instance: %v", err)
+	}
+
+	tenants := Tenants{
+		"":     {Key: []byte("123")},
+		"acme": {ID: "acme", Key: []byte("456"), Issuer: "https://acme.example.com"},
+	}
+	return NewClientService(repo, tenants, logger).(*ClientService)
+}
+
+func newTestKey(t *testing.T) (ed25519.PrivateKey, string) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey() error = %v", err)
+	}
+	return priv, string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func TestClientService_Register(t *testing.T) {
+	_, publicKey := newTestKey(t)
+
+	tests := []struct {
+		name    string
+		client  models.Client
+		secret  string
+		wantErr error
+	}{
+		{
+			name: "secret",
+			client: models.Client{
+				ID:           "backend",
+				GrantTypes:   []models.GrantType{models.GrantTypeIssue, models.GrantTypeRefreshToken},
+				Scopes:       []string{"read"},
+				RedirectURIs: []string{"https://app.example.com/callback"},
+			},
+			secret: "qjfwjnqk",
+		},
+		{
+			name:   "publicKey",
+			client: models.Client{ID: "app", Tenant: "acme", PublicKey: publicKey},
+		},
+		{
+			name:    "invalidID",
+			client:  models.Client{ID: "back end"},
+			secret:  "qjfwjnqk",
+			wantErr: constants.ErrInvalidClientMetadata,
+		},
+		{
+			name:    "unknownTenant",
+			client:  models.Client{ID: "backend", Tenant: "globex"},
+			secret:  "qjfwjnqk",
+			wantErr: constants.ErrInvalidClientMetadata,
+		},
+		{
+			name: "public",
+			client: models.Client{
+				ID:           "spa",
+				GrantTypes:   []models.GrantType{models.GrantTypeAuthorizationCode, models.GrantTypeRefreshToken},
+				RedirectURIs: []string{"https://app.example.com/callback"},
+			},
+		},
+		{
+			name:    "publicIssue",
+			client:  models.Client{ID: "spa", GrantTypes: []models.GrantType{models.GrantTypeIssue}},
+			wantErr: constants.ErrInvalidClientMetadata,
+		},
+		{
+			name:    "publicClientCredentials",
+			client:  models.Client{ID: "spa", GrantTypes: []models.GrantType{models.GrantTypeClientCredentials}},
+			wantErr: constants.ErrInvalidClientMetadata,
+		},
+		{
+			name:    "secretAndPublicKey",
+			client:  models.Client{ID: "backend", PublicKey: publicKey},
+			secret:  "qjfwjnqk",
+			wantErr: constants.ErrInvalidClientMetadata,
+		},
+		{
+			name: "tlsSubject",
+			client: models.Client{
+				ID:           "backend",
+				TLSSubject:   "CN=backend,O=Acme",
+				GrantTypes:   []models.GrantType{models.GrantTypeIssue},
+				GUIDPatterns: []string{"user-*", "admin"},
+			},
+		},
+		{
+			name:    "secretAndTLSSubject",
+			client:  models.Client{ID: "backend", TLSSubject: "CN=backend"},
+			secret:  "qjfwjnqk",
+			wantErr: constants.ErrInvalidClientMetadata,
+		},
+		{
+			name:    "invalidGUIDPattern",
+			client:  models.Client{ID: "backend", GUIDPatterns: []string{"user-["}},
+			secret:  "qjfwjnqk",
+			wantErr: constants.ErrInvalidClientMetadata,
+		},
+		{
+			name:    "invalidPublicKey",
+			client:  models.Client{ID: "backend", PublicKey: "qjfwjnqk"},
+			wantErr: constants.ErrInvalidClientMetadata,
+		},
+		{
+			name:    "unknownGrantType",
+			client:  models.Client{ID: "backend", GrantTypes: []models.GrantType{"password"}},
+			secret:  "qjfwjnqk",
+			wantErr: constants.ErrInvalidClientMetadata,
+		},
+		{
+			name:    "relativeRedirectURI",
+			client:  models.Client{ID: "backend", RedirectURIs: []string{"/callback"}},
+			secret:  "qjfwjnqk",
+			wantErr: constants.ErrInvalidClientMetadata,
+		},
+		{
+			name:    "redirectURIWithFragment",
+			client:  models.Client{ID: "backend", RedirectURIs: []string{"https://app.example.com/#callback"}},
+			secret:  "qjfwjnqk",
+			wantErr: constants.ErrInvalidClientMetadata,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mocks.NewRepository(t)
+			if tt.wantErr == nil {
+				repo.EXPECT().SaveClient(mock.Anything, mock.MatchedBy(func(c models.Client) bool {
+					if tt.secret == "" {
+						return c.SecretHash == ""
+					}
+					return bcrypt.CompareHashAndPassword([]byte(c.SecretHash), []byte(tt.secret)) == nil
+				})).Return(nil)
+			}
+
+			s := newTestClientService(t, repo)
+			if _, err := s.Register(context.Background(), tt.client, tt.secret); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Register() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClientService_Authenticate(t *testing.T) {
+	secretHash, err := bcrypt.GenerateFromPassword([]byte("qjfwjnqk"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("GenerateFromPassword() error = %v", err)
+	}
+	privateKey, publicKey := newTestKey(t)
+	otherKey, _ := newTestKey(t)
+
+	now := time.Unix(1700000000, 0)
+	registered := map[string]models.Client{
+		"backend": {ID: "backend", SecretHash: string(secretHash)},
+		"app":     {ID: "app", PublicKey: publicKey},
+		"acme":    {ID: "acme", Tenant: "acme", PublicKey: publicKey},
+		"spa":     {ID: "spa", GrantTypes: []models.GrantType{models.GrantTypeAuthorizationCode}},
+		"mtls":    {ID: "mtls", TLSSubject: "CN=mtls,O=Acme"},
+	}
+
+	assertion := func(key ed25519.PrivateKey, claims jwt.RegisteredClaims) string {
+		s, err := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims).SignedString(key)
+		if err != nil {
+			t.Fatalf("SignedString() error = %v", err)
+		}
+		return s
+	}
+	claims := func(modify func(c *jwt.RegisteredClaims)) jwt.RegisteredClaims {
+		c := jwt.RegisteredClaims{
+			Issuer:    "app",
+			Subject:   "app",
+			Audience:  jwt.ClaimStrings{_endpoint},
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+			ID:        "ikj",
+		}
+		if modify != nil {
+			modify(&c)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name       string
+		tenant     string
+		creds      models.ClientCredentials
+		wantClient string
+		wantErr    error
+	}{
+		{
+			name:       "basic",
+			creds:      models.ClientCredentials{Method: models.ClientAuthSecretBasic, ID: "backend", Secret: "qjfwjnqk"},
+			wantClient: "backend",
+		},
+		{
+			name:       "post",
+			creds:      models.ClientCredentials{Method: models.ClientAuthSecretPost, ID: "backend", Secret: "qjfwjnqk"},
+			wantClient: "backend",
+		},
+		{
+			name:       "apiKey",
+			creds:      models.ClientCredentials{Method: models.ClientAuthAPIKey, ID: "backend", Secret: "qjfwjnqk"},
+			wantClient: "backend",
+		},
+		{
+			name:       "tls",
+			creds:      models.ClientCredentials{Method: models.ClientAuthTLS, ID: "mtls", TLSSubject: "CN=mtls,O=Acme"},
+			wantClient: "mtls",
+		},
+		{
+			name:    "tlsSubjectMismatch",
+			creds:   models.ClientCredentials{Method: models.ClientAuthTLS, ID: "mtls", TLSSubject: "CN=mtls,O=Globex"},
+			wantErr: constants.ErrInvalidClient,
+		},
+		{
+			name:    "tlsOfSecretClient",
+			creds:   models.ClientCredentials{Method: models.ClientAuthTLS, ID: "backend", TLSSubject: ""},
+			wantErr: constants.ErrInvalidClient,
+		},
+		{
+			name:    "wrongSecret",
+			creds:   models.ClientCredentials{Method: models.ClientAuthSecretBasic, ID: "backend", Secret: "ebfkqbfb"},
+			wantErr: constants.ErrInvalidClient,
+		},
+		{
+			name:    "unknownClient",
+			creds:   models.ClientCredentials{Method: models.ClientAuthSecretBasic, ID: "globex", Secret: "qjfwjnqk"},
+			wantErr: constants.ErrInvalidClient,
+		},
+		{
+			name:    "secretOfKeyClient",
+			creds:   models.ClientCredentials{Method: models.ClientAuthSecretPost, ID: "app", Secret: ""},
+			wantErr: constants.ErrInvalidClient,
+		},
+		{
+			name:       "public",
+			creds:      models.ClientCredentials{Method: models.ClientAuthNone, ID: "spa"},
+			wantClient: "spa",
+		},
+		{
+			name:    "confidentialWithoutCredentials",
+			creds:   models.ClientCredentials{Method: models.ClientAuthNone, ID: "backend"},
+			wantErr: constants.ErrInvalidClient,
+		},
+		{
+			name:    "clientOfAnotherTenant",
+			tenant:  "acme",
+			creds:   models.ClientCredentials{Method: models.ClientAuthSecretBasic, ID: "backend", Secret: "qjfwjnqk"},
+			wantErr: constants.ErrInvalidClient,
+		},
+		{
+			name: "assertion",
+			creds: models.ClientCredentials{
+				Method:    models.ClientAuthPrivateKeyJWT,
+				Assertion: assertion(privateKey, claims(nil)),
+				Audience:  []string{_endpoint},
+			},
+			wantClient: "app",
+		},
+		{
+			name:   "assertionForIssuer",
+			tenant: "acme",
+			creds: models.ClientCredentials{
+				Method: models.ClientAuthPrivateKeyJWT,
+				ID:     "acme",
+				Assertion: assertion(privateKey, claims(func(c *jwt.RegisteredClaims) {
+					c.Issuer, c.Subject, c.Audience = "acme", "acme", jwt.ClaimStrings{"https://acme.example.com"}
+				})),
+				Audience: []string{_endpoint},
+			},
+			wantClient: "acme",
+		},
+		{
+			name: "assertionOfAnotherKey",
+			creds: models.ClientCredentials{
+				Method:    models.ClientAuthPrivateKeyJWT,
+				Assertion: assertion(otherKey, claims(nil)),
+				Audience:  []string{_endpoint},
+			},
+			wantErr: constants.ErrInvalidClient,
+		},
+		{
+			name: "assertionOfAnotherClientID",
+			creds: models.ClientCredentials{
+				Method:    models.ClientAuthPrivateKeyJWT,
+				ID:        "acme",
+				Assertion: assertion(privateKey, claims(nil)),
+				Audience:  []string{_endpoint},
+			},
+			wantErr: constants.ErrInvalidClient,
+		},
+		{
+			name: "assertionSubjectMismatch",
+			creds: models.ClientCredentials{
+				Method:    models.ClientAuthPrivateKeyJWT,
+				Assertion: assertion(privateKey, claims(func(c *jwt.RegisteredClaims) { c.Subject = "backend" })),
+				Audience:  []string{_endpoint},
+			},
+			wantErr: constants.ErrInvalidClient,
+		},
+		{
+			name: "assertionAudienceMismatch",
+			creds: models.ClientCredentials{
+				Method:    models.ClientAuthPrivateKeyJWT,
+				Assertion: assertion(privateKey, claims(func(c *jwt.RegisteredClaims) { c.Audience = jwt.ClaimStrings{"https://evil.example.com"} })),
+				Audience:  []string{_endpoint},
+			},
+			wantErr: constants.ErrInvalidClient,
+		},
+		{
+			name: "assertionExpired",
+			creds: models.ClientCredentials{
+				Method:    models.ClientAuthPrivateKeyJWT,
+				Assertion: assertion(privateKey, claims(func(c *jwt.RegisteredClaims) { c.ExpiresAt = jwt.NewNumericDate(now.Add(-time.Minute)) })),
+				Audience:  []string{_endpoint},
+			},
+			wantErr: constants.ErrInvalidClient,
+		},
+		{
+			name: "assertionWithoutExpiry",
+			creds: models.ClientCredentials{
+				Method:    models.ClientAuthPrivateKeyJWT,
+				Assertion: assertion(privateKey, claims(func(c *jwt.RegisteredClaims) { c.ExpiresAt = nil })),
+				Audience:  []string{_endpoint},
+			},
+			wantErr: constants.ErrInvalidClient,
+		},
+		{
+			name: "assertionWithoutJTI",
+			creds: models.ClientCredentials{
+				Method:    models.ClientAuthPrivateKeyJWT,
+				Assertion: assertion(privateKey, claims(func(c *jwt.RegisteredClaims) { c.ID = "" })),
+				Audience:  []string{_endpoint},
+			},
+			wantErr: constants.ErrInvalidClient,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mocks.NewRepository(t)
+			repo.EXPECT().GetClient(mock.Anything, mock.Anything).RunAndReturn(func(_ context.Context, id string) (models.Client, error) {
+				c, ok := registered[id]
+				if !ok {
+					return c, constants.ErrNotFound
+				}
+				return c, nil
+			}).Maybe()
+
+			s := newTestClientService(t, repo)
+			s.now = func() time.Time { return now }
+
+			ctx := lib.WithTenant(context.Background(), tt.tenant)
+			c, err := s.Authenticate(ctx, tt.creds)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Authenticate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if c.ID != tt.wantClient {
+				t.Errorf("Authenticate() client = %v, want %v", c.ID, tt.wantClient)
+			}
+		})
+	}
+}
+
+func TestClientService_Redirect(t *testing.T) {
+	registered := map[string]models.Client{
+		"spa":    {ID: "spa", RedirectURIs: []string{"https://app.example.com/callback"}},
+		"mobile": {ID: "mobile", RedirectURIs: []string{"com.example.app:/callback", "http://127.0.0.1:8000/callback"}},
+		"acme":   {ID: "acme", Tenant: "acme", RedirectURIs: []string{"https://acme.example.com/callback"}},
+	}
+
+	tests := []struct {
+		name        string
+		id          string
+		redirectURI string
+		wantURI     string
+		wantErr     error
+	}{
+		{
+			name:        "registered",
+			id:          "mobile",
+			redirectURI: "com.example.app:/callback",
+			wantURI:     "com.example.app:/callback",
+		},
+		{
+			name:    "onlyRegistered",
+			id:      "spa",
+			wantURI: "https://app.example.com/callback",
+		},
+		{
+			name:    "omittedOfMany",
+			id:      "mobile",
+			wantErr: constants.ErrInvalidRedirectURI,
+		},
+		{
+			name:        "notRegistered",
+			id:          "spa",
+			redirectURI: "https://app.example.com/callback/../evil",
+			wantErr:     constants.ErrInvalidRedirectURI,
+		},
+		{
+			name:    "unknownClient",
+			id:      "globex",
+			wantErr: constants.ErrInvalidClient,
+		},
+		{
+			name:    "clientOfAnotherTenant",
+			id:      "acme",
+			wantErr: constants.ErrInvalidClient,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mocks.NewRepository(t)
+			repo.EXPECT().GetClient(mock.Anything, tt.id).RunAndReturn(func(_ context.Context, id string) (models.Client, error) {
+				c, ok := registered[id]
+				if !ok {
+					return c, constants.ErrNotFound
+				}
+				return c, nil
+			})
+
+			s := newTestClientService(t, repo)
+			_, uri, err := s.Redirect(context.Background(), tt.id, tt.redirectURI)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Redirect() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if uri != tt.wantURI {
+				t.Errorf("Redirect() uri = %v, want %v", uri, tt.wantURI)
+			}
+		})
+	}
+}
+
+func TestClientService_Authenticate_Replay(t *testing.T) {
+	privateKey, publicKey := newTestKey(t)
+	now := time.Unix(1700000000, 0)
+
+	repo := mocks.NewRepository(t)
+	repo.EXPECT().GetClient(mock.Anything, "app").Return(models.Client{ID: "app", PublicKey: publicKey}, nil)
+
+	s := newTestClientService(t, repo)
+	s.now = func() time.Time { return now }
+
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodEdDSA, jwt.RegisteredClaims{
+		Issuer:    "app",
+		Subject:   "app",
+		Audience:  jwt.ClaimStrings{_endpoint},
+		ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+		ID:        "ikj",
+	}).SignedString(privateKey)
+	if err != nil {
+		t.Fatalf("SignedString() error = %v", err)
+	}
+
+	creds := models.ClientCredentials{Method: models.ClientAuthPrivateKeyJWT, Assertion: assertion, Audience: []string{_endpoint}}
+	if _, err := s.Authenticate(context.Background(), creds); err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if _, err := s.Authenticate(context.Background(), creds); !errors.Is(err, constants.ErrInvalidClient) {
+		t.Errorf("Authenticate() of a replayed assertion error = %v, want %v", err, constants.ErrInvalidClient)
+	}
+
+	// the assertions are forgotten once they expire.
+	now = now.Add(2 * time.Minute)
+	if s.firstUse("app\x00qlmflqm", now.Add(time.Minute).Unix()); len(s.seen) != 1 {
+		t.Errorf("seen = %v, want the expired assertions forgotten", s.seen)
+	}
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+	"go-jwt-auth/internal/domains"
+	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/models"
+	"go.uber.org/zap"
+)
+
+// Events delivers token events to every subscriber.
+type Events struct {
+	subscribers []domains.EventSubscriber
+	logger      lib.Logger
+}
+
+// NewEvents creates a new instance of Events.
+func NewEvents(
+	logger lib.Logger,
+	auditor domains.Auditor,
+	webhooks domains.WebhookDispatcher,
+) domains.EventPublisher {
+	return &Events{
+		subscribers: []domains.EventSubscriber{
+			auditor,
+			webhooks,
+		},
+		logger: logger,
+	}
+}
+
+// Publish delivers the event to the subscribers.
+// A failing subscriber doesn't stop the others and doesn't fail the caller.
+func (ev *Events) Publish(ctx context.Context, e models.Event) {
+	for _, s := range ev.subscribers {
+		if err := s.HandleEvent(ctx, e); err != nil {
+			ev.logger.Error("can't handle event",
+				zap.String("type", string(e.Type)),
+				zap.String("guid", e.GUID),
+				zap.String("client_id", e.ClientID),
+				zap.Error(err),
+			)
+		}
+	}
+}
@@ -2,13 +2,19 @@ package services
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"go-jwt-auth/internal/constants"
 	"go-jwt-auth/internal/domains"
 	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/models"
+	"go-jwt-auth/internal/signing"
 	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
+	"strings"
 	"time"
 )
 
@@ -24,27 +30,61 @@ func NewGeneratorService(logger lib.Logger) domains.GeneratorService {
 
 func (g *GeneratorService) AccessToken(
 	ctx context.Context,
-	guid string, key []byte,
-	accessTTL time.Duration,
+	guid string,
+	tenant models.Tenant,
+	scope string,
+) (access string, exp int64, err error) {
+	return g.accessToken(ctx, _guid, guid, tenant, scope)
+}
+
+// ClientAccessToken signs the access token of the client itself, the client
+// is in the client_id claim, so that its tokens can't pass for a user's.
+func (g *GeneratorService) ClientAccessToken(
+	ctx context.Context,
+	clientID string,
+	tenant models.Tenant,
+	scope string,
+) (access string, exp int64, err error) {
+	return g.accessToken(ctx, _clientIDClaim, clientID, tenant, scope)
+}
+
+// accessToken signs an access token with the subject in the claim.
+func (g *GeneratorService) accessToken(
+	ctx context.Context,
+	claim string,
+	subject string,
+	tenant models.Tenant,
+	scope string,
 ) (access string, exp int64, err error) {
 
 	if ctx.Err() != nil {
 		return "", 0, ctx.Err()
 	}
 
-	if guid == "" {
+	if !validSubject(subject) {
 		return "", 0, constants.ErrInvalidGUID
 	}
 
-	exp = time.Now().Add(accessTTL).Unix()
+	exp = time.Now().Add(tenant.AccessTTL).Unix()
 
-	t := jwt.NewWithClaims(jwt.SigningMethodHS512,
-		jwt.MapClaims{
-			_guid: guid,
-			_iat:  exp,
-		})
+	claims := jwt.MapClaims{
+		claim: subject,
+		_iat:  exp,
+	}
+	if tenant.ID != "" {
+		claims[_tid] = tenant.ID
+	}
+	if tenant.Issuer != "" {
+		claims[_iss] = tenant.Issuer
+	}
+	if tenant.Audience != "" {
+		claims[_aud] = tenant.Audience
+	}
+	if scope != "" {
+		claims[_scope] = scope
+	}
 
-	access, err = t.SignedString(key)
+	access, err = signing.Sign(tenant, claims)
 	if err != nil {
 		g.logger.Error("can't sign token", zap.Error(err))
 		return "", 0, constants.ErrSignToken
@@ -70,6 +110,73 @@ func (g *GeneratorService) RefreshToken(
 	return uuidObj.String(), time.Now().Add(refreshTTL).Unix(), nil
 }
 
+// IDToken signs the ID token with the key of the tenant, see OpenID Connect Core 2.
+// Unlike the access tokens it carries the registered exp and iat claims.
+func (g *GeneratorService) IDToken(ctx context.Context, tenant models.Tenant, t models.IDToken) (string, error) {
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+
+	if !validSubject(t.Subject) {
+		return "", constants.ErrInvalidGUID
+	}
+
+	claims := jwt.MapClaims{
+		"iss":       t.Issuer,
+		"sub":       t.Subject,
+		"aud":       t.Audience,
+		"exp":       t.ExpiresAt,
+		"iat":       time.Now().Unix(),
+		"auth_time": t.AuthTime,
+	}
+	if tenant.ID != "" {
+		claims[_tid] = tenant.ID
+	}
+	if t.Nonce != "" {
+		claims["nonce"] = t.Nonce
+	}
+	method, key, kid, err := signing.Signer(tenant)
+	if err != nil {
+		g.logger.Error("can't load signing key", zap.Error(err))
+		return "", constants.ErrSignToken
+	}
+	if method == jwt.SigningMethodHS512 {
+		g.logger.Error("no asymmetric key signs the id tokens", zap.String("tenant", tenant.ID))
+		return "", constants.ErrSignToken
+	}
+	if t.AccessToken != "" {
+		claims["at_hash"] = accessTokenHash(method.Alg(), t.AccessToken)
+	}
+
+	idToken := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		idToken.Header["kid"] = kid
+	}
+	token, err := idToken.SignedString(key)
+	if err != nil {
+		g.logger.Error("can't sign id token", zap.Error(err))
+		return "", constants.ErrSignToken
+	}
+
+	return token, nil
+}
+
+// accessTokenHash is the at_hash of the access token: the base64url left half
+// of its hash with the hash of the alg of the ID token, see OpenID Connect Core 3.1.3.6.
+// The hash of EdDSA with Ed25519 is SHA-512.
+func accessTokenHash(alg, access string) string {
+	var sum []byte
+	switch alg {
+	case jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg():
+		s := sha256.Sum256([]byte(access))
+		sum = s[:]
+	default:
+		s := sha512.Sum512([]byte(access))
+		sum = s[:]
+	}
+	return base64.RawURLEncoding.EncodeToString(sum[:len(sum)/2])
+}
+
 // bcryptHashFrom generates a bcrypt hash from a given token.
 func bcryptHashFrom(token []byte) ([]byte, error) {
 	if len(token) == 0 {
@@ -83,3 +190,10 @@ func bcryptHashFrom(token []byte) ([]byte, error) {
 
 	return bcryptHash, nil
 }
+
+// validSubject reports whether the GUID or client ID can be the subject of
+// tokens: it is set and has no NUL, which the storages keying the sessions by
+// the GUID use as a separator.
+func validSubject(subject string) bool {
+	return subject != "" && !strings.ContainsRune(subject, 0)
+}
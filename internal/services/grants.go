@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/models"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+// Grant issues the tokens of an OAuth grant to the client of the request, see RFC 6749 4.
+// The refresh tokens of the grants are opaque and carry the GUID of the session,
+// so they are refreshed without the access token.
+func (tm *TokenManager) Grant(ctx context.Context, r models.GrantRequest) (t models.Tokens, err error) {
+	client, ok := lib.Client(ctx)
+	if !ok {
+		return t, constants.ErrInvalidClient
+	}
+
+	switch r.Type {
+	case models.GrantTypeClientCredentials, models.GrantTypeRefreshToken, models.GrantTypeAuthorizationCode:
+	default:
+		return t, constants.ErrUnsupportedGrant
+	}
+	if !client.Allows(r.Type) {
+		return t, constants.ErrUnauthorizedClient
+	}
+	r.Scope = strings.Join(strings.Fields(r.Scope), " ")
+
+	tenant, err := tm.tenant(ctx)
+	if err != nil {
+		return t, err
+	}
+
+	switch r.Type {
+	case models.GrantTypeClientCredentials:
+		return tm.clientCredentials(ctx, tenant, client, r)
+	case models.GrantTypeAuthorizationCode:
+		return tm.authorizationCode(ctx, tenant, client, r)
+	default:
+		return tm.refreshGrant(ctx, tenant, client, r)
+	}
+}
+
+// clientCredentials issues an access token to the client itself, see RFC 6749 4.4.
+// The client is the subject of the token in the client_id claim, the token has
+// no guid. No refresh token is issued.
+func (tm *TokenManager) clientCredentials(
+	ctx context.Context,
+	tenant models.Tenant,
+	client models.Client,
+	r models.GrantRequest,
+) (t models.Tokens, err error) {
+
+	scope := r.Scope
+	if scope == "" {
+		scope = strings.Join(client.Scopes, " ")
+	}
+	if !scopeWithin(scope, client.Scopes) {
+		return t, constants.ErrInvalidScope
+	}
+
+	access, exp, err := tm.generator.ClientAccessToken(ctx, client.ID, tenant, scope)
+	if err != nil {
+		tm.logger.Error("can't generate access token", zap.Error(err))
+		return t, errors.Join(constants.ErrGenerate, err)
+	}
+
+	tm.events.Publish(ctx, models.Event{Type: models.EventIssued, ClientID: client.ID, Time: time.Now().Unix()})
+	return models.Tokens{AccessToken: access, ExpiresAt: exp, Scope: scope}, nil
+}
+
+// refreshGrant exchanges the refresh token for a new pair, see RFC 6749 6.
+// The scope can only be narrowed, it is kept if none is requested.
+func (tm *TokenManager) refreshGrant(
+	ctx context.Context,
+	tenant models.Tenant,
+	client models.Client,
+	r models.GrantRequest,
+) (t models.Tokens, err error) {
+
+	if r.RefreshToken == "" {
+		return t, constants.ErrMissingRefreshToken
+	}
+
+	guid, refresh, ok := parseGrantRefreshToken(r.RefreshToken)
+	if !ok {
+		return t, constants.ErrInvalidToken
+	}
+
+	session, err := tm.consume(ctx, tenant, guid, []byte(refresh), func(s models.TokenData) error {
+		if r.Scope != "" && !(scopeWithin(r.Scope, strings.Fields(s.Scope)) && scopeWithin(r.Scope, client.Scopes)) {
+			return constants.ErrInvalidScope
+		}
+		return nil
+	})
+	if err != nil {
+		return t, err
+	}
+
+	scope := r.Scope
+	if scope == "" {
+		scope = session.Scope
+	}
+
+	access, refresh, exp, err := tm.issue(ctx, tenant, guid, scope)
+	if err != nil {
+		return t, err
+	}
+
+	tm.publish(ctx, models.EventRefreshed, guid)
+	return models.Tokens{
+		AccessToken:  access,
+		RefreshToken: grantRefreshToken(guid, refresh),
+		ExpiresAt:    exp,
+		Scope:        scope,
+	}, nil
+}
+
+// grantRefreshToken is the refresh token of the grants: the GUID the
+// session is stored by and the refresh token of the session.
+func grantRefreshToken(guid, refresh string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(guid)) + "." + refresh
+}
+
+func parseGrantRefreshToken(token string) (guid, refresh string, ok bool) {
+	encoded, refresh, ok := strings.Cut(token, ".")
+	if !ok || refresh == "" {
+		return "", "", false
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(raw) == 0 {
+		return "", "", false
+	}
+	return string(raw), refresh, true
+}
+
+// scopeWithin reports whether every scope of the space-delimited scope is allowed.
+func scopeWithin(scope string, allowed []string) bool {
+	for _, s := range strings.Fields(scope) {
+		found := false
+		for _, a := range allowed {
+			if s == a {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
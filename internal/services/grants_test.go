@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/mock"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains/mocks"
+	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/models"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTokenManager_Grant(t *testing.T) {
+	const (
+		guid    = "kwfwe"
+		refresh = "a41f20b0-45e0-11ee-a4cb-0630f8c4d04c"
+	)
+
+	tenant := models.Tenant{Key: []byte("123"), AccessTTL: time.Minute, RefreshTTL: time.Hour}
+	backend := models.Client{
+		ID:         "backend",
+		GrantTypes: []models.GrantType{models.GrantTypeClientCredentials, models.GrantTypeRefreshToken},
+		Scopes:     []string{"read", "write"},
+	}
+
+	logger, err := lib.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create Logger instance: %v", err)
+	}
+	gen := NewGeneratorService(logger)
+
+	hash, err := bcryptHashFrom([]byte(refresh))
+	if err != nil {
+		t.Fatalf("bcryptHashFrom() error = %v", err)
+	}
+	session := models.TokenData{GUID: guid, RefreshHash: string(hash), RefreshExp: math.MaxInt, ClientID: "backend", Scope: "read write"}
+
+	tests := []struct {
+		name       string
+		client     *models.Client
+		req        models.GrantRequest
+		repo       repoMock
+		wantSub    string
+		wantClient string
+		wantScope  string
+		wantErr    error
+	}{
+		{
+			name:       "clientCredentials",
+			client:     &backend,
+			req:        models.GrantRequest{Type: models.GrantTypeClientCredentials},
+			wantClient: "backend",
+			wantScope:  "read write",
+		},
+		{
+			name:       "clientCredentialsScope",
+			client:     &backend,
+			req:        models.GrantRequest{Type: models.GrantTypeClientCredentials, Scope: " write  "},
+			wantClient: "backend",
+			wantScope:  "write",
+		},
+		{
+			name:    "clientCredentialsInvalidScope",
+			client:  &backend,
+			req:     models.GrantRequest{Type: models.GrantTypeClientCredentials, Scope: "read admin"},
+			wantErr: constants.ErrInvalidScope,
+		},
+		{
+			name:   "refreshToken",
+			client: &backend,
+			req:    models.GrantRequest{Type: models.GrantTypeRefreshToken, RefreshToken: grantRefreshToken(guid, refresh)},
+			repo: func(r *mocks.Repository) {
+				r.On("GetTokensDataByGUID", mock.Anything, guid).Return([]models.TokenData{session}, nil)
+				r.On("DeleteTokenData", mock.Anything, guid, string(hash)).Return(nil)
+				r.On("SaveTokenData", mock.Anything, mock.MatchedBy(func(td models.TokenData) bool {
+					return td.Scope == "read write" && td.ClientID == "backend"
+				})).Return(nil)
+			},
+			wantSub:   guid,
+			wantScope: "read write",
+		},
+		{
+			name:   "refreshTokenNarrowed",
+			client: &backend,
+			req:    models.GrantRequest{Type: models.GrantTypeRefreshToken, RefreshToken: grantRefreshToken(guid, refresh), Scope: "read"},
+			repo: func(r *mocks.Repository) {
+				r.On("GetTokensDataByGUID", mock.Anything, guid).Return([]models.TokenData{session}, nil)
+				r.On("DeleteTokenData", mock.Anything, guid, string(hash)).Return(nil)
+				r.On("SaveTokenData", mock.Anything, mock.MatchedBy(func(td models.TokenData) bool {
+					return td.Scope == "read"
+				})).Return(nil)
+			},
+			wantSub:   guid,
+			wantScope: "read",
+		},
+		{
+			// the session is not consumed, the refresh token stays usable.
+			name:   "refreshTokenWidened",
+			client: &backend,
+			req: models.GrantRequest{
+				Type:         models.GrantTypeRefreshToken,
+				RefreshToken: grantRefreshToken(guid, refresh),
+				Scope:        "read admin",
+			},
+			repo: func(r *mocks.Repository) {
+				r.On("GetTokensDataByGUID", mock.Anything, guid).Return([]models.TokenData{session}, nil)
+			},
+			wantErr: constants.ErrInvalidScope,
+		},
+		{
+			name:    "refreshTokenMissing",
+			client:  &backend,
+			req:     models.GrantRequest{Type: models.GrantTypeRefreshToken},
+			wantErr: constants.ErrMissingRefreshToken,
+		},
+		{
+			name:    "refreshTokenMalformed",
+			client:  &backend,
+			req:     models.GrantRequest{Type: models.GrantTypeRefreshToken, RefreshToken: refresh},
+			wantErr: constants.ErrInvalidToken,
+		},
+		{
+			name:    "unsupportedGrant",
+			client:  &backend,
+			req:     models.GrantRequest{Type: "password"},
+			wantErr: constants.ErrUnsupportedGrant,
+		},
+		{
+			name:    "unauthorizedClient",
+			client:  &models.Client{ID: "mobile", GrantTypes: []models.GrantType{models.GrantTypeRefreshToken}},
+			req:     models.GrantRequest{Type: models.GrantTypeClientCredentials},
+			wantErr: constants.ErrUnauthorizedClient,
+		},
+		{
+			name:    "noClient",
+			req:     models.GrantRequest{Type: models.GrantTypeClientCredentials},
+			wantErr: constants.ErrInvalidClient,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mocks.NewRepository(t)
+			if tt.repo != nil {
+				tt.repo(repo)
+			}
+			events := mocks.NewEventPublisher(t)
+			events.On("Publish", mock.Anything, _eventType).Maybe()
+
+			tm := &TokenManager{
+				repository: repo,
+				logger:     logger,
+				tenants:    Tenants{"": tenant},
+				generator:  gen,
+				events:     events,
+			}
+
+			ctx := context.Background()
+			if tt.client != nil {
+				ctx = lib.WithClient(ctx, *tt.client)
+			}
+
+			got, err := tm.Grant(ctx, tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Grant() err %v, wantErr %v", err, tt.wantErr)
+			} else if tt.wantErr != nil {
+				return
+			}
+
+			if got.Scope != tt.wantScope {
+				t.Errorf("Grant() scope = %q, want %q", got.Scope, tt.wantScope)
+			}
+			if (got.RefreshToken != "") != (tt.req.Type == models.GrantTypeRefreshToken) {
+				t.Errorf("Grant() refresh token = %q", got.RefreshToken)
+			}
+
+			claims := jwt.MapClaims{}
+			if _, err := jwt.ParseWithClaims(got.AccessToken, claims, func(*jwt.Token) (interface{}, error) {
+				return tenant.Key, nil
+			}); err != nil {
+				t.Fatalf("ParseWithClaims() error = %v", err)
+			}
+			sub, _ := claims[_guid].(string)
+			clientID, _ := claims[_clientIDClaim].(string)
+			if sub != tt.wantSub || clientID != tt.wantClient || claims[_scope] != tt.wantScope {
+				t.Errorf("Grant() claims = %v, want guid %q client_id %q scope %q",
+					claims, tt.wantSub, tt.wantClient, tt.wantScope)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains"
+	"go-jwt-auth/internal/models"
+	"go-jwt-auth/internal/signing"
+	"time"
+)
+
+// KeyService manages the lifecycle of the signing keys of the key store:
+// next, active, previous and retired.
+type KeyService struct {
+	storage domains.KeyStorage
+	tenants domains.Tenants
+	now     func() time.Time
+}
+
+// NewKeyService creates a new instance of KeyService.
+func NewKeyService(st domains.KeyStorage, tenants domains.Tenants) domains.Keys {
+	return &KeyService{
+		storage: st,
+		tenants: tenants,
+		now:     time.Now,
+	}
+}
+
+// Generate adds a key of the type to the tenant. It is published in the JWK
+// set of the tenant, except the hmac keys, but only signs once it is rotated in.
+func (s *KeyService) Generate(ctx context.Context, tenant, keyType string) (models.SigningKey, error) {
+	keys, err := s.keys(ctx, tenant)
+	if err != nil {
+		return models.SigningKey{}, err
+	}
+
+	k, err := signing.Generate(tenant, keyType)
+	if err != nil {
+		return k, err
+	}
+
+	if err := s.storage.SaveKeys(ctx, append(keys, k)); err != nil {
+		return k, err
+	}
+	return k, nil
+}
+
+func (s *KeyService) Keys(ctx context.Context) ([]models.SigningKey, error) {
+	return s.storage.Keys(ctx)
+}
+
+// Rotate activates the oldest next key of the tenant, or a new key of the
+// type if it has none. The type defaults to the one of the active key, or
+// hmac. The active key keeps verifying the tokens it signed until it is retired.
+func (s *KeyService) Rotate(ctx context.Context, tenant, keyType string) (models.SigningKey, error) {
+	keys, err := s.keys(ctx, tenant)
+	if err != nil {
+		return models.SigningKey{}, err
+	}
+
+	next, active := -1, -1
+	for i, k := range keys {
+		if k.Tenant != tenant {
+			continue
+		}
+		switch k.State {
+		case models.KeyStateActive:
+			active = i
+		case models.KeyStateNext:
+			if next == -1 || k.CreatedAt.Before(keys[next].CreatedAt) {
+				next = i
+			}
+		}
+	}
+
+	if next == -1 {
+		if keyType == "" {
+			keyType = models.KeyTypeHMAC
+			if active != -1 {
+				keyType = signing.KeyType(keys[active].Algorithm)
+			}
+		}
+		k, err := signing.Generate(tenant, keyType)
+		if err != nil {
+			return k, err
+		}
+		keys, next = append(keys, k), len(keys)
+	}
+
+	now := s.now().UTC().Truncate(time.Second)
+	if active != -1 {
+		keys[active].State, keys[active].UpdatedAt = models.KeyStatePrevious, now
+	}
+	keys[next].State, keys[next].UpdatedAt = models.KeyStateActive, now
+
+	if err := s.storage.SaveKeys(ctx, keys); err != nil {
+		return keys[next], err
+	}
+	return keys[next], nil
+}
+
+// Retire retires the key, the tokens it signed are no longer valid.
+// Retiring a retired key does nothing.
+func (s *KeyService) Retire(ctx context.Context, kid string) (models.SigningKey, error) {
+	keys, err := s.storage.Keys(ctx)
+	if err != nil {
+		return models.SigningKey{}, err
+	}
+
+	for i, k := range keys {
+		if k.ID != kid {
+			continue
+		}
+		switch k.State {
+		case models.KeyStateActive:
+			return k, constants.ErrActiveKey
+		case models.KeyStateRetired:
+			return k, nil
+		}
+
+		keys[i].State, keys[i].UpdatedAt = models.KeyStateRetired, s.now().UTC().Truncate(time.Second)
+		if err := s.storage.SaveKeys(ctx, keys); err != nil {
+			return keys[i], err
+		}
+		return keys[i], nil
+	}
+	return models.SigningKey{}, fmt.Errorf("key %q: %w", kid, constants.ErrNotFound)
+}
+
+// keys returns all the keys if the tenant exists.
+func (s *KeyService) keys(ctx context.Context, tenant string) ([]models.SigningKey, error) {
+	if _, err := s.tenants.Tenant(tenant); err != nil {
+		return nil, err
+	}
+	return s.storage.Keys(ctx)
+}
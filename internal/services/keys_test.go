@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/mock"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains/mocks"
+	"go-jwt-auth/internal/models"
+	"testing"
+	"time"
+)
+
+func TestKeyService_Rotate(t *testing.T) {
+	old := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		keyType   string
+		keys      []models.SigningKey
+		wantAlg   string
+		wantKid   string
+		wantState map[string]models.KeyState
+	}{
+		{
+			name: "next",
+			keys: []models.SigningKey{
+				{ID: "k1", Tenant: "acme", Algorithm: "HS512", State: models.KeyStateActive},
+				{ID: "k3", Tenant: "acme", Algorithm: "EdDSA", State: models.KeyStateNext, CreatedAt: old.Add(time.Hour)},
+				{ID: "k2", Tenant: "acme", Algorithm: "ES256", State: models.KeyStateNext, CreatedAt: old},
+				{ID: "k4", Algorithm: "HS512", State: models.KeyStateActive},
+			},
+			wantAlg: "ES256",
+			wantKid: "k2",
+			wantState: map[string]models.KeyState{
+				"k1": models.KeyStatePrevious, "k2": models.KeyStateActive,
+				"k3": models.KeyStateNext, "k4": models.KeyStateActive,
+			},
+		},
+		{
+			name: "generatedOfTheActiveType",
+			keys: []models.SigningKey{
+				{ID: "k1", Tenant: "acme", Algorithm: "EdDSA", State: models.KeyStateActive},
+			},
+			wantAlg:   "EdDSA",
+			wantState: map[string]models.KeyState{"k1": models.KeyStatePrevious},
+		},
+		{
+			name:      "generatedOfTheType",
+			keyType:   models.KeyTypeRSA,
+			wantAlg:   "RS256",
+			wantState: map[string]models.KeyState{},
+		},
+		{
+			name:      "firstKey",
+			wantAlg:   "HS512",
+			wantState: map[string]models.KeyState{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := mocks.NewKeyStorage(t)
+			storage.On("Keys", mock.Anything).Return(tt.keys, nil)
+
+			var saved []models.SigningKey
+			storage.On("SaveKeys", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				saved = args.Get(1).([]models.SigningKey)
+			}).Return(nil)
+
+			s := NewKeyService(storage, Tenants{"": {}, "acme": {ID: "acme"}})
+			got, err := s.Rotate(context.Background(), "acme", tt.keyType)
+			if err != nil {
+				t.Fatalf("Rotate() error = %v", err)
+			}
+
+			if got.State != models.KeyStateActive || got.Algorithm != tt.wantAlg || got.Tenant != "acme" ||
+				(tt.wantKid != "" && got.ID != tt.wantKid) {
+				t.Errorf("Rotate() = %+v, want an active %s key", got, tt.wantAlg)
+			}
+
+			active := 0
+			for _, k := range saved {
+				if want, ok := tt.wantState[k.ID]; ok && k.State != want {
+					t.Errorf("key %s state = %v, want %v", k.ID, k.State, want)
+				}
+				if k.Tenant == "acme" && k.State == models.KeyStateActive {
+					active++
+				}
+			}
+			if active != 1 {
+				t.Errorf("the tenant has %d active keys, want 1", active)
+			}
+		})
+	}
+}
+
+func TestKeyService_Retire(t *testing.T) {
+	keys := []models.SigningKey{
+		{ID: "k1", Tenant: "acme", State: models.KeyStatePrevious},
+		{ID: "k2", Tenant: "acme", State: models.KeyStateActive},
+	}
+
+	storage := mocks.NewKeyStorage(t)
+	storage.On("Keys", mock.Anything).Return(keys, nil)
+	storage.On("SaveKeys", mock.Anything, mock.MatchedBy(func(keys []models.SigningKey) bool {
+		return keys[0].State == models.KeyStateRetired && keys[1].State == models.KeyStateActive
+	})).Return(nil).Once()
+
+	s := NewKeyService(storage, Tenants{"acme": {ID: "acme"}})
+	ctx := context.Background()
+
+	if k, err := s.Retire(ctx, "k1"); err != nil || k.State != models.KeyStateRetired {
+		t.Errorf("Retire() = %+v, %v", k, err)
+	}
+	if _, err := s.Retire(ctx, "k2"); !errors.Is(err, constants.ErrActiveKey) {
+		t.Errorf("Retire() of the active key error = %v, want %v", err, constants.ErrActiveKey)
+	}
+	if _, err := s.Retire(ctx, "k9"); !errors.Is(err, constants.ErrNotFound) {
+		t.Errorf("Retire() of an unknown key error = %v, want %v", err, constants.ErrNotFound)
+	}
+}
+
+func TestKeyService_Generate(t *testing.T) {
+	storage := mocks.NewKeyStorage(t)
+	storage.On("Keys", mock.Anything).Return(nil, nil)
+	storage.On("SaveKeys", mock.Anything, mock.MatchedBy(func(keys []models.SigningKey) bool {
+		return len(keys) == 1 && keys[0].State == models.KeyStateNext
+	})).Return(nil)
+
+	s := NewKeyService(storage, Tenants{"acme": {ID: "acme"}})
+	ctx := context.Background()
+
+	if k, err := s.Generate(ctx, "acme", models.KeyTypeEC); err != nil || k.Algorithm != "ES256" {
+		t.Errorf("Generate() = %+v, %v", k, err)
+	}
+	if _, err := s.Generate(ctx, "globex", models.KeyTypeEC); !errors.Is(err, constants.ErrUnknownTenant) {
+		t.Errorf("Generate() of an unknown tenant error = %v, want %v", err, constants.ErrUnknownTenant)
+	}
+	if _, err := s.Generate(ctx, "acme", "dsa"); !errors.Is(err, constants.ErrUnknownKeyType) {
+		t.Errorf("Generate() of an unknown type error = %v, want %v", err, constants.ErrUnknownKeyType)
+	}
+}
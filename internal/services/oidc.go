@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/models"
+	"strings"
+	"time"
+)
+
+// UserInfo returns the claims of the subject of an access token issued by the
+// tenant of the request with the openid scope, see OpenID Connect Core 5.3.
+func (tm *TokenManager) UserInfo(ctx context.Context, access string) (u models.UserInfo, err error) {
+	tenant, err := tm.tenant(ctx)
+	if err != nil {
+		return u, err
+	}
+
+	if access == "" {
+		return u, constants.ErrMissingAccessToken
+	}
+
+	claims, err := tm.claimsFromJWT(tenant, access)
+	if err != nil {
+		return u, err
+	}
+
+	// the iat claim of the access tokens is their expiry.
+	if exp, _ := claims[_iat].(float64); int64(exp) < time.Now().Unix() {
+		return u, constants.ErrTokenExpired
+	}
+
+	scope, _ := claims[_scope].(string)
+	if !scopeWithin(models.ScopeOpenID, strings.Fields(scope)) {
+		return u, constants.ErrInsufficientScope
+	}
+
+	// the tokens of a client itself have no user.
+	guid, _ := claims[_guid].(string)
+	if clientID, _ := claims[_clientIDClaim].(string); guid == "" || clientID != "" {
+		return u, constants.ErrInvalidToken
+	}
+
+	return models.UserInfo{Subject: guid, Tenant: tenant.ID}, nil
+}
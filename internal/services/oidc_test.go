@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains/mocks"
+	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/models"
+	"testing"
+	"time"
+)
+
+func TestTokenManager_UserInfo(t *testing.T) {
+	tenant := models.Tenant{ID: "acme", Key: []byte("123"), AccessTTL: time.Minute}
+	sign := func(claims jwt.MapClaims) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS512, claims).SignedString(tenant.Key)
+		if err != nil {
+			t.Fatalf("SignedString() error = %v", err)
+		}
+		return token
+	}
+	exp := time.Now().Add(time.Minute).Unix()
+
+	tests := []struct {
+		name    string
+		access  string
+		want    models.UserInfo
+		wantErr error
+	}{
+		{
+			name:   "ok",
+			access: sign(jwt.MapClaims{_guid: "kwfwe", _iat: exp, _tid: "acme", _scope: "openid read"}),
+			want:   models.UserInfo{Subject: "kwfwe", Tenant: "acme"},
+		},
+		{
+			name:    "noOpenIDScope",
+			access:  sign(jwt.MapClaims{_guid: "kwfwe", _iat: exp, _tid: "acme", _scope: "read"}),
+			wantErr: constants.ErrInsufficientScope,
+		},
+		{
+			name:    "clientToken",
+			access:  sign(jwt.MapClaims{_clientIDClaim: "backend", _iat: exp, _tid: "acme", _scope: "openid"}),
+			wantErr: constants.ErrInvalidToken,
+		},
+		{
+			name:    "expired",
+			access:  sign(jwt.MapClaims{_guid: "kwfwe", _iat: time.Now().Add(-time.Second).Unix(), _tid: "acme", _scope: "openid"}),
+			wantErr: constants.ErrTokenExpired,
+		},
+		{
+			name:    "anotherTenant",
+			access:  sign(jwt.MapClaims{_guid: "kwfwe", _iat: exp, _tid: "globex", _scope: "openid"}),
+			wantErr: constants.ErrInvalidToken,
+		},
+		{
+			name:    "missing",
+			wantErr: constants.ErrMissingAccessToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tm := newTestCodeTokenManager(t, mocks.NewRepository(t), tenant)
+			tm.tenants = Tenants{"acme": tenant}
+
+			got, err := tm.UserInfo(lib.WithTenant(context.Background(), "acme"), tt.access)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UserInfo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("UserInfo() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/models"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+// Revoke deletes the session of a refresh token of the grants issued by the
+// tenant of the request to its client, see RFC 7009 2.1. The access tokens
+// expire on their own and can't be revoked. Like the unknown tokens, the
+// invalid ones are considered revoked already.
+func (tm *TokenManager) Revoke(ctx context.Context, token string) error {
+	client, ok := lib.Client(ctx)
+	if !ok {
+		return constants.ErrInvalidClient
+	}
+
+	tenant, err := tm.tenant(ctx)
+	if err != nil {
+		return err
+	}
+
+	if isJWT(token) {
+		if _, err := tm.claimsFromJWT(tenant, token); err == nil {
+			return constants.ErrUnsupportedTokenType
+		}
+		return nil
+	}
+
+	guid, refresh, ok := parseGrantRefreshToken(token)
+	if !ok {
+		return nil
+	}
+
+	session, found, err := tm.session(ctx, tenant, client, guid, refresh)
+	if err != nil || !found {
+		return err
+	}
+
+	if err := tm.repository.DeleteTokenData(ctx, guid, session.RefreshHash); err != nil {
+		if errors.Is(err, constants.ErrNotFound) {
+			// the session has been consumed by a concurrent request.
+			return nil
+		}
+		tm.logger.Error("can't delete token", zap.Error(err))
+		return constants.ErrRepository
+	}
+
+	tm.publish(ctx, models.EventRevoked, guid)
+	return nil
+}
+
+// Introspect returns the state of an access token or of a refresh token of the
+// grants issued by the tenant of the request, see RFC 7662 2. The refresh
+// tokens are active only for the client they were issued to.
+func (tm *TokenManager) Introspect(ctx context.Context, token string) (i models.Introspection, err error) {
+	client, ok := lib.Client(ctx)
+	if !ok {
+		return i, constants.ErrInvalidClient
+	}
+	if client.Public() {
+		// the introspection reveals the tokens of the users, see RFC 7662 4.
+		return i, constants.ErrUnauthorizedClient
+	}
+
+	tenant, err := tm.tenant(ctx)
+	if err != nil {
+		return i, err
+	}
+
+	if isJWT(token) {
+		return tm.introspectAccess(tenant, token), nil
+	}
+
+	guid, refresh, ok := parseGrantRefreshToken(token)
+	if !ok {
+		return i, nil
+	}
+
+	session, found, err := tm.session(ctx, tenant, client, guid, refresh)
+	if err != nil || !found || session.RefreshExp < time.Now().Unix() {
+		return i, err
+	}
+
+	return models.Introspection{
+		Active:    true,
+		TokenType: models.TokenTypeRefreshToken,
+		Subject:   guid,
+		ClientID:  session.ClientID,
+		Scope:     session.Scope,
+		ExpiresAt: session.RefreshExp,
+		Tenant:    tenant.ID,
+	}, nil
+}
+
+func (tm *TokenManager) introspectAccess(tenant models.Tenant, token string) (i models.Introspection) {
+	claims, err := tm.claimsFromJWT(tenant, token)
+	if err != nil {
+		return i
+	}
+
+	// the iat claim of the access tokens is their expiry.
+	exp, _ := claims[_iat].(float64)
+	if int64(exp) < time.Now().Unix() {
+		return i
+	}
+
+	i = models.Introspection{
+		Active:    true,
+		TokenType: models.TokenTypeAccessToken,
+		ExpiresAt: int64(exp),
+		Tenant:    tenant.ID,
+	}
+	i.Subject, _ = claims[_guid].(string)
+	// the tokens of a client itself have no guid.
+	if clientID, _ := claims[_clientIDClaim].(string); clientID != "" {
+		i.Subject, i.ClientID = clientID, clientID
+	}
+	i.Scope, _ = claims[_scope].(string)
+	i.Issuer, _ = claims[_iss].(string)
+	i.Audience, _ = claims[_aud].(string)
+	return i
+}
+
+// session finds the session of the refresh token among the sessions of the
+// GUID issued by the tenant to the client, it reports whether it was found.
+func (tm *TokenManager) session(
+	ctx context.Context,
+	tenant models.Tenant,
+	client models.Client,
+	guid string,
+	refresh string,
+) (models.TokenData, bool, error) {
+
+	sessions, err := tm.repository.GetTokensDataByGUID(ctx, guid)
+	if errors.Is(err, constants.ErrNotFound) {
+		return models.TokenData{}, false, nil
+	} else if err != nil {
+		tm.logger.Error("can't get token by guid", zap.Error(err))
+		return models.TokenData{}, false, constants.ErrRepository
+	}
+
+	for _, s := range sessionsOf(tenant, client, guid, sessions) {
+		if validateTokenHash([]byte(s.RefreshHash), []byte(refresh)) == nil {
+			return s, true, nil
+		}
+	}
+	return models.TokenData{}, false, nil
+}
+
+// isJWT reports whether the token is a JWS in the compact serialization, the
+// refresh tokens of the grants have a single dot.
+func isJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
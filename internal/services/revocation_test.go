@@ -0,0 +1,249 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/mock"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains/mocks"
+	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/models"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTokenManager_Revoke(t *testing.T) {
+	const (
+		guid    = "kwfwe"
+		refresh = "a41f20b0-45e0-11ee-a4cb-0630f8c4d04c"
+	)
+
+	tenant := models.Tenant{ID: "acme", Key: []byte("123"), AccessTTL: time.Minute}
+	backend := models.Client{ID: "backend"}
+
+	hash, err := bcryptHashFrom([]byte(refresh))
+	if err != nil {
+		t.Fatalf("bcryptHashFrom() error = %v", err)
+	}
+	session := models.TokenData{GUID: guid, RefreshHash: string(hash), RefreshExp: math.MaxInt, Tenant: "acme", ClientID: "backend"}
+
+	access, err := jwt.NewWithClaims(jwt.SigningMethodHS512, jwt.MapClaims{_guid: guid, _tid: "acme"}).SignedString(tenant.Key)
+	if err != nil {
+		t.Fatalf("SignedString() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		client  *models.Client
+		token   string
+		repo    repoMock
+		wantErr error
+	}{
+		{
+			name:   "ok",
+			client: &backend,
+			token:  grantRefreshToken(guid, refresh),
+			repo: func(r *mocks.Repository) {
+				r.On("GetTokensDataByGUID", mock.Anything, guid).Return([]models.TokenData{session}, nil)
+				r.On("DeleteTokenData", mock.Anything, guid, session.RefreshHash).Return(nil)
+			},
+		},
+		{
+			name:   "anotherClient",
+			client: &models.Client{ID: "frontend"},
+			token:  grantRefreshToken(guid, refresh),
+			repo: func(r *mocks.Repository) {
+				r.On("GetTokensDataByGUID", mock.Anything, guid).Return([]models.TokenData{session}, nil)
+			},
+		},
+		{
+			name:   "unknownGUID",
+			client: &backend,
+			token:  grantRefreshToken(guid, refresh),
+			repo: func(r *mocks.Repository) {
+				r.On("GetTokensDataByGUID", mock.Anything, guid).Return(nil, constants.ErrNotFound)
+			},
+		},
+		{
+			name:   "revokedConcurrently",
+			client: &backend,
+			token:  grantRefreshToken(guid, refresh),
+			repo: func(r *mocks.Repository) {
+				r.On("GetTokensDataByGUID", mock.Anything, guid).Return([]models.TokenData{session}, nil)
+				r.On("DeleteTokenData", mock.Anything, guid, session.RefreshHash).Return(constants.ErrNotFound)
+			},
+		},
+		{
+			name:   "malformed",
+			client: &backend,
+			token:  "qfeqjfkj",
+		},
+		{
+			name:    "accessToken",
+			client:  &backend,
+			token:   access,
+			wantErr: constants.ErrUnsupportedTokenType,
+		},
+		{
+			name:   "repositoryError",
+			client: &backend,
+			token:  grantRefreshToken(guid, refresh),
+			repo: func(r *mocks.Repository) {
+				r.On("GetTokensDataByGUID", mock.Anything, guid).Return(nil, errors.New("connection refused"))
+			},
+			wantErr: constants.ErrRepository,
+		},
+		{
+			name:    "noClient",
+			token:   grantRefreshToken(guid, refresh),
+			wantErr: constants.ErrInvalidClient,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mocks.NewRepository(t)
+			if tt.repo != nil {
+				tt.repo(repo)
+			}
+			tm := newTestCodeTokenManager(t, repo, tenant)
+
+			ctx := context.Background()
+			if tt.client != nil {
+				ctx = lib.WithClient(ctx, *tt.client)
+			}
+
+			if err := tm.Revoke(ctx, tt.token); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Revoke() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTokenManager_Introspect(t *testing.T) {
+	const (
+		guid    = "kwfwe"
+		refresh = "a41f20b0-45e0-11ee-a4cb-0630f8c4d04c"
+	)
+
+	tenant := models.Tenant{ID: "acme", Key: []byte("123"), AccessTTL: time.Minute}
+	backend := models.Client{ID: "backend", SecretHash: "hash"}
+	exp := time.Now().Add(time.Minute).Unix()
+
+	sign := func(claims jwt.MapClaims) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS512, claims).SignedString(tenant.Key)
+		if err != nil {
+			t.Fatalf("SignedString() error = %v", err)
+		}
+		return token
+	}
+
+	hash, err := bcryptHashFrom([]byte(refresh))
+	if err != nil {
+		t.Fatalf("bcryptHashFrom() error = %v", err)
+	}
+	session := models.TokenData{GUID: guid, RefreshHash: string(hash), RefreshExp: exp, Tenant: "acme", ClientID: "backend", Scope: "read"}
+
+	tests := []struct {
+		name    string
+		client  models.Client
+		token   string
+		repo    repoMock
+		want    models.Introspection
+		wantErr error
+	}{
+		{
+			name:   "accessToken",
+			client: backend,
+			token:  sign(jwt.MapClaims{_guid: guid, _iat: exp, _tid: "acme", _scope: "read"}),
+			want: models.Introspection{
+				Active:    true,
+				TokenType: models.TokenTypeAccessToken,
+				Subject:   guid,
+				Scope:     "read",
+				ExpiresAt: exp,
+				Tenant:    "acme",
+			},
+		},
+		{
+			name:   "clientAccessToken",
+			client: backend,
+			token:  sign(jwt.MapClaims{_clientIDClaim: "backend", _iat: exp, _tid: "acme", _scope: "read"}),
+			want: models.Introspection{
+				Active:    true,
+				TokenType: models.TokenTypeAccessToken,
+				Subject:   "backend",
+				ClientID:  "backend",
+				Scope:     "read",
+				ExpiresAt: exp,
+				Tenant:    "acme",
+			},
+		},
+		{
+			name:   "expiredAccessToken",
+			client: backend,
+			token:  sign(jwt.MapClaims{_guid: guid, _iat: time.Now().Add(-time.Second).Unix(), _tid: "acme"}),
+		},
+		{
+			name:   "accessTokenOfAnotherTenant",
+			client: backend,
+			token:  sign(jwt.MapClaims{_guid: guid, _iat: exp, _tid: "globex"}),
+		},
+		{
+			name:   "refreshToken",
+			client: backend,
+			token:  grantRefreshToken(guid, refresh),
+			repo: func(r *mocks.Repository) {
+				r.On("GetTokensDataByGUID", mock.Anything, guid).Return([]models.TokenData{session}, nil)
+			},
+			want: models.Introspection{
+				Active:    true,
+				TokenType: models.TokenTypeRefreshToken,
+				Subject:   guid,
+				ClientID:  "backend",
+				Scope:     "read",
+				ExpiresAt: exp,
+				Tenant:    "acme",
+			},
+		},
+		{
+			name:   "refreshTokenOfAnotherClient",
+			client: models.Client{ID: "frontend", SecretHash: "hash"},
+			token:  grantRefreshToken(guid, refresh),
+			repo: func(r *mocks.Repository) {
+				r.On("GetTokensDataByGUID", mock.Anything, guid).Return([]models.TokenData{session}, nil)
+			},
+		},
+		{
+			name:   "unknown",
+			client: backend,
+			token:  "qfeqjfkj",
+		},
+		{
+			name:    "publicClient",
+			client:  models.Client{ID: "spa"},
+			token:   grantRefreshToken(guid, refresh),
+			wantErr: constants.ErrUnauthorizedClient,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mocks.NewRepository(t)
+			if tt.repo != nil {
+				tt.repo(repo)
+			}
+			tm := newTestCodeTokenManager(t, repo, tenant)
+
+			got, err := tm.Introspect(lib.WithClient(context.Background(), tt.client), tt.token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Introspect() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Introspect() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
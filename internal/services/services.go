@@ -6,5 +6,11 @@ import (
 
 var Module = fx.Options(
 	fx.Provide(NewTokenManager),
+	fx.Provide(NewTenants),
+	fx.Provide(NewClientService),
+	fx.Provide(NewKeyService),
 	fx.Provide(NewGeneratorService),
+	fx.Provide(NewAuditService),
+	fx.Provide(NewWebhookService),
+	fx.Provide(NewEvents),
 )
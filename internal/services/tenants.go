@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"go-jwt-auth/internal/config"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains"
+	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/models"
+	"go.uber.org/zap"
+	"time"
+)
+
+// Tenants are the tenants by ID, the default tenant has an empty ID.
+type Tenants map[string]models.Tenant
+
+// NewTenants creates the tenants from the config. The default tenant is
+// configured by the top-level jwt settings, the others inherit its TTLs.
+// Their keys are read from the key store.
+func NewTenants(conf lib.Config, keys domains.KeyStorage, logger lib.Logger) (domains.Tenants, error) {
+	def, err := newTenant("", conf.JWT, models.Tenant{})
+	if err != nil {
+		return nil, err
+	}
+
+	tenants := Tenants{"": def}
+	for _, tc := range conf.Tenants {
+		if !models.TenantIDPattern.MatchString(tc.ID) {
+			return nil, fmt.Errorf("invalid tenant id %q", tc.ID)
+		}
+		if _, ok := tenants[tc.ID]; ok {
+			return nil, fmt.Errorf("duplicate tenant %q", tc.ID)
+		}
+
+		t, err := newTenant(tc.ID, tc.JWT, def)
+		if err != nil {
+			return nil, err
+		}
+		tenants[tc.ID] = t
+	}
+
+	if _, err := keys.Keys(context.Background()); err != nil {
+		return nil, err
+	}
+	return keyedTenants{tenants: tenants, keys: keys, logger: logger}, nil
+}
+
+func newTenant(id string, conf config.JWT, def models.Tenant) (t models.Tenant, err error) {
+	if conf.Key == "" {
+		return t, fmt.Errorf("tenant %q: jwt key is empty", id)
+	}
+
+	t = models.Tenant{
+		ID:         id,
+		Key:        []byte(conf.Key),
+		Issuer:     conf.Issuer,
+		Audience:   conf.Audience,
+		AccessTTL:  def.AccessTTL,
+		RefreshTTL: def.RefreshTTL,
+	}
+
+	if conf.AccessTTL != "" {
+		if t.AccessTTL, err = time.ParseDuration(conf.AccessTTL); err != nil {
+			return t, fmt.Errorf("tenant %q: can't parse access_ttl: %v", id, err)
+		}
+	}
+	if conf.RefreshTTL != "" {
+		if t.RefreshTTL, err = time.ParseDuration(conf.RefreshTTL); err != nil {
+			return t, fmt.Errorf("tenant %q: can't parse refresh_ttl: %v", id, err)
+		}
+	}
+
+	if t.AccessTTL <= 0 || t.RefreshTTL <= 0 {
+		return t, fmt.Errorf("tenant %q: access_ttl and refresh_ttl must be positive", id)
+	}
+
+	return t, nil
+}
+
+// keyedTenants are the tenants with their keys, read from the key store on
+// every lookup so that the rotations are seen without a restart.
+type keyedTenants struct {
+	tenants Tenants
+	keys    domains.KeyStorage
+	logger  lib.Logger
+}
+
+func (kt keyedTenants) Tenant(id string) (models.Tenant, error) {
+	t, err := kt.tenants.Tenant(id)
+	if err != nil {
+		return t, err
+	}
+
+	// the keys read last are kept if the store can't be read again.
+	keys, err := kt.keys.Keys(context.Background())
+	if err != nil {
+		kt.logger.Error("can't read key store", zap.Error(err))
+	}
+	for _, k := range keys {
+		if k.Tenant == id && k.State != models.KeyStateRetired {
+			t.Keys = append(t.Keys, k)
+		}
+	}
+	return t, nil
+}
+
+// Tenant returns the settings of the tenant.
+func (ts Tenants) Tenant(id string) (models.Tenant, error) {
+	t, ok := ts[id]
+	if !ok {
+		return t, constants.ErrUnknownTenant
+	}
+	return t, nil
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"errors"
+	"github.com/stretchr/testify/mock"
+	"go-jwt-auth/internal/config"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains/mocks"
+	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/models"
+	"gotest.tools/v3/assert"
+	"testing"
+	"time"
+)
+
+func TestNewTenants(t *testing.T) {
+	logger, err := lib.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create Logger instance: %v", err)
+	}
+	def := config.JWT{Key: "secret", AccessTTL: "15m", RefreshTTL: "24h"}
+
+	tests := []struct {
+		name    string
+		tenants []config.Tenant
+		want    map[string]models.Tenant
+		wantErr bool
+	}{
+		{
+			name: "default",
+			want: map[string]models.Tenant{
+				"": {Key: []byte("secret"), AccessTTL: 15 * time.Minute, RefreshTTL: 24 * time.Hour},
+			},
+		},
+		{
+			name: "inheritedTTL",
+			tenants: []config.Tenant{
+				{ID: "acme", JWT: config.JWT{Key: "acme-secret", AccessTTL: "5m", Issuer: "acme", Audience: "api"}},
+			},
+			want: map[string]models.Tenant{
+				"": {Key: []byte("secret"), AccessTTL: 15 * time.Minute, RefreshTTL: 24 * time.Hour},
+				"acme": {
+					ID: "acme", Key: []byte("acme-secret"), Issuer: "acme", Audience: "api",
+					AccessTTL: 5 * time.Minute, RefreshTTL: 24 * time.Hour,
+				},
+			},
+		},
+		{
+			name:    "duplicate",
+			tenants: []config.Tenant{{ID: "acme", JWT: config.JWT{Key: "a"}}, {ID: "acme", JWT: config.JWT{Key: "b"}}},
+			wantErr: true,
+		},
+		{
+			name:    "invalidID",
+			tenants: []config.Tenant{{ID: "acme/api", JWT: config.JWT{Key: "a"}}},
+			wantErr: true,
+		},
+		{
+			name:    "noKey",
+			tenants: []config.Tenant{{ID: "acme"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := mocks.NewKeyStorage(t)
+			keys.On("Keys", mock.Anything).Return(nil, nil).Maybe()
+
+			got, err := NewTenants(lib.Config{JWT: def, Tenants: tt.tenants}, keys, logger)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewTenants() error = %v, wantErr %v", err, tt.wantErr)
+			} else if tt.wantErr {
+				return
+			}
+
+			for id, want := range tt.want {
+				tenant, err := got.Tenant(id)
+				if err != nil {
+					t.Fatalf("Tenant(%q) error = %v", id, err)
+				}
+				assert.DeepEqual(t, want, tenant)
+			}
+
+			if _, err := got.Tenant("globex"); !errors.Is(err, constants.ErrUnknownTenant) {
+				t.Errorf("Tenant() of an unknown tenant error = %v, want %v", err, constants.ErrUnknownTenant)
+			}
+		})
+	}
+}
+
+func TestNewTenants_Keys(t *testing.T) {
+	logger, err := lib.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create Logger instance: %v", err)
+	}
+
+	active := models.SigningKey{ID: "k2", Tenant: "acme", Algorithm: "EdDSA", State: models.KeyStateActive}
+	previous := models.SigningKey{ID: "k1", Tenant: "acme", Algorithm: "HS512", State: models.KeyStatePrevious}
+
+	keys := mocks.NewKeyStorage(t)
+	keys.On("Keys", mock.Anything).Return([]models.SigningKey{
+		previous,
+		active,
+		{ID: "k0", Tenant: "acme", Algorithm: "HS512", State: models.KeyStateRetired},
+		{ID: "k3", Algorithm: "HS512", State: models.KeyStateActive},
+	}, nil)
+
+	tenants, err := NewTenants(lib.Config{
+		JWT:     config.JWT{Key: "secret", AccessTTL: "15m", RefreshTTL: "24h"},
+		Tenants: []config.Tenant{{ID: "acme", JWT: config.JWT{Key: "acme-secret"}}},
+	}, keys, logger)
+	if err != nil {
+		t.Fatalf("NewTenants() error = %v", err)
+	}
+
+	acme, err := tenants.Tenant("acme")
+	if err != nil {
+		t.Fatalf("Tenant() error = %v", err)
+	}
+	assert.DeepEqual(t, []models.SigningKey{previous, active}, acme.Keys)
+
+	keys.ExpectedCalls = nil
+	keys.On("Keys", mock.Anything).Return(nil, errors.New("unexpected end of JSON input"))
+	if _, err := tenants.Tenant("acme"); err != nil {
+		t.Errorf("Tenant() error = %v, want the keys read last", err)
+	}
+}
@@ -4,11 +4,13 @@ import (
 	"context"
 	"encoding/base64"
 	"errors"
+	"fmt"
 	"github.com/golang-jwt/jwt/v5"
 	"go-jwt-auth/internal/constants"
 	"go-jwt-auth/internal/domains"
 	"go-jwt-auth/internal/lib"
 	"go-jwt-auth/internal/models"
+	"go-jwt-auth/internal/signing"
 	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 	"time"
@@ -18,10 +20,15 @@ import (
 type TokenManager struct {
 	repository domains.Repository
 	logger     lib.Logger
-	key        []byte
-	accessTTL  time.Duration
-	refreshTTL time.Duration
+	tenants    domains.Tenants
 	generator  domains.GeneratorService
+	events     domains.EventPublisher
+
+	notifier    domains.Notifier
+	newIPPolicy string
+
+	// codeTTL is how long the authorization codes are valid.
+	codeTTL time.Duration
 }
 
 // NewTokenManager creates a new instance of TokenManager.
@@ -29,79 +36,150 @@ func NewTokenManager(
 	st domains.Repository,
 	logger lib.Logger,
 	conf lib.Config,
+	tenants domains.Tenants,
 	generator domains.GeneratorService,
+	events domains.EventPublisher,
+	notifier domains.Notifier,
 ) (domains.TokenManager, error) {
 
-	accessTTL, err := time.ParseDuration(conf.JWT.AccessTTL)
-	if err != nil {
-		logger.Error("can't parse access_ttl", zap.Error(err))
-		return nil, err
+	newIPPolicy := conf.Notifications.NewIPPolicy
+	switch newIPPolicy {
+	case "":
+		newIPPolicy = constants.NewIPPolicyNotify
+	case constants.NewIPPolicyNotify, constants.NewIPPolicyReject:
+	default:
+		return nil, fmt.Errorf("unknown new_ip_policy %q", newIPPolicy)
 	}
 
-	refreshTTL, err := time.ParseDuration(conf.JWT.RefreshTTL)
-	if err != nil {
-		logger.Error("can't parse refresh_ttl", zap.Error(err))
-		return nil, err
+	codeTTL := _defaultCodeTTL
+	if conf.OAuth.CodeTTL != "" {
+		var err error
+		if codeTTL, err = time.ParseDuration(conf.OAuth.CodeTTL); err != nil {
+			return nil, fmt.Errorf("invalid code ttl: %v", err)
+		}
+		if codeTTL <= 0 || codeTTL > 10*time.Minute {
+			// RFC 6749 4.1.2: a maximum lifetime of 10 minutes is recommended.
+			return nil, fmt.Errorf("code ttl must be positive and at most 10m")
+		}
 	}
 
 	return &TokenManager{
-		repository: st,
-		logger:     logger,
-		key:        []byte(conf.JWT.Key),
-		accessTTL:  accessTTL,
-		refreshTTL: refreshTTL,
-		generator:  generator,
+		repository:  st,
+		logger:      logger,
+		tenants:     tenants,
+		generator:   generator,
+		events:      events,
+		notifier:    notifier,
+		newIPPolicy: newIPPolicy,
+		codeTTL:     codeTTL,
 	}, nil
 }
 
 const (
-	_guid = "guid"
-	_iat  = "iat"
+	_guid          = "guid"
+	_clientIDClaim = "client_id"
+	_iat           = "iat"
+	_tid           = "tid"
+	_iss           = "iss"
+	_aud           = "aud"
+	_scope         = "scope"
 )
 
 // GetTokens retrieves the access and refresh tokens for a given GUID.
+// The tokens are issued by the tenant of the request to its client,
+// which must be allowed to issue the tokens of the GUID.
 func (tm *TokenManager) GetTokens(ctx context.Context, guid string) (access string, refresh string, err error) {
-	var (
-		accessExp  int64
-		refreshExp int64
-	)
+	tenant, err := tm.tenant(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	if client, ok := lib.Client(ctx); ok && !client.MayIssue(guid) {
+		tm.logger.Warn("guid not allowed for client", zap.String("client", client.ID), zap.String("guid", guid))
+		return "", "", constants.ErrGUIDNotAllowed
+	}
+
+	if access, refresh, _, err = tm.issue(ctx, tenant, guid, ""); err != nil {
+		return "", "", err
+	}
+
+	tm.publish(ctx, models.EventIssued, guid)
+	return base64.StdEncoding.EncodeToString([]byte(access)), base64.StdEncoding.EncodeToString([]byte(refresh)), nil
+}
+
+// tenant returns the tenant of the request with the TTLs of its client.
+func (tm *TokenManager) tenant(ctx context.Context) (models.Tenant, error) {
+	tenant, err := tm.tenants.Tenant(lib.Tenant(ctx))
+	if err != nil {
+		return tenant, err
+	}
+
+	if client, ok := lib.Client(ctx); ok {
+		if client.AccessTTL > 0 {
+			tenant.AccessTTL = client.AccessTTL
+		}
+		if client.RefreshTTL > 0 {
+			tenant.RefreshTTL = client.RefreshTTL
+		}
+	}
+	return tenant, nil
+}
+
+// issue generates a new pair of tokens and saves the refresh session.
+func (tm *TokenManager) issue(
+	ctx context.Context,
+	tenant models.Tenant,
+	guid string,
+	scope string,
+) (access string, refresh string, accessExp int64, err error) {
+
+	var refreshExp int64
 
-	access, accessExp, err = tm.generator.AccessToken(ctx, guid, tm.key, tm.accessTTL)
+	access, accessExp, err = tm.generator.AccessToken(ctx, guid, tenant, scope)
 	if err != nil {
 		tm.logger.Error("can't generate access token", zap.Error(err))
-		return "", "", errors.Join(constants.ErrGenerate, err)
+		return "", "", 0, errors.Join(constants.ErrGenerate, err)
 	}
 
-	refresh, refreshExp, err = tm.generator.RefreshToken(ctx, tm.refreshTTL)
+	refresh, refreshExp, err = tm.generator.RefreshToken(ctx, tenant.RefreshTTL)
 	if err != nil {
 		tm.logger.Error("can't generate refresh token", zap.Error(err))
-		return "", "", errors.Join(constants.ErrGenerate, err)
+		return "", "", 0, errors.Join(constants.ErrGenerate, err)
 	}
 
 	bcryptHash, err := bcryptHashFrom([]byte(refresh))
 	if err != nil {
 		tm.logger.Error("can't hash refresh token", zap.Error(err))
-		return "", "", constants.ErrCantHashToken
+		return "", "", 0, constants.ErrCantHashToken
 	}
 
+	client, _ := lib.Client(ctx)
 	if err := tm.repository.SaveTokenData(ctx, models.TokenData{
-		GUID:        guid,
-		RefreshHash: string(bcryptHash),
-		RefreshExp:  refreshExp,
-		AccessExp:   accessExp,
+		SchemaVersion: models.TokenDataVersion,
+		GUID:          guid,
+		RefreshHash:   string(bcryptHash),
+		RefreshExp:    refreshExp,
+		AccessExp:     accessExp,
+		IP:            lib.ClientIP(ctx),
+		Tenant:        tenant.ID,
+		ClientID:      client.ID,
+		Scope:         scope,
 	}); err != nil {
 		tm.logger.Error("can't save token", zap.Error(err))
-		return "", "", constants.ErrRepository
+		return "", "", 0, constants.ErrRepository
 	}
 
-	refreshB64 := base64.StdEncoding.EncodeToString([]byte(refresh))
-	accessB64 := base64.StdEncoding.EncodeToString([]byte(access))
-
-	return accessB64, refreshB64, nil
+	return access, refresh, accessExp, nil
 }
 
 // RefreshTokens retrieves the access and refresh tokens for a given GUID.
+// Only the tokens issued by the tenant of the request to its client can be refreshed.
 func (tm *TokenManager) RefreshTokens(ctx context.Context, oldAccessB64, oldRefreshB64 string) (access string, refresh string, err error) {
+	tenant, err := tm.tenant(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
 	if oldRefreshB64 == "" {
 		return "", "", constants.ErrMissingRefreshToken
 	} else if oldAccessB64 == "" {
@@ -120,43 +198,142 @@ func (tm *TokenManager) RefreshTokens(ctx context.Context, oldAccessB64, oldRefr
 		return "", "", constants.ErrInvalidToken
 	}
 
-	guid, err := tm.guidFromJWT(string(oldAccessBytes))
+	guid, err := tm.guidFromJWT(tenant, string(oldAccessBytes))
+	if err != nil {
+		return "", "", err
+	}
+
+	session, err := tm.consume(ctx, tenant, guid, oldRefreshBytes, nil)
 	if err != nil {
 		return "", "", err
 	}
 
+	if access, refresh, _, err = tm.issue(ctx, tenant, guid, session.Scope); err != nil {
+		return "", "", err
+	}
+
+	tm.publish(ctx, models.EventRefreshed, guid)
+	return base64.StdEncoding.EncodeToString([]byte(access)), base64.StdEncoding.EncodeToString([]byte(refresh)), nil
+}
+
+// consume finds the session of the refresh token among the sessions of the
+// GUID issued by the tenant to the client of the request, checks it and deletes it.
+// check, if any, rejects the session before it is deleted.
+func (tm *TokenManager) consume(
+	ctx context.Context,
+	tenant models.Tenant,
+	guid string,
+	refresh []byte,
+	check func(models.TokenData) error,
+) (session models.TokenData, err error) {
+
 	userTokens, err := tm.repository.GetTokensDataByGUID(ctx, guid)
 	if err != nil {
 		tm.logger.Debug("can't get token by guid", zap.Error(err))
 		if errors.Is(err, constants.ErrNotFound) {
-			return "", "", constants.ErrNotFound
+			return session, constants.ErrNotFound
 		}
-		return "", "", err
+		return session, err
+	}
+
+	client, _ := lib.Client(ctx)
+	userTokens = sessionsOf(tenant, client, guid, userTokens)
+	if len(userTokens) == 0 {
+		return session, constants.ErrNotFound
 	}
 
 	for _, tokenData := range userTokens {
-		if err = validateTokenHash([]byte(tokenData.RefreshHash), oldRefreshBytes); err != nil {
+		if err = validateTokenHash([]byte(tokenData.RefreshHash), refresh); err != nil {
 			tm.logger.Debug("can't validate token", zap.Error(err))
 			continue
 		}
 
 		if tokenData.RefreshExp < time.Now().Unix() {
 			err = constants.ErrTokenExpired
-			continue
+			break
+		}
+
+		if err = tm.checkIP(ctx, tokenData); err != nil {
+			break
+		}
+
+		if check != nil {
+			if err = check(tokenData); err != nil {
+				break
+			}
 		}
 
 		if err = tm.repository.DeleteTokenData(ctx, guid, tokenData.RefreshHash); err != nil {
+			if errors.Is(err, constants.ErrNotFound) {
+				// the session has been consumed by a concurrent refresh.
+				return session, constants.ErrInvalidToken
+			}
 			tm.logger.Error("can't delete token", zap.Error(err))
-			return "", "", constants.ErrRepository
+			return session, constants.ErrRepository
 		}
+		session = tokenData
 		break
 	}
 
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		// the user is known, but the refresh token matches none of the
+		// sessions of the user, so it has most likely been used already.
+		tm.logger.Warn("refresh token reuse detected", zap.String("guid", guid))
+		tm.publish(ctx, models.EventReuseDetected, guid)
+		return session, constants.ErrInvalidToken
+	} else if err != nil {
+		return session, err
+	}
+
+	return session, nil
+}
+
+// checkIP notifies the user if the session is refreshed from an IP address
+// other than the one it was issued to, and rejects it if the policy says so.
+// The notifier only queues the notice, the request doesn't wait for it.
+func (tm *TokenManager) checkIP(ctx context.Context, tokenData models.TokenData) error {
+	ip := lib.ClientIP(ctx)
+	if tokenData.IP == "" || ip == "" || tokenData.IP == ip {
+		return nil
+	}
+
+	err := tm.notifier.NotifyNewIP(ctx, models.NewIPNotice{
+		GUID:       tokenData.GUID,
+		PreviousIP: tokenData.IP,
+		IP:         ip,
+		Time:       time.Now().Unix(),
+	})
 	if err != nil {
-		return "", "", err
+		tm.logger.Error("can't send new ip notice", zap.Error(err))
 	}
 
-	return tm.GetTokens(ctx, guid)
+	if tm.newIPPolicy == constants.NewIPPolicyReject {
+		return constants.ErrNewIP
+	}
+	return nil
+}
+
+// publish raises a token lifecycle event.
+func (tm *TokenManager) publish(ctx context.Context, t models.EventType, guid string) {
+	tm.events.Publish(ctx, models.Event{
+		Type: t,
+		GUID: guid,
+		Time: time.Now().Unix(),
+	})
+}
+
+// sessionsOf filters the sessions of the GUID issued by the tenant to the client.
+// The sessions issued before the clients can be refreshed by any client of the tenant.
+// The GUID is checked as the storages may return the sessions of the GUIDs
+// their keys start with.
+func sessionsOf(tenant models.Tenant, client models.Client, guid string, sessions []models.TokenData) []models.TokenData {
+	filtered := sessions[:0:0]
+	for _, s := range sessions {
+		if s.GUID == guid && s.Tenant == tenant.ID && (s.ClientID == "" || s.ClientID == client.ID) {
+			filtered = append(filtered, s)
+		}
+	}
+	return filtered
 }
 
 // validateTokenHash validates the hash of a given token.
@@ -169,33 +346,63 @@ func validateTokenHash(hash []byte, incoming []byte) error {
 	return nil
 }
 
-// guidFromJWT extracts the GUID from a given JWT token.
-func (tm *TokenManager) guidFromJWT(token string) (string, error) {
-	t, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
-		return tm.key, nil
-	})
+// guidFromJWT extracts the GUID from a given JWT token issued by the tenant.
+// The tokens signed with the configured key before the first rotation are
+// accepted, see signing.RefreshKeyfunc: the GUID only selects the sessions the
+// refresh token is checked against.
+func (tm *TokenManager) guidFromJWT(tenant models.Tenant, token string) (string, error) {
+	claims, err := tm.parseJWT(tenant, token, signing.RefreshKeyfunc(tenant))
 	if err != nil {
-		tm.logger.Error("can't parse token", zap.Error(err))
-		return "", constants.ErrInvalidToken
-	}
-
-	claims, ok := t.Claims.(jwt.MapClaims)
-	if !ok {
-		tm.logger.Error("can't extract claims from token", zap.Error(err))
-		return "", constants.ErrInvalidToken
+		return "", err
 	}
 
 	guid, ok := claims[_guid]
 	if !ok {
-		tm.logger.Error("can't extract guid from token", zap.Error(err))
+		tm.logger.Error("can't extract guid from token")
 		return "", constants.ErrInvalidToken
 	}
 
 	guidStr, ok := guid.(string)
 	if !ok {
-		tm.logger.Error("can't convert guid to string", zap.Error(err))
+		tm.logger.Error("can't convert guid to string")
 		return "", constants.ErrInvalidToken
 	}
 
 	return guidStr, nil
 }
+
+// claimsFromJWT verifies a given JWT token issued by the tenant and returns its claims.
+func (tm *TokenManager) claimsFromJWT(tenant models.Tenant, token string) (jwt.MapClaims, error) {
+	return tm.parseJWT(tenant, token, signing.Keyfunc(tenant))
+}
+
+// parseJWT verifies the token of the tenant with the keys of keyfunc and returns its claims.
+func (tm *TokenManager) parseJWT(tenant models.Tenant, token string, keyfunc jwt.Keyfunc) (jwt.MapClaims, error) {
+	opts := []jwt.ParserOption{jwt.WithValidMethods(signing.Methods(tenant))}
+	if tenant.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(tenant.Issuer))
+	}
+	if tenant.Audience != "" {
+		opts = append(opts, jwt.WithAudience(tenant.Audience))
+	}
+
+	t, err := jwt.Parse(token, keyfunc, opts...)
+	if err != nil {
+		tm.logger.Error("can't parse token", zap.Error(err))
+		return nil, constants.ErrInvalidToken
+	}
+
+	claims, ok := t.Claims.(jwt.MapClaims)
+	if !ok {
+		tm.logger.Error("can't extract claims from token")
+		return nil, constants.ErrInvalidToken
+	}
+
+	// the keys of the tenants may be shared, the tenant is checked explicitly.
+	if tid, _ := claims[_tid].(string); tid != tenant.ID {
+		tm.logger.Warn("token of another tenant", zap.String("tid", tid), zap.String("tenant", tenant.ID))
+		return nil, constants.ErrInvalidToken
+	}
+
+	return claims, nil
+}
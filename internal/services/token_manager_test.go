@@ -2,12 +2,15 @@ package services
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/mock"
 	"go-jwt-auth/internal/constants"
 	"go-jwt-auth/internal/domains/mocks"
 	"go-jwt-auth/internal/lib"
 	"go-jwt-auth/internal/models"
+	"go-jwt-auth/internal/signing"
 	"math"
 	"testing"
 	"time"
@@ -22,6 +25,7 @@ var (
 	_contextType = mock.AnythingOfType("context.backgroundCtx")
 	_rtokenType  = mock.AnythingOfType("models.TokenData")
 	_stringType  = mock.AnythingOfType("string")
+	_eventType   = mock.AnythingOfType("models.Event")
 )
 
 func TestTokenManager_GetTokens(t *testing.T) {
@@ -30,6 +34,7 @@ func TestTokenManager_GetTokens(t *testing.T) {
 		accessTTL  = time.Minute
 		refreshTTL = time.Hour
 	)
+	tenant := models.Tenant{Key: []byte("123"), AccessTTL: accessTTL, RefreshTTL: refreshTTL}
 
 	type args struct {
 		ctx  context.Context
@@ -53,7 +58,7 @@ func TestTokenManager_GetTokens(t *testing.T) {
 			wantAccess:  "MTIz",
 			wantRefresh: "MTIz",
 			genMock: func(c *mocks.GeneratorService) {
-				c.On("AccessToken", _contextType, "123", []byte("123"), accessTTL).
+				c.On("AccessToken", _contextType, "123", tenant, "").
 					Return("123", time.Now().Add(accessTTL).Unix(), nil)
 				c.On("RefreshToken", _contextType, refreshTTL).
 					Return("123", time.Now().Add(refreshTTL).Unix(), nil)
@@ -72,7 +77,7 @@ func TestTokenManager_GetTokens(t *testing.T) {
 			wantAccess:  "MTFoZzFmMWYzdjEzcnYxdmYxaGJ1M3JnMTNyamgxMXZraDFo",
 			wantRefresh: "MTM0YnJpdTFnM3J5ZzEzcnkxM3l1cnYxdW92cg==",
 			genMock: func(c *mocks.GeneratorService) {
-				c.On("AccessToken", _contextType, "fkbhq34btyu1g4yug13ur", []byte("123"), accessTTL).
+				c.On("AccessToken", _contextType, "fkbhq34btyu1g4yug13ur", tenant, "").
 					Return("11hg1f1f3v13rv1vf1hbu3rg13rjh11vkh1h", time.Now().Add(accessTTL).Unix(), nil)
 				c.On("RefreshToken", _contextType, refreshTTL).
 					Return("134briu1g3ryg13ry13yurv1uovr", time.Now().Add(refreshTTL).Unix(), nil)
@@ -82,6 +87,36 @@ func TestTokenManager_GetTokens(t *testing.T) {
 					Return(nil)
 			},
 		},
+		{
+			name: "guidAllowed",
+			args: args{
+				ctx:  lib.WithClient(context.Background(), models.Client{ID: "backend", GUIDPatterns: []string{"user-*"}}),
+				guid: "user-42",
+			},
+			wantAccess:  "MTIz",
+			wantRefresh: "MTIz",
+			genMock: func(c *mocks.GeneratorService) {
+				c.On("AccessToken", mock.Anything, "user-42", tenant, "").
+					Return("123", time.Now().Add(accessTTL).Unix(), nil)
+				c.On("RefreshToken", mock.Anything, refreshTTL).
+					Return("123", time.Now().Add(refreshTTL).Unix(), nil)
+			},
+			repoMock: func(c *mocks.Repository) {
+				c.On("SaveTokenData", mock.Anything, mock.MatchedBy(func(td models.TokenData) bool {
+					return td.ClientID == "backend"
+				})).Return(nil)
+			},
+		},
+		{
+			name: "guidNotAllowed",
+			args: args{
+				ctx:  lib.WithClient(context.Background(), models.Client{ID: "backend", GUIDPatterns: []string{"user-*"}}),
+				guid: "admin",
+			},
+			genMock:  func(c *mocks.GeneratorService) {},
+			repoMock: func(c *mocks.Repository) {},
+			wantErr:  constants.ErrGUIDNotAllowed,
+		},
 		{
 			name: "AccessError",
 			args: args{
@@ -89,7 +124,7 @@ func TestTokenManager_GetTokens(t *testing.T) {
 				guid: "",
 			},
 			genMock: func(c *mocks.GeneratorService) {
-				c.On("AccessToken", _contextType, "", []byte("123"), accessTTL).
+				c.On("AccessToken", _contextType, "", tenant, "").
 					Return("", int64(0), constants.ErrInvalidGUID)
 			},
 			repoMock: func(c *mocks.Repository) {
@@ -103,7 +138,7 @@ func TestTokenManager_GetTokens(t *testing.T) {
 				guid: "qkefkq",
 			},
 			genMock: func(c *mocks.GeneratorService) {
-				c.On("AccessToken", _contextType, "qkefkq", []byte("123"), accessTTL).
+				c.On("AccessToken", _contextType, "qkefkq", tenant, "").
 					Return("11hg1f1f3v13rv1vf1hbu3rg13rjh11vkh1h", time.Now().Add(accessTTL).Unix(), nil)
 				c.On("RefreshToken", _contextType, refreshTTL).
 					Return("", int64(0), constants.ErrGenerateToken)
@@ -119,7 +154,7 @@ func TestTokenManager_GetTokens(t *testing.T) {
 				guid: "kl21rlk",
 			},
 			genMock: func(c *mocks.GeneratorService) {
-				c.On("AccessToken", _contextType, "kl21rlk", []byte("123"), accessTTL).
+				c.On("AccessToken", _contextType, "kl21rlk", tenant, "").
 					Return("11hg1f1f3v13rv1vf1hbu3rg13rjh11vkh1h", time.Now().Add(accessTTL).Unix(), nil)
 				c.On("RefreshToken", _contextType, refreshTTL).
 					Return("134briu1g3ryg13ry13yurv1uovr", time.Now().Add(refreshTTL).Unix(), nil)
@@ -138,18 +173,19 @@ func TestTokenManager_GetTokens(t *testing.T) {
 	}
 
 	tm := &TokenManager{
-		logger:     logger,
-		key:        []byte("123"),
-		accessTTL:  accessTTL,
-		refreshTTL: refreshTTL,
+		logger:  logger,
+		tenants: Tenants{"": tenant},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			gen := mocks.NewGeneratorService(t)
 			repo := mocks.NewRepository(t)
+			events := mocks.NewEventPublisher(t)
+			events.On("Publish", mock.Anything, _eventType).Maybe()
 			tm.repository = repo
 			tm.generator = gen
+			tm.events = events
 			tt.genMock(gen)
 			tt.repoMock(repo)
 
@@ -174,6 +210,7 @@ func TestTokenManager_RefreshTokens(t *testing.T) {
 		accessTTL  = time.Minute
 		refreshTTL = time.Hour
 	)
+	tenant := models.Tenant{Key: []byte("123"), AccessTTL: accessTTL, RefreshTTL: refreshTTL}
 
 	type args struct {
 		ctx     context.Context
@@ -199,7 +236,7 @@ func TestTokenManager_RefreshTokens(t *testing.T) {
 			wantAccess:  "bHdrZW5rZm5xbmZxa3dm",
 			wantRefresh: "andybmZid2plYmtmcWh2ZWZxaGo=",
 			genMock: func(c *mocks.GeneratorService) {
-				c.On("AccessToken", _contextType, "ikj", []byte("123"), accessTTL).
+				c.On("AccessToken", _contextType, "ikj", tenant, "").
 					Return("lwkenkfnqnfqkwf", time.Now().Add(accessTTL).Unix(), nil)
 				c.On("RefreshToken", _contextType, refreshTTL).
 					Return("jwrnfbwjebkfqhvefqhj", time.Now().Add(refreshTTL).Unix(), nil)
@@ -230,7 +267,7 @@ func TestTokenManager_RefreshTokens(t *testing.T) {
 			wantAccess:  "andmMzczYjNqaGRiajMxYnJ1",
 			wantRefresh: "bjM3Z2ZiMnUzN2Z1MmY=",
 			genMock: func(c *mocks.GeneratorService) {
-				c.On("AccessToken", _contextType, "kwfwe", []byte("123"), accessTTL).
+				c.On("AccessToken", _contextType, "kwfwe", tenant, "").
 					Return("jwf373b3jhdbj31bru", time.Now().Add(accessTTL).Unix(), nil)
 				c.On("RefreshToken", _contextType, refreshTTL).
 					Return("n37gfb2u37fu2f", time.Now().Add(refreshTTL).Unix(), nil)
@@ -263,7 +300,7 @@ func TestTokenManager_RefreshTokens(t *testing.T) {
 				c.On("GetTokensDataByGUID", _contextType, "kwfwe").
 					Return([]models.TokenData{
 						{
-							GUID:        "kwffwe",
+							GUID:        "kwfwe",
 							RefreshHash: "$2a$10$VEjOdbltCL7QRByQ1g//4e4KseOMXwvEziIMv2ULi0/8vIuY0394S",
 							RefreshExp:  100,
 						},
@@ -271,6 +308,48 @@ func TestTokenManager_RefreshTokens(t *testing.T) {
 			},
 			wantErr: constants.ErrTokenExpired,
 		},
+		{
+			name: "consumedConcurrently",
+			args: args{
+				ctx:     context.Background(),
+				access:  "ZXlKaGJHY2lPaUpJVXpVeE1pSXNJblI1Y0NJNklrcFhWQ0o5LmV5Sm5kV2xrSWpvaWEzZG1kMlVpZlEudWhicFIwRkx3d3VBY3J5eWhRdVJnNlpwVzBNelc1ako2VnhXMlRTZGNxR0o0Vm5oNnBRdk5fN1lBSHlEbEt5eTJyVGg4NXhyZGM0SHlERlJ5elZYNEE=",
+				refresh: "YTQxZjIwYjAtNDVlMC0xMWVlLWE0Y2ItMDYzMGY4YzRkMDRj",
+			},
+			genMock: func(c *mocks.GeneratorService) {},
+			repoMock: func(c *mocks.Repository) {
+				c.On("GetTokensDataByGUID", _contextType, "kwfwe").
+					Return([]models.TokenData{
+						{
+							GUID:        "kwfwe",
+							RefreshHash: "$2a$10$VEjOdbltCL7QRByQ1g//4e4KseOMXwvEziIMv2ULi0/8vIuY0394S",
+							RefreshExp:  math.MaxInt,
+						},
+					}, nil)
+				c.On("DeleteTokenData", _contextType, "kwfwe", "$2a$10$VEjOdbltCL7QRByQ1g//4e4KseOMXwvEziIMv2ULi0/8vIuY0394S").
+					Return(constants.ErrNotFound)
+			},
+			wantErr: constants.ErrInvalidToken,
+		},
+		{
+			name: "reuse",
+			args: args{
+				ctx:     context.Background(),
+				access:  "ZXlKaGJHY2lPaUpJVXpVeE1pSXNJblI1Y0NJNklrcFhWQ0o5LmV5Sm5kV2xrSWpvaWEzZG1kMlVpZlEudWhicFIwRkx3d3VBY3J5eWhRdVJnNlpwVzBNelc1ako2VnhXMlRTZGNxR0o0Vm5oNnBRdk5fN1lBSHlEbEt5eTJyVGg4NXhyZGM0SHlERlJ5elZYNEE=",
+				refresh: "NTA0YmNmMmEtNDVkZi0xMWVlLWE0Y2ItMDYzMGY4YzRkMDRj",
+			},
+			genMock: func(c *mocks.GeneratorService) {},
+			repoMock: func(c *mocks.Repository) {
+				c.On("GetTokensDataByGUID", _contextType, "kwfwe").
+					Return([]models.TokenData{
+						{
+							GUID:        "kwfwe",
+							RefreshHash: "$2a$10$VEjOdbltCL7QRByQ1g//4e4KseOMXwvEziIMv2ULi0/8vIuY0394S",
+							RefreshExp:  math.MaxInt,
+						},
+					}, nil)
+			},
+			wantErr: constants.ErrInvalidToken,
+		},
 		{
 			name: "notFound",
 			args: args{
@@ -294,18 +373,19 @@ func TestTokenManager_RefreshTokens(t *testing.T) {
 	}
 
 	tm := &TokenManager{
-		logger:     logger,
-		key:        []byte("123"),
-		accessTTL:  accessTTL,
-		refreshTTL: refreshTTL,
+		logger:  logger,
+		tenants: Tenants{"": tenant},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			gen := mocks.NewGeneratorService(t)
 			repo := mocks.NewRepository(t)
+			events := mocks.NewEventPublisher(t)
+			events.On("Publish", _contextType, _eventType).Maybe()
 			tm.repository = repo
 			tm.generator = gen
+			tm.events = events
 			tt.genMock(gen)
 			tt.repoMock(repo)
 
@@ -323,3 +403,385 @@ func TestTokenManager_RefreshTokens(t *testing.T) {
 		})
 	}
 }
+
+func TestTokenManager_RefreshTokens_NewIP(t *testing.T) {
+	const (
+		accessTTL  = time.Minute
+		refreshTTL = time.Hour
+
+		access  = "ZXlKaGJHY2lPaUpJVXpVeE1pSXNJblI1Y0NJNklrcFhWQ0o5LmV5Sm5kV2xrSWpvaWEzZG1kMlVpZlEudWhicFIwRkx3d3VBY3J5eWhRdVJnNlpwVzBNelc1ako2VnhXMlRTZGNxR0o0Vm5oNnBRdk5fN1lBSHlEbEt5eTJyVGg4NXhyZGM0SHlERlJ5elZYNEE="
+		refresh = "YTQxZjIwYjAtNDVlMC0xMWVlLWE0Y2ItMDYzMGY4YzRkMDRj"
+		hash    = "$2a$10$VEjOdbltCL7QRByQ1g//4e4KseOMXwvEziIMv2ULi0/8vIuY0394S"
+	)
+	tenant := models.Tenant{Key: []byte("123"), AccessTTL: accessTTL, RefreshTTL: refreshTTL}
+
+	tests := []struct {
+		name       string
+		policy     string
+		ip         string
+		wantNotice bool
+		wantErr    error
+	}{
+		{
+			name:   "sameIP",
+			policy: constants.NewIPPolicyReject,
+			ip:     "10.0.0.1",
+		},
+		{
+			name:       "notify",
+			policy:     constants.NewIPPolicyNotify,
+			ip:         "10.0.0.2",
+			wantNotice: true,
+		},
+		{
+			name:       "reject",
+			policy:     constants.NewIPPolicyReject,
+			ip:         "10.0.0.2",
+			wantNotice: true,
+			wantErr:    constants.ErrNewIP,
+		},
+	}
+
+	logger, err := lib.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create Logger instance: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := mocks.NewGeneratorService(t)
+			repo := mocks.NewRepository(t)
+			events := mocks.NewEventPublisher(t)
+			notifier := mocks.NewNotifier(t)
+			events.On("Publish", mock.Anything, _eventType).Maybe()
+
+			tm := &TokenManager{
+				repository:  repo,
+				logger:      logger,
+				tenants:     Tenants{"": tenant},
+				generator:   gen,
+				events:      events,
+				notifier:    notifier,
+				newIPPolicy: tt.policy,
+			}
+
+			repo.On("GetTokensDataByGUID", mock.Anything, "kwfwe").
+				Return([]models.TokenData{
+					{
+						GUID:        "kwfwe",
+						RefreshHash: hash,
+						RefreshExp:  math.MaxInt,
+						IP:          "10.0.0.1",
+					},
+				}, nil)
+
+			if tt.wantNotice {
+				notifier.On("NotifyNewIP", mock.Anything, mock.MatchedBy(func(n models.NewIPNotice) bool {
+					return n.GUID == "kwfwe" && n.PreviousIP == "10.0.0.1" && n.IP == tt.ip
+				})).Return(nil)
+			}
+
+			if tt.wantErr == nil {
+				gen.On("AccessToken", mock.Anything, "kwfwe", tenant, "").
+					Return("jwf373b3jhdbj31bru", time.Now().Add(accessTTL).Unix(), nil)
+				gen.On("RefreshToken", mock.Anything, refreshTTL).
+					Return("n37gfb2u37fu2f", time.Now().Add(refreshTTL).Unix(), nil)
+				repo.On("DeleteTokenData", mock.Anything, "kwfwe", hash).Return(nil)
+				repo.On("SaveTokenData", mock.Anything, mock.MatchedBy(func(td models.TokenData) bool {
+					return td.IP == tt.ip
+				})).Return(nil)
+			}
+
+			ctx := lib.WithClientIP(context.Background(), tt.ip)
+			if _, _, err := tm.RefreshTokens(ctx, access, refresh); !errors.Is(err, tt.wantErr) {
+				t.Errorf("RefreshTokens() err %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTokenManager_RefreshTokens_Tenants(t *testing.T) {
+	const (
+		guid    = "kwfwe"
+		refresh = "a41f20b0-45e0-11ee-a4cb-0630f8c4d04c"
+	)
+
+	// the tenants share the key, so only the tid claim tells the tokens apart.
+	def := models.Tenant{Key: []byte("123"), AccessTTL: time.Minute, RefreshTTL: time.Hour}
+	acme := models.Tenant{ID: "acme", Key: []byte("123"), Issuer: "acme", AccessTTL: time.Minute, RefreshTTL: time.Hour}
+
+	logger, err := lib.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create Logger instance: %v", err)
+	}
+	gen := NewGeneratorService(logger)
+
+	hash, err := bcryptHashFrom([]byte(refresh))
+	if err != nil {
+		t.Fatalf("bcryptHashFrom() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		issuedBy models.Tenant
+		tenant   string
+		sessions []models.TokenData
+		wantErr  error
+	}{
+		{
+			name:     "sameTenant",
+			issuedBy: acme,
+			tenant:   "acme",
+			sessions: []models.TokenData{{GUID: guid, RefreshHash: string(hash), RefreshExp: math.MaxInt, Tenant: "acme"}},
+		},
+		{
+			name:     "accessOfAnotherTenant",
+			issuedBy: acme,
+			tenant:   "",
+			wantErr:  constants.ErrInvalidToken,
+		},
+		{
+			name:     "sessionOfAnotherTenant",
+			issuedBy: def,
+			tenant:   "",
+			sessions: []models.TokenData{{GUID: guid, RefreshHash: string(hash), RefreshExp: math.MaxInt, Tenant: "acme"}},
+			wantErr:  constants.ErrNotFound,
+		},
+		{
+			name:     "unknownTenant",
+			issuedBy: acme,
+			tenant:   "globex",
+			wantErr:  constants.ErrUnknownTenant,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mocks.NewRepository(t)
+			events := mocks.NewEventPublisher(t)
+			events.On("Publish", mock.Anything, _eventType).Maybe()
+
+			tm := &TokenManager{
+				repository: repo,
+				logger:     logger,
+				tenants:    Tenants{"": def, "acme": acme},
+				generator:  gen,
+				events:     events,
+			}
+
+			access, _, err := gen.AccessToken(context.Background(), guid, tt.issuedBy, "")
+			if err != nil {
+				t.Fatalf("AccessToken() error = %v", err)
+			}
+
+			if tt.sessions != nil {
+				repo.On("GetTokensDataByGUID", mock.Anything, guid).Return(tt.sessions, nil)
+			}
+			if tt.wantErr == nil {
+				repo.On("DeleteTokenData", mock.Anything, guid, string(hash)).Return(nil)
+				repo.On("SaveTokenData", mock.Anything, mock.MatchedBy(func(td models.TokenData) bool {
+					return td.Tenant == tt.tenant
+				})).Return(nil)
+			}
+
+			ctx := lib.WithTenant(context.Background(), tt.tenant)
+			accessB64 := base64.StdEncoding.EncodeToString([]byte(access))
+			refreshB64 := base64.StdEncoding.EncodeToString([]byte(refresh))
+			if _, _, err := tm.RefreshTokens(ctx, accessB64, refreshB64); !errors.Is(err, tt.wantErr) {
+				t.Errorf("RefreshTokens() err %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTokenManager_RefreshTokens_Rotated(t *testing.T) {
+	const (
+		guid    = "kwfwe"
+		refresh = "a41f20b0-45e0-11ee-a4cb-0630f8c4d04c"
+	)
+
+	logger, err := lib.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create Logger instance: %v", err)
+	}
+	gen := NewGeneratorService(logger)
+
+	// the pair was issued with the configured key, before the first rotation.
+	tenant := models.Tenant{Key: []byte("123"), AccessTTL: time.Minute, RefreshTTL: time.Hour}
+	access, _, err := gen.AccessToken(context.Background(), guid, tenant, "")
+	if err != nil {
+		t.Fatalf("AccessToken() error = %v", err)
+	}
+	hash, err := bcryptHashFrom([]byte(refresh))
+	if err != nil {
+		t.Fatalf("bcryptHashFrom() error = %v", err)
+	}
+
+	key, err := signing.Generate("", models.KeyTypeHMAC)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	key.State = models.KeyStateActive
+	tenant.Keys = []models.SigningKey{key}
+
+	tests := []struct {
+		name    string
+		refresh string
+		wantErr error
+	}{
+		{
+			name:    "ok",
+			refresh: refresh,
+		},
+		{
+			// the configured key alone only names the GUID, the refresh token is still checked.
+			name:    "anotherRefresh",
+			refresh: "b41f20b0-45e0-11ee-a4cb-0630f8c4d04c",
+			wantErr: constants.ErrInvalidToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mocks.NewRepository(t)
+			events := mocks.NewEventPublisher(t)
+			events.On("Publish", mock.Anything, _eventType).Maybe()
+
+			tm := &TokenManager{
+				repository: repo,
+				logger:     logger,
+				tenants:    Tenants{"": tenant},
+				generator:  gen,
+				events:     events,
+			}
+
+			repo.On("GetTokensDataByGUID", mock.Anything, guid).
+				Return([]models.TokenData{{GUID: guid, RefreshHash: string(hash), RefreshExp: math.MaxInt}}, nil)
+			if tt.wantErr == nil {
+				repo.On("DeleteTokenData", mock.Anything, guid, string(hash)).Return(nil)
+				repo.On("SaveTokenData", mock.Anything, _rtokenType).Return(nil)
+			}
+
+			accessB64 := base64.StdEncoding.EncodeToString([]byte(access))
+			refreshB64 := base64.StdEncoding.EncodeToString([]byte(tt.refresh))
+			got, _, err := tm.RefreshTokens(context.Background(), accessB64, refreshB64)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RefreshTokens() err %v, wantErr %v", err, tt.wantErr)
+			} else if tt.wantErr != nil {
+				return
+			}
+
+			// the new access token is signed with the active key.
+			newAccess, err := base64.StdEncoding.DecodeString(got)
+			if err != nil {
+				t.Fatalf("DecodeString() error = %v", err)
+			}
+			parsed, err := jwt.Parse(string(newAccess), signing.Keyfunc(tenant))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if parsed.Header["kid"] != key.ID {
+				t.Errorf("RefreshTokens() kid = %v, want %v", parsed.Header["kid"], key.ID)
+			}
+
+			// the old access token itself is no longer accepted.
+			if _, err := tm.claimsFromJWT(tenant, access); err == nil {
+				t.Errorf("claimsFromJWT() error = nil for a token of the retired configured key")
+			}
+		})
+	}
+}
+
+func TestTokenManager_RefreshTokens_Clients(t *testing.T) {
+	const (
+		guid    = "kwfwe"
+		refresh = "a41f20b0-45e0-11ee-a4cb-0630f8c4d04c"
+	)
+
+	tenant := models.Tenant{Key: []byte("123"), AccessTTL: time.Minute, RefreshTTL: time.Hour}
+	backend := models.Client{ID: "backend"}
+	mobile := models.Client{ID: "mobile", AccessTTL: 5 * time.Minute, RefreshTTL: 24 * time.Hour}
+
+	logger, err := lib.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create Logger instance: %v", err)
+	}
+	gen := NewGeneratorService(logger)
+
+	hash, err := bcryptHashFrom([]byte(refresh))
+	if err != nil {
+		t.Fatalf("bcryptHashFrom() error = %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		client         models.Client
+		sessionClient  string
+		wantRefreshTTL time.Duration
+		wantErr        error
+	}{
+		{
+			name:           "sameClient",
+			client:         backend,
+			sessionClient:  "backend",
+			wantRefreshTTL: time.Hour,
+		},
+		{
+			name:           "clientTTLs",
+			client:         mobile,
+			sessionClient:  "mobile",
+			wantRefreshTTL: 24 * time.Hour,
+		},
+		{
+			name:           "sessionBeforeClients",
+			client:         backend,
+			sessionClient:  "",
+			wantRefreshTTL: time.Hour,
+		},
+		{
+			name:          "sessionOfAnotherClient",
+			client:        mobile,
+			sessionClient: "backend",
+			wantErr:       constants.ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mocks.NewRepository(t)
+			events := mocks.NewEventPublisher(t)
+			events.On("Publish", mock.Anything, _eventType).Maybe()
+
+			tm := &TokenManager{
+				repository: repo,
+				logger:     logger,
+				tenants:    Tenants{"": tenant},
+				generator:  gen,
+				events:     events,
+			}
+
+			access, _, err := gen.AccessToken(context.Background(), guid, tenant, "")
+			if err != nil {
+				t.Fatalf("AccessToken() error = %v", err)
+			}
+
+			repo.On("GetTokensDataByGUID", mock.Anything, guid).Return([]models.TokenData{
+				{GUID: guid, RefreshHash: string(hash), RefreshExp: math.MaxInt, ClientID: tt.sessionClient},
+			}, nil)
+			if tt.wantErr == nil {
+				repo.On("DeleteTokenData", mock.Anything, guid, string(hash)).Return(nil)
+				repo.On("SaveTokenData", mock.Anything, mock.MatchedBy(func(td models.TokenData) bool {
+					ttl := time.Until(time.Unix(td.RefreshExp, 0))
+					return td.ClientID == tt.client.ID && ttl > tt.wantRefreshTTL-time.Minute && ttl <= tt.wantRefreshTTL
+				})).Return(nil)
+			}
+
+			ctx := lib.WithClient(context.Background(), tt.client)
+			accessB64 := base64.StdEncoding.EncodeToString([]byte(access))
+			refreshB64 := base64.StdEncoding.EncodeToString([]byte(refresh))
+			if _, _, err := tm.RefreshTokens(ctx, accessB64, refreshB64); !errors.Is(err, tt.wantErr) {
+				t.Errorf("RefreshTokens() err %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
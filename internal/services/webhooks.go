@@ -0,0 +1,387 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"go-jwt-auth/internal/config"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains"
+	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/models"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	_defaultWebhookAttempts       = 5
+	_defaultWebhookInitialBackoff = time.Second
+	_defaultWebhookMaxBackoff     = time.Minute
+	_defaultWebhookTimeout        = 5 * time.Second
+
+	// _webhookWorkers deliver the events, _webhookQueueSize bounds the
+	// deliveries waiting for them, the ones beyond are dead-lettered.
+	_webhookWorkers   = 8
+	_webhookQueueSize = 1024
+
+	// _webhookStorageTimeout bounds writes to the delivery log, they must
+	// succeed even for the deliveries interrupted by shutdown.
+	_webhookStorageTimeout = 5 * time.Second
+	// _webhookDrainTimeout bounds the wait for the queued deliveries on
+	// shutdown, within the default stop timeout of fx.
+	_webhookDrainTimeout = 10 * time.Second
+
+	HeaderWebhookID        = "X-Webhook-ID"
+	HeaderWebhookEvent     = "X-Webhook-Event"
+	HeaderWebhookTimestamp = "X-Webhook-Timestamp"
+	HeaderWebhookSignature = "X-Webhook-Signature"
+)
+
+// WebhookService delivers token events to the configured subscribers.
+// Every payload is signed with the subscription secret:
+// X-Webhook-Signature is hex(HMAC-SHA256(secret, timestamp + "." + body)).
+// Failed deliveries are retried with exponential backoff, every attempt is
+// written to the delivery log and the deliveries that ran out of attempts
+// are moved to the dead-letter collection. The deliveries are queued for a
+// fixed number of workers.
+type WebhookService struct {
+	subscriptions  []config.WebhookSubscription
+	storage        domains.WebhookStorage
+	logger         lib.Logger
+	client         *http.Client
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// mu guards closed and the sends to queue, so that nothing is queued
+	// once Close has closed it.
+	mu      sync.Mutex
+	closed  bool
+	queue   chan webhookJob
+	pending sync.WaitGroup // the queued and in-flight deliveries.
+	workers sync.WaitGroup
+}
+
+// webhookJob is a payload to deliver to a subscription.
+type webhookJob struct {
+	sub     config.WebhookSubscription
+	event   models.EventType
+	payload []byte
+}
+
+// NewWebhookService creates a new instance of WebhookService.
+func NewWebhookService(
+	lc fx.Lifecycle,
+	st domains.WebhookStorage,
+	logger lib.Logger,
+	conf lib.Config,
+) (domains.WebhookDispatcher, error) {
+	w, err := newWebhookService(st, logger, conf.Webhooks)
+	if err != nil {
+		return nil, err
+	}
+
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return w.Close(ctx)
+		},
+	})
+
+	return w, nil
+}
+
+func newWebhookService(st domains.WebhookStorage, logger lib.Logger, conf config.Webhooks) (*WebhookService, error) {
+	initialBackoff, err := durationOr(conf.InitialBackoff, _defaultWebhookInitialBackoff)
+	if err != nil {
+		logger.Error("can't parse webhooks initial_backoff", zap.Error(err))
+		return nil, err
+	}
+
+	maxBackoff, err := durationOr(conf.MaxBackoff, _defaultWebhookMaxBackoff)
+	if err != nil {
+		logger.Error("can't parse webhooks max_backoff", zap.Error(err))
+		return nil, err
+	}
+
+	timeout, err := durationOr(conf.Timeout, _defaultWebhookTimeout)
+	if err != nil {
+		logger.Error("can't parse webhooks timeout", zap.Error(err))
+		return nil, err
+	}
+
+	maxAttempts := conf.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = _defaultWebhookAttempts
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	w := &WebhookService{
+		subscriptions:  conf.Subscriptions,
+		storage:        st,
+		logger:         logger,
+		client:         &http.Client{Timeout: timeout},
+		maxAttempts:    maxAttempts,
+		initialBackoff: initialBackoff,
+		maxBackoff:     maxBackoff,
+		ctx:            ctx,
+		cancel:         cancel,
+		queue:          make(chan webhookJob, _webhookQueueSize),
+	}
+
+	w.workers.Add(_webhookWorkers)
+	for i := 0; i < _webhookWorkers; i++ {
+		go w.work()
+	}
+
+	return w, nil
+}
+
+// work delivers the queued payloads until the queue is closed.
+func (w *WebhookService) work() {
+	defer w.workers.Done()
+	for job := range w.queue {
+		w.deliver(job.sub, job.event, job.payload)
+		w.pending.Done()
+	}
+}
+
+// HandleEvent queues delivery of the event to every subscription interested
+// in it. It fails with constants.ErrClosed once the service is closed. The
+// deliveries beyond the queue are dead-lettered after the lock is released,
+// so that the requests publishing events don't queue up behind the storage.
+func (w *WebhookService) HandleEvent(_ context.Context, e models.Event) error {
+	var jobs []webhookJob
+	for _, sub := range w.subscriptions {
+		if !subscribed(sub, e.Type) {
+			continue
+		}
+
+		payload, err := json.Marshal(models.WebhookPayload{
+			ID:       uuid.NewString(),
+			Type:     e.Type,
+			GUID:     e.GUID,
+			ClientID: e.ClientID,
+			Time:     e.Time,
+		})
+		if err != nil {
+			return fmt.Errorf("can't marshal webhook payload: %v", err)
+		}
+		jobs = append(jobs, webhookJob{sub: sub, event: e.Type, payload: payload})
+	}
+
+	overflow, err := w.enqueue(jobs)
+	if err != nil {
+		return err
+	}
+
+	for _, job := range overflow {
+		w.deadLetter(models.WebhookDelivery{
+			ID:           uuid.NewString(),
+			Subscription: job.sub.Name,
+			URL:          job.sub.URL,
+			Event:        job.event,
+			Payload:      string(job.payload),
+			Time:         time.Now().Unix(),
+			Error:        "delivery queue is full",
+		})
+	}
+	return nil
+}
+
+// enqueue queues the jobs without blocking and returns the ones the queue
+// had no room for.
+func (w *WebhookService) enqueue(jobs []webhookJob) (overflow []webhookJob, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return nil, constants.ErrClosed
+	}
+
+	for _, job := range jobs {
+		w.pending.Add(1)
+		select {
+		case w.queue <- job:
+		default:
+			w.pending.Done()
+			overflow = append(overflow, job)
+		}
+	}
+	return overflow, nil
+}
+
+// Close refuses the new events and waits for the queued deliveries, so that
+// the events of a command exiting right after them are delivered. Once ctx is
+// done, or after _webhookDrainTimeout, it cancels the pending retries and
+// waits for the workers: the deliveries interrupted then are moved to the
+// dead-letter collection and Close returns the error of the context.
+func (w *WebhookService) Close(ctx context.Context) error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	close(w.queue)
+	w.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, _webhookDrainTimeout)
+	defer cancel()
+
+	drained := make(chan struct{})
+	go func() {
+		w.pending.Wait()
+		close(drained)
+	}()
+
+	var err error
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	w.cancel()
+	w.workers.Wait()
+	return err
+}
+
+// deliver sends the payload until the subscriber accepts it or attempts run out.
+func (w *WebhookService) deliver(sub config.WebhookSubscription, event models.EventType, payload []byte) {
+	d := models.WebhookDelivery{
+		ID:           uuid.NewString(),
+		Subscription: sub.Name,
+		URL:          sub.URL,
+		Event:        event,
+		Payload:      string(payload),
+	}
+
+	backoff := w.initialBackoff
+	for d.Attempt = 1; d.Attempt <= w.maxAttempts; d.Attempt++ {
+		d.Time = time.Now().Unix()
+		d.StatusCode, d.Error = w.send(sub, d)
+
+		w.log(d)
+
+		if d.Error == "" {
+			return
+		}
+
+		if d.Attempt == w.maxAttempts {
+			break
+		}
+
+		select {
+		case <-w.ctx.Done():
+			d.Error = "delivery interrupted by shutdown: " + d.Error
+			w.deadLetter(d)
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > w.maxBackoff {
+			backoff = w.maxBackoff
+		}
+	}
+
+	w.deadLetter(d)
+}
+
+// send makes a single delivery attempt and returns the response status and error, if any.
+func (w *WebhookService) send(sub config.WebhookSubscription, d models.WebhookDelivery) (int, string) {
+	req, err := http.NewRequestWithContext(w.ctx, http.MethodPost, sub.URL, bytes.NewBufferString(d.Payload))
+	if err != nil {
+		return 0, err.Error()
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderWebhookID, d.ID)
+	req.Header.Set(HeaderWebhookEvent, string(d.Event))
+	req.Header.Set(HeaderWebhookTimestamp, timestamp)
+	req.Header.Set(HeaderWebhookSignature, SignWebhook([]byte(sub.Secret), timestamp, []byte(d.Payload)))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, err.Error()
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Sprintf("unexpected status: %s", resp.Status)
+	}
+
+	return resp.StatusCode, ""
+}
+
+// log writes the delivery attempt to the delivery log.
+func (w *WebhookService) log(d models.WebhookDelivery) {
+	ctx, cancel := context.WithTimeout(context.Background(), _webhookStorageTimeout)
+	defer cancel()
+
+	if err := w.storage.SaveWebhookDelivery(ctx, d); err != nil {
+		w.logger.Error("can't save webhook delivery", zap.String("id", d.ID), zap.Error(err))
+	}
+}
+
+// deadLetter moves the delivery to the dead-letter collection.
+func (w *WebhookService) deadLetter(d models.WebhookDelivery) {
+	w.logger.Warn("webhook delivery failed",
+		zap.String("id", d.ID),
+		zap.String("subscription", d.Subscription),
+		zap.String("error", d.Error),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), _webhookStorageTimeout)
+	defer cancel()
+
+	if err := w.storage.SaveWebhookDeadLetter(ctx, d); err != nil {
+		w.logger.Error("can't save webhook dead letter", zap.String("id", d.ID), zap.Error(err))
+	}
+}
+
+// SignWebhook signs the webhook body sent at timestamp with the secret.
+func SignWebhook(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// subscribed reports whether the subscription receives events of type t.
+func subscribed(sub config.WebhookSubscription, t models.EventType) bool {
+	if len(sub.Events) == 0 {
+		return true
+	}
+
+	for _, e := range sub.Events {
+		if models.EventType(e) == t {
+			return true
+		}
+	}
+
+	return false
+}
+
+// durationOr parses s, returning def if s is empty.
+func durationOr(s string, def time.Duration) (time.Duration, error) {
+	if s == "" {
+		return def, nil
+	}
+	return time.ParseDuration(s)
+}
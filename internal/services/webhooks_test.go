@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/mock"
+	"go-jwt-auth/internal/config"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains/mocks"
+	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/models"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWebhookService_HandleEvent(t *testing.T) {
+	const secret = "qwfqwfkqw"
+
+	tests := []struct {
+		name           string
+		failures       int32
+		events         []string
+		wantDeliveries int
+		wantDeadLetter bool
+	}{
+		{
+			name:           "ok",
+			wantDeliveries: 1,
+		},
+		{
+			name:           "retried",
+			failures:       2,
+			wantDeliveries: 3,
+		},
+		{
+			name:           "deadLetter",
+			failures:       10,
+			wantDeliveries: 3,
+			wantDeadLetter: true,
+		},
+		{
+			name:   "notSubscribed",
+			events: []string{string(models.EventRevoked)},
+		},
+	}
+
+	logger, err := lib.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create Logger instance: %v", err)
+	}
+
+	e := models.Event{Type: models.EventIssued, GUID: "ikj", Time: 100}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+
+				sign := SignWebhook([]byte(secret), r.Header.Get(HeaderWebhookTimestamp), body)
+				if sign != r.Header.Get(HeaderWebhookSignature) {
+					t.Errorf("signature = %v, want %v", r.Header.Get(HeaderWebhookSignature), sign)
+				}
+
+				var p models.WebhookPayload
+				if err := json.Unmarshal(body, &p); err != nil {
+					t.Errorf("can't unmarshal payload: %v", err)
+				}
+				if p.GUID != e.GUID || p.Type != e.Type {
+					t.Errorf("payload = %+v, want event %+v", p, e)
+				}
+
+				if calls.Add(1) <= tt.failures {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+			}))
+			defer srv.Close()
+
+			st := mocks.NewWebhookStorage(t)
+			if tt.wantDeliveries > 0 {
+				st.On("SaveWebhookDelivery", mock.Anything, mock.AnythingOfType("models.WebhookDelivery")).
+					Return(nil).Times(tt.wantDeliveries)
+			}
+			if tt.wantDeadLetter {
+				st.On("SaveWebhookDeadLetter", mock.Anything, mock.AnythingOfType("models.WebhookDelivery")).
+					Return(nil).Once()
+			}
+
+			w, err := newWebhookService(st, logger, config.Webhooks{
+				Subscriptions: []config.WebhookSubscription{
+					{Name: "fraud", URL: srv.URL, Secret: secret, Events: tt.events},
+				},
+				MaxAttempts:    3,
+				InitialBackoff: "1ms",
+				MaxBackoff:     "2ms",
+			})
+			if err != nil {
+				t.Fatalf("newWebhookService() error = %v", err)
+			}
+
+			if err := w.HandleEvent(context.Background(), e); err != nil {
+				t.Errorf("HandleEvent() error = %v", err)
+			}
+
+			// Close waits for the queued deliveries and their retries.
+			if err := w.Close(context.Background()); err != nil {
+				t.Errorf("Close() error = %v", err)
+			}
+
+			if got := int(calls.Load()); got != tt.wantDeliveries {
+				t.Errorf("deliveries = %v, want %v", got, tt.wantDeliveries)
+			}
+		})
+	}
+}
+
+func TestWebhookService_Close(t *testing.T) {
+	logger, err := lib.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create Logger instance: %v", err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	st := mocks.NewWebhookStorage(t)
+	st.On("SaveWebhookDelivery", mock.Anything, mock.AnythingOfType("models.WebhookDelivery")).
+		Return(nil).Once()
+	st.On("SaveWebhookDeadLetter", mock.Anything, mock.AnythingOfType("models.WebhookDelivery")).
+		Return(nil).Once()
+
+	w, err := newWebhookService(st, logger, config.Webhooks{
+		Subscriptions:  []config.WebhookSubscription{{Name: "fraud", URL: srv.URL}},
+		InitialBackoff: "1h",
+	})
+	if err != nil {
+		t.Fatalf("newWebhookService() error = %v", err)
+	}
+
+	if err := w.HandleEvent(context.Background(), models.Event{Type: models.EventRevoked, GUID: "ikj"}); err != nil {
+		t.Errorf("HandleEvent() error = %v", err)
+	}
+
+	// the retry in an hour can't be waited for, it is interrupted.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := w.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Close() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	err = w.HandleEvent(context.Background(), models.Event{Type: models.EventRevoked, GUID: "ikj"})
+	if !errors.Is(err, constants.ErrClosed) {
+		t.Errorf("HandleEvent() after Close error = %v, want %v", err, constants.ErrClosed)
+	}
+}
+
+func TestWebhookService_HandleEvent_QueueFull(t *testing.T) {
+	logger, err := lib.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create Logger instance: %v", err)
+	}
+
+	// the dead letter is written while another event is handled.
+	saving, release := make(chan struct{}), make(chan struct{})
+	st := mocks.NewWebhookStorage(t)
+	st.On("SaveWebhookDeadLetter", mock.Anything, mock.MatchedBy(func(d models.WebhookDelivery) bool {
+		return d.Error == "delivery queue is full"
+	})).Run(func(mock.Arguments) {
+		close(saving)
+		<-release
+	}).Return(nil).Once()
+
+	// no room and no workers, every delivery overflows.
+	w := &WebhookService{
+		subscriptions: []config.WebhookSubscription{{Name: "fraud", URL: "http://127.0.0.1:1"}},
+		storage:       st,
+		logger:        logger,
+		queue:         make(chan webhookJob),
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- w.HandleEvent(context.Background(), models.Event{Type: models.EventRevoked, GUID: "ikj"})
+	}()
+
+	<-saving
+	if !w.mu.TryLock() {
+		t.Errorf("HandleEvent() holds the lock while writing the dead letter")
+	} else {
+		w.mu.Unlock()
+	}
+	close(release)
+
+	if err := <-done; err != nil {
+		t.Errorf("HandleEvent() error = %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package signing
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"go-jwt-auth/internal/models"
+	"math/big"
+)
+
+// JWK is a verification key, see RFC 7517 4.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// K is the secret of the HMAC keys, they are never published.
+	K   string `json:"k,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKSet is a JWK set, see RFC 7517 5.
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// VerificationJWK returns the JWK verifying the tokens of the key: its public
+// key, or the secret of an HMAC key.
+func VerificationJWK(k models.SigningKey) (JWK, error) {
+	key, err := PublicKey(k)
+	if err != nil {
+		return JWK{}, err
+	}
+
+	j := JWK{Kid: k.ID, Use: "sig", Alg: k.Algorithm}
+	b64 := base64.RawURLEncoding.EncodeToString
+	switch key := key.(type) {
+	case []byte:
+		j.Kty, j.K = "oct", b64(key)
+	case *rsa.PublicKey:
+		j.Kty, j.N, j.E = "RSA", b64(key.N.Bytes()), b64(big.NewInt(int64(key.E)).Bytes())
+	case *ecdsa.PublicKey:
+		// the coordinates are padded to the size of the curve, see RFC 7518 6.2.1.2.
+		size := (key.Curve.Params().BitSize + 7) / 8
+		j.Kty, j.Crv = "EC", key.Curve.Params().Name
+		j.X, j.Y = b64(key.X.FillBytes(make([]byte, size))), b64(key.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		j.Kty, j.Crv, j.X = "OKP", "Ed25519", b64(key)
+	default:
+		return JWK{}, fmt.Errorf("key %q: unsupported key %T", k.ID, key)
+	}
+	return j, nil
+}
+
+// PublicPEM returns the PKIX PEM of the public key, the HMAC keys have none.
+func PublicPEM(k models.SigningKey) (string, error) {
+	if KeyType(k.Algorithm) == models.KeyTypeHMAC {
+		return "", fmt.Errorf("key %q: the hmac keys have no public key", k.ID)
+	}
+
+	key, err := PublicKey(k)
+	if err != nil {
+		return "", err
+	}
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return "", fmt.Errorf("key %q: %v", k.ID, err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
+
+// JWKS is the published JWK set of the tenant: the public keys of its
+// asymmetric keys that are not retired, the next ones included.
+func JWKS(t models.Tenant) (JWKSet, error) {
+	set := JWKSet{Keys: []JWK{}}
+	for _, k := range t.Keys {
+		if k.State == models.KeyStateRetired || KeyType(k.Algorithm) == models.KeyTypeHMAC {
+			continue
+		}
+		j, err := VerificationJWK(k)
+		if err != nil {
+			return set, err
+		}
+		set.Keys = append(set.Keys, j)
+	}
+	return set, nil
+}
@@ -0,0 +1,286 @@
+// Package signing generates the keys of the key store and selects the keys
+// signing and verifying the tokens of the tenants.
+package signing
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/models"
+	"time"
+)
+
+const (
+	// _hmacSize is the size of the HMAC secrets, the size of the SHA-512 blocks would be 128.
+	_hmacSize = 64
+	_rsaBits  = 2048
+	_kidSize  = 12
+)
+
+// _algs are the algs of the key types.
+var _algs = map[string]string{
+	models.KeyTypeHMAC:    jwt.SigningMethodHS512.Alg(),
+	models.KeyTypeRSA:     jwt.SigningMethodRS256.Alg(),
+	models.KeyTypeEC:      jwt.SigningMethodES256.Alg(),
+	models.KeyTypeEd25519: jwt.SigningMethodEdDSA.Alg(),
+}
+
+// KeyType returns the type of the key of the alg.
+func KeyType(alg string) string {
+	for typ, a := range _algs {
+		if a == alg {
+			return typ
+		}
+	}
+	return ""
+}
+
+// Generate generates a key of the type for the tenant, in the next state:
+// a 512-bit HS512 secret, a 2048-bit RS256 key, an ES256 key or an EdDSA Ed25519 key.
+func Generate(tenant, keyType string) (k models.SigningKey, err error) {
+	alg, ok := _algs[keyType]
+	if !ok {
+		return k, fmt.Errorf("%w %q", constants.ErrUnknownKeyType, keyType)
+	}
+
+	kid := make([]byte, _kidSize)
+	if _, err := rand.Read(kid); err != nil {
+		return k, fmt.Errorf("can't generate kid: %v", err)
+	}
+
+	var private string
+	if keyType == models.KeyTypeHMAC {
+		secret := make([]byte, _hmacSize)
+		if _, err := rand.Read(secret); err != nil {
+			return k, fmt.Errorf("can't generate secret: %v", err)
+		}
+		private = base64.RawURLEncoding.EncodeToString(secret)
+	} else {
+		var key crypto.Signer
+		switch keyType {
+		case models.KeyTypeRSA:
+			key, err = rsa.GenerateKey(rand.Reader, _rsaBits)
+		case models.KeyTypeEC:
+			key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		default:
+			_, key, err = ed25519.GenerateKey(rand.Reader)
+		}
+		if err != nil {
+			return k, fmt.Errorf("can't generate %s key: %v", keyType, err)
+		}
+
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return k, fmt.Errorf("can't marshal %s key: %v", keyType, err)
+		}
+		private = string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	return models.SigningKey{
+		ID:        base64.RawURLEncoding.EncodeToString(kid),
+		Tenant:    tenant,
+		Algorithm: alg,
+		State:     models.KeyStateNext,
+		Private:   private,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, nil
+}
+
+// PrivateKey returns the key signing with the key: the secret of the HMAC
+// keys, the crypto.Signer of the others.
+func PrivateKey(k models.SigningKey) (any, error) {
+	if k.Algorithm == jwt.SigningMethodHS512.Alg() {
+		secret, err := base64.RawURLEncoding.DecodeString(k.Private)
+		if err != nil || len(secret) == 0 {
+			return nil, fmt.Errorf("key %q: invalid secret", k.ID)
+		}
+		return secret, nil
+	}
+
+	block, _ := pem.Decode([]byte(k.Private))
+	if block == nil {
+		return nil, fmt.Errorf("key %q: no PEM block", k.ID)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("key %q: %v", k.ID, err)
+	}
+
+	var ok bool
+	switch k.Algorithm {
+	case jwt.SigningMethodRS256.Alg():
+		_, ok = key.(*rsa.PrivateKey)
+	case jwt.SigningMethodES256.Alg():
+		var ec *ecdsa.PrivateKey
+		if ec, ok = key.(*ecdsa.PrivateKey); ok {
+			ok = ec.Curve == elliptic.P256()
+		}
+	case jwt.SigningMethodEdDSA.Alg():
+		_, ok = key.(ed25519.PrivateKey)
+	}
+	if !ok {
+		return nil, fmt.Errorf("key %q: not a %s key", k.ID, k.Algorithm)
+	}
+	return key, nil
+}
+
+// PublicKey returns the key verifying with the key: the secret of the HMAC
+// keys, the public key of the others.
+func PublicKey(k models.SigningKey) (any, error) {
+	key, err := PrivateKey(k)
+	if err != nil {
+		return nil, err
+	}
+	if signer, ok := key.(crypto.Signer); ok {
+		return signer.Public(), nil
+	}
+	return key, nil
+}
+
+// Signer returns the method, the key and the kid signing the tokens of the
+// tenant: its active key, or its configured HS512 key without a kid.
+func Signer(t models.Tenant) (jwt.SigningMethod, any, string, error) {
+	for _, k := range t.Keys {
+		if k.State != models.KeyStateActive {
+			continue
+		}
+		key, err := PrivateKey(k)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		return jwt.GetSigningMethod(k.Algorithm), key, k.ID, nil
+	}
+	return jwt.SigningMethodHS512, t.Key, "", nil
+}
+
+// Sign signs the claims with the signer of the tenant.
+func Sign(t models.Tenant, claims jwt.Claims) (string, error) {
+	method, key, kid, err := Signer(t)
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	return token.SignedString(key)
+}
+
+// Keyfunc returns the key verifying a token of the tenant: the key of its
+// kid, which must not be retired, or the configured key without a kid while
+// the tenant has no active key. The configured key stops signing once a key
+// is active, and the active key can't be retired, so it is retired for good:
+// a leaked jwt.key can't forge the tokens of the key store. The alg of the
+// token must be the one of the key.
+func Keyfunc(t models.Tenant) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			if hasActiveKey(t) {
+				return nil, fmt.Errorf("no kid, the configured key is retired")
+			}
+			if token.Method.Alg() != jwt.SigningMethodHS512.Alg() {
+				return nil, fmt.Errorf("unexpected alg %s", token.Method.Alg())
+			}
+			return t.Key, nil
+		}
+
+		for _, k := range t.Keys {
+			if k.ID != kid || k.State == models.KeyStateRetired {
+				continue
+			}
+			if token.Method.Alg() != k.Algorithm {
+				return nil, fmt.Errorf("unexpected alg %s for key %q", token.Method.Alg(), kid)
+			}
+			return PublicKey(k)
+		}
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+}
+
+// RefreshKeyfunc is Keyfunc still accepting the configured key for the tokens
+// without a kid once a key is active. It only verifies the access tokens
+// /v1/refresh exchanges along with their refresh token: the refresh token,
+// checked against its hash, proves the session, the access token only names
+// its GUID, so the sessions started before the first rotation can be refreshed.
+func RefreshKeyfunc(t models.Tenant) jwt.Keyfunc {
+	keyfunc := Keyfunc(t)
+	return func(token *jwt.Token) (interface{}, error) {
+		if kid, _ := token.Header["kid"].(string); kid != "" {
+			return keyfunc(token)
+		}
+		if token.Method.Alg() != jwt.SigningMethodHS512.Alg() {
+			return nil, fmt.Errorf("unexpected alg %s", token.Method.Alg())
+		}
+		return t.Key, nil
+	}
+}
+
+// hasActiveKey reports whether a key of the key store signs the tokens of the tenant.
+func hasActiveKey(t models.Tenant) bool {
+	for _, k := range t.Keys {
+		if k.State == models.KeyStateActive {
+			return true
+		}
+	}
+	return false
+}
+
+// Methods are the algs of the tokens of the tenant.
+func Methods(t models.Tenant) []string {
+	methods := []string{jwt.SigningMethodHS512.Alg()}
+	for _, k := range t.Keys {
+		if k.State != models.KeyStateRetired && !contains(methods, k.Algorithm) {
+			methods = append(methods, k.Algorithm)
+		}
+	}
+	return methods
+}
+
+// SignsIDTokens reports whether the tenant can sign ID tokens: its active key
+// is RS256, ES256 or EdDSA. The HS512 ID tokens could only be checked with the
+// secret of the tenant, which the clients don't have.
+func SignsIDTokens(t models.Tenant) bool {
+	for _, k := range t.Keys {
+		if k.State == models.KeyStateActive {
+			return k.Algorithm != jwt.SigningMethodHS512.Alg()
+		}
+	}
+	return false
+}
+
+// IDTokenMethods are the algs of the ID tokens of the tenant: the ones of its
+// active and previous keys, except the hmac keys.
+func IDTokenMethods(t models.Tenant) []string {
+	methods := []string{}
+	for _, k := range t.Keys {
+		if k.State != models.KeyStateActive && k.State != models.KeyStatePrevious {
+			continue
+		}
+		if k.Algorithm != jwt.SigningMethodHS512.Alg() && !contains(methods, k.Algorithm) {
+			methods = append(methods, k.Algorithm)
+		}
+	}
+	return methods
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
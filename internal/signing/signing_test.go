@@ -0,0 +1,179 @@
+package signing
+
+import (
+	"github.com/golang-jwt/jwt/v5"
+	"go-jwt-auth/internal/models"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSign(t *testing.T) {
+	for _, keyType := range []string{models.KeyTypeHMAC, models.KeyTypeRSA, models.KeyTypeEC, models.KeyTypeEd25519} {
+		t.Run(keyType, func(t *testing.T) {
+			k, err := Generate("acme", keyType)
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+			if k.State != models.KeyStateNext || k.Tenant != "acme" || KeyType(k.Algorithm) != keyType {
+				t.Fatalf("Generate() = %+v", k)
+			}
+
+			previous, err := Generate("acme", models.KeyTypeHMAC)
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+			previous.State = models.KeyStatePrevious
+
+			k.State = models.KeyStateActive
+			tenant := models.Tenant{ID: "acme", Key: []byte("acme-secret"), Keys: []models.SigningKey{previous, k}}
+
+			token, err := Sign(tenant, jwt.MapClaims{"guid": "kwfwe"})
+			if err != nil {
+				t.Fatalf("Sign() error = %v", err)
+			}
+
+			parsed, err := jwt.Parse(token, Keyfunc(tenant), jwt.WithValidMethods(Methods(tenant)))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if parsed.Header["kid"] != k.ID || parsed.Method.Alg() != k.Algorithm {
+				t.Errorf("header = %v, want the kid and alg of %+v", parsed.Header, k)
+			}
+
+			// the tokens are no longer valid once the key is retired.
+			tenant.Keys[1].State = models.KeyStateRetired
+			if _, err := jwt.Parse(token, Keyfunc(tenant)); err == nil {
+				t.Errorf("Parse() error = nil with the key retired")
+			}
+		})
+	}
+}
+
+func TestKeyfunc(t *testing.T) {
+	hmac, err := Generate("", models.KeyTypeHMAC)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	hmac.State = models.KeyStateNext
+	tenant := models.Tenant{Key: []byte("secret"), Keys: []models.SigningKey{hmac}}
+
+	sign := func(method jwt.SigningMethod, kid string, key any) string {
+		token := jwt.NewWithClaims(method, jwt.MapClaims{"guid": "kwfwe"})
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		s, err := token.SignedString(key)
+		if err != nil {
+			t.Fatalf("SignedString() error = %v", err)
+		}
+		return s
+	}
+	secret, _ := PrivateKey(hmac)
+
+	for name, tt := range map[string]struct {
+		token   string
+		wantErr bool
+	}{
+		// the configured key verifies the tokens without a kid until a key is rotated in.
+		"configuredKey":   {token: sign(jwt.SigningMethodHS512, "", []byte("secret"))},
+		"kid":             {token: sign(jwt.SigningMethodHS512, hmac.ID, secret)},
+		"unknownKid":      {token: sign(jwt.SigningMethodHS512, "k0", secret), wantErr: true},
+		"anotherAlg":      {token: sign(jwt.SigningMethodHS256, hmac.ID, secret), wantErr: true},
+		"anotherAlgNoKid": {token: sign(jwt.SigningMethodHS256, "", []byte("secret")), wantErr: true},
+	} {
+		if _, err := jwt.Parse(tt.token, Keyfunc(tenant)); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Parse() error = %v, wantErr %v", name, err, tt.wantErr)
+		}
+	}
+
+	// the configured key is retired once a key of the key store is active.
+	tenant.Keys[0].State = models.KeyStateActive
+	if _, err := jwt.Parse(sign(jwt.SigningMethodHS512, "", []byte("secret")), Keyfunc(tenant)); err == nil {
+		t.Errorf("configuredKeyRetired: Parse() error = nil")
+	}
+	if _, err := jwt.Parse(sign(jwt.SigningMethodHS512, hmac.ID, secret), Keyfunc(tenant)); err != nil {
+		t.Errorf("activeKid: Parse() error = %v", err)
+	}
+
+	// the refreshes still take the tokens of the configured key.
+	if _, err := jwt.Parse(sign(jwt.SigningMethodHS512, "", []byte("secret")), RefreshKeyfunc(tenant)); err != nil {
+		t.Errorf("refreshConfiguredKey: Parse() error = %v", err)
+	}
+	if _, err := jwt.Parse(sign(jwt.SigningMethodHS512, "", []byte("another")), RefreshKeyfunc(tenant)); err == nil {
+		t.Errorf("refreshAnotherKey: Parse() error = nil")
+	}
+	if _, err := jwt.Parse(sign(jwt.SigningMethodHS512, "k0", secret), RefreshKeyfunc(tenant)); err == nil {
+		t.Errorf("refreshUnknownKid: Parse() error = nil")
+	}
+}
+
+func TestVerificationJWK(t *testing.T) {
+	for keyType, want := range map[string]string{
+		models.KeyTypeHMAC:    "oct",
+		models.KeyTypeRSA:     "RSA",
+		models.KeyTypeEC:      "EC",
+		models.KeyTypeEd25519: "OKP",
+	} {
+		k, err := Generate("", keyType)
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		j, err := VerificationJWK(k)
+		if err != nil || j.Kty != want || j.Kid != k.ID || j.Alg != k.Algorithm {
+			t.Errorf("VerificationJWK(%s) = %+v, %v", keyType, j, err)
+		}
+
+		pem, err := PublicPEM(k)
+		if keyType == models.KeyTypeHMAC {
+			if err == nil {
+				t.Errorf("PublicPEM(hmac) error = nil")
+			}
+		} else if err != nil || !strings.HasPrefix(pem, "-----BEGIN PUBLIC KEY-----") {
+			t.Errorf("PublicPEM(%s) = %q, %v", keyType, pem, err)
+		}
+	}
+
+	if _, err := Generate("", "dsa"); err == nil {
+		t.Errorf("Generate(dsa) error = nil")
+	}
+}
+
+func TestIDTokenMethods(t *testing.T) {
+	key := func(alg string, state models.KeyState) models.SigningKey {
+		return models.SigningKey{Algorithm: alg, State: state}
+	}
+
+	for name, tt := range map[string]struct {
+		keys        []models.SigningKey
+		wantSigns   bool
+		wantMethods []string
+	}{
+		"configuredKey": {wantMethods: []string{}},
+		"hmacActive": {
+			keys:        []models.SigningKey{key("HS512", models.KeyStateActive), key("RS256", models.KeyStateNext)},
+			wantMethods: []string{},
+		},
+		"rotatedFromHMAC": {
+			keys:        []models.SigningKey{key("HS512", models.KeyStatePrevious), key("ES256", models.KeyStateActive)},
+			wantSigns:   true,
+			wantMethods: []string{"ES256"},
+		},
+		"rotated": {
+			keys: []models.SigningKey{
+				key("RS256", models.KeyStatePrevious), key("EdDSA", models.KeyStateActive),
+				key("ES256", models.KeyStateNext), key("ES256", models.KeyStateRetired),
+			},
+			wantSigns:   true,
+			wantMethods: []string{"RS256", "EdDSA"},
+		},
+	} {
+		tenant := models.Tenant{Key: []byte("secret"), Keys: tt.keys}
+		if got := SignsIDTokens(tenant); got != tt.wantSigns {
+			t.Errorf("%s: SignsIDTokens() = %v, want %v", name, got, tt.wantSigns)
+		}
+		if got := IDTokenMethods(tenant); !reflect.DeepEqual(got, tt.wantMethods) {
+			t.Errorf("%s: IDTokenMethods() = %v, want %v", name, got, tt.wantMethods)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains"
+	"go-jwt-auth/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	_audit = "audit"
+	_seq   = "seq"
+)
+
+// NewAuditStorage exposes the audit trail of the database if it supports one.
+func NewAuditStorage(db domains.Database) (domains.AuditStorage, error) {
+	st, ok := db.(domains.AuditStorage)
+	if !ok {
+		return nil, fmt.Errorf("audit trail: %w", constants.ErrNotSupported)
+	}
+	return st, nil
+}
+
+// AppendAuditRecord appends a record to the audit trail.
+// The audit collection is append-only: records are never updated or deleted.
+func (d Database) AppendAuditRecord(ctx context.Context, r models.AuditRecord) error {
+	if _, err := d.db.Collection(_audit).InsertOne(ctx, r); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return constants.ErrAlreadyExists
+		}
+		return fmt.Errorf("can't insert audit record: %v", err)
+	}
+
+	return nil
+}
+
+// LastAuditRecord retrieves the record with the highest sequence number.
+func (d Database) LastAuditRecord(ctx context.Context) (r models.AuditRecord, err error) {
+	opts := options.FindOne().SetSort(bson.D{{Key: _seq, Value: -1}})
+	err = d.db.Collection(_audit).FindOne(ctx, bson.D{}, opts).Decode(&r)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return r, constants.ErrNotFound
+		}
+		return r, err
+	}
+
+	return r, nil
+}
+
+// GetAuditRecords retrieves up to limit records starting from the given sequence number.
+func (d Database) GetAuditRecords(ctx context.Context, fromSeq int64, limit int64) (r []models.AuditRecord, err error) {
+	filter := bson.D{{Key: _seq, Value: bson.D{{Key: "$gte", Value: fromSeq}}}}
+	opts := options.Find().SetSort(bson.D{{Key: _seq, Value: 1}}).SetLimit(limit)
+
+	cur, err := d.db.Collection(_audit).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer func(cur *mongo.Cursor, ctx context.Context) {
+		errClose := cur.Close(ctx)
+		if errClose != nil && err == nil {
+			err = errClose
+		}
+	}(cur, ctx)
+
+	if err := cur.All(ctx, &r); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
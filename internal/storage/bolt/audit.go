@@ -0,0 +1,72 @@
+package bolt
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/models"
+	"go.etcd.io/bbolt"
+)
+
+// AppendAuditRecord appends a record to the audit trail.
+func (d *Database) AppendAuditRecord(ctx context.Context, r models.AuditRecord) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("can't marshal audit record: %v", err)
+	}
+
+	return d.db.Update(func(tx *bbolt.Tx) error {
+		audit := tx.Bucket(_audit)
+
+		key := itob(uint64(r.Seq))
+		if audit.Get(key) != nil {
+			return constants.ErrAlreadyExists
+		}
+
+		return audit.Put(key, data)
+	})
+}
+
+// LastAuditRecord retrieves the record with the highest sequence number.
+func (d *Database) LastAuditRecord(ctx context.Context) (r models.AuditRecord, err error) {
+	if err := ctx.Err(); err != nil {
+		return r, err
+	}
+
+	err = d.db.View(func(tx *bbolt.Tx) error {
+		k, v := tx.Bucket(_audit).Cursor().Last()
+		if k == nil {
+			return constants.ErrNotFound
+		}
+		return json.Unmarshal(v, &r)
+	})
+
+	return r, err
+}
+
+// GetAuditRecords retrieves up to limit records starting from the given sequence number.
+func (d *Database) GetAuditRecords(ctx context.Context, fromSeq int64, limit int64) (r []models.AuditRecord, err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	err = d.db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket(_audit).Cursor()
+		for k, v := c.Seek(itob(uint64(fromSeq))); k != nil && int64(len(r)) < limit; k, v = c.Next() {
+			var ar models.AuditRecord
+			if err := json.Unmarshal(v, &ar); err != nil {
+				return fmt.Errorf("can't unmarshal audit record %d: %v", binary.BigEndian.Uint64(k), err)
+			}
+			r = append(r, ar)
+		}
+		return nil
+	})
+
+	return r, err
+}
@@ -0,0 +1,321 @@
+// Package bolt is an embedded storage in a single local file.
+// The space taken by the purged sessions is reused by the new sessions.
+package bolt
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/models"
+	"go-jwt-auth/internal/storage/tokenschema"
+	"go.etcd.io/bbolt"
+)
+
+var (
+	// _sessions maps guid + 0x00 + id to the session, so the sessions of a
+	// GUID are adjacent and ordered by creation.
+	_sessions = []byte("sessions")
+	// _expiry maps refresh expiry + session key to nothing, it is the index of PurgeExpired.
+	_expiry = []byte("expiry")
+
+	// _clients maps the client ID to the client.
+	_clients = []byte("clients")
+	// _codes maps the hash of the authorization code to the code.
+	_codes = []byte("codes")
+
+	_audit              = []byte("audit")
+	_webhookDeliveries  = []byte("webhook_deliveries")
+	_webhookDeadLetters = []byte("webhook_dead_letters")
+
+	_buckets = [][]byte{_sessions, _expiry, _clients, _codes, _audit, _webhookDeliveries, _webhookDeadLetters}
+)
+
+// Database is an embedded storage in a single local file.
+type Database struct {
+	db *bbolt.DB
+}
+
+// New creates a new instance of Database.
+func New(db *bbolt.DB) (*Database, error) {
+	err := db.Update(func(tx *bbolt.Tx) error {
+		for _, name := range _buckets {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't create buckets: %v", err)
+	}
+
+	return &Database{db: db}, nil
+}
+
+// Close closes the file.
+func (d *Database) Close() error {
+	return d.db.Close()
+}
+
+// SaveTokenData saves a token.
+func (d *Database) SaveTokenData(ctx context.Context, t models.TokenData) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(t)
+	if err != nil {
+		return fmt.Errorf("can't marshal token: %v", err)
+	}
+
+	err = d.db.Update(func(tx *bbolt.Tx) error {
+		sessions := tx.Bucket(_sessions)
+
+		id, err := sessions.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		key := sessionKey(t.GUID, id)
+		if err := sessions.Put(key, data); err != nil {
+			return err
+		}
+
+		if t.RefreshExp <= 0 {
+			return nil
+		}
+		return tx.Bucket(_expiry).Put(expiryKey(t.RefreshExp, key), nil)
+	})
+	if err != nil {
+		return fmt.Errorf("can't save token: %v", err)
+	}
+
+	return nil
+}
+
+// GetTokensDataByGUID retrieves the sessions of a given GUID.
+func (d *Database) GetTokensDataByGUID(ctx context.Context, guid string) (t []models.TokenData, err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	err = d.db.View(func(tx *bbolt.Tx) error {
+		prefix := guidPrefix(guid)
+		c := tx.Bucket(_sessions).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var td models.TokenData
+			if err := json.Unmarshal(v, &td); err != nil {
+				return fmt.Errorf("can't unmarshal token: %v", err)
+			}
+			if td.GUID != guid {
+				// the key of a GUID continuing with a NUL, see guidPrefix.
+				continue
+			}
+			t = append(t, tokenschema.Upgrade(td))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(t) == 0 {
+		return nil, constants.ErrNotFound
+	}
+
+	return t, nil
+}
+
+// DeleteTokenData consumes a session. Writes are serialized by the file
+// lock, so when the same session is consumed concurrently, only one caller
+// succeeds, the others get constants.ErrNotFound.
+func (d *Database) DeleteTokenData(ctx context.Context, guid, hash string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return d.db.Update(func(tx *bbolt.Tx) error {
+		prefix := guidPrefix(guid)
+		c := tx.Bucket(_sessions).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var td models.TokenData
+			if err := json.Unmarshal(v, &td); err != nil {
+				return fmt.Errorf("can't unmarshal token: %v", err)
+			}
+
+			if td.GUID != guid || td.RefreshHash != hash {
+				continue
+			}
+
+			if td.RefreshExp > 0 {
+				if err := tx.Bucket(_expiry).Delete(expiryKey(td.RefreshExp, k)); err != nil {
+					return err
+				}
+			}
+			return c.Delete()
+		}
+
+		return constants.ErrNotFound
+	})
+}
+
+// PurgeExpired deletes the sessions whose refresh token expired before now.
+// The expired authorization codes are deleted too, they aren't counted.
+func (d *Database) PurgeExpired(ctx context.Context, now int64) (purged int64, err error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	err = d.db.Update(func(tx *bbolt.Tx) error {
+		var expired [][]byte
+
+		c := tx.Bucket(_expiry).Cursor()
+		for k, _ := c.First(); k != nil && int64(binary.BigEndian.Uint64(k)) < now; k, _ = c.Next() {
+			expired = append(expired, k)
+		}
+
+		sessions, expiry := tx.Bucket(_sessions), tx.Bucket(_expiry)
+		for _, k := range expired {
+			if err := sessions.Delete(k[8:]); err != nil {
+				return err
+			}
+			if err := expiry.Delete(k); err != nil {
+				return err
+			}
+		}
+
+		purged = int64(len(expired))
+		return purgeExpiredCodes(tx, now)
+	})
+
+	return purged, err
+}
+
+// ScanTokenData calls fn with every session until it returns an error.
+// fn must not write to the database, the scan is a read transaction.
+func (d *Database) ScanTokenData(ctx context.Context, fn func(models.TokenData) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return d.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(_sessions).ForEach(func(_, v []byte) error {
+			var td models.TokenData
+			if err := json.Unmarshal(v, &td); err != nil {
+				return fmt.Errorf("can't unmarshal token: %v", err)
+			}
+			return fn(tokenschema.Upgrade(td))
+		})
+	})
+}
+
+// CountOutdatedTokenData counts the sessions saved in an older schema version.
+func (d *Database) CountOutdatedTokenData(ctx context.Context) (n int64, err error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	err = d.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(_sessions).ForEach(func(_, v []byte) error {
+			var td models.TokenData
+			if err := json.Unmarshal(v, &td); err != nil {
+				return fmt.Errorf("can't unmarshal token: %v", err)
+			}
+			if tokenschema.Outdated(td) {
+				n++
+			}
+			return nil
+		})
+	})
+
+	return n, err
+}
+
+// RewriteOutdatedTokenData upgrades up to limit sessions saved in an older
+// schema version. A batch is a single write transaction.
+func (d *Database) RewriteOutdatedTokenData(ctx context.Context, limit int64) (n int64, err error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	err = d.db.Update(func(tx *bbolt.Tx) error {
+		sessions, expiry := tx.Bucket(_sessions), tx.Bucket(_expiry)
+
+		// the bucket isn't modified while the cursor walks it, that would
+		// invalidate the cursor.
+		var (
+			keys     [][]byte
+			outdated []models.TokenData
+		)
+		c := sessions.Cursor()
+		for k, v := c.First(); k != nil && int64(len(keys)) < limit; k, v = c.Next() {
+			var td models.TokenData
+			if err := json.Unmarshal(v, &td); err != nil {
+				return fmt.Errorf("can't unmarshal token: %v", err)
+			}
+			if tokenschema.Outdated(td) {
+				keys = append(keys, append([]byte(nil), k...))
+				outdated = append(outdated, td)
+			}
+		}
+
+		for i, td := range outdated {
+			upgraded := tokenschema.Upgrade(td)
+			data, err := json.Marshal(upgraded)
+			if err != nil {
+				return fmt.Errorf("can't marshal token: %v", err)
+			}
+
+			if err := sessions.Put(keys[i], data); err != nil {
+				return err
+			}
+
+			if upgraded.RefreshExp == td.RefreshExp {
+				continue
+			}
+			if td.RefreshExp > 0 {
+				if err := expiry.Delete(expiryKey(td.RefreshExp, keys[i])); err != nil {
+					return err
+				}
+			}
+			if upgraded.RefreshExp > 0 {
+				if err := expiry.Put(expiryKey(upgraded.RefreshExp, keys[i]), nil); err != nil {
+					return err
+				}
+			}
+		}
+
+		n = int64(len(outdated))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// guidPrefix is the prefix of the keys of the sessions of the GUID. The keys
+// of the GUIDs continuing with a NUL share it, the scans check the GUID of
+// the sessions.
+func guidPrefix(guid string) []byte {
+	return append([]byte(guid), 0)
+}
+
+// sessionKey is the key of a session of the GUID.
+func sessionKey(guid string, id uint64) []byte {
+	return binary.BigEndian.AppendUint64(guidPrefix(guid), id)
+}
+
+// expiryKey is the key of a session in the expiry index.
+func expiryKey(exp int64, key []byte) []byte {
+	return append(binary.BigEndian.AppendUint64(nil, uint64(exp)), key...)
+}
+
+// itob encodes a sequence number so the keys are ordered by it.
+func itob(n uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, n)
+}
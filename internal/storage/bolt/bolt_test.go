@@ -0,0 +1,113 @@
+package bolt
+
+import (
+	"context"
+	"errors"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains"
+	"go-jwt-auth/internal/models"
+	"go-jwt-auth/internal/storage/storagetest"
+	"go.etcd.io/bbolt"
+	"path/filepath"
+	"testing"
+)
+
+func newDatabase(t *testing.T, path string) *Database {
+	file, err := bbolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatalf("can't open %s: %v", path, err)
+	}
+
+	d, err := New(file)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return d
+}
+
+func TestDatabase(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) domains.Database {
+		d := newDatabase(t, filepath.Join(t.TempDir(), "tokens.db"))
+		t.Cleanup(func() { d.Close() })
+		return d
+	})
+}
+
+func TestDatabase_PurgeExpired(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.db")
+	d := newDatabase(t, path)
+
+	ctx := context.Background()
+	for _, td := range []models.TokenData{
+		{GUID: "ikj", RefreshHash: "qjfwjnqk", RefreshExp: 100},
+		{GUID: "ikj", RefreshHash: "ebfkqbfb", RefreshExp: 300},
+		{GUID: "kwfwe", RefreshHash: "hwejhvqvf", RefreshExp: 100},
+		{GUID: "kwfwe", RefreshHash: "qlmflqm"},
+	} {
+		if err := d.SaveTokenData(ctx, td); err != nil {
+			t.Fatalf("SaveTokenData() error = %v", err)
+		}
+	}
+
+	if err := d.DeleteTokenData(ctx, "ikj", "ebfkqbfb"); err != nil {
+		t.Fatalf("DeleteTokenData() error = %v", err)
+	}
+
+	purged, err := d.PurgeExpired(ctx, 200)
+	if err != nil {
+		t.Fatalf("PurgeExpired() error = %v", err)
+	}
+	if purged != 2 {
+		t.Errorf("PurgeExpired() = %v, want %v", purged, 2)
+	}
+
+	// the sessions must survive reopening of the file.
+	if err := d.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	d = newDatabase(t, path)
+	defer d.Close()
+
+	if _, err := d.GetTokensDataByGUID(ctx, "ikj"); !errors.Is(err, constants.ErrNotFound) {
+		t.Errorf("GetTokensDataByGUID() error = %v, want %v", err, constants.ErrNotFound)
+	}
+
+	td, err := d.GetTokensDataByGUID(ctx, "kwfwe")
+	if err != nil {
+		t.Fatalf("GetTokensDataByGUID() error = %v", err)
+	}
+	if len(td) != 1 || td[0].RefreshHash != "qlmflqm" {
+		t.Errorf("GetTokensDataByGUID() = %v, want the session without expiry", td)
+	}
+}
+
+func TestDatabase_GUIDPrefix(t *testing.T) {
+	d := newDatabase(t, filepath.Join(t.TempDir(), "tokens.db"))
+	defer d.Close()
+
+	// the keys of "a\x00x" start with the prefix of "a".
+	ctx := context.Background()
+	for _, td := range []models.TokenData{
+		{GUID: "a", RefreshHash: "qjfwjnqk"},
+		{GUID: "a\x00x", RefreshHash: "ebfkqbfb"},
+	} {
+		if err := d.SaveTokenData(ctx, td); err != nil {
+			t.Fatalf("SaveTokenData() error = %v", err)
+		}
+	}
+
+	td, err := d.GetTokensDataByGUID(ctx, "a")
+	if err != nil {
+		t.Fatalf("GetTokensDataByGUID() error = %v", err)
+	}
+	if len(td) != 1 || td[0].GUID != "a" {
+		t.Errorf("GetTokensDataByGUID() = %v, want the session of a only", td)
+	}
+
+	if err := d.DeleteTokenData(ctx, "a", "ebfkqbfb"); !errors.Is(err, constants.ErrNotFound) {
+		t.Errorf("DeleteTokenData() error = %v, want %v", err, constants.ErrNotFound)
+	}
+	if _, err := d.GetTokensDataByGUID(ctx, "a\x00x"); err != nil {
+		t.Errorf("GetTokensDataByGUID() error = %v, the session of another GUID was deleted", err)
+	}
+}
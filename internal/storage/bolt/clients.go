@@ -0,0 +1,81 @@
+package bolt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/models"
+	"go.etcd.io/bbolt"
+)
+
+// SaveClient saves the client, replacing the one with the same ID.
+func (d *Database) SaveClient(ctx context.Context, c models.Client) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("can't marshal client: %v", err)
+	}
+
+	err = d.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(_clients).Put([]byte(c.ID), data)
+	})
+	if err != nil {
+		return fmt.Errorf("can't save client: %v", err)
+	}
+
+	return nil
+}
+
+// GetClient retrieves the client by ID.
+func (d *Database) GetClient(ctx context.Context, id string) (c models.Client, err error) {
+	if err := ctx.Err(); err != nil {
+		return c, err
+	}
+
+	err = d.db.View(func(tx *bbolt.Tx) error {
+		data := tx.Bucket(_clients).Get([]byte(id))
+		if data == nil {
+			return constants.ErrNotFound
+		}
+		return json.Unmarshal(data, &c)
+	})
+	return c, err
+}
+
+// GetClients retrieves all the clients ordered by ID.
+func (d *Database) GetClients(ctx context.Context) (clients []models.Client, err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	err = d.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(_clients).ForEach(func(_, data []byte) error {
+			var c models.Client
+			if err := json.Unmarshal(data, &c); err != nil {
+				return fmt.Errorf("can't unmarshal client: %v", err)
+			}
+			clients = append(clients, c)
+			return nil
+		})
+	})
+	return clients, err
+}
+
+// DeleteClient deletes the client by ID.
+func (d *Database) DeleteClient(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return d.db.Update(func(tx *bbolt.Tx) error {
+		clients := tx.Bucket(_clients)
+		if clients.Get([]byte(id)) == nil {
+			return constants.ErrNotFound
+		}
+		return clients.Delete([]byte(id))
+	})
+}
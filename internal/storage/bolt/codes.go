@@ -0,0 +1,75 @@
+package bolt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/models"
+	"go.etcd.io/bbolt"
+)
+
+// SaveCode saves the authorization code.
+func (d *Database) SaveCode(ctx context.Context, c models.AuthorizationCode) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("can't marshal code: %v", err)
+	}
+
+	err = d.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(_codes).Put([]byte(c.Hash), data)
+	})
+	if err != nil {
+		return fmt.Errorf("can't save code: %v", err)
+	}
+
+	return nil
+}
+
+// ConsumeCode deletes the authorization code and returns it.
+func (d *Database) ConsumeCode(ctx context.Context, hash string) (c models.AuthorizationCode, err error) {
+	if err := ctx.Err(); err != nil {
+		return c, err
+	}
+
+	err = d.db.Update(func(tx *bbolt.Tx) error {
+		codes := tx.Bucket(_codes)
+		data := codes.Get([]byte(hash))
+		if data == nil {
+			return constants.ErrNotFound
+		}
+		if err := json.Unmarshal(data, &c); err != nil {
+			return fmt.Errorf("can't unmarshal code: %v", err)
+		}
+		return codes.Delete([]byte(hash))
+	})
+	return c, err
+}
+
+// purgeExpiredCodes deletes the authorization codes that expired before now.
+func purgeExpiredCodes(tx *bbolt.Tx, now int64) error {
+	var expired [][]byte
+
+	codes := tx.Bucket(_codes)
+	err := codes.ForEach(func(k, data []byte) error {
+		var c models.AuthorizationCode
+		if err := json.Unmarshal(data, &c); err != nil || c.ExpiresAt < now {
+			expired = append(expired, k)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, k := range expired {
+		if err := codes.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package bolt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-jwt-auth/internal/models"
+	"go.etcd.io/bbolt"
+)
+
+// SaveWebhookDelivery saves a delivery attempt to the delivery log.
+func (d *Database) SaveWebhookDelivery(ctx context.Context, wd models.WebhookDelivery) error {
+	if err := d.push(ctx, _webhookDeliveries, wd); err != nil {
+		return fmt.Errorf("can't save webhook delivery: %v", err)
+	}
+	return nil
+}
+
+// SaveWebhookDeadLetter saves a delivery that ran out of attempts.
+func (d *Database) SaveWebhookDeadLetter(ctx context.Context, wd models.WebhookDelivery) error {
+	if err := d.push(ctx, _webhookDeadLetters, wd); err != nil {
+		return fmt.Errorf("can't save webhook dead letter: %v", err)
+	}
+	return nil
+}
+
+func (d *Database) push(ctx context.Context, bucket []byte, wd models.WebhookDelivery) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(wd)
+	if err != nil {
+		return err
+	}
+
+	return d.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(bucket)
+
+		id, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		return b.Put(itob(id), data)
+	})
+}
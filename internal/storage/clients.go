@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	_clients = "clients"
+	_id      = "_id"
+)
+
+// SaveClient saves the client, replacing the one with the same ID.
+func (d Database) SaveClient(ctx context.Context, c models.Client) error {
+	filter := bson.D{{Key: _id, Value: c.ID}}
+	opts := options.Replace().SetUpsert(true)
+	if _, err := d.db.Collection(_clients).ReplaceOne(ctx, filter, c, opts); err != nil {
+		return fmt.Errorf("can't save client: %v", err)
+	}
+
+	return nil
+}
+
+// GetClient retrieves the client by ID.
+func (d Database) GetClient(ctx context.Context, id string) (c models.Client, err error) {
+	err = d.db.Collection(_clients).FindOne(ctx, bson.D{{Key: _id, Value: id}}).Decode(&c)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return c, constants.ErrNotFound
+		}
+		return c, err
+	}
+
+	return c, nil
+}
+
+// GetClients retrieves all the clients ordered by ID.
+func (d Database) GetClients(ctx context.Context) (c []models.Client, err error) {
+	opts := options.Find().SetSort(bson.D{{Key: _id, Value: 1}})
+	cur, err := d.db.Collection(_clients).Find(ctx, bson.D{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer func(cur *mongo.Cursor, ctx context.Context) {
+		errClose := cur.Close(ctx)
+		if errClose != nil && err == nil {
+			err = errClose
+		}
+	}(cur, ctx)
+
+	if err := cur.All(ctx, &c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// DeleteClient deletes the client by ID.
+func (d Database) DeleteClient(ctx context.Context, id string) error {
+	res, err := d.db.Collection(_clients).DeleteOne(ctx, bson.D{{Key: _id, Value: id}})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return constants.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"time"
+)
+
+const (
+	_codes         = "authorization_codes"
+	_codeExpiresAt = "expires_at_date"
+)
+
+// codeDocument is an authorization code as it is stored in mongo.
+// ExpiresAtDate duplicates ExpiresAt as a Date for the TTL index.
+type codeDocument struct {
+	models.AuthorizationCode `bson:",inline"`
+	ExpiresAtDate            time.Time `bson:"expires_at_date"`
+}
+
+// SaveCode saves the authorization code.
+func (d Database) SaveCode(ctx context.Context, c models.AuthorizationCode) error {
+	doc := codeDocument{AuthorizationCode: c, ExpiresAtDate: time.Unix(c.ExpiresAt, 0).UTC()}
+	if _, err := d.db.Collection(_codes).InsertOne(ctx, doc); err != nil {
+		return fmt.Errorf("can't save code: %v", err)
+	}
+
+	return nil
+}
+
+// ConsumeCode deletes the authorization code and returns it.
+func (d Database) ConsumeCode(ctx context.Context, hash string) (models.AuthorizationCode, error) {
+	var doc codeDocument
+	err := d.db.Collection(_codes).FindOneAndDelete(ctx, bson.D{{Key: _id, Value: hash}}).Decode(&doc)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return models.AuthorizationCode{}, constants.ErrNotFound
+		}
+		return models.AuthorizationCode{}, err
+	}
+
+	return doc.AuthorizationCode, nil
+}
@@ -2,30 +2,53 @@ package storage
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"go-jwt-auth/internal/constants"
 	"go-jwt-auth/internal/domains"
 	"go-jwt-auth/internal/lib"
 	"go-jwt-auth/internal/models"
+	"go-jwt-auth/internal/storage/tokenschema"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
 )
 
 const (
-	_refreshHash = "refresh_hash"
-	_tokens      = "tokens"
-	_guid        = "guid"
+	_refreshHash      = "refresh_hash"
+	_refreshExp       = "refresh_exp"
+	_refreshExpiresAt = "refresh_expires_at"
+	_schemaVersion    = "schema_version"
+	_tokens           = "tokens"
+	_guid             = "guid"
 )
 
+// tokenDocument is a session as it is stored in mongo.
+// RefreshExpiresAt duplicates RefreshExp as a Date, the TTL index only
+// works on Date fields.
+type tokenDocument struct {
+	ID               primitive.ObjectID `bson:"_id,omitempty"`
+	models.TokenData `bson:",inline"`
+	RefreshExpiresAt time.Time `bson:"refresh_expires_at,omitempty"`
+}
+
+func newTokenDocument(t models.TokenData) tokenDocument {
+	doc := tokenDocument{TokenData: t}
+	if t.RefreshExp > 0 {
+		doc.RefreshExpiresAt = time.Unix(t.RefreshExp, 0).UTC()
+	}
+	return doc
+}
+
 // Database is a struct that contains a database.
 type Database struct {
 	db lib.Database
 }
 
-// NewDatabase creates a new instance of Database.
-func NewDatabase(db lib.Database) domains.Database {
-	return Database{db: db}
+// NewMongoDatabase creates a new instance of Database.
+func NewMongoDatabase(db lib.Database) (domains.Database, error) {
+	return Database{db: db}, nil
 }
 
 // Close closes the database.
@@ -36,9 +59,9 @@ func (d Database) Close() error {
 	return nil
 }
 
-// SaveToken saves a token.
+// SaveTokenData saves a token.
 func (d Database) SaveTokenData(ctx context.Context, t models.TokenData) error {
-	if _, err := d.db.Collection(_tokens).InsertOne(ctx, t); err != nil {
+	if _, err := d.db.Collection(_tokens).InsertOne(ctx, newTokenDocument(t)); err != nil {
 		return fmt.Errorf("can't insert token: %v", err)
 	}
 
@@ -47,7 +70,7 @@ func (d Database) SaveTokenData(ctx context.Context, t models.TokenData) error {
 
 // GetTokensDataByGUID retrieves the access and refresh tokens for a given GUID.
 func (d Database) GetTokensDataByGUID(ctx context.Context, guid string) (t []models.TokenData, err error) {
-	filter := bson.D{{_guid, guid}}
+	filter := bson.D{{Key: _guid, Value: guid}}
 	cur, err := d.db.Collection(_tokens).Find(ctx, filter)
 	if err != nil {
 		return t, err
@@ -67,19 +90,97 @@ func (d Database) GetTokensDataByGUID(ctx context.Context, guid string) (t []mod
 		return t, constants.ErrNotFound
 	}
 
-	return t, nil
+	return tokenschema.UpgradeAll(t), nil
 }
 
 // DeleteTokenData deletes a token.
 func (d Database) DeleteTokenData(ctx context.Context, guid, hash string) error {
-	filter := bson.D{{_guid, guid}, {_refreshHash, hash}}
-	_, err := d.db.Collection(_tokens).DeleteOne(ctx, filter)
+	filter := bson.D{{Key: _guid, Value: guid}, {Key: _refreshHash, Value: hash}}
+	res, err := d.db.Collection(_tokens).DeleteOne(ctx, filter)
 	if err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
-			return constants.ErrNotFound
-		}
 		return err
 	}
 
+	if res.DeletedCount == 0 {
+		return constants.ErrNotFound
+	}
+
 	return nil
 }
+
+// ScanTokenData calls fn with every session until it returns an error.
+func (d Database) ScanTokenData(ctx context.Context, fn func(models.TokenData) error) (err error) {
+	cur, err := d.db.Collection(_tokens).Find(ctx, bson.D{})
+	if err != nil {
+		return err
+	}
+	defer func(cur *mongo.Cursor, ctx context.Context) {
+		errClose := cur.Close(ctx)
+		if errClose != nil && err == nil {
+			err = errClose
+		}
+	}(cur, ctx)
+
+	for cur.Next(ctx) {
+		var td models.TokenData
+		if err := cur.Decode(&td); err != nil {
+			return err
+		}
+		if err := fn(tokenschema.Upgrade(td)); err != nil {
+			return err
+		}
+	}
+
+	return cur.Err()
+}
+
+// outdatedFilter matches the sessions saved in an older schema version,
+// the sessions saved before versioning have no version at all.
+func outdatedFilter() bson.D {
+	return bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: _schemaVersion, Value: bson.D{{Key: "$exists", Value: false}}}},
+		bson.D{{Key: _schemaVersion, Value: bson.D{{Key: "$lt", Value: models.TokenDataVersion}}}},
+	}}}
+}
+
+// CountOutdatedTokenData counts the sessions saved in an older schema version.
+func (d Database) CountOutdatedTokenData(ctx context.Context) (int64, error) {
+	return d.db.Collection(_tokens).CountDocuments(ctx, outdatedFilter())
+}
+
+// RewriteOutdatedTokenData upgrades up to limit sessions saved in an older
+// schema version. A document is only replaced if it wasn't changed in the
+// meantime, the consumed ones aren't brought back nor counted.
+func (d Database) RewriteOutdatedTokenData(ctx context.Context, limit int64) (n int64, err error) {
+	// a batch consumed in the meantime isn't the end, the next one is looked at.
+	for n == 0 {
+		cur, err := d.db.Collection(_tokens).Find(ctx, outdatedFilter(), options.Find().SetLimit(limit))
+		if err != nil {
+			return 0, err
+		}
+
+		var docs []tokenDocument
+		if err := cur.All(ctx, &docs); err != nil {
+			return 0, err
+		}
+		if len(docs) == 0 {
+			return 0, nil
+		}
+
+		for _, doc := range docs {
+			replacement := newTokenDocument(tokenschema.Upgrade(doc.TokenData))
+			filter := bson.D{{Key: "_id", Value: doc.ID}, {Key: _schemaVersion, Value: doc.SchemaVersion}}
+			if doc.SchemaVersion == 0 {
+				filter[1].Value = bson.D{{Key: "$in", Value: bson.A{nil, 0}}}
+			}
+
+			res, err := d.db.Collection(_tokens).ReplaceOne(ctx, filter, replacement)
+			if err != nil {
+				return n, fmt.Errorf("can't rewrite token: %v", err)
+			}
+			n += res.MatchedCount
+		}
+	}
+
+	return n, nil
+}
@@ -5,9 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"github.com/egorgasay/dockerdb/v3"
-	"go-jwt-auth/internal/constants"
+	"github.com/google/uuid"
+	"go-jwt-auth/internal/domains"
 	"go-jwt-auth/internal/lib"
 	"go-jwt-auth/internal/models"
+	"go-jwt-auth/internal/storage/storagetest"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -70,7 +72,7 @@ func TestDatabase_SaveToken(t *testing.T) {
 				t.Errorf("SaveToken() error = %v, wantErr %v", err, tt.wantErr)
 			}
 
-			r := collection.FindOne(ctx, bson.D{{_guid, tt.args.t.GUID}})
+			r := collection.FindOne(ctx, bson.D{{Key: _guid, Value: tt.args.t.GUID}})
 			if err := r.Err(); err != nil {
 				t.Fatalf("FindOne error: %v", err)
 			}
@@ -115,59 +117,7 @@ func upMongo(ctx context.Context, t *testing.T) (vdb *dockerdb.VDB, cl *mongo.Cl
 	return vdb, cl, err
 }
 
-func TestDatabase_GetTokensDataByGUID(t *testing.T) {
-	type args struct {
-		ctx  context.Context
-		guid string
-	}
-	tests := []struct {
-		name    string
-		args    args
-		want    []models.TokenData
-		wantErr error
-	}{
-		{
-			name: "ok",
-			args: args{
-				ctx:  context.Background(),
-				guid: "123",
-			},
-			want: []models.TokenData{
-				{
-					GUID:        "123",
-					RefreshHash: "hwejhvqvf",
-				},
-			},
-			wantErr: nil,
-		},
-		{
-			name: "ok#2",
-			args: args{
-				ctx:  context.Background(),
-				guid: "yguf67d7rr7di",
-			},
-			want: []models.TokenData{
-				{
-					GUID:        "yguf67d7rr7di",
-					RefreshHash: "qjfwjnqk",
-				},
-				{
-					GUID:        "yguf67d7rr7di",
-					RefreshHash: "ebfkqbfb",
-				},
-			},
-			wantErr: nil,
-		},
-		{
-			name: "notFound",
-			args: args{
-				ctx:  context.Background(),
-				guid: "qlmf;lqm",
-			},
-			wantErr: constants.ErrNotFound,
-		},
-	}
-
+func TestDatabase(t *testing.T) {
 	ctx := context.Background()
 	vdb, client, err := upMongo(ctx, t)
 	defer func() {
@@ -177,24 +127,19 @@ func TestDatabase_GetTokensDataByGUID(t *testing.T) {
 		}
 	}()
 
-	d := Database{
-		db: lib.Database{Database: client.Database(lib.DBName)},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			for _, td := range tt.want {
-				if err := d.SaveTokenData(tt.args.ctx, td); !errors.Is(err, tt.wantErr) {
-					t.Errorf("SaveToken() error = %v, wantErr %v", err, tt.wantErr)
-				}
-			}
-
-			td, err := d.GetTokensDataByGUID(tt.args.ctx, tt.args.guid)
-			if !errors.Is(err, tt.wantErr) {
-				t.Errorf("GetTokensDataByGUID() error = %v, wantErr %v", err, tt.wantErr)
-			}
+	storagetest.Run(t, func(t *testing.T) domains.Database {
+		d, err := NewMongoDatabase(lib.Database{Database: client.Database(uuid.NewString())})
+		if err != nil {
+			t.Fatalf("NewMongoDatabase() error = %v", err)
+		}
 
-			assert.DeepEqual(t, tt.want, td)
-		})
-	}
+		m, err := NewMigrator(d)
+		if err != nil {
+			t.Fatalf("NewMigrator() error = %v", err)
+		}
+		if _, err := m.Up(ctx); err != nil {
+			t.Fatalf("Up() error = %v", err)
+		}
+		return d
+	})
 }
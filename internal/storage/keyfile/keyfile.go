@@ -0,0 +1,112 @@
+// Package keyfile is the key store of the signing keys in a JSON file.
+package keyfile
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/models"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// file is the content of the key store.
+type file struct {
+	Keys []models.SigningKey `json:"keys"`
+}
+
+// Store is the key store in the file at path, it is read again when the file
+// changes so that the servers see the keys the keys command saves.
+type Store struct {
+	path string
+
+	mu      sync.Mutex
+	keys    []models.SigningKey
+	modTime time.Time
+	size    int64
+	loaded  bool
+}
+
+// New creates the key store of the file, which may not exist yet. Without a
+// path the store has no keys and can't save any.
+func New(path string) *Store {
+	return &Store{path: path}
+}
+
+// Keys returns the keys of the file, none if it doesn't exist. If the file
+// can't be read once it has been, the keys read last are returned with the error.
+func (s *Store) Keys(context.Context) ([]models.SigningKey, error) {
+	if s.path == "" {
+		return nil, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, err := os.Stat(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		s.keys, s.loaded = nil, true
+		s.modTime, s.size = time.Time{}, 0
+		return nil, nil
+	} else if err != nil {
+		return s.copy(), fmt.Errorf("can't stat key store: %v", err)
+	}
+	if s.loaded && info.ModTime().Equal(s.modTime) && info.Size() == s.size {
+		return s.copy(), nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return s.copy(), fmt.Errorf("can't read key store: %v", err)
+	}
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return s.copy(), fmt.Errorf("can't unmarshal key store %s: %v", s.path, err)
+	}
+
+	s.keys, s.loaded = f.Keys, true
+	s.modTime, s.size = info.ModTime(), info.Size()
+	return s.copy(), nil
+}
+
+// copy lets the callers change the keys they are returned.
+func (s *Store) copy() []models.SigningKey {
+	if s.keys == nil {
+		return nil
+	}
+	return append([]models.SigningKey(nil), s.keys...)
+}
+
+// SaveKeys replaces the file atomically, it is only readable by its owner.
+func (s *Store) SaveKeys(_ context.Context, keys []models.SigningKey) error {
+	if s.path == "" {
+		return constants.ErrNoKeyStore
+	}
+
+	data, err := json.MarshalIndent(file{Keys: keys}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("can't marshal keys: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("can't create key store: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("can't write key store: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("can't write key store: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("can't replace key store: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package keyfile
+
+import (
+	"context"
+	"errors"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/models"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "keys.json")
+	s := New(path)
+
+	if keys, err := s.Keys(ctx); err != nil || len(keys) != 0 {
+		t.Fatalf("Keys() = %v, %v, want no keys before the file exists", keys, err)
+	}
+
+	want := []models.SigningKey{{ID: "k1", Tenant: "acme", Algorithm: "HS512", State: models.KeyStateActive, Private: "c2VjcmV0"}}
+	if err := s.SaveKeys(ctx, want); err != nil {
+		t.Fatalf("SaveKeys() error = %v", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("Stat() = %v, %v, want a file only readable by its owner", info, err)
+	}
+
+	// another process, the keys command, sees the keys.
+	got, err := New(path).Keys(ctx)
+	if err != nil || len(got) != 1 || got[0] != want[0] {
+		t.Fatalf("Keys() = %v, %v, want %v", got, err, want)
+	}
+
+	// the keys returned can be changed by the callers.
+	got[0].State = models.KeyStateRetired
+	if keys, _ := s.Keys(ctx); keys[0].State != models.KeyStateActive {
+		t.Errorf("Keys() state = %v after the caller changed it", keys[0].State)
+	}
+
+	// the keys read last are kept if the file is broken.
+	if err := os.WriteFile(path, []byte(`{"keys": [`), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if keys, err := s.Keys(ctx); err == nil || len(keys) != 1 {
+		t.Errorf("Keys() = %v, %v, want the keys read last and an error", keys, err)
+	}
+	if _, err := New(path).Keys(ctx); err == nil {
+		t.Errorf("Keys() error = nil, want an error for a broken file")
+	}
+}
+
+func TestStore_NoPath(t *testing.T) {
+	s := New("")
+	if keys, err := s.Keys(context.Background()); err != nil || keys != nil {
+		t.Errorf("Keys() = %v, %v, want none", keys, err)
+	}
+	if err := s.SaveKeys(context.Background(), nil); !errors.Is(err, constants.ErrNoKeyStore) {
+		t.Errorf("SaveKeys() error = %v, want %v", err, constants.ErrNoKeyStore)
+	}
+}
@@ -0,0 +1,64 @@
+package memory
+
+import (
+	"context"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/models"
+	"sort"
+)
+
+// AppendAuditRecord appends a record to the audit trail.
+func (d *Database) AppendAuditRecord(ctx context.Context, r models.AuditRecord) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if n := len(d.audit); n > 0 && d.audit[n-1].Seq >= r.Seq {
+		return constants.ErrAlreadyExists
+	}
+
+	d.audit = append(d.audit, r)
+	return nil
+}
+
+// LastAuditRecord retrieves the record with the highest sequence number.
+func (d *Database) LastAuditRecord(ctx context.Context) (models.AuditRecord, error) {
+	if err := ctx.Err(); err != nil {
+		return models.AuditRecord{}, err
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if len(d.audit) == 0 {
+		return models.AuditRecord{}, constants.ErrNotFound
+	}
+
+	return d.audit[len(d.audit)-1], nil
+}
+
+// GetAuditRecords retrieves up to limit records starting from the given sequence number.
+func (d *Database) GetAuditRecords(ctx context.Context, fromSeq int64, limit int64) ([]models.AuditRecord, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	i := sort.Search(len(d.audit), func(i int) bool {
+		return d.audit[i].Seq >= fromSeq
+	})
+
+	end := len(d.audit)
+	if limit > 0 && int64(end-i) > limit {
+		end = i + int(limit)
+	}
+
+	r := make([]models.AuditRecord, end-i)
+	copy(r, d.audit[i:end])
+	return r, nil
+}
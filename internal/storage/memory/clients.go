@@ -0,0 +1,70 @@
+package memory
+
+import (
+	"context"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/models"
+	"sort"
+)
+
+// SaveClient saves the client, replacing the one with the same ID.
+func (d *Database) SaveClient(ctx context.Context, c models.Client) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.clients[c.ID] = c
+	return nil
+}
+
+// GetClient retrieves the client by ID.
+func (d *Database) GetClient(ctx context.Context, id string) (models.Client, error) {
+	if err := ctx.Err(); err != nil {
+		return models.Client{}, err
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	c, ok := d.clients[id]
+	if !ok {
+		return c, constants.ErrNotFound
+	}
+	return c, nil
+}
+
+// GetClients retrieves all the clients ordered by ID.
+func (d *Database) GetClients(ctx context.Context) ([]models.Client, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	clients := make([]models.Client, 0, len(d.clients))
+	for _, c := range d.clients {
+		clients = append(clients, c)
+	}
+	sort.Slice(clients, func(i, j int) bool { return clients[i].ID < clients[j].ID })
+	return clients, nil
+}
+
+// DeleteClient deletes the client by ID.
+func (d *Database) DeleteClient(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.clients[id]; !ok {
+		return constants.ErrNotFound
+	}
+	delete(d.clients, id)
+	return nil
+}
@@ -0,0 +1,37 @@
+package memory
+
+import (
+	"context"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/models"
+)
+
+// SaveCode saves the authorization code.
+func (d *Database) SaveCode(ctx context.Context, c models.AuthorizationCode) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.codes[c.Hash] = c
+	return nil
+}
+
+// ConsumeCode deletes the authorization code and returns it.
+func (d *Database) ConsumeCode(ctx context.Context, hash string) (models.AuthorizationCode, error) {
+	if err := ctx.Err(); err != nil {
+		return models.AuthorizationCode{}, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	c, ok := d.codes[hash]
+	if !ok {
+		return c, constants.ErrNotFound
+	}
+	delete(d.codes, hash)
+	return c, nil
+}
@@ -0,0 +1,190 @@
+// Package memory is an in-process storage for development and tests.
+// Everything is lost when the process exits.
+package memory
+
+import (
+	"context"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/models"
+	"go-jwt-auth/internal/storage/tokenschema"
+	"sync"
+)
+
+// Database is a concurrency-safe in-memory storage.
+type Database struct {
+	mu     sync.RWMutex
+	tokens map[string][]models.TokenData
+
+	clients map[string]models.Client
+	codes   map[string]models.AuthorizationCode
+
+	audit       []models.AuditRecord
+	deliveries  []models.WebhookDelivery
+	deadLetters []models.WebhookDelivery
+}
+
+// New creates a new instance of Database.
+func New() *Database {
+	return &Database{
+		tokens:  make(map[string][]models.TokenData),
+		clients: make(map[string]models.Client),
+		codes:   make(map[string]models.AuthorizationCode),
+	}
+}
+
+// SaveTokenData saves a token.
+func (d *Database) SaveTokenData(ctx context.Context, t models.TokenData) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.tokens[t.GUID] = append(d.tokens[t.GUID], t)
+	return nil
+}
+
+// GetTokensDataByGUID retrieves the sessions of a given GUID.
+func (d *Database) GetTokensDataByGUID(ctx context.Context, guid string) ([]models.TokenData, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	tokens := d.tokens[guid]
+	if len(tokens) == 0 {
+		return nil, constants.ErrNotFound
+	}
+
+	t := make([]models.TokenData, len(tokens))
+	copy(t, tokens)
+	return tokenschema.UpgradeAll(t), nil
+}
+
+// DeleteTokenData deletes a token.
+func (d *Database) DeleteTokenData(ctx context.Context, guid, hash string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	tokens := d.tokens[guid]
+	for i, t := range tokens {
+		if t.RefreshHash != hash {
+			continue
+		}
+
+		tokens = append(tokens[:i:i], tokens[i+1:]...)
+		if len(tokens) == 0 {
+			delete(d.tokens, guid)
+		} else {
+			d.tokens[guid] = tokens
+		}
+		return nil
+	}
+
+	return constants.ErrNotFound
+}
+
+// PurgeExpired deletes the sessions whose refresh token expired before now.
+// The expired authorization codes are deleted too, they aren't counted.
+func (d *Database) PurgeExpired(ctx context.Context, now int64) (purged int64, err error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for guid, tokens := range d.tokens {
+		alive := tokens[:0]
+		for _, t := range tokens {
+			if t.RefreshExp > 0 && t.RefreshExp < now {
+				purged++
+				continue
+			}
+			alive = append(alive, t)
+		}
+
+		if len(alive) == 0 {
+			delete(d.tokens, guid)
+		} else {
+			d.tokens[guid] = alive
+		}
+	}
+
+	for hash, c := range d.codes {
+		if c.ExpiresAt < now {
+			delete(d.codes, hash)
+		}
+	}
+
+	return purged, nil
+}
+
+// ScanTokenData calls fn with every session until it returns an error.
+// fn must not use the database, it is locked.
+func (d *Database) ScanTokenData(ctx context.Context, fn func(models.TokenData) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	for _, tokens := range d.tokens {
+		for _, t := range tokens {
+			if err := fn(tokenschema.Upgrade(t)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// CountOutdatedTokenData counts the sessions saved in an older schema version.
+func (d *Database) CountOutdatedTokenData(ctx context.Context) (n int64, err error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	for _, tokens := range d.tokens {
+		for _, t := range tokens {
+			if tokenschema.Outdated(t) {
+				n++
+			}
+		}
+	}
+	return n, nil
+}
+
+// RewriteOutdatedTokenData upgrades up to limit sessions saved in an older schema version.
+func (d *Database) RewriteOutdatedTokenData(ctx context.Context, limit int64) (n int64, err error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, tokens := range d.tokens {
+		for i := range tokens {
+			if n == limit {
+				return n, nil
+			}
+			if tokenschema.Outdated(tokens[i]) {
+				tokens[i] = tokenschema.Upgrade(tokens[i])
+				n++
+			}
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains"
+	"go-jwt-auth/internal/models"
+	"go-jwt-auth/internal/storage/storagetest"
+	"testing"
+)
+
+func TestDatabase(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) domains.Database {
+		return New()
+	})
+}
+
+func TestDatabase_PurgeExpired(t *testing.T) {
+	d := New()
+
+	ctx := context.Background()
+	for _, td := range []models.TokenData{
+		{GUID: "ikj", RefreshHash: "qjfwjnqk", RefreshExp: 100},
+		{GUID: "ikj", RefreshHash: "ebfkqbfb", RefreshExp: 300},
+		{GUID: "kwfwe", RefreshHash: "hwejhvqvf", RefreshExp: 100},
+		{GUID: "qkefkq", RefreshHash: "qlmflqm"},
+	} {
+		if err := d.SaveTokenData(ctx, td); err != nil {
+			t.Fatalf("SaveTokenData() error = %v", err)
+		}
+	}
+
+	purged, err := d.PurgeExpired(ctx, 200)
+	if err != nil {
+		t.Fatalf("PurgeExpired() error = %v", err)
+	}
+	if purged != 2 {
+		t.Errorf("PurgeExpired() = %v, want %v", purged, 2)
+	}
+
+	if td, err := d.GetTokensDataByGUID(ctx, "ikj"); err != nil || len(td) != 1 {
+		t.Errorf("GetTokensDataByGUID() = %v, %v, want 1 session", td, err)
+	}
+
+	if _, err := d.GetTokensDataByGUID(ctx, "kwfwe"); !errors.Is(err, constants.ErrNotFound) {
+		t.Errorf("GetTokensDataByGUID() error = %v, want %v", err, constants.ErrNotFound)
+	}
+
+	if _, err := d.GetTokensDataByGUID(ctx, "qkefkq"); err != nil {
+		t.Errorf("GetTokensDataByGUID() of a session without expiry error = %v", err)
+	}
+}
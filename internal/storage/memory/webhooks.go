@@ -0,0 +1,32 @@
+package memory
+
+import (
+	"context"
+	"go-jwt-auth/internal/models"
+)
+
+// SaveWebhookDelivery saves a delivery attempt to the delivery log.
+func (d *Database) SaveWebhookDelivery(ctx context.Context, wd models.WebhookDelivery) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.deliveries = append(d.deliveries, wd)
+	return nil
+}
+
+// SaveWebhookDeadLetter saves a delivery that ran out of attempts.
+func (d *Database) SaveWebhookDeadLetter(ctx context.Context, wd models.WebhookDelivery) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.deadLetters = append(d.deadLetters, wd)
+	return nil
+}
@@ -0,0 +1,136 @@
+// Package migrate applies ordered, versioned migrations to a storage and
+// records them, so every migration is applied once.
+//
+// A migration may be interrupted between its changes and its record, so
+// migrations must be idempotent: applying one again must be a no-op.
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/models"
+	"sort"
+	"time"
+)
+
+// Migration is a versioned change of the storage schema.
+type Migration struct {
+	// Version orders the migrations, it must never change once released.
+	Version int64
+	Name    string
+	Up      func(ctx context.Context) error
+	// Down reverts Up, the migration is irreversible if it is nil.
+	Down func(ctx context.Context) error
+}
+
+// Store records the applied migrations, e.g. in a schema_migrations table.
+type Store interface {
+	// Applied maps the versions of the applied migrations to the unix time they were applied at.
+	Applied(ctx context.Context) (map[int64]int64, error)
+	Record(ctx context.Context, version int64, name string, appliedAt int64) error
+	Forget(ctx context.Context, version int64) error
+}
+
+// Migrator applies the migrations recorded in a Store.
+type Migrator struct {
+	store      Store
+	migrations []Migration
+	now        func() time.Time
+}
+
+// New creates a new instance of Migrator.
+// The migrations are sorted by version, the versions must be unique.
+func New(store Store, migrations ...Migration) (*Migrator, error) {
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].Version == sorted[i-1].Version {
+			return nil, fmt.Errorf("duplicate migration version %d", sorted[i].Version)
+		}
+	}
+
+	return &Migrator{
+		store:      store,
+		migrations: sorted,
+		now:        time.Now,
+	}, nil
+}
+
+// Up applies the pending migrations in order and returns them.
+// It stops at the first failing migration.
+func (m *Migrator) Up(ctx context.Context) (applied []models.MigrationStatus, err error) {
+	done, err := m.store.Applied(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("can't load applied migrations: %v", err)
+	}
+
+	for _, mg := range m.migrations {
+		if _, ok := done[mg.Version]; ok {
+			continue
+		}
+
+		if err := mg.Up(ctx); err != nil {
+			return applied, fmt.Errorf("can't apply migration %d %s: %v", mg.Version, mg.Name, err)
+		}
+
+		at := m.now().Unix()
+		if err := m.store.Record(ctx, mg.Version, mg.Name, at); err != nil {
+			return applied, fmt.Errorf("can't record migration %d %s: %v", mg.Version, mg.Name, err)
+		}
+
+		applied = append(applied, models.MigrationStatus{Version: mg.Version, Name: mg.Name, AppliedAt: at})
+	}
+
+	return applied, nil
+}
+
+// Down reverts the last steps applied migrations, newest first, and returns them.
+func (m *Migrator) Down(ctx context.Context, steps int) (reverted []models.MigrationStatus, err error) {
+	done, err := m.store.Applied(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("can't load applied migrations: %v", err)
+	}
+
+	for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+		mg := m.migrations[i]
+		at, ok := done[mg.Version]
+		if !ok {
+			continue
+		}
+
+		if mg.Down == nil {
+			return reverted, fmt.Errorf("%w: %d %s", constants.ErrIrreversibleMigration, mg.Version, mg.Name)
+		}
+
+		if err := mg.Down(ctx); err != nil {
+			return reverted, fmt.Errorf("can't revert migration %d %s: %v", mg.Version, mg.Name, err)
+		}
+
+		if err := m.store.Forget(ctx, mg.Version); err != nil {
+			return reverted, fmt.Errorf("can't forget migration %d %s: %v", mg.Version, mg.Name, err)
+		}
+
+		reverted = append(reverted, models.MigrationStatus{Version: mg.Version, Name: mg.Name, AppliedAt: at})
+	}
+
+	return reverted, nil
+}
+
+// Status returns all the migrations in order.
+func (m *Migrator) Status(ctx context.Context) ([]models.MigrationStatus, error) {
+	done, err := m.store.Applied(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("can't load applied migrations: %v", err)
+	}
+
+	status := make([]models.MigrationStatus, 0, len(m.migrations))
+	for _, mg := range m.migrations {
+		status = append(status, models.MigrationStatus{Version: mg.Version, Name: mg.Name, AppliedAt: done[mg.Version]})
+	}
+
+	return status, nil
+}
@@ -0,0 +1,143 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/models"
+	"gotest.tools/v3/assert"
+	"testing"
+	"time"
+)
+
+type store map[int64]int64
+
+func (s store) Applied(context.Context) (map[int64]int64, error) {
+	applied := make(map[int64]int64, len(s))
+	for v, at := range s {
+		applied[v] = at
+	}
+	return applied, nil
+}
+
+func (s store) Record(_ context.Context, version int64, _ string, appliedAt int64) error {
+	s[version] = appliedAt
+	return nil
+}
+
+func (s store) Forget(_ context.Context, version int64) error {
+	delete(s, version)
+	return nil
+}
+
+func newMigrator(t *testing.T, st store, migrations ...Migration) *Migrator {
+	m, err := New(st, migrations...)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	m.now = func() time.Time { return time.Unix(1000, 0) }
+	return m
+}
+
+func migration(version int64, name string, log *[]string) Migration {
+	return Migration{
+		Version: version,
+		Name:    name,
+		Up: func(context.Context) error {
+			*log = append(*log, "up "+name)
+			return nil
+		},
+		Down: func(context.Context) error {
+			*log = append(*log, "down "+name)
+			return nil
+		},
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	var log []string
+	st := store{}
+	m := newMigrator(t, st,
+		migration(2, "expiry_index", &log),
+		migration(1, "guid_index", &log),
+		migration(3, "audit_index", &log),
+	)
+	ctx := context.Background()
+
+	applied, err := m.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	assert.DeepEqual(t, []models.MigrationStatus{
+		{Version: 1, Name: "guid_index", AppliedAt: 1000},
+		{Version: 2, Name: "expiry_index", AppliedAt: 1000},
+		{Version: 3, Name: "audit_index", AppliedAt: 1000},
+	}, applied)
+
+	applied, err = m.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up() again error = %v", err)
+	}
+	assert.Equal(t, len(applied), 0)
+
+	reverted, err := m.Down(ctx, 2)
+	if err != nil {
+		t.Fatalf("Down() error = %v", err)
+	}
+	assert.DeepEqual(t, []models.MigrationStatus{
+		{Version: 3, Name: "audit_index", AppliedAt: 1000},
+		{Version: 2, Name: "expiry_index", AppliedAt: 1000},
+	}, reverted)
+
+	status, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	assert.DeepEqual(t, []models.MigrationStatus{
+		{Version: 1, Name: "guid_index", AppliedAt: 1000},
+		{Version: 2, Name: "expiry_index"},
+		{Version: 3, Name: "audit_index"},
+	}, status)
+
+	assert.DeepEqual(t, []string{
+		"up guid_index", "up expiry_index", "up audit_index",
+		"down audit_index", "down expiry_index",
+	}, log)
+}
+
+func TestMigrator_Up_Failure(t *testing.T) {
+	var log []string
+	st := store{}
+	broken := migration(2, "broken", &log)
+	broken.Up = func(context.Context) error { return errors.New("index build failed") }
+
+	m := newMigrator(t, st, migration(1, "guid_index", &log), broken, migration(3, "audit_index", &log))
+
+	applied, err := m.Up(context.Background())
+	if err == nil {
+		t.Fatalf("Up() error = nil, want error")
+	}
+	assert.Equal(t, len(applied), 1)
+	assert.DeepEqual(t, store{1: 1000}, st)
+}
+
+func TestMigrator_Down_Irreversible(t *testing.T) {
+	var log []string
+	st := store{1: 1000}
+	irreversible := migration(1, "backfill", &log)
+	irreversible.Down = nil
+
+	m := newMigrator(t, st, irreversible)
+
+	if _, err := m.Down(context.Background(), 1); !errors.Is(err, constants.ErrIrreversibleMigration) {
+		t.Errorf("Down() error = %v, want %v", err, constants.ErrIrreversibleMigration)
+	}
+	assert.DeepEqual(t, store{1: 1000}, st)
+}
+
+func TestNew_DuplicateVersion(t *testing.T) {
+	var log []string
+	if _, err := New(store{}, migration(1, "a", &log), migration(1, "b", &log)); err == nil {
+		t.Errorf("New() error = nil, want error")
+	}
+}
@@ -0,0 +1,193 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains"
+	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/models"
+	"go-jwt-auth/internal/storage/migrate"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	_schemaMigrations = "schema_migrations"
+
+	// _indexNotFound is the error code of dropping a missing index.
+	_indexNotFound = 27
+)
+
+// schemaDatabase is implemented by the databases with a schema.
+type schemaDatabase interface {
+	Migrator() (domains.Migrator, error)
+}
+
+// NewMigrator creates the migrator of the database. The databases without
+// a schema get one that fails with constants.ErrNotSupported.
+func NewMigrator(db domains.Database) (domains.Migrator, error) {
+	if sd, ok := db.(schemaDatabase); ok {
+		return sd.Migrator()
+	}
+	return unsupportedMigrator{}, nil
+}
+
+type unsupportedMigrator struct{}
+
+func (unsupportedMigrator) Up(context.Context) ([]models.MigrationStatus, error) {
+	return nil, fmt.Errorf("migrations: %w", constants.ErrNotSupported)
+}
+
+func (unsupportedMigrator) Down(context.Context, int) ([]models.MigrationStatus, error) {
+	return nil, fmt.Errorf("migrations: %w", constants.ErrNotSupported)
+}
+
+func (unsupportedMigrator) Status(context.Context) ([]models.MigrationStatus, error) {
+	return nil, fmt.Errorf("migrations: %w", constants.ErrNotSupported)
+}
+
+// Migrator returns the migrator of the mongo schema.
+func (d Database) Migrator() (domains.Migrator, error) {
+	return migrate.New(migrationStore{db: d.db}, d.migrations()...)
+}
+
+// migrations are the schema changes of the mongo storage.
+// Released migrations must never change, new ones are appended.
+func (d Database) migrations() []migrate.Migration {
+	return []migrate.Migration{
+		{
+			Version: 1,
+			Name:    "tokens_guid_index",
+			Up: d.createIndex(_tokens, mongo.IndexModel{
+				Keys: bson.D{{Key: _guid, Value: 1}},
+			}),
+			Down: d.dropIndex(_tokens, "guid_1"),
+		},
+		{
+			Version: 2,
+			Name:    "tokens_refresh_expires_at_ttl_index",
+			Up: func(ctx context.Context) error {
+				if err := d.backfillRefreshExpiresAt(ctx); err != nil {
+					return err
+				}
+				return d.createIndex(_tokens, mongo.IndexModel{
+					Keys:    bson.D{{Key: _refreshExpiresAt, Value: 1}},
+					Options: options.Index().SetExpireAfterSeconds(0),
+				})(ctx)
+			},
+			Down: d.dropIndex(_tokens, "refresh_expires_at_1"),
+		},
+		{
+			// makes the sequence number unique, so two writers can never
+			// append different records at the same position of the chain.
+			Version: 3,
+			Name:    "audit_seq_unique_index",
+			Up: d.createIndex(_audit, mongo.IndexModel{
+				Keys:    bson.D{{Key: _seq, Value: 1}},
+				Options: options.Index().SetUnique(true),
+			}),
+			Down: d.dropIndex(_audit, "seq_1"),
+		},
+		{
+			Version: 4,
+			Name:    "authorization_codes_ttl_index",
+			Up: d.createIndex(_codes, mongo.IndexModel{
+				Keys:    bson.D{{Key: _codeExpiresAt, Value: 1}},
+				Options: options.Index().SetExpireAfterSeconds(0),
+			}),
+			Down: d.dropIndex(_codes, "expires_at_date_1"),
+		},
+	}
+}
+
+// backfillRefreshExpiresAt sets the Date expiry of the sessions saved before it existed.
+func (d Database) backfillRefreshExpiresAt(ctx context.Context) error {
+	filter := bson.D{
+		{Key: _refreshExpiresAt, Value: bson.D{{Key: "$exists", Value: false}}},
+		{Key: _refreshExp, Value: bson.D{{Key: "$gt", Value: 0}}},
+	}
+	backfill := mongo.Pipeline{{{Key: "$set", Value: bson.D{{
+		Key: _refreshExpiresAt,
+		Value: bson.D{{Key: "$toDate", Value: bson.D{{
+			Key: "$multiply", Value: bson.A{"$" + _refreshExp, 1000},
+		}}}},
+	}}}}}
+	if _, err := d.db.Collection(_tokens).UpdateMany(ctx, filter, backfill); err != nil {
+		return fmt.Errorf("can't backfill token expiry: %v", err)
+	}
+	return nil
+}
+
+// createIndex creates an index, creating an existing index is a no-op.
+func (d Database) createIndex(collection string, index mongo.IndexModel) func(context.Context) error {
+	return func(ctx context.Context) error {
+		if _, err := d.db.Collection(collection).Indexes().CreateOne(ctx, index); err != nil {
+			return fmt.Errorf("can't create %s index: %v", collection, err)
+		}
+		return nil
+	}
+}
+
+// dropIndex drops an index, dropping a missing index is a no-op.
+func (d Database) dropIndex(collection, name string) func(context.Context) error {
+	return func(ctx context.Context) error {
+		_, err := d.db.Collection(collection).Indexes().DropOne(ctx, name)
+		var cmdErr mongo.CommandError
+		if errors.As(err, &cmdErr) && cmdErr.Code == _indexNotFound {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("can't drop %s index %s: %v", collection, name, err)
+		}
+		return nil
+	}
+}
+
+// migrationStore records the applied migrations in the schema_migrations collection.
+type migrationStore struct {
+	db lib.Database
+}
+
+type migrationRecord struct {
+	Version   int64  `bson:"_id"`
+	Name      string `bson:"name"`
+	AppliedAt int64  `bson:"applied_at"`
+}
+
+func (s migrationStore) Applied(ctx context.Context) (applied map[int64]int64, err error) {
+	cur, err := s.db.Collection(_schemaMigrations).Find(ctx, bson.D{})
+	if err != nil {
+		return nil, err
+	}
+
+	var records []migrationRecord
+	if err := cur.All(ctx, &records); err != nil {
+		return nil, err
+	}
+
+	applied = make(map[int64]int64, len(records))
+	for _, r := range records {
+		applied[r.Version] = r.AppliedAt
+	}
+	return applied, nil
+}
+
+func (s migrationStore) Record(ctx context.Context, version int64, name string, appliedAt int64) error {
+	r := migrationRecord{Version: version, Name: name, AppliedAt: appliedAt}
+	if _, err := s.db.Collection(_schemaMigrations).InsertOne(ctx, r); err != nil {
+		// applied by another instance at the same time.
+		if mongo.IsDuplicateKeyError(err) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+func (s migrationStore) Forget(ctx context.Context, version int64) error {
+	_, err := s.db.Collection(_schemaMigrations).DeleteOne(ctx, bson.D{{Key: "_id", Value: version}})
+	return err
+}
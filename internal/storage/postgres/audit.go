@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/models"
+)
+
+// AppendAuditRecord appends a record to the audit trail.
+func (d *Database) AppendAuditRecord(ctx context.Context, r models.AuditRecord) error {
+	_, err := d.db.ExecContext(ctx,
+		`INSERT INTO audit (seq, event, guid, client_id, time, prev_hash, hash, checkpoint) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		r.Seq, string(r.Event), r.GUID, r.ClientID, r.Time, r.PrevHash, r.Hash, r.Checkpoint,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return constants.ErrAlreadyExists
+		}
+		return fmt.Errorf("can't insert audit record: %v", err)
+	}
+
+	return nil
+}
+
+// LastAuditRecord retrieves the record with the highest sequence number.
+func (d *Database) LastAuditRecord(ctx context.Context) (r models.AuditRecord, err error) {
+	row := d.db.QueryRowContext(ctx,
+		`SELECT seq, event, guid, client_id, time, prev_hash, hash, checkpoint FROM audit ORDER BY seq DESC LIMIT 1`,
+	)
+
+	err = scanAuditRecord(row, &r)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return r, constants.ErrNotFound
+		}
+		return r, err
+	}
+
+	return r, nil
+}
+
+// GetAuditRecords retrieves up to limit records starting from the given sequence number.
+func (d *Database) GetAuditRecords(ctx context.Context, fromSeq int64, limit int64) (r []models.AuditRecord, err error) {
+	rows, err := d.db.QueryContext(ctx,
+		`SELECT seq, event, guid, client_id, time, prev_hash, hash, checkpoint FROM audit WHERE seq >= $1 ORDER BY seq LIMIT $2`,
+		fromSeq, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if errClose := rows.Close(); errClose != nil && err == nil {
+			err = errClose
+		}
+	}()
+
+	for rows.Next() {
+		var ar models.AuditRecord
+		if err := scanAuditRecord(rows, &ar); err != nil {
+			return nil, err
+		}
+		r = append(r, ar)
+	}
+
+	return r, rows.Err()
+}
+
+// scanAuditRecord scans a row of the audit table into r.
+func scanAuditRecord(row interface{ Scan(dest ...any) error }, r *models.AuditRecord) error {
+	var event string
+	if err := row.Scan(&r.Seq, &event, &r.GUID, &r.ClientID, &r.Time, &r.PrevHash, &r.Hash, &r.Checkpoint); err != nil {
+		return err
+	}
+
+	r.Event = models.EventType(event)
+	return nil
+}
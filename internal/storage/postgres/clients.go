@@ -0,0 +1,120 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/models"
+	"time"
+)
+
+const _clientColumns = `id, tenant, secret_hash, public_key, grant_types, scopes, redirect_uris, access_ttl, refresh_ttl, tls_subject, guid_patterns`
+
+// SaveClient saves the client, replacing the one with the same ID.
+func (d *Database) SaveClient(ctx context.Context, c models.Client) error {
+	var lists [4][]byte
+	for i, list := range []any{c.GrantTypes, c.Scopes, c.RedirectURIs, c.GUIDPatterns} {
+		data, err := json.Marshal(list)
+		if err != nil {
+			return fmt.Errorf("can't marshal client: %v", err)
+		}
+		lists[i] = data
+	}
+
+	_, err := d.db.ExecContext(ctx,
+		`INSERT INTO clients (`+_clientColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (id) DO UPDATE SET tenant = $2, secret_hash = $3, public_key = $4,
+		grant_types = $5, scopes = $6, redirect_uris = $7, access_ttl = $8, refresh_ttl = $9,
+		tls_subject = $10, guid_patterns = $11`,
+		c.ID, c.Tenant, c.SecretHash, c.PublicKey, lists[0], lists[1], lists[2], int64(c.AccessTTL), int64(c.RefreshTTL),
+		c.TLSSubject, lists[3],
+	)
+	if err != nil {
+		return fmt.Errorf("can't save client: %v", err)
+	}
+
+	return nil
+}
+
+// GetClient retrieves the client by ID.
+func (d *Database) GetClient(ctx context.Context, id string) (models.Client, error) {
+	c, err := scanClient(d.db.QueryRowContext(ctx,
+		`SELECT `+_clientColumns+` FROM clients WHERE id = $1`, id,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return c, constants.ErrNotFound
+	}
+	return c, err
+}
+
+// GetClients retrieves all the clients ordered by ID.
+func (d *Database) GetClients(ctx context.Context) (clients []models.Client, err error) {
+	rows, err := d.db.QueryContext(ctx, `SELECT `+_clientColumns+` FROM clients ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if errClose := rows.Close(); errClose != nil && err == nil {
+			err = errClose
+		}
+	}()
+
+	for rows.Next() {
+		c, err := scanClient(rows)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, c)
+	}
+
+	return clients, rows.Err()
+}
+
+// DeleteClient deletes the client by ID.
+func (d *Database) DeleteClient(ctx context.Context, id string) error {
+	res, err := d.db.ExecContext(ctx, `DELETE FROM clients WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return constants.ErrNotFound
+	}
+	return nil
+}
+
+func scanClient(row interface{ Scan(dest ...any) error }) (c models.Client, err error) {
+	var (
+		grantTypes, scopes, redirectURIs, guidPatterns []byte
+		accessTTL, refreshTTL                          int64
+	)
+	err = row.Scan(&c.ID, &c.Tenant, &c.SecretHash, &c.PublicKey, &grantTypes, &scopes, &redirectURIs, &accessTTL, &refreshTTL,
+		&c.TLSSubject, &guidPatterns)
+	if err != nil {
+		return c, err
+	}
+
+	if err := json.Unmarshal(grantTypes, &c.GrantTypes); err != nil {
+		return c, fmt.Errorf("can't unmarshal grant types: %v", err)
+	}
+	if err := json.Unmarshal(scopes, &c.Scopes); err != nil {
+		return c, fmt.Errorf("can't unmarshal scopes: %v", err)
+	}
+	if err := json.Unmarshal(redirectURIs, &c.RedirectURIs); err != nil {
+		return c, fmt.Errorf("can't unmarshal redirect uris: %v", err)
+	}
+	if err := json.Unmarshal(guidPatterns, &c.GUIDPatterns); err != nil {
+		return c, fmt.Errorf("can't unmarshal guid patterns: %v", err)
+	}
+	c.AccessTTL = time.Duration(accessTTL)
+	c.RefreshTTL = time.Duration(refreshTTL)
+
+	return c, nil
+}
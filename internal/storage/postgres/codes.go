@@ -0,0 +1,36 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/models"
+)
+
+const _codeColumns = `hash, tenant, client_id, redirect_uri, guid, scope, code_challenge, nonce, auth_time, expires_at`
+
+// SaveCode saves the authorization code.
+func (d *Database) SaveCode(ctx context.Context, c models.AuthorizationCode) error {
+	_, err := d.db.ExecContext(ctx,
+		`INSERT INTO authorization_codes (`+_codeColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		c.Hash, c.Tenant, c.ClientID, c.RedirectURI, c.GUID, c.Scope, c.CodeChallenge, c.Nonce, c.AuthTime, c.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("can't save code: %v", err)
+	}
+
+	return nil
+}
+
+// ConsumeCode deletes the authorization code and returns it.
+func (d *Database) ConsumeCode(ctx context.Context, hash string) (c models.AuthorizationCode, err error) {
+	err = d.db.QueryRowContext(ctx,
+		`DELETE FROM authorization_codes WHERE hash = $1 RETURNING `+_codeColumns, hash,
+	).Scan(&c.Hash, &c.Tenant, &c.ClientID, &c.RedirectURI, &c.GUID, &c.Scope, &c.CodeChallenge, &c.Nonce, &c.AuthTime, &c.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c, constants.ErrNotFound
+	}
+	return c, err
+}
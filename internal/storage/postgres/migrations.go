@@ -0,0 +1,225 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"go-jwt-auth/internal/domains"
+	"go-jwt-auth/internal/storage/migrate"
+)
+
+// _migrations are the schema changes of the postgres storage.
+// Released migrations must never change, new ones are appended.
+var _migrations = []struct {
+	version  int64
+	name     string
+	up, down string
+}{
+	{
+		version: 1,
+		name:    "create_tokens",
+		up: `CREATE TABLE IF NOT EXISTS tokens (
+    id           BIGSERIAL PRIMARY KEY,
+    guid         TEXT   NOT NULL,
+    refresh_hash TEXT   NOT NULL,
+    refresh_exp  BIGINT NOT NULL,
+    access_exp   BIGINT NOT NULL,
+    ip           TEXT   NOT NULL DEFAULT ''
+)`,
+		down: `DROP TABLE IF EXISTS tokens`,
+	},
+	{
+		version: 2,
+		name:    "tokens_guid_index",
+		up:      `CREATE INDEX IF NOT EXISTS tokens_guid_idx ON tokens (guid)`,
+		down:    `DROP INDEX IF EXISTS tokens_guid_idx`,
+	},
+	{
+		version: 3,
+		name:    "tokens_refresh_exp_index",
+		up:      `CREATE INDEX IF NOT EXISTS tokens_refresh_exp_idx ON tokens (refresh_exp)`,
+		down:    `DROP INDEX IF EXISTS tokens_refresh_exp_idx`,
+	},
+	{
+		version: 4,
+		name:    "create_audit",
+		up: `CREATE TABLE IF NOT EXISTS audit (
+    seq        BIGINT PRIMARY KEY,
+    event      TEXT   NOT NULL,
+    guid       TEXT   NOT NULL,
+    time       BIGINT NOT NULL,
+    prev_hash  TEXT   NOT NULL,
+    hash       TEXT   NOT NULL,
+    checkpoint TEXT   NOT NULL DEFAULT ''
+)`,
+		down: `DROP TABLE IF EXISTS audit`,
+	},
+	{
+		version: 5,
+		name:    "create_webhook_log",
+		up: `CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id           TEXT    NOT NULL,
+    subscription TEXT    NOT NULL,
+    url          TEXT    NOT NULL,
+    event        TEXT    NOT NULL,
+    payload      TEXT    NOT NULL,
+    attempt      INTEGER NOT NULL,
+    status_code  INTEGER NOT NULL,
+    error        TEXT    NOT NULL DEFAULT '',
+    time         BIGINT  NOT NULL
+);
+CREATE TABLE IF NOT EXISTS webhook_dead_letters (LIKE webhook_deliveries)`,
+		down: `DROP TABLE IF EXISTS webhook_dead_letters, webhook_deliveries`,
+	},
+	{
+		// the sessions saved before are version 0.
+		version: 6,
+		name:    "tokens_schema_version",
+		up:      `ALTER TABLE tokens ADD COLUMN IF NOT EXISTS schema_version INTEGER NOT NULL DEFAULT 0`,
+		down:    `ALTER TABLE tokens DROP COLUMN IF EXISTS schema_version`,
+	},
+	{
+		version: 7,
+		name:    "tokens_tenant",
+		up:      `ALTER TABLE tokens ADD COLUMN IF NOT EXISTS tenant TEXT NOT NULL DEFAULT ''`,
+		down:    `ALTER TABLE tokens DROP COLUMN IF EXISTS tenant`,
+	},
+	{
+		version: 8,
+		name:    "tokens_client_id",
+		up:      `ALTER TABLE tokens ADD COLUMN IF NOT EXISTS client_id TEXT NOT NULL DEFAULT ''`,
+		down:    `ALTER TABLE tokens DROP COLUMN IF EXISTS client_id`,
+	},
+	{
+		// the lists are JSON arrays, the TTLs are nanoseconds.
+		version: 9,
+		name:    "create_clients",
+		up: `CREATE TABLE IF NOT EXISTS clients (
+    id            TEXT   PRIMARY KEY,
+    tenant        TEXT   NOT NULL DEFAULT '',
+    secret_hash   TEXT   NOT NULL DEFAULT '',
+    public_key    TEXT   NOT NULL DEFAULT '',
+    grant_types   JSONB  NOT NULL DEFAULT '[]',
+    scopes        JSONB  NOT NULL DEFAULT '[]',
+    redirect_uris JSONB  NOT NULL DEFAULT '[]',
+    access_ttl    BIGINT NOT NULL DEFAULT 0,
+    refresh_ttl   BIGINT NOT NULL DEFAULT 0
+)`,
+		down: `DROP TABLE IF EXISTS clients`,
+	},
+	{
+		version: 10,
+		name:    "tokens_scope",
+		up:      `ALTER TABLE tokens ADD COLUMN IF NOT EXISTS scope TEXT NOT NULL DEFAULT ''`,
+		down:    `ALTER TABLE tokens DROP COLUMN IF EXISTS scope`,
+	},
+	{
+		// hash is the hex SHA-256 of the code.
+		version: 11,
+		name:    "create_authorization_codes",
+		up: `CREATE TABLE IF NOT EXISTS authorization_codes (
+    hash           TEXT   PRIMARY KEY,
+    tenant         TEXT   NOT NULL DEFAULT '',
+    client_id      TEXT   NOT NULL,
+    redirect_uri   TEXT   NOT NULL DEFAULT '',
+    guid           TEXT   NOT NULL,
+    scope          TEXT   NOT NULL DEFAULT '',
+    code_challenge TEXT   NOT NULL,
+    expires_at     BIGINT NOT NULL
+)`,
+		down: `DROP TABLE IF EXISTS authorization_codes`,
+	},
+	{
+		version: 12,
+		name:    "authorization_codes_oidc",
+		up: `ALTER TABLE authorization_codes
+    ADD COLUMN IF NOT EXISTS nonce     TEXT   NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS auth_time BIGINT NOT NULL DEFAULT 0`,
+		down: `ALTER TABLE authorization_codes DROP COLUMN IF EXISTS nonce, DROP COLUMN IF EXISTS auth_time`,
+	},
+	{
+		version: 13,
+		name:    "clients_issuance",
+		up: `ALTER TABLE clients
+    ADD COLUMN IF NOT EXISTS tls_subject   TEXT  NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS guid_patterns JSONB NOT NULL DEFAULT '[]'`,
+		down: `ALTER TABLE clients DROP COLUMN IF EXISTS tls_subject, DROP COLUMN IF EXISTS guid_patterns`,
+	},
+	{
+		version: 14,
+		name:    "audit_client_id",
+		up:      `ALTER TABLE audit ADD COLUMN IF NOT EXISTS client_id TEXT NOT NULL DEFAULT ''`,
+		down:    `ALTER TABLE audit DROP COLUMN IF EXISTS client_id`,
+	},
+}
+
+// Migrator returns the migrator of the schema.
+func (d *Database) Migrator() (domains.Migrator, error) {
+	migrations := make([]migrate.Migration, 0, len(_migrations))
+	for _, m := range _migrations {
+		migrations = append(migrations, migrate.Migration{
+			Version: m.version,
+			Name:    m.name,
+			Up:      d.exec(m.up),
+			Down:    d.exec(m.down),
+		})
+	}
+	return migrate.New(migrationStore{db: d.db}, migrations...)
+}
+
+func (d *Database) exec(query string) func(context.Context) error {
+	return func(ctx context.Context) error {
+		_, err := d.db.ExecContext(ctx, query)
+		return err
+	}
+}
+
+// migrationStore records the applied migrations in the schema_migrations table.
+type migrationStore struct {
+	db *sql.DB
+}
+
+func (s migrationStore) Applied(ctx context.Context) (applied map[int64]int64, err error) {
+	_, err = s.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version    BIGINT PRIMARY KEY,
+    name       TEXT   NOT NULL,
+    applied_at BIGINT NOT NULL
+)`)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if errClose := rows.Close(); errClose != nil && err == nil {
+			err = errClose
+		}
+	}()
+
+	applied = make(map[int64]int64)
+	for rows.Next() {
+		var version, at int64
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+
+	return applied, rows.Err()
+}
+
+func (s migrationStore) Record(ctx context.Context, version int64, name string, appliedAt int64) error {
+	// DO NOTHING: applied by another instance at the same time.
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3) ON CONFLICT (version) DO NOTHING`,
+		version, name, appliedAt,
+	)
+	return err
+}
+
+func (s migrationStore) Forget(ctx context.Context, version int64) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, version)
+	return err
+}
@@ -0,0 +1,228 @@
+// Package postgres is a PostgreSQL storage.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/models"
+	"go-jwt-auth/internal/storage/tokenschema"
+)
+
+// _uniqueViolation is the SQLSTATE of unique constraint violations.
+const _uniqueViolation = "23505"
+
+// Database is a PostgreSQL storage.
+type Database struct {
+	db *sql.DB
+}
+
+// New creates a new instance of Database.
+// The schema is created by the migrations, see Migrator.
+func New(db *sql.DB) *Database {
+	return &Database{db: db}
+}
+
+// Close closes the connection pool.
+func (d *Database) Close() error {
+	return d.db.Close()
+}
+
+// SaveTokenData saves a token.
+func (d *Database) SaveTokenData(ctx context.Context, t models.TokenData) error {
+	_, err := d.db.ExecContext(ctx,
+		`INSERT INTO tokens (schema_version, guid, refresh_hash, refresh_exp, access_exp, ip, tenant, client_id, scope) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		t.SchemaVersion, t.GUID, t.RefreshHash, t.RefreshExp, t.AccessExp, t.IP, t.Tenant, t.ClientID, t.Scope,
+	)
+	if err != nil {
+		return fmt.Errorf("can't insert token: %v", err)
+	}
+
+	return nil
+}
+
+// GetTokensDataByGUID retrieves the sessions of a given GUID.
+func (d *Database) GetTokensDataByGUID(ctx context.Context, guid string) (t []models.TokenData, err error) {
+	rows, err := d.db.QueryContext(ctx,
+		`SELECT schema_version, guid, refresh_hash, refresh_exp, access_exp, ip, tenant, client_id, scope FROM tokens WHERE guid = $1 ORDER BY id`,
+		guid,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if errClose := rows.Close(); errClose != nil && err == nil {
+			err = errClose
+		}
+	}()
+
+	for rows.Next() {
+		var td models.TokenData
+		if err := rows.Scan(&td.SchemaVersion, &td.GUID, &td.RefreshHash, &td.RefreshExp, &td.AccessExp, &td.IP, &td.Tenant, &td.ClientID, &td.Scope); err != nil {
+			return nil, err
+		}
+		t = append(t, tokenschema.Upgrade(td))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(t) == 0 {
+		return nil, constants.ErrNotFound
+	}
+
+	return t, nil
+}
+
+// DeleteTokenData consumes a session.
+// The delete is a transaction on its own: when the same session is
+// consumed concurrently, only one caller deletes the row, the others get
+// constants.ErrNotFound.
+func (d *Database) DeleteTokenData(ctx context.Context, guid, hash string) error {
+	res, err := d.db.ExecContext(ctx,
+		`DELETE FROM tokens WHERE guid = $1 AND refresh_hash = $2`,
+		guid, hash,
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return constants.ErrNotFound
+	}
+
+	return nil
+}
+
+// PurgeExpired deletes the sessions whose refresh token expired before now.
+// The expired authorization codes are deleted too, they aren't counted.
+func (d *Database) PurgeExpired(ctx context.Context, now int64) (purged int64, err error) {
+	res, err := d.db.ExecContext(ctx,
+		`DELETE FROM tokens WHERE refresh_exp > 0 AND refresh_exp < $1`,
+		now,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("can't purge expired sessions: %v", err)
+	}
+
+	if _, err := d.db.ExecContext(ctx, `DELETE FROM authorization_codes WHERE expires_at < $1`, now); err != nil {
+		return 0, fmt.Errorf("can't purge expired codes: %v", err)
+	}
+
+	return res.RowsAffected()
+}
+
+// ScanTokenData calls fn with every session until it returns an error.
+// The rows are streamed, fn holds a connection of the pool while it runs.
+func (d *Database) ScanTokenData(ctx context.Context, fn func(models.TokenData) error) (err error) {
+	rows, err := d.db.QueryContext(ctx,
+		`SELECT schema_version, guid, refresh_hash, refresh_exp, access_exp, ip, tenant, client_id, scope FROM tokens`,
+	)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if errClose := rows.Close(); errClose != nil && err == nil {
+			err = errClose
+		}
+	}()
+
+	for rows.Next() {
+		var td models.TokenData
+		if err := rows.Scan(&td.SchemaVersion, &td.GUID, &td.RefreshHash, &td.RefreshExp, &td.AccessExp, &td.IP, &td.Tenant, &td.ClientID, &td.Scope); err != nil {
+			return err
+		}
+		if err := fn(tokenschema.Upgrade(td)); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// CountOutdatedTokenData counts the sessions saved in an older schema version.
+func (d *Database) CountOutdatedTokenData(ctx context.Context) (n int64, err error) {
+	err = d.db.QueryRowContext(ctx,
+		`SELECT count(*) FROM tokens WHERE schema_version < $1`,
+		models.TokenDataVersion,
+	).Scan(&n)
+	return n, err
+}
+
+// RewriteOutdatedTokenData upgrades up to limit sessions saved in an older
+// schema version. A row is only rewritten if it wasn't changed in the meantime,
+// the rows consumed in the meantime aren't counted.
+func (d *Database) RewriteOutdatedTokenData(ctx context.Context, limit int64) (n int64, err error) {
+	// a batch consumed in the meantime isn't the end, the next one is looked at.
+	for n == 0 {
+		ids, outdated, err := d.outdatedTokenData(ctx, limit)
+		if err != nil {
+			return 0, err
+		}
+		if len(outdated) == 0 {
+			return 0, nil
+		}
+
+		for i, td := range outdated {
+			up := tokenschema.Upgrade(td)
+			res, err := d.db.ExecContext(ctx,
+				`UPDATE tokens SET schema_version = $1, guid = $2, refresh_hash = $3, refresh_exp = $4, access_exp = $5, ip = $6, tenant = $7, client_id = $8, scope = $9
+				WHERE id = $10 AND schema_version = $11`,
+				up.SchemaVersion, up.GUID, up.RefreshHash, up.RefreshExp, up.AccessExp, up.IP, up.Tenant, up.ClientID, up.Scope, ids[i], td.SchemaVersion,
+			)
+			if err != nil {
+				return n, fmt.Errorf("can't rewrite token: %v", err)
+			}
+			rows, err := res.RowsAffected()
+			if err != nil {
+				return n, fmt.Errorf("can't rewrite token: %v", err)
+			}
+			n += rows
+		}
+	}
+
+	return n, nil
+}
+
+func (d *Database) outdatedTokenData(ctx context.Context, limit int64) (ids []int64, t []models.TokenData, err error) {
+	rows, err := d.db.QueryContext(ctx,
+		`SELECT id, schema_version, guid, refresh_hash, refresh_exp, access_exp, ip, tenant, client_id, scope FROM tokens
+		WHERE schema_version < $1 ORDER BY id LIMIT $2`,
+		models.TokenDataVersion, limit,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		if errClose := rows.Close(); errClose != nil && err == nil {
+			err = errClose
+		}
+	}()
+
+	for rows.Next() {
+		var (
+			id int64
+			td models.TokenData
+		)
+		if err := rows.Scan(&id, &td.SchemaVersion, &td.GUID, &td.RefreshHash, &td.RefreshExp, &td.AccessExp, &td.IP, &td.Tenant, &td.ClientID, &td.Scope); err != nil {
+			return nil, nil, err
+		}
+		ids = append(ids, id)
+		t = append(t, td)
+	}
+
+	return ids, t, rows.Err()
+}
+
+// isUniqueViolation reports whether err is a unique constraint violation.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == _uniqueViolation
+}
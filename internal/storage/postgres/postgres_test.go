@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/egorgasay/dockerdb/v3"
+	"github.com/google/uuid"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"go-jwt-auth/internal/domains"
+	"go-jwt-auth/internal/storage/storagetest"
+	"testing"
+	"time"
+)
+
+func TestDatabase(t *testing.T) {
+	ctx := context.Background()
+	vdb, dsn := upPostgres(ctx, t)
+	defer func() {
+		if err := vdb.Clear(ctx); err != nil {
+			t.Errorf("can't clear container, possible container leak and wrong results in the future tests: %v", err)
+		}
+	}()
+
+	storagetest.Run(t, func(t *testing.T) domains.Database {
+		pool, err := sql.Open("pgx", dsn)
+		if err != nil {
+			t.Fatalf("can't open postgres: %v", err)
+		}
+		t.Cleanup(func() { pool.Close() })
+
+		// every test works in its own schema to start with empty tables.
+		schema := "test_" + uuid.NewString()[:8]
+		pool.SetMaxOpenConns(1)
+		if _, err := pool.ExecContext(ctx, fmt.Sprintf("CREATE SCHEMA %s; SET search_path TO %s", schema, schema)); err != nil {
+			t.Fatalf("can't create schema: %v", err)
+		}
+
+		d := New(pool)
+		m, err := d.Migrator()
+		if err != nil {
+			t.Fatalf("Migrator() error = %v", err)
+		}
+		if _, err := m.Up(ctx); err != nil {
+			t.Fatalf("Up() error = %v", err)
+		}
+		return d
+	})
+}
+
+func TestDatabase_Migrator(t *testing.T) {
+	ctx := context.Background()
+	vdb, dsn := upPostgres(ctx, t)
+	defer func() {
+		if err := vdb.Clear(ctx); err != nil {
+			t.Errorf("can't clear container, possible container leak and wrong results in the future tests: %v", err)
+		}
+	}()
+
+	pool, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatalf("can't open postgres: %v", err)
+	}
+	defer pool.Close()
+
+	m, err := New(pool).Migrator()
+	if err != nil {
+		t.Fatalf("Migrator() error = %v", err)
+	}
+
+	applied, err := m.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	if len(applied) != len(_migrations) {
+		t.Errorf("Up() applied %d migrations, want %d", len(applied), len(_migrations))
+	}
+
+	if applied, err = m.Up(ctx); err != nil || len(applied) != 0 {
+		t.Errorf("Up() again = %v, %v, want nothing applied", applied, err)
+	}
+
+	if _, err := m.Down(ctx, len(_migrations)); err != nil {
+		t.Fatalf("Down() error = %v", err)
+	}
+
+	status, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	for _, s := range status {
+		if s.Applied() {
+			t.Errorf("migration %d %s is applied after Down()", s.Version, s.Name)
+		}
+	}
+
+	// the migrations are idempotent, so they can be applied again.
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("Up() after Down() error = %v", err)
+	}
+}
+
+func upPostgres(ctx context.Context, t *testing.T) (vdb *dockerdb.VDB, dsn string) {
+	cfg := dockerdb.EmptyConfig().Vendor(dockerdb.Postgres15).DBName("tokens").
+		NoSQL(func(c dockerdb.Config) (stop bool) {
+			dsn = fmt.Sprintf("postgres://%s:%s@127.0.0.1:%s/%s?sslmode=disable",
+				c.GetDBUser(), c.GetDBPassword(), c.GetActualPort(), c.GetDBName())
+
+			pool, err := sql.Open("pgx", dsn)
+			if err != nil {
+				t.Logf("can't open postgres: %v", err)
+				return false
+			}
+			defer pool.Close()
+
+			if err := pool.PingContext(ctx); err != nil {
+				t.Logf("can't ping postgres: %v", err)
+				return false
+			}
+
+			t.Logf("connected to postgres at %s", dsn)
+			return true
+		}, 30, 2*time.Second).PullImage().StandardDBPort("5432").Build()
+
+	vdb, err := dockerdb.New(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return vdb, dsn
+}
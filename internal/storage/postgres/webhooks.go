@@ -0,0 +1,32 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"go-jwt-auth/internal/models"
+)
+
+// SaveWebhookDelivery saves a delivery attempt to the delivery log.
+func (d *Database) SaveWebhookDelivery(ctx context.Context, wd models.WebhookDelivery) error {
+	if err := d.insertDelivery(ctx, "webhook_deliveries", wd); err != nil {
+		return fmt.Errorf("can't insert webhook delivery: %v", err)
+	}
+	return nil
+}
+
+// SaveWebhookDeadLetter saves a delivery that ran out of attempts.
+func (d *Database) SaveWebhookDeadLetter(ctx context.Context, wd models.WebhookDelivery) error {
+	if err := d.insertDelivery(ctx, "webhook_dead_letters", wd); err != nil {
+		return fmt.Errorf("can't insert webhook dead letter: %v", err)
+	}
+	return nil
+}
+
+func (d *Database) insertDelivery(ctx context.Context, table string, wd models.WebhookDelivery) error {
+	_, err := d.db.ExecContext(ctx,
+		`INSERT INTO `+table+` (id, subscription, url, event, payload, attempt, status_code, error, time)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		wd.ID, wd.Subscription, wd.URL, string(wd.Event), wd.Payload, wd.Attempt, wd.StatusCode, wd.Error, wd.Time,
+	)
+	return err
+}
@@ -0,0 +1,83 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	goredis "github.com/redis/go-redis/v9"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/models"
+	"strconv"
+)
+
+const _audit = _prefix + "audit"
+
+// _append adds the record to the audit trail unless its position is taken.
+//
+// KEYS[1] - audit trail, ARGV[1] - sequence number, ARGV[2] - record.
+var _append = goredis.NewScript(`
+if redis.call('ZCOUNT', KEYS[1], ARGV[1], ARGV[1]) > 0 then
+	return 0
+end
+redis.call('ZADD', KEYS[1], ARGV[1], ARGV[2])
+return 1
+`)
+
+// AppendAuditRecord appends a record to the audit trail.
+func (d *Database) AppendAuditRecord(ctx context.Context, r models.AuditRecord) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("can't marshal audit record: %v", err)
+	}
+
+	added, err := _append.Run(ctx, d.client, []string{_audit}, r.Seq, data).Int()
+	if err != nil {
+		return fmt.Errorf("can't append audit record: %v", err)
+	}
+	if added == 0 {
+		return constants.ErrAlreadyExists
+	}
+
+	return nil
+}
+
+// LastAuditRecord retrieves the record with the highest sequence number.
+func (d *Database) LastAuditRecord(ctx context.Context) (models.AuditRecord, error) {
+	values, err := d.client.ZRevRange(ctx, _audit, 0, 0).Result()
+	if err != nil {
+		return models.AuditRecord{}, err
+	}
+	if len(values) == 0 {
+		return models.AuditRecord{}, constants.ErrNotFound
+	}
+
+	var r models.AuditRecord
+	if err := json.Unmarshal([]byte(values[0]), &r); err != nil {
+		return r, fmt.Errorf("can't unmarshal audit record: %v", err)
+	}
+
+	return r, nil
+}
+
+// GetAuditRecords retrieves up to limit records starting from the given sequence number.
+func (d *Database) GetAuditRecords(ctx context.Context, fromSeq int64, limit int64) ([]models.AuditRecord, error) {
+	values, err := d.client.ZRangeByScore(ctx, _audit, &goredis.ZRangeBy{
+		Min:   strconv.FormatInt(fromSeq, 10),
+		Max:   "+inf",
+		Count: limit,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	r := make([]models.AuditRecord, 0, len(values))
+	for _, v := range values {
+		var ar models.AuditRecord
+		if err := json.Unmarshal([]byte(v), &ar); err != nil {
+			return nil, fmt.Errorf("can't unmarshal audit record: %v", err)
+		}
+		r = append(r, ar)
+	}
+
+	return r, nil
+}
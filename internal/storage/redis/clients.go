@@ -0,0 +1,74 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	goredis "github.com/redis/go-redis/v9"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/models"
+	"sort"
+)
+
+// _clients is the hash of the clients by ID.
+const _clients = _prefix + "clients"
+
+// SaveClient saves the client, replacing the one with the same ID.
+func (d *Database) SaveClient(ctx context.Context, c models.Client) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("can't marshal client: %v", err)
+	}
+
+	if err := d.client.HSet(ctx, _clients, c.ID, data).Err(); err != nil {
+		return fmt.Errorf("can't save client: %v", err)
+	}
+	return nil
+}
+
+// GetClient retrieves the client by ID.
+func (d *Database) GetClient(ctx context.Context, id string) (c models.Client, err error) {
+	data, err := d.client.HGet(ctx, _clients, id).Bytes()
+	if err != nil {
+		if err == goredis.Nil {
+			return c, constants.ErrNotFound
+		}
+		return c, err
+	}
+
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("can't unmarshal client: %v", err)
+	}
+	return c, nil
+}
+
+// GetClients retrieves all the clients ordered by ID.
+func (d *Database) GetClients(ctx context.Context) ([]models.Client, error) {
+	all, err := d.client.HGetAll(ctx, _clients).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	clients := make([]models.Client, 0, len(all))
+	for _, data := range all {
+		var c models.Client
+		if err := json.Unmarshal([]byte(data), &c); err != nil {
+			return nil, fmt.Errorf("can't unmarshal client: %v", err)
+		}
+		clients = append(clients, c)
+	}
+	sort.Slice(clients, func(i, j int) bool { return clients[i].ID < clients[j].ID })
+	return clients, nil
+}
+
+// DeleteClient deletes the client by ID.
+func (d *Database) DeleteClient(ctx context.Context, id string) error {
+	deleted, err := d.client.HDel(ctx, _clients, id).Result()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return constants.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	goredis "github.com/redis/go-redis/v9"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/models"
+	"time"
+)
+
+// codeKey is the key of the authorization code, it expires with the code.
+func codeKey(hash string) string {
+	return _prefix + "code:" + hash
+}
+
+// SaveCode saves the authorization code.
+func (d *Database) SaveCode(ctx context.Context, c models.AuthorizationCode) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("can't marshal code: %v", err)
+	}
+
+	if err := d.client.Set(ctx, codeKey(c.Hash), data, time.Until(time.Unix(c.ExpiresAt, 0))).Err(); err != nil {
+		return fmt.Errorf("can't save code: %v", err)
+	}
+	return nil
+}
+
+// ConsumeCode deletes the authorization code and returns it.
+func (d *Database) ConsumeCode(ctx context.Context, hash string) (c models.AuthorizationCode, err error) {
+	data, err := d.client.GetDel(ctx, codeKey(hash)).Bytes()
+	if err != nil {
+		if err == goredis.Nil {
+			return c, constants.ErrNotFound
+		}
+		return c, err
+	}
+
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("can't unmarshal code: %v", err)
+	}
+	return c, nil
+}
@@ -0,0 +1,268 @@
+// Package redis is a Redis storage.
+// Every session is stored in its own key expiring with the refresh token,
+// the keys of the sessions of a GUID are kept in a sorted set.
+package redis
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	goredis "github.com/redis/go-redis/v9"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/models"
+	"go-jwt-auth/internal/storage/tokenschema"
+	"time"
+)
+
+const (
+	_prefix = "jwt-auth:"
+	// _sessionPattern matches the keys of all the sessions.
+	_sessionPattern = _prefix + "*:session:*"
+	_scanCount      = 500
+)
+
+// _save saves the session and adds it to the sessions of the GUID.
+// The sessions set lives as long as the longest of its sessions.
+//
+// KEYS[1] - session, KEYS[2] - sessions of the GUID.
+// ARGV[1] - session data, ARGV[2] - refresh expiry (unix, 0 - never),
+// ARGV[3] - position of the session in the set, ARGV[4] - current time (unix).
+var _save = goredis.NewScript(`
+local existed = redis.call('EXISTS', KEYS[2])
+local ttl = redis.call('TTL', KEYS[2])
+
+redis.call('SET', KEYS[1], ARGV[1])
+redis.call('ZADD', KEYS[2], ARGV[3], KEYS[1])
+
+local exp = tonumber(ARGV[2])
+if exp == 0 then
+	redis.call('PERSIST', KEYS[2])
+	return 1
+end
+
+redis.call('EXPIREAT', KEYS[1], exp)
+if existed == 0 or (ttl >= 0 and exp - tonumber(ARGV[4]) > ttl) then
+	redis.call('EXPIREAT', KEYS[2], exp)
+end
+return 1
+`)
+
+// _consume deletes the session, it returns 0 if the session doesn't exist.
+//
+// KEYS[1] - session, KEYS[2] - sessions of the GUID.
+var _consume = goredis.NewScript(`
+if redis.call('DEL', KEYS[1]) == 0 then
+	return 0
+end
+redis.call('ZREM', KEYS[2], KEYS[1])
+return 1
+`)
+
+// Database is a Redis storage.
+type Database struct {
+	client *goredis.Client
+}
+
+// New creates a new instance of Database.
+func New(client *goredis.Client) *Database {
+	return &Database{client: client}
+}
+
+// Close closes the client.
+func (d *Database) Close() error {
+	return d.client.Close()
+}
+
+// SaveTokenData saves a token, the session expires with the refresh token.
+func (d *Database) SaveTokenData(ctx context.Context, t models.TokenData) error {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return fmt.Errorf("can't marshal token: %v", err)
+	}
+
+	now := time.Now()
+	keys := []string{sessionKey(t.GUID, t.RefreshHash), sessionsKey(t.GUID)}
+	if err := _save.Run(ctx, d.client, keys, data, t.RefreshExp, now.UnixNano(), now.Unix()).Err(); err != nil {
+		return fmt.Errorf("can't save token: %v", err)
+	}
+
+	return nil
+}
+
+// GetTokensDataByGUID retrieves the sessions of a given GUID.
+func (d *Database) GetTokensDataByGUID(ctx context.Context, guid string) ([]models.TokenData, error) {
+	keys, err := d.client.ZRange(ctx, sessionsKey(guid), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, constants.ErrNotFound
+	}
+
+	values, err := d.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		t     []models.TokenData
+		stale []any
+	)
+	for i, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			// the session has expired.
+			stale = append(stale, keys[i])
+			continue
+		}
+
+		var td models.TokenData
+		if err := json.Unmarshal([]byte(s), &td); err != nil {
+			return nil, fmt.Errorf("can't unmarshal token: %v", err)
+		}
+		t = append(t, tokenschema.Upgrade(td))
+	}
+
+	if len(stale) > 0 {
+		if err := d.client.ZRem(ctx, sessionsKey(guid), stale...).Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(t) == 0 {
+		return nil, constants.ErrNotFound
+	}
+
+	return t, nil
+}
+
+// DeleteTokenData consumes a session atomically: when the same session is
+// consumed concurrently, only one caller succeeds, the others get
+// constants.ErrNotFound.
+func (d *Database) DeleteTokenData(ctx context.Context, guid, hash string) error {
+	keys := []string{sessionKey(guid, hash), sessionsKey(guid)}
+	deleted, err := _consume.Run(ctx, d.client, keys).Int()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return constants.ErrNotFound
+	}
+
+	return nil
+}
+
+// CountOutdatedTokenData counts the sessions saved in an older schema version.
+func (d *Database) CountOutdatedTokenData(ctx context.Context) (n int64, err error) {
+	err = d.scanOutdated(ctx, func(string, models.TokenData) (bool, error) {
+		n++
+		return true, nil
+	})
+	return n, err
+}
+
+// RewriteOutdatedTokenData upgrades up to limit sessions saved in an older
+// schema version. The sessions keep their expiry, the sessions consumed in
+// the meantime aren't brought back nor counted.
+func (d *Database) RewriteOutdatedTokenData(ctx context.Context, limit int64) (n int64, err error) {
+	err = d.scanOutdated(ctx, func(key string, td models.TokenData) (bool, error) {
+		data, err := json.Marshal(tokenschema.Upgrade(td))
+		if err != nil {
+			return false, fmt.Errorf("can't marshal token: %v", err)
+		}
+
+		err = d.client.SetArgs(ctx, key, data, goredis.SetArgs{Mode: "XX", KeepTTL: true}).Err()
+		if err == goredis.Nil {
+			return true, nil
+		} else if err != nil {
+			return false, err
+		}
+		n++
+		return n < limit, nil
+	})
+	return n, err
+}
+
+// ScanTokenData calls fn with every session until it returns an error.
+func (d *Database) ScanTokenData(ctx context.Context, fn func(models.TokenData) error) error {
+	return d.scan(ctx, func(_ string, td models.TokenData) (bool, error) {
+		return true, fn(tokenschema.Upgrade(td))
+	})
+}
+
+// scanOutdated calls fn with the sessions saved in an older schema version
+// until it returns false.
+func (d *Database) scanOutdated(ctx context.Context, fn func(key string, td models.TokenData) (bool, error)) error {
+	return d.scan(ctx, func(key string, td models.TokenData) (bool, error) {
+		if !tokenschema.Outdated(td) {
+			return true, nil
+		}
+		return fn(key, td)
+	})
+}
+
+// scan calls fn with the sessions, as they are stored, until it returns false.
+func (d *Database) scan(ctx context.Context, fn func(key string, td models.TokenData) (bool, error)) error {
+	iter := d.client.Scan(ctx, 0, _sessionPattern, _scanCount).Iterator()
+
+	batch := make([]string, 0, _scanCount)
+	flush := func() (bool, error) {
+		if len(batch) == 0 {
+			return true, nil
+		}
+		defer func() { batch = batch[:0] }()
+
+		values, err := d.client.MGet(ctx, batch...).Result()
+		if err != nil {
+			return false, err
+		}
+
+		for i, v := range values {
+			s, ok := v.(string)
+			if !ok {
+				// the session has expired.
+				continue
+			}
+
+			var td models.TokenData
+			if err := json.Unmarshal([]byte(s), &td); err != nil {
+				return false, fmt.Errorf("can't unmarshal token: %v", err)
+			}
+
+			if more, err := fn(batch[i], td); err != nil || !more {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+
+	for iter.Next(ctx) {
+		batch = append(batch, iter.Val())
+		if len(batch) < _scanCount {
+			continue
+		}
+		if more, err := flush(); err != nil || !more {
+			return err
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+
+	_, err := flush()
+	return err
+}
+
+// sessionsKey is the key of the sorted set of the sessions of the GUID.
+// The GUID is a hash tag, so all keys of the GUID belong to the same cluster slot.
+func sessionsKey(guid string) string {
+	return _prefix + "{" + guid + "}:sessions"
+}
+
+// sessionKey is the key of a single session.
+func sessionKey(guid, hash string) string {
+	sum := sha256.Sum256([]byte(hash))
+	return _prefix + "{" + guid + "}:session:" + hex.EncodeToString(sum[:])
+}
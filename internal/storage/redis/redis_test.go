@@ -0,0 +1,89 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains"
+	"go-jwt-auth/internal/models"
+	"go-jwt-auth/internal/storage/storagetest"
+	"os"
+	"testing"
+	"time"
+)
+
+// newClient connects to the redis-server at JWT_AUTH_TEST_REDIS_DSN,
+// or to an in-process miniredis if it is not set.
+func newClient(t *testing.T) (*goredis.Client, *miniredis.Miniredis) {
+	if dsn, ok := os.LookupEnv("JWT_AUTH_TEST_REDIS_DSN"); ok {
+		opt, err := goredis.ParseURL(dsn)
+		if err != nil {
+			t.Fatalf("can't parse JWT_AUTH_TEST_REDIS_DSN: %v", err)
+		}
+
+		client := goredis.NewClient(opt)
+		if err := client.FlushDB(context.Background()).Err(); err != nil {
+			t.Fatalf("can't flush redis: %v", err)
+		}
+		t.Cleanup(func() { client.Close() })
+		return client, nil
+	}
+
+	mr := miniredis.RunT(t)
+	client := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return client, mr
+}
+
+func TestDatabase(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) domains.Database {
+		client, _ := newClient(t)
+		return New(client)
+	})
+}
+
+func TestDatabase_Expiry(t *testing.T) {
+	client, mr := newClient(t)
+	if mr == nil {
+		t.Skip("expiry is tested with miniredis only")
+	}
+
+	d := New(client)
+	ctx := context.Background()
+	now := time.Now()
+	mr.SetTime(now)
+
+	for _, td := range []models.TokenData{
+		{GUID: "ikj", RefreshHash: "qjfwjnqk", RefreshExp: now.Add(time.Minute).Unix()},
+		{GUID: "ikj", RefreshHash: "ebfkqbfb", RefreshExp: now.Add(time.Hour).Unix()},
+	} {
+		if err := d.SaveTokenData(ctx, td); err != nil {
+			t.Fatalf("SaveTokenData() error = %v", err)
+		}
+	}
+
+	if ttl := mr.TTL(sessionsKey("ikj")); ttl < 59*time.Minute {
+		t.Errorf("sessions ttl = %v, want the ttl of the longest session", ttl)
+	}
+
+	mr.FastForward(2 * time.Minute)
+
+	td, err := d.GetTokensDataByGUID(ctx, "ikj")
+	if err != nil {
+		t.Fatalf("GetTokensDataByGUID() error = %v", err)
+	}
+	if len(td) != 1 || td[0].RefreshHash != "ebfkqbfb" {
+		t.Errorf("GetTokensDataByGUID() = %v, want only the unexpired session", td)
+	}
+
+	mr.FastForward(time.Hour)
+
+	if _, err := d.GetTokensDataByGUID(ctx, "ikj"); !errors.Is(err, constants.ErrNotFound) {
+		t.Errorf("GetTokensDataByGUID() error = %v, want %v", err, constants.ErrNotFound)
+	}
+	if mr.Exists(sessionsKey("ikj")) {
+		t.Errorf("sessions set of the expired sessions still exists")
+	}
+}
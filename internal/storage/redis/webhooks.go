@@ -0,0 +1,37 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-jwt-auth/internal/models"
+)
+
+const (
+	_webhookDeliveries  = _prefix + "webhook_deliveries"
+	_webhookDeadLetters = _prefix + "webhook_dead_letters"
+)
+
+// SaveWebhookDelivery saves a delivery attempt to the delivery log.
+func (d *Database) SaveWebhookDelivery(ctx context.Context, wd models.WebhookDelivery) error {
+	if err := d.push(ctx, _webhookDeliveries, wd); err != nil {
+		return fmt.Errorf("can't save webhook delivery: %v", err)
+	}
+	return nil
+}
+
+// SaveWebhookDeadLetter saves a delivery that ran out of attempts.
+func (d *Database) SaveWebhookDeadLetter(ctx context.Context, wd models.WebhookDelivery) error {
+	if err := d.push(ctx, _webhookDeadLetters, wd); err != nil {
+		return fmt.Errorf("can't save webhook dead letter: %v", err)
+	}
+	return nil
+}
+
+func (d *Database) push(ctx context.Context, key string, wd models.WebhookDelivery) error {
+	data, err := json.Marshal(wd)
+	if err != nil {
+		return err
+	}
+	return d.client.RPush(ctx, key, data).Err()
+}
@@ -0,0 +1,168 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"go-jwt-auth/internal/domains"
+	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/metrics"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	_defaultRewriteBatchSize = 500
+	_defaultRewritePause     = time.Second
+)
+
+// Rewriter upgrades the sessions saved in an older schema version in the
+// background, batch by batch, until none is left.
+type Rewriter struct {
+	rewriter  domains.TokenDataRewriter
+	logger    lib.Logger
+	batchSize int64
+	pause     time.Duration
+
+	started atomic.Bool
+	stopped sync.Once
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// NewRewriter creates a new instance of Rewriter, it is stopped with the application.
+func NewRewriter(lc fx.Lifecycle, db domains.Database, conf lib.Config, logger lib.Logger) (*Rewriter, error) {
+	rw, _ := db.(domains.TokenDataRewriter)
+
+	batchSize := conf.Storage.RewriteBatchSize
+	if batchSize == 0 {
+		batchSize = _defaultRewriteBatchSize
+	}
+	if batchSize < 0 {
+		return nil, fmt.Errorf("rewrite batch size must be positive")
+	}
+
+	pause := _defaultRewritePause
+	if conf.Storage.RewritePause != "" {
+		var err error
+		if pause, err = time.ParseDuration(conf.Storage.RewritePause); err != nil {
+			return nil, fmt.Errorf("invalid rewrite pause: %v", err)
+		}
+	}
+
+	r := newRewriter(rw, logger, batchSize, pause)
+	lc.Append(fx.Hook{
+		OnStop: func(context.Context) error {
+			r.Close()
+			return nil
+		},
+	})
+
+	return r, nil
+}
+
+func newRewriter(rw domains.TokenDataRewriter, logger lib.Logger, batchSize int64, pause time.Duration) *Rewriter {
+	return &Rewriter{
+		rewriter:  rw,
+		logger:    logger,
+		batchSize: batchSize,
+		pause:     pause,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// Start starts upgrading in the background, it is a no-op if the database
+// can't rewrite the sessions. It must be called once the migrations are applied.
+func (r *Rewriter) Start() {
+	if r.rewriter == nil || !r.started.CompareAndSwap(false, true) {
+		return
+	}
+	go r.run()
+}
+
+// Close stops the rewriter and waits for the running batch.
+func (r *Rewriter) Close() {
+	r.stopped.Do(func() { close(r.stop) })
+	if r.started.Load() {
+		<-r.done
+	}
+}
+
+// Rewrite upgrades all the outdated sessions and reports the progress.
+// It returns how many sessions it upgraded.
+func (r *Rewriter) Rewrite(ctx context.Context) (upgraded int64, err error) {
+	total, err := r.rewriter.CountOutdatedTokenData(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("can't count outdated sessions: %v", err)
+	}
+	metrics.OutdatedSessions.Set(float64(total))
+	if total == 0 {
+		return 0, nil
+	}
+
+	r.logger.Info("upgrading outdated sessions", zap.Int64("total", total))
+
+	for {
+		n, err := r.rewriter.RewriteOutdatedTokenData(ctx, r.batchSize)
+		upgraded += n
+		metrics.SessionsUpgraded.Add(float64(n))
+		if left := total - upgraded; left > 0 {
+			metrics.OutdatedSessions.Set(float64(left))
+		} else {
+			metrics.OutdatedSessions.Set(0)
+		}
+		if err != nil {
+			return upgraded, fmt.Errorf("can't rewrite outdated sessions: %v", err)
+		}
+		if n == 0 {
+			break
+		}
+
+		r.logger.Info("outdated sessions upgraded",
+			zap.Int64("done", upgraded), zap.Int64("total", total))
+
+		select {
+		case <-r.stop:
+			return upgraded, nil
+		case <-ctx.Done():
+			return upgraded, ctx.Err()
+		case <-time.After(r.pause):
+		}
+	}
+
+	metrics.OutdatedSessions.Set(0)
+	r.logger.Info("all sessions are upgraded", zap.Int64("upgraded", upgraded))
+	return upgraded, nil
+}
+
+// run rewrites until done, retrying after a pause on errors.
+func (r *Rewriter) run() {
+	defer close(r.done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-r.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	for {
+		_, err := r.Rewrite(ctx)
+		if err == nil || ctx.Err() != nil {
+			return
+		}
+		r.logger.Error("can't upgrade outdated sessions", zap.Error(err))
+
+		select {
+		case <-r.stop:
+			return
+		case <-time.After(r.pause):
+		}
+	}
+}
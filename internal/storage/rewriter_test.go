@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/metrics"
+	"go-jwt-auth/internal/models"
+	"go-jwt-auth/internal/storage/memory"
+	"testing"
+)
+
+func TestRewriter_Rewrite(t *testing.T) {
+	logger, err := lib.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create Logger instance: %v", err)
+	}
+
+	db := memory.New()
+	ctx := context.Background()
+	for _, td := range []models.TokenData{
+		{GUID: "ikj", RefreshHash: "qjfwjnqk"},
+		{GUID: "ikj", RefreshHash: "ebfkqbfb"},
+		{GUID: "kwfwe", RefreshHash: "hwejhvqvf"},
+		{GUID: "kwfwe", RefreshHash: "qlmflqm", SchemaVersion: models.TokenDataVersion},
+	} {
+		if err := db.SaveTokenData(ctx, td); err != nil {
+			t.Fatalf("SaveTokenData() error = %v", err)
+		}
+	}
+
+	before := testutil.ToFloat64(metrics.SessionsUpgraded)
+	upgraded, err := newRewriter(db, logger, 2, 0).Rewrite(ctx)
+	if err != nil {
+		t.Fatalf("Rewrite() error = %v", err)
+	}
+	if upgraded != 3 {
+		t.Errorf("Rewrite() = %v, want %v", upgraded, 3)
+	}
+
+	if outdated, err := db.CountOutdatedTokenData(ctx); err != nil || outdated != 0 {
+		t.Errorf("CountOutdatedTokenData() = %v, %v, want 0", outdated, err)
+	}
+	if got := testutil.ToFloat64(metrics.SessionsUpgraded) - before; got != 3 {
+		t.Errorf("upgraded sessions metric grew by %v, want %v", got, 3)
+	}
+	if got := testutil.ToFloat64(metrics.OutdatedSessions); got != 0 {
+		t.Errorf("outdated sessions metric = %v, want %v", got, 0)
+	}
+}
@@ -1,9 +1,65 @@
 package storage
 
 import (
+	"context"
+	"go-jwt-auth/internal/domains"
+	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/storage/bolt"
+	"go-jwt-auth/internal/storage/keyfile"
+	"go-jwt-auth/internal/storage/memory"
+	"go-jwt-auth/internal/storage/postgres"
+	"go-jwt-auth/internal/storage/redis"
 	"go.uber.org/fx"
 )
 
 var Module = fx.Options(
 	fx.Provide(NewDatabase),
+	fx.Provide(NewAuditStorage),
+	fx.Provide(NewWebhookStorage),
+	fx.Provide(NewSweeper),
+	fx.Provide(NewMigrator),
+	fx.Provide(NewRewriter),
+	fx.Provide(NewKeyStorage),
 )
+
+// NewKeyStorage creates the key store of the signing keys.
+func NewKeyStorage(conf lib.Config) domains.KeyStorage {
+	return keyfile.New(conf.Keys.File)
+}
+
+// NewDatabase creates the storage for the driver selected by the DSN.
+func NewDatabase(lc fx.Lifecycle, db lib.Database) (domains.Database, error) {
+	switch db.Driver {
+	case lib.DriverMemory:
+		return memory.New(), nil
+	case lib.DriverPostgres:
+		pg := postgres.New(db.SQL)
+		lc.Append(fx.Hook{
+			OnStop: func(context.Context) error {
+				return pg.Close()
+			},
+		})
+		return pg, nil
+	case lib.DriverRedis:
+		rd := redis.New(db.Redis)
+		lc.Append(fx.Hook{
+			OnStop: func(context.Context) error {
+				return rd.Close()
+			},
+		})
+		return rd, nil
+	case lib.DriverFile:
+		file, err := bolt.New(db.Bolt)
+		if err != nil {
+			return nil, err
+		}
+		lc.Append(fx.Hook{
+			OnStop: func(context.Context) error {
+				return file.Close()
+			},
+		})
+		return file, nil
+	default:
+		return NewMongoDatabase(db)
+	}
+}
@@ -0,0 +1,485 @@
+// Package storagetest is a conformance suite for domains.Database implementations.
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains"
+	"go-jwt-auth/internal/models"
+	"go-jwt-auth/internal/storage/tokenschema"
+	"gotest.tools/v3/assert"
+	"sort"
+	"testing"
+	"time"
+)
+
+// NewDatabase creates an empty database for a single test.
+type NewDatabase func(t *testing.T) domains.Database
+
+// Run runs the suite against the databases created by newDB.
+// Storages are free to drop the sessions once their refresh token expires,
+// so the sessions saved by the suite expire in the far future or never.
+// The audit tests are skipped if the database doesn't implement domains.AuditStorage,
+// the purge tests if it doesn't implement domains.ExpiredPurger,
+// the scan tests if it doesn't implement domains.TokenDataScanner,
+// the rewrite tests if it doesn't implement domains.TokenDataRewriter,
+// the client tests if it doesn't implement domains.ClientStorage,
+// the code tests if it doesn't implement domains.CodeStorage.
+func Run(t *testing.T, newDB NewDatabase) {
+	t.Run("SaveTokenData", func(t *testing.T) { testSaveTokenData(t, newDB(t)) })
+	t.Run("GetTokensDataByGUID", func(t *testing.T) { testGetTokensDataByGUID(t, newDB(t)) })
+	t.Run("DeleteTokenData", func(t *testing.T) { testDeleteTokenData(t, newDB(t)) })
+	t.Run("Audit", func(t *testing.T) { testAudit(t, newDB(t)) })
+	t.Run("PurgeExpired", func(t *testing.T) { testPurgeExpired(t, newDB(t)) })
+	t.Run("ScanTokenData", func(t *testing.T) { testScanTokenData(t, newDB(t)) })
+	t.Run("RewriteOutdatedTokenData", func(t *testing.T) { testRewriteOutdatedTokenData(t, newDB(t)) })
+	t.Run("Clients", func(t *testing.T) { testClients(t, newDB(t)) })
+	t.Run("Codes", func(t *testing.T) { testCodes(t, newDB(t)) })
+}
+
+// upgraded is how the sessions are read back: in the current schema version.
+func upgraded(t ...models.TokenData) []models.TokenData {
+	return tokenschema.UpgradeAll(append([]models.TokenData(nil), t...))
+}
+
+func testSaveTokenData(t *testing.T, d domains.Database) {
+	tests := []struct {
+		name    string
+		t       models.TokenData
+		wantErr error
+	}{
+		{
+			name: "ok",
+			t: models.TokenData{
+				GUID: "123",
+			},
+		},
+		{
+			name: "ok#2",
+			t: models.TokenData{
+				GUID:        "yguf67d7rr7di",
+				RefreshHash: "qjfwjnqk",
+				RefreshExp:  4102444800,
+				AccessExp:   4102441200,
+				IP:          "10.0.0.1",
+				Tenant:      "acme",
+				ClientID:    "backend",
+				Scope:       "read write",
+			},
+		},
+	}
+
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := d.SaveTokenData(ctx, tt.t); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SaveTokenData() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			td, err := d.GetTokensDataByGUID(ctx, tt.t.GUID)
+			if err != nil {
+				t.Fatalf("GetTokensDataByGUID() error = %v", err)
+			}
+
+			assert.DeepEqual(t, upgraded(tt.t), td)
+		})
+	}
+}
+
+func testGetTokensDataByGUID(t *testing.T, d domains.Database) {
+	tests := []struct {
+		name    string
+		guid    string
+		want    []models.TokenData
+		wantErr error
+	}{
+		{
+			name: "ok",
+			guid: "123",
+			want: []models.TokenData{
+				{
+					GUID:        "123",
+					RefreshHash: "hwejhvqvf",
+				},
+			},
+		},
+		{
+			name: "ok#2",
+			guid: "yguf67d7rr7di",
+			want: []models.TokenData{
+				{
+					GUID:        "yguf67d7rr7di",
+					RefreshHash: "qjfwjnqk",
+				},
+				{
+					GUID:        "yguf67d7rr7di",
+					RefreshHash: "ebfkqbfb",
+				},
+			},
+		},
+		{
+			name:    "notFound",
+			guid:    "qlmf;lqm",
+			wantErr: constants.ErrNotFound,
+		},
+	}
+
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, td := range tt.want {
+				if err := d.SaveTokenData(ctx, td); err != nil {
+					t.Fatalf("SaveTokenData() error = %v", err)
+				}
+			}
+
+			td, err := d.GetTokensDataByGUID(ctx, tt.guid)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetTokensDataByGUID() error = %v, wantErr %v", err, tt.wantErr)
+			} else if tt.wantErr != nil {
+				return
+			}
+
+			assert.DeepEqual(t, upgraded(tt.want...), td)
+		})
+	}
+}
+
+func testDeleteTokenData(t *testing.T, d domains.Database) {
+	ctx := context.Background()
+
+	sessions := []models.TokenData{
+		{GUID: "kwfwe", RefreshHash: "qjfwjnqk"},
+		{GUID: "kwfwe", RefreshHash: "ebfkqbfb"},
+		{GUID: "ikj", RefreshHash: "qjfwjnqk"},
+	}
+	for _, td := range sessions {
+		if err := d.SaveTokenData(ctx, td); err != nil {
+			t.Fatalf("SaveTokenData() error = %v", err)
+		}
+	}
+
+	if err := d.DeleteTokenData(ctx, "kwfwe", "qjfwjnqk"); err != nil {
+		t.Fatalf("DeleteTokenData() error = %v", err)
+	}
+
+	td, err := d.GetTokensDataByGUID(ctx, "kwfwe")
+	if err != nil {
+		t.Fatalf("GetTokensDataByGUID() error = %v", err)
+	}
+	assert.DeepEqual(t, upgraded(sessions[1:2]...), td)
+
+	td, err = d.GetTokensDataByGUID(ctx, "ikj")
+	if err != nil {
+		t.Fatalf("GetTokensDataByGUID() error = %v", err)
+	}
+	assert.DeepEqual(t, upgraded(sessions[2:]...), td)
+
+	if err := d.DeleteTokenData(ctx, "kwfwe", "qjfwjnqk"); !errors.Is(err, constants.ErrNotFound) {
+		t.Errorf("DeleteTokenData() of a consumed session error = %v, want %v", err, constants.ErrNotFound)
+	}
+
+	if err := d.DeleteTokenData(ctx, "kwfwe", "ebfkqbfb"); err != nil {
+		t.Fatalf("DeleteTokenData() error = %v", err)
+	}
+
+	if _, err := d.GetTokensDataByGUID(ctx, "kwfwe"); !errors.Is(err, constants.ErrNotFound) {
+		t.Errorf("GetTokensDataByGUID() error = %v, want %v", err, constants.ErrNotFound)
+	}
+}
+
+func testAudit(t *testing.T, d domains.Database) {
+	st, ok := d.(domains.AuditStorage)
+	if !ok {
+		t.Skip("audit trail is not supported")
+	}
+
+	ctx := context.Background()
+
+	if _, err := st.LastAuditRecord(ctx); !errors.Is(err, constants.ErrNotFound) {
+		t.Errorf("LastAuditRecord() of an empty trail error = %v, want %v", err, constants.ErrNotFound)
+	}
+
+	records := []models.AuditRecord{
+		{Seq: 1, Event: models.EventIssued, GUID: "ikj", Time: 1, Hash: "a"},
+		{Seq: 2, Event: models.EventRefreshed, GUID: "ikj", Time: 2, PrevHash: "a", Hash: "b"},
+		{Seq: 3, Event: models.EventIssued, GUID: "kwfwe", Time: 3, PrevHash: "b", Hash: "c", Checkpoint: "d"},
+	}
+	for _, r := range records {
+		if err := st.AppendAuditRecord(ctx, r); err != nil {
+			t.Fatalf("AppendAuditRecord() error = %v", err)
+		}
+	}
+
+	dup := records[2]
+	dup.GUID = "qkefkq"
+	if err := st.AppendAuditRecord(ctx, dup); !errors.Is(err, constants.ErrAlreadyExists) {
+		t.Errorf("AppendAuditRecord() of a taken seq error = %v, want %v", err, constants.ErrAlreadyExists)
+	}
+
+	last, err := st.LastAuditRecord(ctx)
+	if err != nil {
+		t.Fatalf("LastAuditRecord() error = %v", err)
+	}
+	assert.DeepEqual(t, records[2], last)
+
+	got, err := st.GetAuditRecords(ctx, 2, 1)
+	if err != nil {
+		t.Fatalf("GetAuditRecords() error = %v", err)
+	}
+	assert.DeepEqual(t, records[1:2], got)
+
+	got, err = st.GetAuditRecords(ctx, 1, 10)
+	if err != nil {
+		t.Fatalf("GetAuditRecords() error = %v", err)
+	}
+	assert.DeepEqual(t, records, got)
+}
+
+func testPurgeExpired(t *testing.T, d domains.Database) {
+	p, ok := d.(domains.ExpiredPurger)
+	if !ok {
+		t.Skip("purging is not supported")
+	}
+
+	ctx := context.Background()
+	for _, td := range []models.TokenData{
+		{GUID: "wjnfwkj", RefreshHash: "qjfwjnqk", RefreshExp: 100},
+		{GUID: "wjnfwkj", RefreshHash: "ebfkqbfb", RefreshExp: 4102444800},
+		{GUID: "lkmlkwe", RefreshHash: "hwejhvqvf", RefreshExp: 150},
+		{GUID: "lkmlkwe", RefreshHash: "qlmflqm"},
+	} {
+		if err := d.SaveTokenData(ctx, td); err != nil {
+			t.Fatalf("SaveTokenData() error = %v", err)
+		}
+	}
+
+	purged, err := p.PurgeExpired(ctx, 200)
+	if err != nil {
+		t.Fatalf("PurgeExpired() error = %v", err)
+	}
+	assert.Equal(t, purged, int64(2))
+
+	got, err := d.GetTokensDataByGUID(ctx, "wjnfwkj")
+	if err != nil {
+		t.Fatalf("GetTokensDataByGUID() error = %v", err)
+	}
+	assert.Equal(t, len(got), 1)
+	assert.Equal(t, got[0].RefreshHash, "ebfkqbfb")
+
+	got, err = d.GetTokensDataByGUID(ctx, "lkmlkwe")
+	if err != nil {
+		t.Fatalf("GetTokensDataByGUID() error = %v", err)
+	}
+	assert.Equal(t, len(got), 1)
+	assert.Equal(t, got[0].RefreshHash, "qlmflqm")
+
+	if purged, err = p.PurgeExpired(ctx, 200); err != nil || purged != 0 {
+		t.Errorf("PurgeExpired() again = %v, %v, want 0", purged, err)
+	}
+}
+
+func testScanTokenData(t *testing.T, d domains.Database) {
+	sc, ok := d.(domains.TokenDataScanner)
+	if !ok {
+		t.Skip("scanning is not supported")
+	}
+
+	ctx := context.Background()
+	sessions := []models.TokenData{
+		{GUID: "wjnfwkj", RefreshHash: "qjfwjnqk", RefreshExp: 4102444800},
+		{GUID: "wjnfwkj", RefreshHash: "ebfkqbfb", RefreshExp: 4102444800, Tenant: "acme"},
+		{GUID: "lkmlkwe", RefreshHash: "hwejhvqvf", RefreshExp: 4102444800, SchemaVersion: models.TokenDataVersion},
+		{GUID: "lkmlkwe", RefreshHash: "qlmflqm"},
+	}
+
+	var got []models.TokenData
+	scan := func(td models.TokenData) error {
+		got = append(got, td)
+		return nil
+	}
+	if err := sc.ScanTokenData(ctx, scan); err != nil {
+		t.Fatalf("ScanTokenData() error = %v", err)
+	}
+	assert.Equal(t, len(got), 0)
+
+	for _, td := range sessions {
+		if err := d.SaveTokenData(ctx, td); err != nil {
+			t.Fatalf("SaveTokenData() error = %v", err)
+		}
+	}
+
+	if err := sc.ScanTokenData(ctx, scan); err != nil {
+		t.Fatalf("ScanTokenData() error = %v", err)
+	}
+	sort.Slice(got, func(i, j int) bool { return got[i].RefreshHash < got[j].RefreshHash })
+	want := upgraded(sessions...)
+	sort.Slice(want, func(i, j int) bool { return want[i].RefreshHash < want[j].RefreshHash })
+	assert.DeepEqual(t, got, want)
+
+	stop := errors.New("stop")
+	n := 0
+	err := sc.ScanTokenData(ctx, func(models.TokenData) error {
+		n++
+		return stop
+	})
+	assert.Equal(t, err, stop)
+	assert.Equal(t, n, 1)
+}
+
+func testRewriteOutdatedTokenData(t *testing.T, d domains.Database) {
+	rw, ok := d.(domains.TokenDataRewriter)
+	if !ok {
+		t.Skip("rewriting is not supported")
+	}
+
+	ctx := context.Background()
+	sessions := []models.TokenData{
+		{GUID: "wjnfwkj", RefreshHash: "qjfwjnqk", RefreshExp: 4102444800},
+		{GUID: "wjnfwkj", RefreshHash: "ebfkqbfb", RefreshExp: 4102444800, SchemaVersion: models.TokenDataVersion},
+		{GUID: "lkmlkwe", RefreshHash: "hwejhvqvf", RefreshExp: 4102444800},
+		{GUID: "lkmlkwe", RefreshHash: "qlmflqm"},
+	}
+	for _, td := range sessions {
+		if err := d.SaveTokenData(ctx, td); err != nil {
+			t.Fatalf("SaveTokenData() error = %v", err)
+		}
+	}
+
+	outdated, err := rw.CountOutdatedTokenData(ctx)
+	if err != nil {
+		t.Fatalf("CountOutdatedTokenData() error = %v", err)
+	}
+	assert.Equal(t, outdated, int64(3))
+
+	var rewritten int64
+	for {
+		n, err := rw.RewriteOutdatedTokenData(ctx, 2)
+		if err != nil {
+			t.Fatalf("RewriteOutdatedTokenData() error = %v", err)
+		}
+		if n > 2 {
+			t.Fatalf("RewriteOutdatedTokenData() = %v, want at most the limit", n)
+		}
+		if n == 0 {
+			break
+		}
+		rewritten += n
+	}
+	assert.Equal(t, rewritten, int64(3))
+
+	if outdated, err = rw.CountOutdatedTokenData(ctx); err != nil || outdated != 0 {
+		t.Errorf("CountOutdatedTokenData() after rewrite = %v, %v, want 0", outdated, err)
+	}
+
+	got, err := d.GetTokensDataByGUID(ctx, "lkmlkwe")
+	if err != nil {
+		t.Fatalf("GetTokensDataByGUID() error = %v", err)
+	}
+	assert.DeepEqual(t, upgraded(sessions[2:]...), got)
+}
+
+func testClients(t *testing.T, d domains.Database) {
+	st, ok := d.(domains.ClientStorage)
+	if !ok {
+		t.Skip("client registry is not supported")
+	}
+
+	ctx := context.Background()
+
+	if _, err := st.GetClient(ctx, "backend"); !errors.Is(err, constants.ErrNotFound) {
+		t.Errorf("GetClient() of a missing client error = %v, want %v", err, constants.ErrNotFound)
+	}
+
+	clients := []models.Client{
+		{
+			ID:           "backend",
+			Tenant:       "acme",
+			SecretHash:   "qjfwjnqk",
+			GrantTypes:   []models.GrantType{models.GrantTypeIssue, models.GrantTypeRefreshToken},
+			Scopes:       []string{"read", "write"},
+			RedirectURIs: []string{"https://acme.example.com/callback"},
+			GUIDPatterns: []string{"user-*", "admin"},
+			AccessTTL:    5 * time.Minute,
+			RefreshTTL:   24 * time.Hour,
+		},
+		{
+			ID:         "app",
+			PublicKey:  "-----BEGIN PUBLIC KEY-----",
+			TLSSubject: "CN=app,O=Acme",
+			GrantTypes: []models.GrantType{models.GrantTypeClientCredentials},
+		},
+	}
+	for _, c := range clients {
+		if err := st.SaveClient(ctx, c); err != nil {
+			t.Fatalf("SaveClient() error = %v", err)
+		}
+	}
+
+	got, err := st.GetClient(ctx, "backend")
+	if err != nil {
+		t.Fatalf("GetClient() error = %v", err)
+	}
+	assert.DeepEqual(t, clients[0], got)
+
+	clients[0].Scopes = []string{"read"}
+	if err := st.SaveClient(ctx, clients[0]); err != nil {
+		t.Fatalf("SaveClient() of an existing client error = %v", err)
+	}
+
+	all, err := st.GetClients(ctx)
+	if err != nil {
+		t.Fatalf("GetClients() error = %v", err)
+	}
+	assert.DeepEqual(t, []models.Client{clients[1], clients[0]}, all)
+
+	if err := st.DeleteClient(ctx, "app"); err != nil {
+		t.Fatalf("DeleteClient() error = %v", err)
+	}
+	if err := st.DeleteClient(ctx, "app"); !errors.Is(err, constants.ErrNotFound) {
+		t.Errorf("DeleteClient() of a deleted client error = %v, want %v", err, constants.ErrNotFound)
+	}
+	if _, err := st.GetClient(ctx, "app"); !errors.Is(err, constants.ErrNotFound) {
+		t.Errorf("GetClient() of a deleted client error = %v, want %v", err, constants.ErrNotFound)
+	}
+}
+
+func testCodes(t *testing.T, d domains.Database) {
+	st, ok := d.(domains.CodeStorage)
+	if !ok {
+		t.Skip("authorization codes are not supported")
+	}
+
+	ctx := context.Background()
+
+	if _, err := st.ConsumeCode(ctx, "qjfwjnqk"); !errors.Is(err, constants.ErrNotFound) {
+		t.Errorf("ConsumeCode() of a missing code error = %v, want %v", err, constants.ErrNotFound)
+	}
+
+	code := models.AuthorizationCode{
+		Hash:          "qjfwjnqk",
+		Tenant:        "acme",
+		ClientID:      "app",
+		RedirectURI:   "https://acme.example.com/callback",
+		GUID:          "yguf67d7rr7di",
+		Scope:         "read write",
+		CodeChallenge: "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM",
+		Nonce:         "n-0S6_WzA2Mj",
+		AuthTime:      time.Now().Unix(),
+		ExpiresAt:     time.Now().Add(time.Hour).Unix(),
+	}
+	if err := st.SaveCode(ctx, code); err != nil {
+		t.Fatalf("SaveCode() error = %v", err)
+	}
+
+	got, err := st.ConsumeCode(ctx, code.Hash)
+	if err != nil {
+		t.Fatalf("ConsumeCode() error = %v", err)
+	}
+	assert.DeepEqual(t, code, got)
+
+	if _, err := st.ConsumeCode(ctx, code.Hash); !errors.Is(err, constants.ErrNotFound) {
+		t.Errorf("ConsumeCode() of a used code error = %v, want %v", err, constants.ErrNotFound)
+	}
+}
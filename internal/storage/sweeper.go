@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"go-jwt-auth/internal/domains"
+	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/metrics"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"time"
+)
+
+const _defaultSweepInterval = time.Minute
+
+// Sweeper periodically purges the expired sessions of the databases
+// that can't expire them natively.
+type Sweeper struct {
+	purger domains.ExpiredPurger
+	logger lib.Logger
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewSweeper creates a new instance of Sweeper and starts it if the
+// database needs one. It is stopped with the application. The sessions are
+// purged through the repository, so that its caches drop them.
+func NewSweeper(
+	lc fx.Lifecycle,
+	db domains.Database,
+	repo domains.Repository,
+	conf lib.Config,
+	logger lib.Logger,
+) (*Sweeper, error) {
+	if _, ok := db.(domains.ExpiredPurger); !ok {
+		return &Sweeper{}, nil
+	}
+
+	interval := _defaultSweepInterval
+	if conf.Storage.SweepInterval != "" {
+		var err error
+		if interval, err = time.ParseDuration(conf.Storage.SweepInterval); err != nil {
+			return nil, fmt.Errorf("invalid sweep interval: %v", err)
+		}
+		if interval <= 0 {
+			return nil, fmt.Errorf("sweep interval must be positive")
+		}
+	}
+
+	s := newSweeper(repo, logger)
+	go s.run(interval)
+
+	lc.Append(fx.Hook{
+		OnStop: func(context.Context) error {
+			s.Close()
+			return nil
+		},
+	})
+
+	return s, nil
+}
+
+func newSweeper(purger domains.ExpiredPurger, logger lib.Logger) *Sweeper {
+	return &Sweeper{
+		purger: purger,
+		logger: logger,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// Sweep purges the sessions that expired before now.
+func (s *Sweeper) Sweep(ctx context.Context, now time.Time) (int64, error) {
+	purged, err := s.purger.PurgeExpired(ctx, now.Unix())
+	metrics.ExpiredSessionsPurged.Add(float64(purged))
+	if err != nil {
+		metrics.SweepFailures.Inc()
+		return purged, err
+	}
+	return purged, nil
+}
+
+// Close stops the sweeper and waits for the running sweep.
+func (s *Sweeper) Close() {
+	if s.stop == nil {
+		return
+	}
+	close(s.stop)
+	<-s.done
+}
+
+// run sweeps every interval until Close is called.
+func (s *Sweeper) run(interval time.Duration) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case now := <-ticker.C:
+			purged, err := s.Sweep(context.Background(), now)
+			if err != nil {
+				s.logger.Error("can't purge expired sessions", zap.Error(err))
+				continue
+			}
+			if purged > 0 {
+				s.logger.Info("expired sessions purged", zap.Int64("count", purged))
+			}
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go-jwt-auth/internal/lib"
+	"go-jwt-auth/internal/metrics"
+	"go-jwt-auth/internal/models"
+	"go-jwt-auth/internal/storage/memory"
+	"testing"
+	"time"
+)
+
+type failingPurger struct{}
+
+func (failingPurger) PurgeExpired(context.Context, int64) (int64, error) {
+	return 0, errors.New("connection refused")
+}
+
+func TestSweeper_Sweep(t *testing.T) {
+	logger, err := lib.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create Logger instance: %v", err)
+	}
+
+	db := memory.New()
+	ctx := context.Background()
+	for _, td := range []models.TokenData{
+		{GUID: "ikj", RefreshHash: "qjfwjnqk", RefreshExp: 100},
+		{GUID: "ikj", RefreshHash: "ebfkqbfb", RefreshExp: 300},
+	} {
+		if err := db.SaveTokenData(ctx, td); err != nil {
+			t.Fatalf("SaveTokenData() error = %v", err)
+		}
+	}
+
+	purgedBefore := testutil.ToFloat64(metrics.ExpiredSessionsPurged)
+	purged, err := newSweeper(db, logger).Sweep(ctx, time.Unix(200, 0))
+	if err != nil {
+		t.Fatalf("Sweep() error = %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("Sweep() = %v, want %v", purged, 1)
+	}
+	if got := testutil.ToFloat64(metrics.ExpiredSessionsPurged) - purgedBefore; got != 1 {
+		t.Errorf("purged sessions metric grew by %v, want %v", got, 1)
+	}
+
+	failuresBefore := testutil.ToFloat64(metrics.SweepFailures)
+	if _, err := newSweeper(failingPurger{}, logger).Sweep(ctx, time.Unix(200, 0)); err == nil {
+		t.Errorf("Sweep() error = nil, want error")
+	}
+	if got := testutil.ToFloat64(metrics.SweepFailures) - failuresBefore; got != 1 {
+		t.Errorf("sweep failures metric grew by %v, want %v", got, 1)
+	}
+}
@@ -0,0 +1,42 @@
+// Package tokenschema upgrades the sessions saved in the older versions of
+// models.TokenData, so the old and the new sessions can be read side by side.
+//
+// Every storage upgrades the sessions it reads, the outdated sessions are
+// rewritten in the background by storage.Rewriter.
+package tokenschema
+
+import "go-jwt-auth/internal/models"
+
+// _upgrades[v] upgrades a session from version v to v+1.
+var _upgrades = [models.TokenDataVersion]func(t models.TokenData) models.TokenData{
+	// 0 → 1: the version is introduced, the shape is the same.
+	func(t models.TokenData) models.TokenData { return t },
+	// 1 → 2: the tenant is introduced, the older sessions belong to the default tenant.
+	func(t models.TokenData) models.TokenData { return t },
+	// 2 → 3: the client is introduced, the older sessions belong to none.
+	func(t models.TokenData) models.TokenData { return t },
+	// 3 → 4: the scope is introduced, the older sessions are granted none.
+	func(t models.TokenData) models.TokenData { return t },
+}
+
+// Outdated reports whether the session is saved in an older version.
+func Outdated(t models.TokenData) bool {
+	return t.SchemaVersion < models.TokenDataVersion
+}
+
+// Upgrade upgrades the session to models.TokenDataVersion.
+func Upgrade(t models.TokenData) models.TokenData {
+	for Outdated(t) {
+		t = _upgrades[t.SchemaVersion](t)
+		t.SchemaVersion++
+	}
+	return t
+}
+
+// UpgradeAll upgrades the sessions in place.
+func UpgradeAll(t []models.TokenData) []models.TokenData {
+	for i := range t {
+		t[i] = Upgrade(t[i])
+	}
+	return t
+}
@@ -0,0 +1,33 @@
+package tokenschema
+
+import (
+	"go-jwt-auth/internal/models"
+	"gotest.tools/v3/assert"
+	"testing"
+)
+
+func TestUpgrade(t *testing.T) {
+	tests := []struct {
+		name string
+		t    models.TokenData
+		want models.TokenData
+	}{
+		{
+			name: "legacy",
+			t:    models.TokenData{GUID: "ikj", RefreshHash: "qjfwjnqk", RefreshExp: 100},
+			want: models.TokenData{SchemaVersion: models.TokenDataVersion, GUID: "ikj", RefreshHash: "qjfwjnqk", RefreshExp: 100},
+		},
+		{
+			name: "current",
+			t:    models.TokenData{SchemaVersion: models.TokenDataVersion, GUID: "ikj", IP: "10.0.0.1"},
+			want: models.TokenData{SchemaVersion: models.TokenDataVersion, GUID: "ikj", IP: "10.0.0.1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Upgrade(tt.t)
+			assert.DeepEqual(t, tt.want, got)
+			assert.Equal(t, Outdated(got), false)
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains"
+	"go-jwt-auth/internal/models"
+)
+
+const (
+	_webhookDeliveries  = "webhook_deliveries"
+	_webhookDeadLetters = "webhook_dead_letters"
+)
+
+// NewWebhookStorage exposes the webhook delivery log of the database if it supports one.
+func NewWebhookStorage(db domains.Database) (domains.WebhookStorage, error) {
+	st, ok := db.(domains.WebhookStorage)
+	if !ok {
+		return nil, fmt.Errorf("webhook delivery log: %w", constants.ErrNotSupported)
+	}
+	return st, nil
+}
+
+// SaveWebhookDelivery saves a delivery attempt to the delivery log.
+func (d Database) SaveWebhookDelivery(ctx context.Context, wd models.WebhookDelivery) error {
+	if _, err := d.db.Collection(_webhookDeliveries).InsertOne(ctx, wd); err != nil {
+		return fmt.Errorf("can't insert webhook delivery: %v", err)
+	}
+
+	return nil
+}
+
+// SaveWebhookDeadLetter saves a delivery that ran out of attempts.
+func (d Database) SaveWebhookDeadLetter(ctx context.Context, wd models.WebhookDelivery) error {
+	if _, err := d.db.Collection(_webhookDeadLetters).InsertOne(ctx, wd); err != nil {
+		return fmt.Errorf("can't insert webhook dead letter: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package userauth
+
+import (
+	"fmt"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// HeaderAuthenticator trusts the GUID in a header set by an authenticating
+// reverse proxy, e.g. X-Forwarded-User of oauth2-proxy. The header is only
+// read from the peers in the trusted proxies, which must remove it from the
+// requests they receive.
+type HeaderAuthenticator struct {
+	header  string
+	proxies []*net.IPNet
+}
+
+// NewHeaderAuthenticator creates a new instance of HeaderAuthenticator
+// trusting the header of the proxies, IPs or CIDRs.
+func NewHeaderAuthenticator(header string, trustedProxies []string) (domains.UserAuthenticator, error) {
+	if header == "" {
+		return nil, fmt.Errorf("user_header is required by the header authenticator")
+	}
+	if len(trustedProxies) == 0 {
+		return nil, fmt.Errorf("trusted_proxies are required by the header authenticator")
+	}
+
+	a := &HeaderAuthenticator{header: header}
+	for _, p := range trustedProxies {
+		if ip := net.ParseIP(p); ip != nil {
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			a.proxies = append(a.proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, cidr, err := net.ParseCIDR(p)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q must be an IP or a CIDR", p)
+		}
+		a.proxies = append(a.proxies, cidr)
+	}
+	return a, nil
+}
+
+// AuthenticateUser returns the GUID in the header of a request of a trusted
+// proxy. The peer is the address of the connection, X-Forwarded-For can't
+// make a request pass for one of the proxy.
+func (a *HeaderAuthenticator) AuthenticateUser(r *http.Request) (string, error) {
+	if !a.trusted(r.RemoteAddr) {
+		return "", constants.ErrLoginRequired
+	}
+
+	guid := strings.TrimSpace(r.Header.Get(a.header))
+	if guid == "" {
+		return "", constants.ErrLoginRequired
+	}
+	return guid, nil
+}
+
+// trusted reports whether the peer at addr, host:port, is a trusted proxy.
+func (a *HeaderAuthenticator) trusted(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	for _, p := range a.proxies {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
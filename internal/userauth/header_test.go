@@ -0,0 +1,82 @@
+package userauth
+
+import (
+	"errors"
+	"go-jwt-auth/internal/constants"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHeaderAuthenticator_AuthenticateUser(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		header     string
+		forwarded  string
+		want       string
+		wantErr    error
+	}{
+		{
+			name:       "ok",
+			remoteAddr: "10.0.0.1:51234",
+			header:     "kwfwe",
+			want:       "kwfwe",
+		},
+		{
+			name:       "okCIDR",
+			remoteAddr: "[fd00::7]:51234",
+			header:     "kwfwe",
+			want:       "kwfwe",
+		},
+		{
+			name:       "noHeader",
+			remoteAddr: "10.0.0.1:51234",
+			wantErr:    constants.ErrLoginRequired,
+		},
+		{
+			name:       "untrustedPeer",
+			remoteAddr: "203.0.113.7:51234",
+			header:     "kwfwe",
+			wantErr:    constants.ErrLoginRequired,
+		},
+		{
+			name:       "forwardedForProxy",
+			remoteAddr: "203.0.113.7:51234",
+			header:     "kwfwe",
+			forwarded:  "10.0.0.1",
+			wantErr:    constants.ErrLoginRequired,
+		},
+	}
+
+	a, err := NewHeaderAuthenticator("X-Forwarded-User", []string{"10.0.0.1", "fd00::/8"})
+	if err != nil {
+		t.Fatalf("NewHeaderAuthenticator() error = %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/v1/authorize", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.header != "" {
+				r.Header.Set("X-Forwarded-User", tt.header)
+			}
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+
+			got, err := a.AuthenticateUser(r)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AuthenticateUser() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("AuthenticateUser() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewHeaderAuthenticator(t *testing.T) {
+	if _, err := NewHeaderAuthenticator("X-Forwarded-User", nil); err == nil {
+		t.Errorf("NewHeaderAuthenticator() without trusted proxies error = nil")
+	}
+}
@@ -0,0 +1,35 @@
+// Package userauth authenticates the users of the authorization requests.
+package userauth
+
+import (
+	"fmt"
+	"go-jwt-auth/internal/constants"
+	"go-jwt-auth/internal/domains"
+	"go-jwt-auth/internal/lib"
+	"go.uber.org/fx"
+	"net/http"
+)
+
+var Module = fx.Options(
+	fx.Provide(NewUserAuthenticator),
+)
+
+// NewUserAuthenticator creates the authenticator selected in the config.
+func NewUserAuthenticator(conf lib.Config) (domains.UserAuthenticator, error) {
+	switch conf.OAuth.Authenticator {
+	case "":
+		return Disabled{}, nil
+	case constants.UserAuthenticatorHeader:
+		return NewHeaderAuthenticator(conf.OAuth.UserHeader, conf.TrustedProxies)
+	default:
+		return nil, fmt.Errorf("unknown authenticator %q", conf.OAuth.Authenticator)
+	}
+}
+
+// Disabled authenticates nobody, the authorization requests are denied.
+type Disabled struct{}
+
+// AuthenticateUser always fails.
+func (Disabled) AuthenticateUser(*http.Request) (string, error) {
+	return "", constants.ErrUserAuthDisabled
+}
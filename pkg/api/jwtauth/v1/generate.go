@@ -0,0 +1,4 @@
+// Package jwtauthv1 is the generated code of proto/jwtauth/v1, the gRPC API of the server.
+package jwtauthv1
+
+//go:generate protoc -I ../../../../proto --go_out=../../../.. --go_opt=module=go-jwt-auth --go-grpc_out=../../../.. --go-grpc_opt=module=go-jwt-auth jwtauth/v1/auth.proto
@@ -0,0 +1,132 @@
+// Package client is a Go client of the jwt-auth server. It issues, refreshes,
+// revokes and introspects the tokens of a client registered on the server,
+// and keeps the tokens of a user fresh with a TokenSource.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultRefreshBefore is how long before their expiry the tokens are refreshed by a TokenSource.
+const DefaultRefreshBefore = 30 * time.Second
+
+// Client is a client of the jwt-auth server, it is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	// auth sets the credentials of the client on a request, nil for the
+	// clients authenticated by the certificate of the HTTP client.
+	auth          func(r *http.Request)
+	refreshBefore time.Duration
+}
+
+// Option configures a Client.
+type Option func(c *Client)
+
+// WithHTTPClient sets the HTTP client of the requests, e.g. one with the
+// certificate of a tls_client_auth client. http.DefaultClient by default.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithTenant sends the requests to the tenant, the default tenant otherwise.
+func WithTenant(id string) Option {
+	return func(c *Client) {
+		c.baseURL += "/t/" + url.PathEscape(id)
+	}
+}
+
+// WithClientSecret authenticates the client with client_secret_basic.
+func WithClientSecret(id, secret string) Option {
+	return func(c *Client) {
+		c.auth = func(r *http.Request) {
+			// RFC 6749 2.3.1: the credentials are form-encoded before being put in the header.
+			r.SetBasicAuth(url.QueryEscape(id), url.QueryEscape(secret))
+		}
+	}
+}
+
+// WithAPIKey authenticates the client with its secret in the X-API-Key header.
+func WithAPIKey(id, secret string) Option {
+	return func(c *Client) {
+		c.auth = func(r *http.Request) {
+			r.Header.Set("X-API-Key", id+":"+secret)
+		}
+	}
+}
+
+// WithRefreshBefore sets how long before their expiry the tokens are
+// refreshed by a TokenSource, DefaultRefreshBefore by default.
+func WithRefreshBefore(d time.Duration) Option {
+	return func(c *Client) {
+		c.refreshBefore = d
+	}
+}
+
+// New creates a client of the server at the base URL, e.g. "https://auth.example.com".
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("invalid base url %q: an absolute http(s) url is required", baseURL)
+	}
+
+	c := &Client{
+		baseURL:       strings.TrimSuffix(baseURL, "/"),
+		httpClient:    http.DefaultClient,
+		refreshBefore: DefaultRefreshBefore,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.refreshBefore < 0 {
+		return nil, fmt.Errorf("refresh before must not be negative")
+	}
+	return c, nil
+}
+
+// post sends the form to the endpoint and decodes the JSON response into v, if any.
+func (c *Client) post(ctx context.Context, endpoint string, form url.Values, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if c.auth != nil {
+		c.auth(req)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp.StatusCode, body)
+	}
+	if v == nil {
+		return nil
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("can't decode response: %w", err)
+	}
+	return nil
+}
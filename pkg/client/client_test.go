@@ -0,0 +1,287 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// accessToken is an unsigned access token of the server, the client doesn't verify them.
+func accessToken(guid string, exp time.Time) string {
+	payload, _ := json.Marshal(map[string]any{"guid": guid, "iat": exp.Unix()})
+	return "eyJhbGciOiJIUzUxMiJ9." + base64.RawURLEncoding.EncodeToString(payload) + ".c2ln"
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func TestClient_Issue(t *testing.T) {
+	exp := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		handler  http.HandlerFunc
+		wantPath string
+		want     *Tokens
+		wantErr  error
+		wantCode int
+	}{
+		{
+			name: "basic",
+			opts: []Option{WithClientSecret("backend", "qjf:wjnqk")},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if id, secret, _ := r.BasicAuth(); id != "backend" || secret != "qjf%3Awjnqk" {
+					writeJSON(w, http.StatusUnauthorized, map[string]string{"error": ErrInvalidClient.Error()})
+					return
+				}
+				writeJSON(w, http.StatusOK, map[string]string{
+					"access_token":  base64.StdEncoding.EncodeToString([]byte(accessToken(r.FormValue("guid"), exp))),
+					"refresh_token": base64.StdEncoding.EncodeToString([]byte("ebfkqbfb")),
+				})
+			},
+			wantPath: "/v1/tokens",
+			want: &Tokens{
+				AccessToken:  accessToken("kwfwe", exp),
+				RefreshToken: "a3dmd2U.ebfkqbfb",
+				GUID:         "kwfwe",
+				Expiry:       exp,
+			},
+		},
+		{
+			name: "apiKeyTenant",
+			opts: []Option{WithAPIKey("backend", "qjfwjnqk"), WithTenant("acme")},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("X-API-Key") != "backend:qjfwjnqk" {
+					writeJSON(w, http.StatusUnauthorized, map[string]string{"error": ErrInvalidClient.Error()})
+					return
+				}
+				writeJSON(w, http.StatusOK, map[string]string{
+					"access_token":  base64.StdEncoding.EncodeToString([]byte(accessToken(r.FormValue("guid"), exp))),
+					"refresh_token": base64.StdEncoding.EncodeToString([]byte("ebfkqbfb")),
+				})
+			},
+			wantPath: "/t/acme/v1/tokens",
+			want: &Tokens{
+				AccessToken:  accessToken("kwfwe", exp),
+				RefreshToken: "a3dmd2U.ebfkqbfb",
+				GUID:         "kwfwe",
+				Expiry:       exp,
+			},
+		},
+		{
+			name: "guidNotAllowed",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(w, http.StatusForbidden, map[string]string{"error": ErrGUIDNotAllowed.Error()})
+			},
+			wantPath: "/v1/tokens",
+			wantErr:  ErrGUIDNotAllowed,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name: "unknownError",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadGateway)
+			},
+			wantPath: "/v1/tokens",
+			wantCode: http.StatusBadGateway,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != tt.wantPath {
+					t.Errorf("path = %v, want %v", r.URL.Path, tt.wantPath)
+				}
+				tt.handler(w, r)
+			}))
+			defer srv.Close()
+
+			c, err := New(srv.URL, tt.opts...)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			got, err := c.Issue(context.Background(), "kwfwe")
+			if tt.wantCode != 0 {
+				var e *Error
+				if !errors.As(err, &e) || e.StatusCode != tt.wantCode {
+					t.Fatalf("Issue() error = %v, want status %v", err, tt.wantCode)
+				}
+				if e.Err != tt.wantErr {
+					t.Errorf("Issue() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Issue() error = %v", err)
+			}
+			if *got != *tt.want {
+				t.Errorf("Issue() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_Refresh(t *testing.T) {
+	exp := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/oauth/token" || r.FormValue("grant_type") != "refresh_token" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request", "error_description": "unexpected request"})
+			return
+		}
+		if r.FormValue("refresh_token") != "a3dmd2U.ebfkqbfb" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": ErrInvalidToken.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"access_token":  accessToken("kwfwe", exp),
+			"token_type":    "Bearer",
+			"expires_in":    3600,
+			"refresh_token": "a3dmd2U.qfeqjfkj",
+		})
+	}))
+	defer srv.Close()
+
+	c, err := New(srv.URL)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	got, err := c.Refresh(context.Background(), "a3dmd2U.ebfkqbfb")
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	want := Tokens{AccessToken: accessToken("kwfwe", exp), RefreshToken: "a3dmd2U.qfeqjfkj", GUID: "kwfwe", Expiry: exp}
+	if *got != want {
+		t.Errorf("Refresh() = %+v, want %+v", got, want)
+	}
+
+	_, err = c.Refresh(context.Background(), "a3dmd2U.ebfkqbfb.used")
+	var e *Error
+	if !errors.As(err, &e) || e.Code != "invalid_grant" || !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Refresh() error = %v, want invalid_grant wrapping %v", err, ErrInvalidToken)
+	}
+}
+
+func TestClient_RevokeIntrospect(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/oauth/revoke":
+			if r.FormValue("token_type_hint") != TokenTypeRefreshToken {
+				t.Errorf("token_type_hint = %v, want %v", r.FormValue("token_type_hint"), TokenTypeRefreshToken)
+			}
+			w.WriteHeader(http.StatusOK)
+		case "/oauth/introspect":
+			if r.FormValue("token") != "a3dmd2U.ebfkqbfb" {
+				writeJSON(w, http.StatusOK, map[string]bool{"active": false})
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]any{
+				"active":     true,
+				"token_type": TokenTypeRefreshToken,
+				"sub":        "kwfwe",
+				"client_id":  "backend",
+				"exp":        1700000000,
+			})
+		}
+	}))
+	defer srv.Close()
+
+	c, err := New(srv.URL)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if err := c.Revoke(context.Background(), "a3dmd2U.ebfkqbfb"); err != nil {
+		t.Errorf("Revoke() error = %v", err)
+	}
+
+	got, err := c.Introspect(context.Background(), "a3dmd2U.ebfkqbfb")
+	if err != nil {
+		t.Fatalf("Introspect() error = %v", err)
+	}
+	want := Introspection{Active: true, TokenType: TokenTypeRefreshToken, Subject: "kwfwe", ClientID: "backend", ExpiresAt: 1700000000}
+	if *got != want {
+		t.Errorf("Introspect() = %+v, want %+v", got, want)
+	}
+
+	got, err = c.Introspect(context.Background(), "qfeqjfkj")
+	if err != nil || got.Active {
+		t.Errorf("Introspect() = %+v, %v, want inactive", got, err)
+	}
+}
+
+func TestTokenSource_Token(t *testing.T) {
+	var refreshes atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := refreshes.Add(1)
+		// the rotated refresh token can't be used twice.
+		if want := fmt.Sprintf("a3dmd2U.%d", n-1); r.FormValue("refresh_token") != want {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": ErrInvalidToken.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"access_token":  accessToken("kwfwe", time.Now().Add(time.Hour)),
+			"refresh_token": fmt.Sprintf("a3dmd2U.%d", n),
+		})
+	}))
+	defer srv.Close()
+
+	c, err := New(srv.URL, WithRefreshBefore(time.Minute))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	// the tokens expire within the refresh window, they are refreshed once.
+	ts := c.TokenSource(context.Background(), &Tokens{
+		AccessToken:  accessToken("kwfwe", time.Now().Add(30*time.Second)),
+		RefreshToken: "a3dmd2U.0",
+		Expiry:       time.Now().Add(30 * time.Second),
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tok, err := ts.Token()
+			if err != nil {
+				t.Errorf("Token() error = %v", err)
+				return
+			}
+			if tok.RefreshToken != "a3dmd2U.1" || tok.TokenType != "Bearer" || time.Until(tok.Expiry) < 59*time.Minute {
+				t.Errorf("Token() = %+v, want the refreshed token", tok)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := refreshes.Load(); n != 1 {
+		t.Errorf("refreshes = %v, want 1", n)
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, baseURL := range []string{"", "auth.example.com", "ftp://auth.example.com", "http://"} {
+		if _, err := New(baseURL); err == nil {
+			t.Errorf("New(%q) error = nil, want an error", baseURL)
+		}
+	}
+	if _, err := New("http://auth.example.com", WithRefreshBefore(-time.Second)); err == nil {
+		t.Errorf("New() error = nil, want an error for a negative refresh window")
+	}
+}
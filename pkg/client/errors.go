@@ -0,0 +1,87 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-jwt-auth/internal/constants"
+	"net/http"
+)
+
+// The errors of the server, an *Error wraps the one it was answered with.
+var (
+	ErrMissingRefreshToken  = constants.ErrMissingRefreshToken
+	ErrMissingAccessToken   = constants.ErrMissingAccessToken
+	ErrInvalidToken         = constants.ErrInvalidToken
+	ErrTokenExpired         = constants.ErrTokenExpired
+	ErrInvalidGUID          = constants.ErrInvalidGUID
+	ErrNewIP                = constants.ErrNewIP
+	ErrInvalidScope         = constants.ErrInvalidScope
+	ErrUnsupportedGrant     = constants.ErrUnsupportedGrant
+	ErrUnsupportedTokenType = constants.ErrUnsupportedTokenType
+	ErrInvalidClient        = constants.ErrInvalidClient
+	ErrUnauthorizedClient   = constants.ErrUnauthorizedClient
+	ErrMultipleClientAuth   = constants.ErrMultipleClientAuth
+	ErrGUIDNotAllowed       = constants.ErrGUIDNotAllowed
+	ErrUnknownTenant        = constants.ErrUnknownTenant
+	ErrNotFound             = constants.ErrNotFound
+)
+
+// _errors are the errors of the server by their message, the one the responses carry.
+var _errors = map[string]error{}
+
+func init() {
+	for _, err := range []error{
+		ErrMissingRefreshToken, ErrMissingAccessToken, ErrInvalidToken, ErrTokenExpired,
+		ErrInvalidGUID, ErrNewIP, ErrInvalidScope, ErrUnsupportedGrant, ErrUnsupportedTokenType,
+		ErrInvalidClient, ErrUnauthorizedClient, ErrMultipleClientAuth, ErrGUIDNotAllowed,
+		ErrUnknownTenant, ErrNotFound,
+	} {
+		_errors[err.Error()] = err
+	}
+}
+
+// Error is an error response of the server.
+type Error struct {
+	StatusCode int
+	// Code is the OAuth error code, e.g. invalid_grant, empty for the /v1 endpoints.
+	Code string
+	// Message is the description of the error.
+	Message string
+	// Err is the error of the server, nil if it is none of the known ones.
+	Err error
+}
+
+func (e *Error) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("jwt-auth: %s: %s (%d)", e.Code, e.Message, e.StatusCode)
+	}
+	return fmt.Sprintf("jwt-auth: %s (%d)", e.Message, e.StatusCode)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// errorResponse is both the OAuth error, see RFC 6749 5.2, and the error of the /v1 endpoints.
+type errorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func responseError(status int, body []byte) *Error {
+	e := &Error{StatusCode: status}
+
+	var resp errorResponse
+	if err := json.Unmarshal(body, &resp); err != nil || resp.Error == "" {
+		e.Message = http.StatusText(status)
+		return e
+	}
+
+	if resp.ErrorDescription != "" {
+		e.Code, e.Message = resp.Error, resp.ErrorDescription
+	} else {
+		e.Message = resp.Error
+	}
+	e.Err = _errors[e.Message]
+	return e
+}
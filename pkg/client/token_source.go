@@ -0,0 +1,59 @@
+package client
+
+import (
+	"context"
+	"golang.org/x/oauth2"
+	"sync"
+	"time"
+)
+
+// TokenSource returns the access token of a user, it refreshes the tokens
+// before they expire. It is safe for concurrent use and implements
+// oauth2.TokenSource, so it can be used with oauth2.NewClient.
+type TokenSource struct {
+	client *Client
+	ctx    context.Context
+
+	mu     sync.Mutex
+	tokens *Tokens
+}
+
+var _ oauth2.TokenSource = (*TokenSource)(nil)
+
+// TokenSource returns a TokenSource starting with the tokens, e.g. the ones of
+// Issue. The context is the one of the refresh requests.
+func (c *Client) TokenSource(ctx context.Context, t *Tokens) *TokenSource {
+	return &TokenSource{client: c, ctx: ctx, tokens: t}
+}
+
+// Token returns the access token, refreshed first if it expires within the
+// refresh window of the client. The refresh token is rotated by the server,
+// so at most one refresh is in flight.
+func (s *TokenSource) Token() (*oauth2.Token, error) {
+	t, err := s.Tokens()
+	if err != nil {
+		return nil, err
+	}
+
+	return &oauth2.Token{
+		AccessToken:  t.AccessToken,
+		TokenType:    "Bearer",
+		RefreshToken: t.RefreshToken,
+		Expiry:       t.Expiry,
+	}, nil
+}
+
+// Tokens returns the current tokens, refreshed first like the ones of Token.
+func (s *TokenSource) Tokens() (Tokens, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if time.Until(s.tokens.Expiry) <= s.client.refreshBefore {
+		t, err := s.client.Refresh(s.ctx, s.tokens.RefreshToken)
+		if err != nil {
+			return Tokens{}, err
+		}
+		s.tokens = t
+	}
+	return *s.tokens, nil
+}
@@ -0,0 +1,145 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// The token type hints of Revoke and Introspect, see RFC 7009 2.1.
+const (
+	TokenTypeAccessToken  = "access_token"
+	TokenTypeRefreshToken = "refresh_token"
+)
+
+// Tokens are the tokens of a user.
+type Tokens struct {
+	// AccessToken is the JWT of the user.
+	AccessToken string
+	// RefreshToken is in the form of the refresh tokens of the OAuth grants,
+	// the ones accepted by Refresh, Revoke and Introspect.
+	RefreshToken string
+	// GUID is the user the tokens were issued to.
+	GUID string
+	// Expiry is when the access token expires.
+	Expiry time.Time
+}
+
+// Introspection is the state of a token, see RFC 7662 2.2.
+type Introspection struct {
+	Active bool `json:"active"`
+	// TokenType is TokenTypeAccessToken or TokenTypeRefreshToken.
+	TokenType string `json:"token_type"`
+	// Subject is the GUID of the user or the ID of the client of a client_credentials token.
+	Subject  string `json:"sub"`
+	ClientID string `json:"client_id"`
+	Scope    string `json:"scope"`
+	// ExpiresAt is unix.
+	ExpiresAt int64  `json:"exp"`
+	Issuer    string `json:"iss"`
+	Audience  string `json:"aud"`
+	Tenant    string `json:"tid"`
+}
+
+// tokenResponse is the response of both /v1/tokens and /oauth/token.
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Issue issues the tokens of the GUID, the client must be allowed to issue them.
+func (c *Client) Issue(ctx context.Context, guid string) (*Tokens, error) {
+	var resp tokenResponse
+	if err := c.post(ctx, "/v1/tokens", url.Values{"guid": {guid}}, &resp); err != nil {
+		return nil, err
+	}
+
+	// the tokens of the /v1 endpoints are base64 encoded.
+	access, err := base64.StdEncoding.DecodeString(resp.AccessToken)
+	if err != nil {
+		return nil, fmt.Errorf("can't decode access token: %w", err)
+	}
+	refresh, err := base64.StdEncoding.DecodeString(resp.RefreshToken)
+	if err != nil {
+		return nil, fmt.Errorf("can't decode refresh token: %w", err)
+	}
+
+	return newTokens(string(access), grantRefreshToken(guid, string(refresh)))
+}
+
+// Refresh exchanges the refresh token for a new pair with the refresh_token
+// grant, the refresh token can't be used again.
+func (c *Client) Refresh(ctx context.Context, refreshToken string) (*Tokens, error) {
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	}
+
+	var resp tokenResponse
+	if err := c.post(ctx, "/oauth/token", form, &resp); err != nil {
+		return nil, err
+	}
+	return newTokens(resp.AccessToken, resp.RefreshToken)
+}
+
+// Revoke revokes a refresh token issued to the client, see RFC 7009.
+// The access tokens can't be revoked, they expire on their own.
+func (c *Client) Revoke(ctx context.Context, refreshToken string) error {
+	form := url.Values{
+		"token":           {refreshToken},
+		"token_type_hint": {TokenTypeRefreshToken},
+	}
+	return c.post(ctx, "/oauth/revoke", form, nil)
+}
+
+// Introspect returns the state of an access token or of a refresh token issued to the client, see RFC 7662.
+func (c *Client) Introspect(ctx context.Context, token string) (*Introspection, error) {
+	var i Introspection
+	if err := c.post(ctx, "/oauth/introspect", url.Values{"token": {token}}, &i); err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
+
+// claims are the claims of the access tokens the client needs.
+type claims struct {
+	GUID string `json:"guid"`
+	// Expiry is the iat claim, the access tokens of the server carry their expiry in it.
+	Expiry int64 `json:"iat"`
+}
+
+// newTokens reads the GUID and the expiry of the tokens from the access token,
+// which is trusted as it comes from the server.
+func newTokens(access, refresh string) (*Tokens, error) {
+	parts := strings.Split(access, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed access token")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("can't decode access token: %w", err)
+	}
+
+	var cl claims
+	if err := json.Unmarshal(payload, &cl); err != nil {
+		return nil, fmt.Errorf("can't decode access token claims: %w", err)
+	}
+
+	return &Tokens{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		GUID:         cl.GUID,
+		Expiry:       time.Unix(cl.Expiry, 0),
+	}, nil
+}
+
+// grantRefreshToken is the refresh token of the grants: the GUID the
+// session is stored by and the refresh token of the session.
+func grantRefreshToken(guid, refresh string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(guid)) + "." + refresh
+}
@@ -0,0 +1,323 @@
+package verifier
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// keySource returns the key verifying the signature of a token.
+type keySource interface {
+	// key returns the key of the kid, if any, for the alg of the token.
+	key(ctx context.Context, kid, alg string) (any, error)
+	// methods are the algs of the keys.
+	methods() []string
+}
+
+var (
+	_hmacMethods = []string{"HS256", "HS384", "HS512"}
+	_allMethods  = []string{
+		"HS256", "HS384", "HS512",
+		"RS256", "RS384", "RS512",
+		"PS256", "PS384", "PS512",
+		"ES256", "ES384", "ES512",
+		"EdDSA",
+	}
+)
+
+// staticKey is the shared key of the tenant.
+type staticKey []byte
+
+func (k staticKey) key(context.Context, string, string) (any, error) {
+	return []byte(k), nil
+}
+
+func (k staticKey) methods() []string {
+	return _hmacMethods
+}
+
+const (
+	// DefaultJWKSRefresh is how often the JWK set is fetched again.
+	DefaultJWKSRefresh = 15 * time.Minute
+	// _minJWKSFetch is how often the JWK set may be fetched for an unknown kid.
+	_minJWKSFetch = 10 * time.Second
+)
+
+// JWKSOption configures the JWK set of WithJWKS.
+type JWKSOption func(s *jwks)
+
+// WithJWKSRefresh sets how often the JWK set is fetched again, DefaultJWKSRefresh by default.
+func WithJWKSRefresh(d time.Duration) JWKSOption {
+	return func(s *jwks) {
+		s.refresh = d
+	}
+}
+
+// WithJWKSHTTPClient sets the HTTP client fetching the JWK set, http.DefaultClient by default.
+func WithJWKSHTTPClient(hc *http.Client) JWKSOption {
+	return func(s *jwks) {
+		s.httpClient = hc
+	}
+}
+
+// jwk is a verification key of the JWK set, see RFC 7517 4.
+type jwk struct {
+	kid string
+	// alg is empty if the key doesn't restrict it.
+	alg string
+	key any
+}
+
+// jwks is a JWK set cached and refreshed in the background, see RFC 7517 5.
+type jwks struct {
+	url        string
+	httpClient *http.Client
+	refresh    time.Duration
+
+	mu      sync.RWMutex
+	keys    []jwk
+	fetched time.Time
+
+	// fetchMu lets a single request fetch the set for an unknown kid.
+	fetchMu sync.Mutex
+	done    chan struct{}
+	once    sync.Once
+}
+
+func newJWKS(url string, opts ...JWKSOption) (*jwks, error) {
+	s := &jwks{
+		url:        url,
+		httpClient: http.DefaultClient,
+		refresh:    DefaultJWKSRefresh,
+		done:       make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.refresh <= 0 {
+		return nil, fmt.Errorf("jwks refresh must be positive")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := s.fetch(ctx); err != nil {
+		return nil, err
+	}
+
+	go s.run()
+	return s, nil
+}
+
+// run fetches the set every refresh interval, the keys are kept if it fails.
+func (s *jwks) run() {
+	ticker := time.NewTicker(s.refresh)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), s.refresh)
+			_ = s.fetch(ctx)
+			cancel()
+		}
+	}
+}
+
+func (s *jwks) close() {
+	s.once.Do(func() { close(s.done) })
+}
+
+func (s *jwks) key(ctx context.Context, kid, alg string) (any, error) {
+	if k, err := s.find(kid, alg); err == nil || kid == "" {
+		return k, err
+	}
+
+	// the keys may have been rotated since the last fetch.
+	s.fetchMu.Lock()
+	defer s.fetchMu.Unlock()
+
+	s.mu.RLock()
+	stale := time.Since(s.fetched) >= _minJWKSFetch
+	s.mu.RUnlock()
+	if stale {
+		if err := s.fetch(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return s.find(kid, alg)
+}
+
+// find returns the key of the kid or, without a kid, the only key for the alg.
+func (s *jwks) find(kid, alg string) (any, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var found []any
+	for _, k := range s.keys {
+		if (kid == "" || k.kid == kid) && (k.alg == "" || k.alg == alg) && keyFor(k.key, alg) {
+			found = append(found, k.key)
+		}
+	}
+
+	switch {
+	case len(found) == 1:
+		return found[0], nil
+	case len(found) == 0:
+		return nil, fmt.Errorf("no key %q for %s", kid, alg)
+	default:
+		return nil, fmt.Errorf("%d keys for %s, the token has no kid", len(found), alg)
+	}
+}
+
+func (s *jwks) methods() []string {
+	return _allMethods
+}
+
+func (s *jwks) fetch(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("can't fetch jwks: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("can't fetch jwks: %s", resp.Status)
+	}
+
+	var set struct {
+		Keys []json.RawMessage `json:"keys"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&set); err != nil {
+		return fmt.Errorf("can't decode jwks: %w", err)
+	}
+
+	keys := make([]jwk, 0, len(set.Keys))
+	for _, raw := range set.Keys {
+		k, err := parseJWK(raw)
+		if errors.Is(err, errSkipKey) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		keys = append(keys, k)
+	}
+
+	s.mu.Lock()
+	s.keys, s.fetched = keys, time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+// errSkipKey is returned for the keys of other uses and of unknown types.
+var errSkipKey = errors.New("skip key")
+
+func parseJWK(raw json.RawMessage) (k jwk, err error) {
+	var j struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Alg string `json:"alg"`
+		Use string `json:"use"`
+		K   string `json:"k"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+		Crv string `json:"crv"`
+		X   string `json:"x"`
+		Y   string `json:"y"`
+	}
+	if err := json.Unmarshal(raw, &j); err != nil {
+		return k, fmt.Errorf("can't decode jwk: %w", err)
+	}
+	if j.Use != "" && j.Use != "sig" {
+		return k, errSkipKey
+	}
+	k.kid, k.alg = j.Kid, j.Alg
+
+	var b [4][]byte
+	for i, v := range []string{j.K, j.N, j.E, j.X} {
+		if b[i], err = base64.RawURLEncoding.DecodeString(v); err != nil {
+			return k, fmt.Errorf("invalid jwk %q: %w", j.Kid, err)
+		}
+	}
+	secret, n, e, x := b[0], b[1], b[2], b[3]
+
+	switch j.Kty {
+	case "oct":
+		if len(secret) == 0 {
+			return k, fmt.Errorf("invalid jwk %q: no k", j.Kid)
+		}
+		k.key = secret
+	case "RSA":
+		if len(n) == 0 || len(e) == 0 || len(e) > 4 {
+			return k, fmt.Errorf("invalid jwk %q: invalid n or e", j.Kid)
+		}
+		k.key = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	case "EC":
+		y, err := base64.RawURLEncoding.DecodeString(j.Y)
+		if err != nil {
+			return k, fmt.Errorf("invalid jwk %q: %w", j.Kid, err)
+		}
+		var curve elliptic.Curve
+		switch j.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return k, errSkipKey
+		}
+		pub := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(pub.X, pub.Y) {
+			return k, fmt.Errorf("invalid jwk %q: the point is not on the curve", j.Kid)
+		}
+		k.key = pub
+	case "OKP":
+		if j.Crv != "Ed25519" {
+			return k, errSkipKey
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return k, fmt.Errorf("invalid jwk %q: invalid x", j.Kid)
+		}
+		k.key = ed25519.PublicKey(x)
+	default:
+		return k, errSkipKey
+	}
+	return k, nil
+}
+
+// keyFor reports whether the key verifies the signatures of the alg.
+func keyFor(key any, alg string) bool {
+	switch key.(type) {
+	case []byte:
+		return strings.HasPrefix(alg, "HS")
+	case *rsa.PublicKey:
+		return strings.HasPrefix(alg, "RS") || strings.HasPrefix(alg, "PS")
+	case *ecdsa.PublicKey:
+		return strings.HasPrefix(alg, "ES")
+	case ed25519.PublicKey:
+		return alg == "EdDSA"
+	default:
+		return false
+	}
+}
@@ -0,0 +1,103 @@
+package verifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// ClaimsKey is the key of the claims in the gin.Context.
+const ClaimsKey = "jwt-auth.claims"
+
+type claimsKey struct{}
+
+// NewContext returns a copy of the context with the claims.
+func NewContext(ctx context.Context, c *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, c)
+}
+
+// FromContext returns the claims of the request, set by the middleware.
+func FromContext(ctx context.Context) (*Claims, bool) {
+	c, ok := ctx.Value(claimsKey{}).(*Claims)
+	return c, ok
+}
+
+// GUID returns the GUID of the request, empty if it has no claims.
+func GUID(ctx context.Context) string {
+	if c, ok := FromContext(ctx); ok {
+		return c.GUID
+	}
+	return ""
+}
+
+// ClientID returns the client of a client_credentials token of the request,
+// empty for the tokens of the users.
+func ClientID(ctx context.Context) string {
+	if c, ok := FromContext(ctx); ok {
+		return c.ClientID
+	}
+	return ""
+}
+
+// Middleware verifies the bearer token of the requests and requires the
+// scopes. The claims of the token are put in the context of the request, the
+// other requests are answered with the errors of RFC 6750 3.1.
+func (v *Verifier) Middleware(scopes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, err := v.Verify(r.Context(), tokenFromRequest(r), scopes...)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), claims)))
+		})
+	}
+}
+
+// Gin is Middleware for gin, the claims are also set under ClaimsKey.
+func (v *Verifier) Gin(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := v.Verify(c.Request.Context(), tokenFromRequest(c.Request), scopes...)
+		if err != nil {
+			writeError(c.Writer, err)
+			c.Abort()
+			return
+		}
+		c.Request = c.Request.WithContext(NewContext(c.Request.Context(), claims))
+		c.Set(ClaimsKey, claims)
+		c.Next()
+	}
+}
+
+// Bearer token error codes, see RFC 6750 3.1.
+const (
+	_invalidToken      = "invalid_token"
+	_insufficientScope = "insufficient_scope"
+)
+
+type errorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// writeError answers with the error of the bearer token, see RFC 6750 3.
+func writeError(w http.ResponseWriter, err error) {
+	status, code := http.StatusUnauthorized, _invalidToken
+	switch {
+	case errors.Is(err, ErrMissingToken):
+		// no error code if the request has no token, see RFC 6750 3.1.
+		w.Header().Set("WWW-Authenticate", `Bearer realm="jwt-auth"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	case errors.Is(err, ErrInsufficientScope):
+		status, code = http.StatusForbidden, _insufficientScope
+	}
+
+	w.Header().Set("WWW-Authenticate", `Bearer realm="jwt-auth", error="`+code+`"`)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(errorResponse{Error: code, ErrorDescription: err.Error()})
+}
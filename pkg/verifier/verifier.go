@@ -0,0 +1,253 @@
+// Package verifier validates the access tokens of the jwt-auth server in the
+// services they are sent to, see Verifier.Middleware and Verifier.Gin.
+package verifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"go-jwt-auth/internal/constants"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// The errors of Verify, the first ones are the errors of the server.
+var (
+	ErrMissingToken      = constants.ErrMissingAccessToken
+	ErrInvalidToken      = constants.ErrInvalidToken
+	ErrTokenExpired      = constants.ErrTokenExpired
+	ErrInsufficientScope = errors.New("the access token lacks a required scope")
+)
+
+// The claims of the access tokens of the server.
+const (
+	_guid     = "guid"
+	_clientID = "client_id"
+	_tid      = "tid"
+	_scope    = "scope"
+	_iss      = "iss"
+	// _iat is the expiry of the access tokens of the server, unlike the registered claim.
+	_iat = "iat"
+)
+
+// Claims are the claims of a verified access token.
+type Claims struct {
+	// GUID is empty for the client_credentials tokens, which carry ClientID instead.
+	GUID     string
+	ClientID string
+	// Tenant is empty for the tokens of the default tenant.
+	Tenant    string
+	Issuer    string
+	Audience  []string
+	Scopes    []string
+	ExpiresAt time.Time
+	// Raw are all the claims of the token.
+	Raw map[string]any
+}
+
+// HasScope reports whether the token was granted the scope.
+func (c *Claims) HasScope(scope string) bool {
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Verifier validates the access tokens, it is safe for concurrent use.
+type Verifier struct {
+	keys     keySource
+	issuer   string
+	audience string
+	tenant   *string
+	leeway   time.Duration
+	now      func() time.Time
+}
+
+var errOneKeySource = errors.New("only one of WithKey and WithJWKS can be used")
+
+// Option configures a Verifier.
+type Option func(v *Verifier) error
+
+// WithKey verifies the HS256, HS384 and HS512 signatures with the key of the tenant.
+func WithKey(key []byte) Option {
+	return func(v *Verifier) error {
+		if v.keys != nil {
+			return errOneKeySource
+		}
+		if len(key) == 0 {
+			return fmt.Errorf("empty key")
+		}
+		v.keys = staticKey(key)
+		return nil
+	}
+}
+
+// WithJWKS verifies the signatures with the keys of the JWK set at the URL,
+// fetched by New and refreshed every interval, see JWKSOption.
+func WithJWKS(url string, opts ...JWKSOption) Option {
+	return func(v *Verifier) error {
+		if v.keys != nil {
+			return errOneKeySource
+		}
+		set, err := newJWKS(url, opts...)
+		if err != nil {
+			return err
+		}
+		v.keys = set
+		return nil
+	}
+}
+
+// WithIssuer requires the iss claim, the iss of the tenant.
+func WithIssuer(iss string) Option {
+	return func(v *Verifier) error {
+		v.issuer = iss
+		return nil
+	}
+}
+
+// WithAudience requires the audience in the aud claim, the aud of the tenant.
+func WithAudience(aud string) Option {
+	return func(v *Verifier) error {
+		v.audience = aud
+		return nil
+	}
+}
+
+// WithTenant requires the tid claim, empty for the default tenant. The
+// tenants may share their keys, so it is needed to tell their tokens apart.
+func WithTenant(id string) Option {
+	return func(v *Verifier) error {
+		v.tenant = &id
+		return nil
+	}
+}
+
+// WithLeeway tolerates the clock skew between the server and the service.
+func WithLeeway(d time.Duration) Option {
+	return func(v *Verifier) error {
+		if d < 0 {
+			return fmt.Errorf("negative leeway")
+		}
+		v.leeway = d
+		return nil
+	}
+}
+
+// New creates a Verifier, WithKey or WithJWKS is required.
+func New(opts ...Option) (*Verifier, error) {
+	v := &Verifier{now: time.Now}
+	for _, opt := range opts {
+		if err := opt(v); err != nil {
+			v.Close()
+			return nil, err
+		}
+	}
+	if v.keys == nil {
+		return nil, fmt.Errorf("no key: WithKey or WithJWKS is required")
+	}
+	return v, nil
+}
+
+// Close stops the background refresh of the JWK set, if any.
+func (v *Verifier) Close() {
+	if set, ok := v.keys.(*jwks); ok {
+		set.close()
+	}
+}
+
+// Verify validates the access token and returns its claims. The errors wrap
+// ErrInvalidToken or ErrTokenExpired, or are ErrInsufficientScope if the token
+// lacks one of the scopes.
+func (v *Verifier) Verify(ctx context.Context, token string, scopes ...string) (*Claims, error) {
+	if token == "" {
+		return nil, ErrMissingToken
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(v.keys.methods()),
+		jwt.WithLeeway(v.leeway),
+		jwt.WithTimeFunc(v.now),
+	}
+	if v.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(v.issuer))
+	}
+	if v.audience != "" {
+		opts = append(opts, jwt.WithAudience(v.audience))
+	}
+
+	t, err := jwt.Parse(token, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.keys.key(ctx, kid, t.Method.Alg())
+	}, opts...)
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil, ErrTokenExpired
+	} else if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	claims, err := v.claims(t.Claims.(jwt.MapClaims))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range scopes {
+		if !claims.HasScope(s) {
+			return nil, ErrInsufficientScope
+		}
+	}
+	return claims, nil
+}
+
+// claims checks the claims the parser doesn't know about.
+func (v *Verifier) claims(mc jwt.MapClaims) (*Claims, error) {
+	c := &Claims{Raw: mc}
+	c.GUID, _ = mc[_guid].(string)
+	c.ClientID, _ = mc[_clientID].(string)
+	c.Tenant, _ = mc[_tid].(string)
+	c.Issuer, _ = mc[_iss].(string)
+	c.Audience, _ = mc.GetAudience()
+	if scope, _ := mc[_scope].(string); scope != "" {
+		c.Scopes = strings.Fields(scope)
+	}
+
+	if (c.GUID == "") == (c.ClientID == "") {
+		return nil, fmt.Errorf("%w: no guid or client_id", ErrInvalidToken)
+	}
+	if v.tenant != nil && c.Tenant != *v.tenant {
+		return nil, fmt.Errorf("%w: token of tenant %q", ErrInvalidToken, c.Tenant)
+	}
+
+	// the access tokens carry their expiry in iat, the registered exp is
+	// checked by the parser if the tokens of another issuer carry it.
+	exp, err := mc.GetExpirationTime()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if exp == nil {
+		iat, ok := mc[_iat].(float64)
+		if !ok {
+			return nil, fmt.Errorf("%w: no expiry", ErrInvalidToken)
+		}
+		exp = jwt.NewNumericDate(time.Unix(int64(iat), 0))
+	}
+	c.ExpiresAt = exp.Time
+	if !v.now().Before(c.ExpiresAt.Add(v.leeway)) {
+		return nil, ErrTokenExpired
+	}
+
+	return c, nil
+}
+
+// tokenFromRequest returns the bearer token of the Authorization header, see RFC 6750 2.1.
+func tokenFromRequest(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}